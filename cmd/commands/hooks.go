@@ -1,24 +1,34 @@
 package commands
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"io"
 	"os"
 	"path/filepath"
-
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/iamhabbeboy/gitresume/config"
 	"github.com/iamhabbeboy/gitresume/internal/ai"
 	"github.com/iamhabbeboy/gitresume/internal/database"
 	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/internal/lint"
 	"github.com/iamhabbeboy/gitresume/internal/server"
 	"github.com/iamhabbeboy/gitresume/util"
+	"golang.org/x/term"
 )
 
-func SetupHook(db database.IDatabase) error {
+// SetupHook writes the config for the global git identity and creates its
+// user, asking in for a password the first time.
+func SetupHook(db database.IDatabase, in io.Reader) error {
 	if err := db.Migrate(); err != nil {
 		return err
 	}
@@ -99,6 +109,13 @@ func SetupHook(db database.IDatabase) error {
 				ApiKey:    "",
 				IsDefault: false,
 			},
+			{
+				Name:      string(ai.HuggingFace),
+				Model:     "meta-llama/Llama-3.1-8B-Instruct",
+				ApiKey:    "",
+				IsDefault: false,
+				BaseURL:   ai.HuggingFaceRouter,
+			},
 		},
 	})
 
@@ -111,11 +128,16 @@ func SetupHook(db database.IDatabase) error {
 		return err
 	}
 
-	if prf.ID == 0 {
-		_, err = db.CreateUser(git.Profile{
+	uID := int64(prf.ID)
+	if uID == 0 {
+		password, err := newPassword(in, userCfg["email"])
+		if err != nil {
+			return err
+		}
+		uID, err = db.CreateUser(git.Profile{
 			Name:         userCfg["name"],
 			Email:        userCfg["email"],
-			PasswordHash: "admin",
+			PasswordHash: password,
 		})
 
 		if err != nil {
@@ -123,14 +145,17 @@ func SetupHook(db database.IDatabase) error {
 		}
 	}
 
-	// create llm config
-	llmPrmts := defaultPromptConfig()
-	for _, v := range llmPrmts {
+	return createDefaultPrompts(db.ForUser(uID))
+}
+
+// createDefaultPrompts stores the default prompts for the user db is
+// scoped to.
+func createDefaultPrompts(db database.IDatabase) error {
+	for _, v := range defaultPromptConfig() {
 		if err := db.CreateOrUpdateLLmPrompt(v); err != nil {
 			return fmt.Errorf("failed to create default llm prompt %q: %w", v.Title, err)
 		}
 	}
-
 	return nil
 }
 
@@ -151,63 +176,221 @@ func SeedHook(db database.IDatabase) error {
 	usrEmail := strings.TrimSpace(conf.Email)
 
 	gitutil := git.NewGitUtil(project)
+	if p.ID != 0 {
+		if err := database.ExpandHashes(db, &p, gitutil, usrEmail); err != nil {
+			return err
+		}
+	}
 
-	var logCount int = 0
-
-	var mergeLogs []git.GitCommit
+	lastHash := ""
 	if len(p.Commits) > 0 {
 		// retrieve the new ones
-		lastIndex := len(p.Commits) - 1
-		lastHash := p.Commits[lastIndex].Hash
-		newLogs, err := gitutil.GetCommits(usrEmail, lastHash)
-		if err != nil {
-			return err
-		}
-		if len(newLogs) > 0 && newLogs[0].Hash == lastHash {
-			newLogs = newLogs[1:]
+		lastHash = p.Commits[len(p.Commits)-1].Hash
+	}
+
+	tech, err := gitutil.GetStacks(usrEmail)
+	if err != nil {
+		return err
+	}
+	techJSON, _ := json.Marshal(tech)
+	p.Name = prjName
+	if p.ID == 0 {
+		p.Path = project
+		p.Technologies = string(techJSON)
+	}
+
+	// commits are stored a batch at a time as git log prints them, so a
+	// large history is never held in memory
+	w := database.NewCommitWriter(db, p, database.CommitBatchSize)
+	w.Progress = func(seen, _ int) {
+		if seen >= database.CommitBatchSize {
+			fmt.Printf("\r  Stored %d commits", seen)
 		}
-		if len(newLogs) == 0 {
+	}
+	err = gitutil.EachCommit(usrEmail, lastHash, w.Write)
+	if err == nil {
+		err = w.Flush()
+	}
+	if w.Seen >= database.CommitBatchSize {
+		fmt.Println()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store commits: %w", err)
+	}
+
+	if w.Added == 0 {
+		if lastHash != "" {
 			fmt.Println("No new commits to update")
 			return nil
 		}
-		logCount = len(newLogs)
+		return errors.New("no commits available")
+	}
+	fmt.Printf("✔ Fetched %v of your commits from %v \n", w.Added, prjName)
+	fmt.Printf("✔ Extracted contribution details, tech stack, and frameworks \n\n")
+	return nil
+}
 
-		mergeLogs = newLogs
-	} else {
-		// no previous logs
-		allLogs, err := gitutil.GetCommits(usrEmail, "")
-		if err != nil {
-			return fmt.Errorf("failed to get commits: %w", err)
+// findProject looks a project up by name, or by ID when no project has
+// that name.
+func findProject(db database.IDatabase, ref string) (git.Project, error) {
+	p, err := db.GetProjectByName(ref)
+	if err != nil || p.ID != 0 {
+		return p, err
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		return db.GetProject(id)
+	}
+	return p, fmt.Errorf("no project named %q, see `gitresume projects list`", ref)
+}
+
+func ProjectsHook(db database.IDatabase, archived bool) error {
+	projects, err := db.ListProjects(archived)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		if all, err := db.ListProjects(true); err == nil && len(all) > 0 {
+			fmt.Println("All your projects are archived, see `gitresume projects list --all`")
+			return nil
 		}
-		if len(allLogs) == 0 {
-			return errors.New("no commits available")
+		fmt.Println("No projects yet, run `gitresume seed` in a git repository")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCOMMITS\tPATH")
+	for _, p := range projects {
+		name := p.Name
+		if p.Archived {
+			name += " (archived)"
 		}
-		logCount = len(allLogs)
-		mergeLogs = allLogs
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", p.ID, name, p.CommitCount, p.Path)
 	}
+	w.Flush()
+	return nil
+}
 
-	tech, err := gitutil.GetStacks(usrEmail)
+// recentCommits is how many commits `projects show` lists.
+const recentCommits = 10
+
+func ProjectShowHook(db database.IDatabase, ref string) error {
+	p, err := findProject(db, ref)
 	if err != nil {
 		return err
 	}
-	techJSON, _ := json.Marshal(tech)
-	prj := git.Project{
-		Name:         prjName,
-		Path:         project,
-		Commits:      mergeLogs,
-		Technologies: string(techJSON),
+	summaries, err := db.GetAllCommitSummary(p.ID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\t%d\n", p.ID)
+	fmt.Fprintf(w, "Name\t%s\n", p.Name)
+	fmt.Fprintf(w, "Path\t%s\n", p.Path)
+	if p.Archived {
+		fmt.Fprintln(w, "Archived\tyes")
+	}
+	fmt.Fprintf(w, "Tech stack\t%s\n", strings.Join(git.ParseTechStack(p.Technologies).Names(), ", "))
+	fmt.Fprintf(w, "Commits\t%d\n", len(p.Commits))
+	fmt.Fprintf(w, "Summaries\t%d\n", len(summaries))
+	w.Flush()
+
+	if len(p.Commits) == 0 {
+		return nil
+	}
+	fmt.Println("\nLatest commits:")
+	for i := len(p.Commits) - 1; i >= max(0, len(p.Commits)-recentCommits); i-- {
+		c := p.Commits[i]
+		fmt.Printf("  %s  %s  %s\n", git.ShortHash(c.Hash), c.Date, c.Msg)
+	}
+	return nil
+}
+
+func ProjectRenameHook(db database.IDatabase, ref, name string) error {
+	p, err := findProject(db, ref)
+	if err != nil {
+		return err
 	}
+	old := p.Name
+	p.Name = name
+	if err := db.UpdateProject(p); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Renamed %s to %s\n", old, strings.TrimSpace(name))
+	return nil
+}
 
-	if err = db.CreateProject(prj); err != nil {
+func ProjectArchiveHook(db database.IDatabase, ref string, archived bool) error {
+	p, err := findProject(db, ref)
+	if err != nil {
 		return err
 	}
-	fmt.Printf("✔ Fetched %v of your commits from %v \n", logCount, prj.Name)
-	fmt.Printf("✔ Extracted contribution details, tech stack, and frameworks \n\n")
+	if err := db.ArchiveProject(p.ID, archived); err != nil {
+		return err
+	}
+	if archived {
+		fmt.Printf("✔ Archived %s; its commits are kept but left out of the dashboard\n", p.Name)
+	} else {
+		fmt.Printf("✔ %s is back in the dashboard\n", p.Name)
+	}
+	return nil
+}
+
+// ProjectRemoveHook deletes a project with its commits, summaries and
+// suggestions, asking in to confirm unless yes is set.
+func ProjectRemoveHook(db database.IDatabase, ref string, yes bool, in io.Reader) error {
+	p, err := findProject(db, ref)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		fmt.Printf("Delete %s with its %d commits, summaries and suggestions? [y/N] ", p.Name, len(p.Commits))
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Kept", p.Name)
+			return nil
+		}
+	}
+	if err := db.DeleteProject(p.ID); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Deleted %s\n", p.Name)
+	return nil
+}
+
+// ProjectRescanHook reads a project's whole history again, from path when
+// it moved. See database.RescanProject.
+func ProjectRescanHook(db database.IDatabase, ref, path string, prune bool) error {
+	p, err := findProject(db, ref)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		if path, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	conf, _ := config.GetProject(p.Path)
+	r, err := database.RescanProject(db, p.ID, path, strings.TrimSpace(conf.Email), prune, func(seen, _ int) {
+		if seen >= database.CommitBatchSize {
+			fmt.Printf("\r  Read %d commits", seen)
+		}
+	})
+	if r.Seen >= database.CommitBatchSize {
+		fmt.Println()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✔ Read %d commits from %s: %d new", r.Seen, p.Name, r.Added)
+	if prune {
+		fmt.Printf(", %d removed", r.Removed)
+	}
+	fmt.Println()
 	return nil
 }
 
-func DashboardHook(db database.IDatabase) error {
-	server.Serve(db)
+func DashboardHook(db database.IDatabase, login bool) error {
+	server.Serve(db, login)
 	return nil
 }
 
@@ -220,12 +403,6 @@ func AiTestHook(db database.IDatabase) error {
 	if len(cfg.AiOptions) == 0 {
 		return fmt.Errorf("LLM config is missing. RUN 'gitresume init'")
 	}
-	var defaultLLM config.AiOptions
-	for _, v := range cfg.AiOptions {
-		if v.IsDefault {
-			defaultLLM = v
-		}
-	}
 
 	prmps, err := db.GetLLmPromptConfig()
 
@@ -245,42 +422,588 @@ func AiTestHook(db database.IDatabase) error {
 	}
 
 	// get the prompt and replace
-	aiResp := ai.NewChatModel(ai.ModelConfig{
-		Type:        ai.ModelType(defaultLLM.Name),
-		Model:       defaultLLM.Model,
-		Temperature: prjPrt.Temperature,
-		MaxToken:    prjPrt.MaxTokens,
-		APIKey:      defaultLLM.ApiKey,
+	aiResp, err := ai.NewTaskModel(cfg, config.ProjectPrompt, prjPrt, ai.ModelConfig{
+		ResponseSchema: ai.BulletSchema,
+		Meter:          ai.NewMeter(cfg, db),
+		Audit:          ai.NewAuditor(cfg, db),
 	})
+	if err != nil {
+		return err
+	}
 
 	// sample commit messages
-	commits := []string{
-		"chore(database): Setup bbolt database to store commit logs",
-		"feat(api): Add new endpoint to get commit logs",
+	commits := []git.GitCommit{
+		{Hash: "a1b2c3d", Msg: "chore(database): Setup bbolt database to store commit logs"},
+		{Hash: "e4f5a6b", Msg: "feat(api): Add new endpoint to get commit logs"},
 	}
 
-	newPrompt := []config.Prompt{}
-	for _, v := range prjPrt.Prompts {
-		nCont := strings.ReplaceAll(v.Content, "%content%", util.ToUserContent(commits))
-		newPrompt = append(newPrompt, config.Prompt{
-			Role:    v.Role,
-			Content: nCont,
-		})
+	newPrompt, err := prjPrt.Render(config.PromptData{
+		Commits:     ai.CommitLines(commits),
+		ProjectName: "gitresume",
+		TechStack:   []string{"Go"},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := ai.WithCallInfo(context.Background(), ai.CallInfo{Task: config.ProjectPrompt, Origin: "cli:ai"})
+	generate := ai.GenerateBullets
+	if cfg.AiLint {
+		generate = ai.GenerateLintedBullets
+	}
+	bullets, err := generate(ctx, aiResp, newPrompt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Translated\n%s\n", ai.CommitContent(commits))
+	fmt.Println("To:")
+	for _, b := range bullets {
+		fmt.Printf("• %s (commits: %s, confidence: %.2f)\n", b.Text, strings.Join(b.SourceCommits, ", "), b.Confidence)
+	}
+	return nil
+}
+
+func AiBudgetHook(limit float64) error {
+	if err := config.UpdateAIBudget(limit); err != nil {
+		return err
+	}
+	if limit == 0 {
+		fmt.Println("✔ Monthly AI budget removed")
+		return nil
+	}
+	fmt.Printf("✔ Paid AI providers will be blocked after $%.2f per month\n", limit)
+	return nil
+}
+
+func DbStatusHook(db database.IDatabase) error {
+	status, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED\tREVERSIBLE")
+	pending := 0
+	for _, m := range status {
+		applied := m.AppliedAt
+		if applied == "" {
+			applied = "pending"
+			pending++
+		}
+		reversible := "no"
+		if m.Reversible {
+			reversible = "yes"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", m.Version, m.Name, applied, reversible)
+	}
+	w.Flush()
+	if pending > 0 {
+		fmt.Printf("\n%d pending, run `gitresume db migrate` to apply\n", pending)
+	}
+	return nil
+}
+
+func DbMigrateHook(db database.IDatabase) error {
+	before, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if err := db.Migrate(); err != nil {
+		return err
+	}
+	applied := 0
+	for _, m := range before {
+		if m.AppliedAt == "" {
+			fmt.Printf("✔ Applied %d %s\n", m.Version, m.Name)
+			applied++
+		}
+	}
+	if applied == 0 {
+		fmt.Println("✔ The database is up to date")
+	}
+	return nil
+}
+
+// DbCheckHook lists duplicate commits, orphaned summaries and dangling
+// references, and fixes them when repair is set.
+func DbCheckHook(db database.IDatabase, repair bool) error {
+	issues, err := db.CheckIntegrity(repair)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		fmt.Println("✔ No problems found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tTABLE\tREFERENCE\tROWS")
+	for _, i := range issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", i.Check, i.Table, i.Reference, i.Count)
+	}
+	w.Flush()
+	if repair {
+		fmt.Println("\n✔ Repaired")
+	} else {
+		fmt.Println("\nRun `gitresume db check --repair` to fix them")
+	}
+	return nil
+}
+
+func DbRollbackHook(db database.IDatabase, steps int) error {
+	reverted, err := db.Rollback(steps)
+	for _, m := range reverted {
+		fmt.Printf("✔ Rolled back %d %s\n", m.Version, m.Name)
+	}
+	return err
+}
+
+func AiLocalOnlyHook(on bool) error {
+	if err := config.UpdateAILocalOnly(on); err != nil {
+		return err
+	}
+	if on {
+		fmt.Println("✔ Only AI providers running on this machine will be used")
+		return nil
+	}
+	fmt.Println("✔ Remote AI providers are allowed again")
+	return nil
+}
+
+func AiAuditHook(db database.IDatabase, since string, limit int) error {
+	entries, err := db.GetAIAudit(since, limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No requests to AI providers have been logged")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tPROVIDER\tMODEL\tTASK\tORIGIN\tREDACTED\tRESULT")
+	for _, e := range entries {
+		result := shortHash(e.ResponseHash)
+		if !e.Success {
+			result = "failed"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", e.ID, e.CreatedAt, e.Provider, e.Model, e.PromptType, e.Origin, e.Redactions, result)
+	}
+	w.Flush()
+	fmt.Println("\nRun `gitresume ai audit show <id>` to see a full prompt")
+	return nil
+}
+
+func AiAuditShowHook(db database.IDatabase, id int64) error {
+	e, err := db.GetAIAuditEntry(id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Time:      %s\n", e.CreatedAt)
+	fmt.Printf("Provider:  %s (%s)\n", e.Provider, e.Model)
+	fmt.Printf("Endpoint:  %s\n", e.Endpoint)
+	fmt.Printf("Task:      %s\n", e.PromptType)
+	fmt.Printf("Origin:    %s\n", e.Origin)
+	if e.Project != "" {
+		fmt.Printf("Project:   %s\n", e.Project)
+	}
+	fmt.Printf("Redacted:  %d\n", e.Redactions)
+	if e.Success {
+		fmt.Printf("Response:  sha256:%s\n", e.ResponseHash)
+	} else {
+		fmt.Printf("Error:     %s\n", e.Error)
+	}
+	fmt.Printf("\n%s\n", e.Prompt)
+	return nil
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func AiUsageHook(db database.IDatabase, by, since string) error {
+	if since == "" {
+		now := time.Now().UTC()
+		since = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+	}
+	records, err := db.GetAIUsage(since)
+	if err != nil {
+		return err
+	}
+	summary, err := ai.SummarizeUsage(records, by)
+	if err != nil {
+		return err
+	}
+	if len(summary) == 0 {
+		fmt.Printf("No AI usage recorded since %s\n", since)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(by)+"\tCALLS\tFAILED\tINPUT TOKENS\tOUTPUT TOKENS\tAVG LATENCY\tCOST (USD)")
+	var total float64
+	for _, s := range summary {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%dms\t%.4f\n", s.Key, s.Calls, s.Failures, s.InputTokens, s.OutputTokens, s.AvgLatencyMs, s.Cost)
+		total += s.Cost
+	}
+	w.Flush()
+	fmt.Printf("\nTotal since %s: $%.4f\n", since, total)
+
+	cfg, err := config.LoadConfig()
+	if err == nil && cfg.AiBudget.MonthlyLimit > 0 {
+		spent, err := ai.NewMeter(cfg, db).MonthToDate()
+		if err == nil {
+			fmt.Printf("Monthly budget: $%.2f of $%.2f used\n", spent, cfg.AiBudget.MonthlyLimit)
+		}
+	}
+	return nil
+}
+
+// AiEvalHook runs prompt versions against models over a fixed commit sample
+// and prints a comparison. With no versions the latest two are compared; with
+// no models the providers routed for the task are used.
+func AiEvalHook(db database.IDatabase, task config.PromptType, versions []int, models []string, promote bool) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	all, err := db.GetPromptVersions(task)
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("no saved versions of the %s prompt, kindly run 'gitresume init'", task)
+	}
+	selected, err := ai.SelectVersions(all, versions)
+	if err != nil {
+		return err
+	}
+
+	providers := cfg.ProvidersFor(task)
+	if len(models) > 0 {
+		if providers, err = cfg.Providers(models); err != nil {
+			return err
+		}
+	}
+
+	ev := &ai.Evaluator{
+		Store: db,
+		Meter: ai.NewMeter(cfg, db),
+		Audit: ai.NewAuditor(cfg, db),
+		Data:  config.PromptData{ProjectName: "gitresume", TechStack: []string{"Go", "React"}},
+	}
+	runID, evals, err := ev.Run(context.Background(), selected, providers)
+	if err != nil {
+		return err
+	}
+
+	printEvals(runID, evals)
+	winner, ok := ai.Winner(evals)
+	if !ok {
+		return errors.New("no version produced usable output")
+	}
+	fmt.Printf("\nWinner: version %d on %s/%s\n", winner.Version, winner.Provider, winner.Model)
+	if !promote {
+		fmt.Printf("Run 'gitresume ai promote %d --task %s' to make it active\n", winner.Version, task)
+		return nil
+	}
+	return AiPromoteHook(db, task, winner.Version)
+}
+
+func printEvals(runID string, evals []config.PromptEval) {
+	fmt.Printf("Evaluation %s\n\n", runID)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMODEL\tBULLETS\tAVG LENGTH\tACTION VERBS\tQUANTIFIED\tLATENCY\tCOST (USD)\tSCORE")
+	for _, e := range evals {
+		if e.Error != "" {
+			fmt.Fprintf(w, "v%d\t%s/%s\terror: %s\n", e.Version, e.Provider, e.Model, e.Error)
+			continue
+		}
+		fmt.Fprintf(w, "v%d\t%s/%s\t%d\t%.0f\t%.0f%%\t%.0f%%\t%dms\t%.4f\t%.2f\n", e.Version, e.Provider, e.Model,
+			e.Bullets, e.AvgLength, e.ActionVerbs*100, e.Quantified*100, e.LatencyMs, e.Cost, ai.EvalScore(e))
+	}
+	w.Flush()
+}
+
+func AiVersionsHook(db database.IDatabase, task config.PromptType) error {
+	versions, err := db.GetPromptVersions(task)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Printf("No saved versions of the %s prompt\n", task)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSAVED\tTEMPERATURE\tMAX TOKENS\tACTIVE")
+	for _, v := range versions {
+		active := ""
+		if v.Active {
+			active = "✔"
+		}
+		fmt.Fprintf(w, "v%d\t%s\t%.2f\t%d\t%s\n", v.Version, v.CreatedAt, v.Temperature, v.MaxTokens, active)
+	}
+	w.Flush()
+	return nil
+}
+
+func AiPromoteHook(db database.IDatabase, task config.PromptType, version int) error {
+	if err := db.PromotePromptVersion(task, version); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Version %d is now the active %s prompt\n", version, task)
+	return nil
+}
+
+func SuggestionsHook(db database.IDatabase, projectID int, state config.SuggestionState) error {
+	if state != "" && !state.Valid() {
+		return config.ErrInvalidSuggestionState
+	}
+	suggestions, err := db.GetSuggestions(projectID, state)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestions to review")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tPROJECT\tMODEL\tPROMPT\tCOMMITS\tTEXT")
+	for _, s := range suggestions {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s/%s\tv%d\t%s\t%s\n", s.ID, s.State, s.ProjectID, s.Provider, s.Model,
+			s.PromptVersion, strings.Join(s.SourceCommits, ","), truncate(s.FinalText(), 70))
+	}
+	w.Flush()
+	return nil
+}
+
+func ReviewSuggestionHook(db database.IDatabase, id int64, state config.SuggestionState, text string) error {
+	if err := db.ReviewSuggestion(id, state, text); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Suggestion %d %s\n", id, state)
+	return nil
+}
+
+// MatchHook ranks the user's evidence against a job description read from
+// path, or from stdin when path is "-".
+func MatchHook(db database.IDatabase, path string, resumeID int64, top int) error {
+	jd, err := readJobDescription(path)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	m, err := ai.NewMatcher(cfg, db, ai.NewMeter(cfg, db), ai.NewAuditor(cfg, db))
+	if err != nil {
+		return err
+	}
+	report, err := m.MatchJob(context.Background(), db, string(jd), resumeID, top)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Top evidence (%s)\n\n", report.Model)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tKIND\tSOURCE\tEVIDENCE")
+	for _, mt := range report.Matches {
+		fmt.Fprintf(w, "%.2f\t%s\t%s\t%s\n", mt.Score, mt.Kind, mt.Source, truncate(mt.Text, 80))
+	}
+	w.Flush()
+
+	fmt.Printf("\nRequirements\n\n")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range report.Requirements {
+		mark, best := "✘", "no evidence"
+		if r.Covered {
+			mark = "✔"
+		}
+		if r.Best != nil {
+			best = fmt.Sprintf("%.2f %s", r.Best.Score, truncate(r.Best.Text, 60))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", mark, truncate(r.Requirement, 60), best)
+	}
+	w.Flush()
+
+	if len(report.Missing) > 0 {
+		fmt.Printf("\n%d of %d requirements have no matching evidence\n", len(report.Missing), len(report.Requirements))
+	}
+	return nil
+}
+
+// TailorHook shows the changes that tailoring a resume to a job would make.
+// With apply, the job and the tailored copy are saved.
+func TailorHook(db database.IDatabase, resumeID int64, path string, job git.Job, apply bool) error {
+	jd, err := readJobDescription(path)
+	if err != nil {
+		return err
+	}
+	job.Description = string(jd)
+
+	resume, err := db.GetResume(resumeID)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	model, err := ai.NewTaskModel(cfg, config.TailorPrompt, config.CustomPrompt{Temperature: 0.4, MaxTokens: 2000}, ai.ModelConfig{
+		ResponseSchema: ai.TailorSchema,
+		GenOptions:     ai.GenOptions{System: ai.TailorSystem},
+		Meter:          ai.NewMeter(cfg, db),
+		Audit:          ai.NewAuditor(cfg, db),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := ai.WithCallInfo(context.Background(), ai.CallInfo{Task: config.TailorPrompt, Origin: "cli:tailor"})
+	tailored, err := ai.Tailor(ctx, model, resume, job)
+	if err != nil {
+		return err
 	}
 
-	resp, err := aiResp.Chat(context.Background(), newPrompt)
+	changes := git.DiffResumes(resume, tailored)
+	if len(changes) == 0 {
+		fmt.Println("The resume already fits the job, nothing to change")
+		return nil
+	}
+	printChanges(changes)
+
+	if !apply {
+		fmt.Printf("\nRun again with --apply to save this as a new version of %q\n", resume.Title)
+		return nil
+	}
+	if tailored.JobID, err = db.CreateJob(job); err != nil {
+		return err
+	}
+	id, err := database.CopyResume(db, tailored)
 	if err != nil {
 		return err
 	}
+	fmt.Printf("\n✅ Saved %q as resume %d\n", tailored.Title, id)
+	return nil
+}
+
+func printChanges(changes []git.Change) {
+	added := color.New(color.FgGreen).SprintFunc()
+	removed := color.New(color.FgRed).SprintFunc()
+	for _, c := range changes {
+		fmt.Printf("\n%s\n", color.New(color.Bold).Sprint(c.Label))
+		for _, l := range c.Lines {
+			switch l.Op {
+			case git.LineAdded:
+				fmt.Println(added("+ " + l.Text))
+			case git.LineRemoved:
+				fmt.Println(removed("- " + l.Text))
+			default:
+				fmt.Println("  " + l.Text)
+			}
+		}
+	}
+}
+
+// readJobDescription reads a job description from a file, or from stdin
+// when path is "-".
+func readJobDescription(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
 
-	spl := strings.Join(commits, "\n")
-	fmt.Println(fmt.Sprintf("Translated\n%s ", spl))
-	output := strings.Join(resp, "\n")
-	fmt.Println(fmt.Sprintf("To:\n%s\n", output))
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// AiModelsHook lists the models pulled into the local Ollama server.
+func AiModelsHook() error {
+	models, err := ai.NewOllamaClient().ListModels(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		fmt.Printf("No local models, pull one with 'gitresume ai pull %s'\n", configuredLlamaModel())
+		return nil
+	}
+
+	configured := configuredLlamaModel()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tMODIFIED\tCONFIGURED")
+	for _, m := range models {
+		mark := ""
+		if ai.ModelPulled([]ai.OllamaModel{m}, configured) {
+			mark = "✔"
+		}
+		fmt.Fprintf(w, "%s\t%.1f GB\t%s\t%s\n", m.Name, float64(m.Size)/1e9, m.ModifiedAt.Format(time.DateOnly), mark)
+	}
+	w.Flush()
+	return nil
+}
+
+// AiPullHook pulls a model into Ollama, printing progress on one line.
+func AiPullHook(model string) error {
+	if model == "" {
+		model = configuredLlamaModel()
+	}
+	last := ""
+	err := ai.NewOllamaClient().PullModel(context.Background(), model, func(p ai.PullProgress) {
+		line := p.Status
+		if pct := p.Percent(); pct >= 0 {
+			line = fmt.Sprintf("%s %3d%% (%d/%d MB)", p.Status, pct, p.Completed>>20, p.Total>>20)
+		}
+		if line != last {
+			fmt.Printf("\r\033[K%s", line)
+			last = line
+		}
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✔ %s is ready\n", model)
 	return nil
 }
 
-func IsConfigInitialized() bool {
+// EnsureLocalModelHook checks that the configured Ollama model is pulled and
+// offers to pull it. A missing Ollama server is only reported, since remote
+// providers work without it.
+func EnsureLocalModelHook(in io.Reader) error {
+	model := configuredLlamaModel()
+	ok, err := ai.NewOllamaClient().HasModel(context.Background(), model)
+	if errors.Is(err, ai.ErrOllamaUnavailable) {
+		fmt.Printf("⚠ Ollama is not running; start it with 'ollama serve' to use the local %s model\n", model)
+		return nil
+	}
+	if err != nil || ok {
+		return err
+	}
+
+	fmt.Printf("The local model %s is not pulled yet. Pull it now? [y/N] ", model)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Printf("Skipped, run 'gitresume ai pull %s' later\n", model)
+		return nil
+	}
+	return AiPullHook(model)
+}
+
+func configuredLlamaModel() string {
+	cfg, _ := config.LoadConfig()
+	return ai.ConfiguredOllamaModel(cfg)
+}
+
+var IsConfigInitialized = func() bool {
 	homeDir, _ := os.UserHomeDir()
 	folderPath := filepath.Join(homeDir, "."+util.APP_NAME+"/config.yaml")
 	_, err := os.Stat(folderPath)
@@ -297,8 +1020,8 @@ func defaultPromptConfig() []config.CustomPrompt {
 			Temperature: 0.5,
 			MaxTokens:   400,
 			Prompts: newPrompt([]string{
-				`You are a professional resume writer specializing in software engineering roles.Transform git commit messages into polished, resume-ready bullet points that highlight technical achievements and business impact.Use strong action verbs, past tense, and concise phrasing (1–2 lines max).Do not include any introduction, summary, or explanation. Only return the bullet points, each starting with a "•"`,
-				"Transform these commit messages into 3-5 concise resume bullet points: %content%",
+				`You are a professional resume writer specializing in software engineering roles.Transform git commit messages into polished, resume-ready bullet points that highlight technical achievements and business impact.Use strong action verbs, past tense, and concise phrasing (1–2 lines max).Do not include any introduction, summary, or explanation. Respond with a JSON object containing a "bullets" array; each bullet has the "text", the "source_commits" ids (shown in square brackets) it is based on, and a "confidence" between 0 and 1.`,
+				"Transform these commit messages{{if .ProjectName}} from {{.ProjectName}}{{end}} into 3-5 concise resume bullet points{{if .TargetRole}} for a {{.TargetRole}} role{{end}}{{if .Language}}, written in {{.Language}}{{end}}:\n{{lines .Commits}}",
 			}),
 		}, {
 			Title:       config.SummaryPrompt,
@@ -306,7 +1029,7 @@ func defaultPromptConfig() []config.CustomPrompt {
 			MaxTokens:   300,
 			Prompts: newPrompt([]string{
 				"You are an expert technical writer specializing in crafting professional resume summaries for software engineers.",
-				"Write a concise 3–5 sentence summary highlighting key programming languages, frameworks, and problem-solving experience for a Software Engineer: %content%",
+				"Write a concise 3–5 sentence summary highlighting key programming languages, frameworks, and problem-solving experience for a {{with .TargetRole}}{{.}}{{else}}Software Engineer{{end}}{{if .Language}}, written in {{.Language}}{{end}}: {{.Content}}",
 			}),
 		},
 	}
@@ -318,3 +1041,315 @@ func newPrompt(cont []string) []config.Prompt {
 		{Role: string(ai.User), Content: cont[1]},
 	}
 }
+
+// LintHook prints the linter's findings for a resume, underlining the part
+// of the text each one is about.
+func LintHook(db database.IDatabase, resumeID int64) error {
+	resume, err := db.GetResume(resumeID)
+	if err != nil {
+		return err
+	}
+	diags := lint.Resume(resume)
+	if len(diags) == 0 {
+		fmt.Println("✔ No issues found")
+		return nil
+	}
+
+	warn := color.New(color.FgYellow).SprintFunc()
+	info := color.New(color.FgCyan).SprintFunc()
+	mark := color.New(color.FgYellow, color.Underline).SprintFunc()
+	field := ""
+	for _, d := range diags {
+		if d.Field != field {
+			field = d.Field
+			fmt.Printf("\n%s\n", color.New(color.Bold).Sprint(field))
+		}
+		sev := info(d.Severity)
+		if d.Severity == lint.Warning {
+			sev = warn(d.Severity)
+		}
+		fmt.Printf("  %s %s: %s\n", sev, d.Rule, d.Message)
+		if d.Span.End > d.Span.Start {
+			text := []rune(d.Text)
+			fmt.Printf("    %s%s%s\n", string(text[:d.Span.Start]), mark(string(text[d.Span.Start:d.Span.End])), string(text[d.Span.End:]))
+		}
+	}
+	warnings := lint.Warnings(diags)
+	fmt.Printf("\n%d warnings, %d suggestions\n", warnings, len(diags)-warnings)
+	return nil
+}
+
+// TranslateHook translates a resume and saves it as a new resume linked to
+// the original.
+func TranslateHook(db database.IDatabase, resumeID int64, language string) error {
+	resume, err := db.GetResume(resumeID)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	model, err := ai.NewTaskModel(cfg, config.TranslatePrompt, config.CustomPrompt{Temperature: 0.2, MaxTokens: 3000}, ai.ModelConfig{
+		ResponseSchema: ai.TranslationSchema,
+		GenOptions:     ai.GenOptions{System: ai.TranslateSystem},
+		Meter:          ai.NewMeter(cfg, db),
+		Audit:          ai.NewAuditor(cfg, db),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := ai.WithCallInfo(context.Background(), ai.CallInfo{Task: config.TranslatePrompt, Origin: "cli:translate"})
+	translated, err := ai.Translate(ctx, model, resume, language)
+	if err != nil {
+		return err
+	}
+	id, err := database.CopyResume(db, translated)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✔ Saved %q as resume %d\n", translated.Title, id)
+	return nil
+}
+
+// SearchHook prints the results of a full-text search, highlighting the
+// matched words. project is a project name; empty searches every project.
+func SearchHook(db database.IDatabase, q config.SearchQuery, project string) error {
+	if project != "" {
+		p, err := db.GetProjectByName(project)
+		if err != nil {
+			return err
+		}
+		if p.ID == 0 {
+			return fmt.Errorf("no project named %q, run `gitresume seed` in it first", project)
+		}
+		q.ProjectID = p.ID
+	}
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	results, err := db.Search(q)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Printf("Nothing matches %q\n", q.Text)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tSOURCE\tDATE\tMATCH")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Kind, searchSource(r), r.Date, highlight(r.Snippet))
+	}
+	w.Flush()
+	return nil
+}
+
+func searchSource(r config.SearchResult) string {
+	switch r.Kind {
+	case config.SearchCommit:
+		return r.Project + "@" + r.Title
+	case config.SearchSummary:
+		return r.Project
+	}
+	return fmt.Sprintf("resume %d: %s", r.ResumeID, r.Title)
+}
+
+// highlight turns the marked HTML of a snippet into terminal text.
+func highlight(snippet string) string {
+	mark := color.New(color.FgYellow, color.Bold).SprintFunc()
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, config.SnippetOpen)
+		end := strings.Index(snippet, config.SnippetClose)
+		if start < 0 || end < start {
+			b.WriteString(html.UnescapeString(snippet))
+			return b.String()
+		}
+		b.WriteString(html.UnescapeString(snippet[:start]))
+		b.WriteString(mark(html.UnescapeString(snippet[start+len(config.SnippetOpen) : end])))
+		snippet = snippet[end+len(config.SnippetClose):]
+	}
+}
+
+func RevisionsHook(db database.IDatabase, resumeID int64) error {
+	revisions, err := db.GetResumeRevisions(resumeID)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		fmt.Println("This resume has no revisions yet")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tTIME\tCHANGE")
+	for _, r := range revisions {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", r.Revision, r.CreatedAt, r.Note)
+	}
+	w.Flush()
+	return nil
+}
+
+// RevisionDiffHook prints what changed from revision from to revision to,
+// or to the resume as it is now when to is 0.
+func RevisionDiffHook(db database.IDatabase, resumeID int64, from, to int) error {
+	changes, err := database.DiffResumeRevisions(db, resumeID, from, to)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing changed")
+		return nil
+	}
+	printChanges(changes)
+	return nil
+}
+
+func RestoreRevisionHook(db database.IDatabase, resumeID int64, revision int) error {
+	if err := db.RestoreResumeRevision(resumeID, revision); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Resume %d is back to revision %d\n", resumeID, revision)
+	return nil
+}
+
+// UserAddHook creates another user on this machine with the default prompts.
+func UserAddHook(db database.IDatabase, name, email string, in io.Reader) error {
+	prf, err := db.GetUser(email)
+	if err != nil {
+		return err
+	}
+	if prf.ID != 0 {
+		return fmt.Errorf("a user with the email %s already exists", email)
+	}
+	password, err := newPassword(in, email)
+	if err != nil {
+		return err
+	}
+	uID, err := db.CreateUser(git.Profile{Name: name, Email: email, PasswordHash: password})
+	if err != nil {
+		return err
+	}
+	if err := createDefaultPrompts(db.ForUser(uID)); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Added %s, run 'gitresume user switch %s' to use it\n", email, email)
+	return nil
+}
+
+// UserSwitchHook makes the CLI act as another user once their password is
+// checked.
+func UserSwitchHook(db database.IDatabase, email string, in io.Reader) error {
+	password, err := readPassword(in, "Password for "+email+": ")
+	if err != nil {
+		return err
+	}
+	prf, err := db.Authenticate(email, password)
+	if err != nil {
+		return err
+	}
+	if err := config.UpdateUser(config.User{Name: prf.Name, Email: prf.Email}); err != nil {
+		return err
+	}
+	fmt.Printf("✔ Switched to %s\n", prf.Email)
+	return nil
+}
+
+func UsersHook(db database.IDatabase) error {
+	users, err := db.GetUsers()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tID\tNAME\tEMAIL")
+	for _, u := range users {
+		current := ""
+		if int64(u.ID) == db.UserID() {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", current, u.ID, u.Name, u.Email)
+	}
+	w.Flush()
+	return nil
+}
+
+// UserPasswordHook changes the current user's password after checking the
+// old one.
+func UserPasswordHook(db database.IDatabase, in io.Reader) error {
+	if db.UserID() == 0 {
+		return errors.New("no user selected, run 'gitresume init' or 'gitresume user switch'")
+	}
+	prf, err := db.GetUserByID(int32(db.UserID()))
+	if err != nil {
+		return err
+	}
+	old, err := readPassword(in, "Current password: ")
+	if err != nil {
+		return err
+	}
+	if _, err := db.Authenticate(prf.Email, old); err != nil {
+		return err
+	}
+	password, err := newPassword(in, prf.Email)
+	if err != nil {
+		return err
+	}
+	if err := db.SetPassword(db.UserID(), password); err != nil {
+		return err
+	}
+	fmt.Println("✔ Password changed")
+	return nil
+}
+
+// newPassword asks for a new password twice.
+func newPassword(in io.Reader, email string) (string, error) {
+	password, err := readPassword(in, "Choose a password for "+email+": ")
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+	again, err := readPassword(in, "Repeat the password: ")
+	if err != nil {
+		return "", err
+	}
+	if again != password {
+		return "", errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+// readPassword prompts for a password without echoing it when in is a
+// terminal, and reads a line otherwise.
+func readPassword(in io.Reader, prompt string) (string, error) {
+	fmt.Print(prompt)
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Println()
+		return string(b), err
+	}
+	// Read a byte at a time so a later prompt on the same reader still gets
+	// its own line.
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	fmt.Println()
+	return strings.TrimRight(string(line), "\r"), nil
+}
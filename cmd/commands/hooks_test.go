@@ -1,26 +1,76 @@
 package commands
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/ai"
 	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
 )
 
-type mockDB struct{ database.IDatabase }
+type mockDB struct {
+	database.IDatabase
+	prompts []config.CustomPrompt
+	usage   []config.AiUsage
+	audit   []config.AiAudit
+}
+
+func (m *mockDB) Migrate() error                                    { return nil }
+func (m *mockDB) ForUser(uID int64) database.IDatabase              { return m }
+func (m *mockDB) UserID() int64                                     { return 0 }
+func (m *mockDB) GetUser(email string) (git.Profile, error)         { return git.Profile{ID: 0}, nil }
+func (m *mockDB) CreateUser(data git.Profile) (int64, error)        { return 1, nil }
+func (m *mockDB) GetProjectByName(name string) (git.Project, error) { return git.Project{}, nil }
+func (m *mockDB) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
+	m.prompts = append(m.prompts, cfg)
+	return nil
+}
+func (m *mockDB) GetLLmPromptConfig() ([]config.CustomPrompt, error) { return m.prompts, nil }
+func (m *mockDB) CreateAIUsage(u config.AiUsage) error               { m.usage = append(m.usage, u); return nil }
+func (m *mockDB) GetAIUsage(since string) ([]config.AiUsage, error)  { return m.usage, nil }
+func (m *mockDB) GetAIUsageCost(since string) (float64, error) {
+	var cost float64
+	for _, u := range m.usage {
+		cost += u.Cost
+	}
+	return cost, nil
+}
+func (m *mockDB) CreateAIAudit(a config.AiAudit) error      { m.audit = append(m.audit, a); return nil }
+func (m *mockDB) PruneAIAudit(before string) (int64, error) { return 0, nil }
 
-func (m *mockDB) Migrate() error                             { return nil }
-func (m *mockDB) GetUser(email string) (interface{}, error)  { return struct{ ID int }{0}, nil }
-func (m *mockDB) CreateUser(data interface{}) (int64, error) { return 1, nil }
-func (m *mockDB) GetProjectByName(name string) (interface{}, error) {
-	return struct{ Path string }{""}, nil
+// withHome points HOME at a temp dir with a global git identity so the hooks
+// never touch the real ~/.gitresume.
+func withHome(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	gitcfg := "[user]\n\tname = Test User\n\temail = test@example.com\n"
+	if err := os.WriteFile(filepath.Join(dir, ".gitconfig"), []byte(gitcfg), 0644); err != nil {
+		t.Fatal(err)
+	}
 }
-func (m *mockDB) Store(data interface{}) error { return nil }
 
 func TestSetupHookBasic(t *testing.T) {
+	withHome(t)
 	db := &mockDB{}
-	if err := SetupHook(db); err != nil {
+	if err := SetupHook(db, strings.NewReader("secret\nsecret\n")); err != nil {
 		t.Errorf("SetupHook failed: %v", err)
 	}
+	if len(db.prompts) == 0 {
+		t.Error("expected default prompts to be stored")
+	}
+}
+
+func TestSetupHookPasswordMismatch(t *testing.T) {
+	withHome(t)
+	if err := SetupHook(&mockDB{}, strings.NewReader("secret\nsecrte\n")); err == nil {
+		t.Error("SetupHook should fail when the passwords differ")
+	}
 }
 
 func TestSeedHookConfigNotInitialized(t *testing.T) {
@@ -33,3 +83,58 @@ func TestSeedHookConfigNotInitialized(t *testing.T) {
 		t.Error("SeedHook should fail if config not initialized")
 	}
 }
+
+func TestAiTestHookFakeProvider(t *testing.T) {
+	withHome(t)
+	err := config.SaveConfig(&config.AppConfig{
+		User:      config.User{Name: "Test User", Email: "test@example.com"},
+		AiOptions: []config.AiOptions{{Name: string(ai.Fake), Model: "fake", IsDefault: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &mockDB{prompts: defaultPromptConfig()}
+	if err := AiTestHook(db); err != nil {
+		t.Errorf("AiTestHook failed: %v", err)
+	}
+}
+
+func TestDefaultPromptsValidate(t *testing.T) {
+	for _, p := range defaultPromptConfig() {
+		if err := p.Validate(); err != nil {
+			t.Errorf("default %s prompt is invalid: %v", p.Title, err)
+		}
+	}
+}
+
+type projectsDB struct {
+	mockDB
+	deleted []int
+}
+
+func (m *projectsDB) GetProjectByName(name string) (git.Project, error) {
+	if name == "shop" {
+		return git.Project{ID: 1, Name: "shop"}, nil
+	}
+	return git.Project{}, nil
+}
+func (m *projectsDB) GetProject(id int) (git.Project, error) {
+	if id == 1 {
+		return git.Project{ID: 1, Name: "shop"}, nil
+	}
+	return git.Project{}, errors.New("project not found")
+}
+func (m *projectsDB) DeleteProject(id int) error { m.deleted = append(m.deleted, id); return nil }
+
+func TestProjectRemoveHook(t *testing.T) {
+	db := &projectsDB{}
+	if err := ProjectRemoveHook(db, "shop", false, strings.NewReader("n\n")); err != nil || len(db.deleted) != 0 {
+		t.Errorf("expected the project to be kept without a yes, got %v, deleted %v", err, db.deleted)
+	}
+	if err := ProjectRemoveHook(db, "1", false, strings.NewReader("y\n")); err != nil || len(db.deleted) != 1 {
+		t.Errorf("expected the project found by ID to be deleted, got %v, deleted %v", err, db.deleted)
+	}
+	if err := ProjectRemoveHook(db, "blog", true, nil); err == nil {
+		t.Error("expected an error for an unknown project")
+	}
+}
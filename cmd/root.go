@@ -2,14 +2,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/iamhabbeboy/gitresume/cmd/commands"
 	"github.com/iamhabbeboy/gitresume/config"
 	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
 	"github.com/spf13/cobra"
 )
 
@@ -25,10 +28,38 @@ import (
  */
 
 var (
-	aiName string
-	apiKey string
-	model  string
-	db     database.IDatabase
+	aiName      string
+	apiKey      string
+	model       string
+	usageBy     string
+	since       string
+	task        string
+	models      []string
+	evalVer     []int
+	promote     bool
+	jobFile     string
+	resumeID    int64
+	top         int
+	limit       int
+	jobTitle    string
+	company     string
+	apply       bool
+	project     int
+	state       string
+	language    string
+	steps       int
+	kinds       []string
+	until       string
+	projectName string
+	userName    string
+	email       string
+	login       bool
+	repair      bool
+	archived    bool
+	yes         bool
+	projectPath string
+	prune       bool
+	db          database.IDatabase
 )
 
 var errColor = color.New(color.FgRed).SprintFunc()
@@ -38,7 +69,7 @@ var rootCmd = &cobra.Command{
 	Short: "🚀 Gitresume helps you summarize git activity and prep for job interviews",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if db == nil {
-			db = database.GetInstance()
+			db = database.ForConfiguredUser(database.GetInstance())
 		}
 		return nil
 	},
@@ -51,9 +82,88 @@ func init() {
 
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(seedCmd)
+	dashboardCmd.Flags().BoolVar(&login, "login", false, "Ask for an email and password and only show that user's data")
 	rootCmd.AddCommand(dashboardCmd)
 	// aiCmd.AddCommand(aiConfigCmd)
+	aiUsageCmd.Flags().StringVar(&usageBy, "by", "provider", "Group usage by provider, day or project")
+	aiUsageCmd.Flags().StringVar(&since, "since", "", "Only include calls after this date (YYYY-MM-DD), defaults to the start of the month")
+	aiCmd.AddCommand(aiUsageCmd)
+	aiCmd.AddCommand(aiBudgetCmd)
+	aiAuditCmd.Flags().StringVar(&since, "since", "", "Only include requests after this date (YYYY-MM-DD)")
+	aiAuditCmd.Flags().IntVar(&limit, "limit", 20, "Number of requests to list, 0 for all")
+	aiAuditCmd.AddCommand(aiAuditShowCmd)
+	aiCmd.AddCommand(aiAuditCmd)
+	aiCmd.AddCommand(aiLocalOnlyCmd)
+	aiEvalCmd.Flags().StringVar(&task, "task", string(config.ProjectPrompt), "Prompt type to evaluate (project or summary)")
+	aiEvalCmd.Flags().IntSliceVar(&evalVer, "versions", nil, "Prompt versions to compare, defaults to the latest two")
+	aiEvalCmd.Flags().StringSliceVar(&models, "models", nil, "Providers to run, as provider or provider/model, defaults to the configured routing")
+	aiEvalCmd.Flags().BoolVar(&promote, "promote", false, "Make the winning version the active prompt")
+	aiCmd.AddCommand(aiEvalCmd)
+	aiVersionsCmd.Flags().StringVar(&task, "task", string(config.ProjectPrompt), "Prompt type (project or summary)")
+	aiCmd.AddCommand(aiVersionsCmd)
+	aiPromoteCmd.Flags().StringVar(&task, "task", string(config.ProjectPrompt), "Prompt type (project or summary)")
+	aiCmd.AddCommand(aiPromoteCmd)
+	aiCmd.AddCommand(aiModelsCmd)
+	aiCmd.AddCommand(aiPullCmd)
 	rootCmd.AddCommand(aiCmd)
+	matchCmd.Flags().StringVar(&jobFile, "job", "", "File with the job description, or - to read stdin")
+	matchCmd.Flags().Int64Var(&resumeID, "resume", 0, "Only match the evidence of this resume, defaults to all resumes")
+	matchCmd.Flags().IntVar(&top, "top", 10, "Number of top matching evidence to show")
+	rootCmd.AddCommand(matchCmd)
+	tailorCmd.Flags().Int64Var(&resumeID, "resume", 0, "Resume to tailor")
+	tailorCmd.Flags().StringVar(&jobFile, "job", "", "File with the job description, or - to read stdin")
+	tailorCmd.Flags().StringVar(&jobTitle, "title", "", "Job title, used to name the new version")
+	tailorCmd.Flags().StringVar(&company, "company", "", "Company the job is at")
+	tailorCmd.Flags().BoolVar(&apply, "apply", false, "Save the tailored resume as a new version")
+	rootCmd.AddCommand(tailorCmd)
+	suggestionsCmd.Flags().IntVar(&project, "project", 0, "Only list the suggestions of this project")
+	suggestionsCmd.Flags().StringVar(&state, "state", string(config.SuggestionPending), "pending, accepted, edited or rejected, empty for all")
+	suggestionsCmd.AddCommand(reviewCmd("accept", config.SuggestionAccepted))
+	suggestionsCmd.AddCommand(reviewCmd("reject", config.SuggestionRejected))
+	suggestionsCmd.AddCommand(reviewCmd("edit", config.SuggestionEdited))
+	suggestionsCmd.AddCommand(reviewCmd("reopen", config.SuggestionPending))
+	rootCmd.AddCommand(suggestionsCmd)
+	lintCmd.Flags().Int64Var(&resumeID, "resume", 0, "Resume to check")
+	rootCmd.AddCommand(lintCmd)
+	translateCmd.Flags().Int64Var(&resumeID, "resume", 0, "Resume to translate")
+	translateCmd.Flags().StringVar(&language, "lang", "", "Language code to translate into, e.g. de, fr or pt-BR")
+	rootCmd.AddCommand(translateCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbRollbackCmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to revert")
+	dbCmd.AddCommand(dbRollbackCmd)
+	dbCheckCmd.Flags().BoolVar(&repair, "repair", false, "Fix the problems found, backing up the database first")
+	dbCmd.AddCommand(dbCheckCmd)
+	rootCmd.AddCommand(dbCmd)
+	searchCmd.Flags().StringVar(&projectName, "project", "", "Only search the commits and summaries of this project")
+	searchCmd.Flags().StringSliceVar(&kinds, "type", nil, "Kinds to search: commit, summary, experience or project, defaults to all")
+	searchCmd.Flags().StringVar(&since, "since", "", "Only include results from this date on (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&until, "until", "", "Only include results up to this date (YYYY-MM-DD)")
+	searchCmd.Flags().IntVar(&limit, "limit", 20, "Number of results to show, 0 for all")
+	rootCmd.AddCommand(searchCmd)
+	revisionsCmd.PersistentFlags().Int64Var(&resumeID, "resume", 0, "Resume whose revisions to use")
+	revisionsCmd.AddCommand(revisionDiffCmd)
+	revisionsCmd.AddCommand(revisionRestoreCmd)
+	rootCmd.AddCommand(revisionsCmd)
+	userAddCmd.Flags().StringVar(&userName, "name", "", "Full name of the user")
+	userAddCmd.Flags().StringVar(&email, "email", "", "Email of the user, also used to pick their commits")
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userSwitchCmd)
+	userCmd.AddCommand(userListCmd)
+	userCmd.AddCommand(userPasswordCmd)
+	rootCmd.AddCommand(userCmd)
+	projectsListCmd.Flags().BoolVar(&archived, "all", false, "Include archived projects")
+	projectsCmd.AddCommand(projectsListCmd)
+	projectsCmd.AddCommand(projectsShowCmd)
+	projectsCmd.AddCommand(projectsRenameCmd)
+	projectsCmd.AddCommand(projectsArchiveCmd)
+	projectsCmd.AddCommand(projectsUnarchiveCmd)
+	projectsRmCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete without asking")
+	projectsCmd.AddCommand(projectsRmCmd)
+	projectsRescanCmd.Flags().StringVar(&projectPath, "path", "", "Where the repository is now, if it moved")
+	projectsRescanCmd.Flags().BoolVar(&prune, "prune", false, "Also delete the commits git no longer lists, with their summaries")
+	projectsCmd.AddCommand(projectsRescanCmd)
+	rootCmd.AddCommand(projectsCmd)
 	rootCmd.AddCommand(completionCmd)
 }
 
@@ -69,9 +179,13 @@ var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize gitresume config",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := commands.SetupHook(db)
+		err := commands.SetupHook(db, os.Stdin)
 		if err != nil {
 			fmt.Println(errColor("🚫 Error:" + err.Error()))
+			return
+		}
+		if err := commands.EnsureLocalModelHook(os.Stdin); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
 		}
 	},
 }
@@ -93,7 +207,7 @@ var dashboardCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Web dashboard for managing your resumes",
 	Run: func(cmd *cobra.Command, args []string) {
-		commands.DashboardHook(db)
+		commands.DashboardHook(db, login)
 		// if err != nil {
 		// 	fmt.Println("Failed to start dashboard server:", err)
 		// } else {
@@ -107,7 +221,7 @@ var aiConfigCmd = &cobra.Command{
 	Short: "Configure AI provider credentials",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if aiName == "" || apiKey == "" || model == "" {
-			return fmt.Errorf(errColor("--name, --api-key, and --model are required"))
+			return errors.New(errColor("--name, --api-key, and --model are required"))
 		}
 		cfg := config.AiOptions{
 			Name:      strings.ToLower(aiName),
@@ -116,7 +230,7 @@ var aiConfigCmd = &cobra.Command{
 			IsDefault: true,
 		}
 		if err := config.UpdateAIConfig(cfg); err != nil {
-			return fmt.Errorf(errColor("🚫 Unable to update config"))
+			return errors.New(errColor("🚫 Unable to update config"))
 		}
 		fmt.Printf("🎉 AI provider '%s' configured successfully \n", aiName)
 		return nil
@@ -136,6 +250,475 @@ var aiCmd = &cobra.Command{
 	},
 }
 
+var aiUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show AI token usage and estimated cost",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.AiUsageHook(db, usageBy, since); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiEvalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Compare prompt versions across models on a fixed set of commits",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.AiEvalHook(db, config.PromptType(task), evalVer, models, promote); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiVersionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List saved versions of a prompt",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.AiVersionsHook(db, config.PromptType(task)); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiPromoteCmd = &cobra.Command{
+	Use:   "promote <version>",
+	Short: "Make a saved prompt version the active one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.Atoi(strings.TrimPrefix(args[0], "v"))
+		if err != nil {
+			fmt.Println(errColor("🚫 Invalid version:", args[0]))
+			return
+		}
+		if err := commands.AiPromoteHook(db, config.PromptType(task), version); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var matchCmd = &cobra.Command{
+	Use:   "match",
+	Short: "Rank your commits, responsibilities and projects against a job description",
+	Run: func(cmd *cobra.Command, args []string) {
+		if jobFile == "" {
+			fmt.Println(errColor("🚫 Error:", "--job is required, pass a file or - for stdin"))
+			return
+		}
+		if err := commands.MatchHook(db, jobFile, resumeID, top); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var tailorCmd = &cobra.Command{
+	Use:   "tailor",
+	Short: "Tailor a resume to a job description and review the changes",
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 || jobFile == "" {
+			fmt.Println(errColor("🚫 Error:", "--resume and --job are required"))
+			return
+		}
+		job := git.Job{Title: jobTitle, Company: company}
+		if err := commands.TailorHook(db, resumeID, jobFile, job, apply); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var translateCmd = &cobra.Command{
+	Use:   "translate",
+	Short: "Translate a resume and save it as a linked language version",
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 || language == "" {
+			fmt.Println(errColor("🚫 Error:", "--resume and --lang are required"))
+			return
+		}
+		if err := commands.TranslateHook(db, resumeID, language); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check resume bullets and summary for weak wording, without AI",
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 {
+			fmt.Println(errColor("🚫 Error:", "--resume is required"))
+			return
+		}
+		if err := commands.LintHook(db, resumeID); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var suggestionsCmd = &cobra.Command{
+	Use:   "suggestions",
+	Short: "List AI suggestions waiting for review",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.SuggestionsHook(db, project, config.SuggestionState(state)); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+// reviewCmd moves a suggestion to state; edit takes the new wording too.
+func reviewCmd(name string, state config.SuggestionState) *cobra.Command {
+	use, args := name+" <id>", cobra.ExactArgs(1)
+	if state == config.SuggestionEdited {
+		use, args = name+" <id> <text>", cobra.ExactArgs(2)
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: fmt.Sprintf("Mark a suggestion as %s", state),
+		Args:  args,
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				fmt.Println(errColor("🚫 Invalid suggestion ID:", args[0]))
+				return
+			}
+			text := ""
+			if len(args) > 1 {
+				text = args[1]
+			}
+			if err := commands.ReviewSuggestionHook(db, id, state, text); err != nil {
+				fmt.Println(errColor("🚫 Error:", err))
+			}
+		},
+	}
+}
+
+var aiModelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List the models pulled into the local Ollama server",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.AiModelsHook(); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiPullCmd = &cobra.Command{
+	Use:   "pull [model]",
+	Short: "Pull a model into the local Ollama server, defaults to the configured one",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		model := ""
+		if len(args) > 0 {
+			model = args[0]
+		}
+		if err := commands.AiPullHook(model); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiBudgetCmd = &cobra.Command{
+	Use:   "budget <usd>",
+	Short: "Set a monthly spending cap for paid AI providers (0 removes it)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			fmt.Println(errColor("🚫 Invalid amount:", args[0]))
+			return
+		}
+		if err := commands.AiBudgetHook(limit); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "List what was sent to AI providers",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.AiAuditHook(db, since, limit); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiAuditShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print the full prompt of a logged request",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Println(errColor("🚫 Invalid id:", args[0]))
+			return
+		}
+		if err := commands.AiAuditShowHook(db, id); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var aiLocalOnlyCmd = &cobra.Command{
+	Use:       "local-only <on|off>",
+	Short:     "Refuse every AI provider that is not running on this machine",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if args[0] != "on" && args[0] != "off" {
+			fmt.Println(errColor("🚫 Expected on or off, got", args[0]))
+			return
+		}
+		if err := commands.AiLocalOnlyHook(args[0] == "on"); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search your commits, summaries and resume content",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		q := config.SearchQuery{Text: strings.Join(args, " "), Kinds: kinds, Since: since, Until: until, Limit: limit}
+		if err := commands.SearchHook(db, q, projectName); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var revisionsCmd = &cobra.Command{
+	Use:   "revisions",
+	Short: "List the saved revisions of a resume",
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 {
+			fmt.Println(errColor("🚫 Error:", "--resume is required"))
+			return
+		}
+		if err := commands.RevisionsHook(db, resumeID); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var revisionDiffCmd = &cobra.Command{
+	Use:   "diff <from> [to]",
+	Short: "Show what changed between two revisions, or since one when to is left out",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 {
+			fmt.Println(errColor("🚫 Error:", "--resume is required"))
+			return
+		}
+		revs := make([]int, 2)
+		for i, a := range args {
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				fmt.Println(errColor("🚫 Invalid revision:", a))
+				return
+			}
+			revs[i] = n
+		}
+		if err := commands.RevisionDiffHook(db, resumeID, revs[0], revs[1]); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var revisionRestoreCmd = &cobra.Command{
+	Use:   "restore <revision>",
+	Short: "Put a resume back as it was at a revision",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if resumeID == 0 {
+			fmt.Println(errColor("🚫 Error:", "--resume is required"))
+			return
+		}
+		revision, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println(errColor("🚫 Invalid revision:", args[0]))
+			return
+		}
+		if err := commands.RestoreRevisionHook(db, resumeID, revision); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the users sharing this machine",
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		if userName == "" || email == "" {
+			fmt.Println(errColor("🚫 Error:", "--name and --email are required"))
+			return
+		}
+		if err := commands.UserAddHook(db, userName, email, os.Stdin); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var userSwitchCmd = &cobra.Command{
+	Use:   "switch <email>",
+	Short: "Act as another user from now on",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.UserSwitchHook(db, args[0], os.Stdin); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the users, marking the current one",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.UsersHook(db); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var userPasswordCmd = &cobra.Command{
+	Use:   "password",
+	Short: "Change the current user's password",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.UserPasswordHook(db, os.Stdin); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the local database schema",
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List schema migrations and whether they are applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.DbStatusHook(db); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations, backing up the database first",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.DbMigrateHook(db); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var dbRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Revert the most recent schema migrations, backing up the database first",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.DbRollbackHook(db, steps); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var dbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Find duplicate commits, orphaned summaries and dangling references",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.DbCheckHook(db, repair); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Manage the projects seeded from your git repositories",
+}
+
+var projectsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectsHook(db, archived); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsShowCmd = &cobra.Command{
+	Use:   "show <project>",
+	Short: "Show a project, by name or ID, and its latest commits",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectShowHook(db, args[0]); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsRenameCmd = &cobra.Command{
+	Use:   "rename <project> <new-name>",
+	Short: "Rename a project",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectRenameHook(db, args[0], args[1]); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsArchiveCmd = &cobra.Command{
+	Use:   "archive <project>",
+	Short: "Keep a project but leave it out of the dashboard and resume generation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectArchiveHook(db, args[0], true); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsUnarchiveCmd = &cobra.Command{
+	Use:   "unarchive <project>",
+	Short: "Bring an archived project back",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectArchiveHook(db, args[0], false); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsRmCmd = &cobra.Command{
+	Use:   "rm <project>",
+	Short: "Delete a project with its commits, summaries and suggestions",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectRemoveHook(db, args[0], yes, os.Stdin); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
+var projectsRescanCmd = &cobra.Command{
+	Use:   "rescan <project>",
+	Short: "Read a project's whole git history again and refresh its tech stack",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := commands.ProjectRescanHook(db, args[0], projectPath, prune); err != nil {
+			fmt.Println(errColor("🚫 Error:", err))
+		}
+	},
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
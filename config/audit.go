@@ -0,0 +1,24 @@
+package config
+
+// DefaultAuditRetention is how many days audit entries are kept when
+// ai_audit_retention is not set.
+const DefaultAuditRetention = 90
+
+// AiAudit is one request sent to an AI provider. Prompt is exactly what
+// left the machine, after redaction. UserID is the user it was sent for.
+type AiAudit struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	CreatedAt    string     `json:"created_at"`
+	Provider     string     `json:"provider"`
+	Model        string     `json:"model"`
+	Endpoint     string     `json:"endpoint"`
+	PromptType   PromptType `json:"prompt_type"`
+	Project      string     `json:"project"`
+	Origin       string     `json:"origin"`
+	Prompt       string     `json:"prompt"`
+	Redactions   int        `json:"redactions"`
+	ResponseHash string     `json:"response_hash"`
+	Success      bool       `json:"success"`
+	Error        string     `json:"error,omitempty"`
+}
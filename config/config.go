@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,15 +16,47 @@ var (
 	once      sync.Once
 )
 
+// User is the current user: the one the CLI reads and writes as, and whose
+// email picks their commits from git log.
 type User struct {
 	Name  string `yaml:"name"`
 	Email string `yaml:"email"`
 }
 
+// ErrInvalidCredentials is returned for an unknown email or a wrong
+// password.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrProjectExists is returned when a project would take the name of
+// another project of the same user.
+var ErrProjectExists = errors.New("a project with that name already exists")
+
 type AppConfig struct {
 	AuthToken string      `yaml:"auth_token"`
 	User      User        `yaml:"user"`
 	AiOptions []AiOptions `mapstructure:"ai_options" yaml:"ai_options" json:"ai_options"`
+	// AiFallback lists provider names tried in order when the preferred
+	// provider fails or times out, e.g. [llama, gemini, openai].
+	AiFallback []string `mapstructure:"ai_fallback" yaml:"ai_fallback" json:"ai_fallback"`
+	// AiRoutes picks a provider/model per prompt type.
+	AiRoutes []AiRoute `mapstructure:"ai_routes" yaml:"ai_routes" json:"ai_routes"`
+	// AiTimeout is the per-provider timeout in seconds before falling back.
+	AiTimeout int `mapstructure:"ai_timeout" yaml:"ai_timeout" json:"ai_timeout"`
+	// AiPricing overrides DefaultPricing for cost estimates.
+	AiPricing []AiPrice `mapstructure:"ai_pricing" yaml:"ai_pricing" json:"ai_pricing"`
+	AiBudget  AiBudget  `mapstructure:"ai_budget" yaml:"ai_budget" json:"ai_budget"`
+	// AiLint gives generated bullets one extra model turn to fix what the
+	// linter warns about.
+	AiLint bool `mapstructure:"ai_lint" yaml:"ai_lint" json:"ai_lint"`
+	// AiLocalOnly refuses every provider that is not running on this
+	// machine, such as Ollama or a TGI container on localhost.
+	AiLocalOnly bool `mapstructure:"ai_local_only" yaml:"ai_local_only" json:"ai_local_only"`
+	// AiAuditRetention is how many days the log of requests sent to AI
+	// providers is kept; 0 means DefaultAuditRetention.
+	AiAuditRetention int `mapstructure:"ai_audit_retention" yaml:"ai_audit_retention" json:"ai_audit_retention"`
+	// Database picks the storage driver: "sqlite" (the default) or "bolt",
+	// which needs no cgo.
+	Database string `mapstructure:"database" yaml:"database" json:"database,omitempty"`
 }
 
 type AiOptions struct {
@@ -31,13 +64,50 @@ type AiOptions struct {
 	ApiKey    string `mapstructure:"api_key" yaml:"api_key" json:"api_key"`
 	Model     string `mapstructure:"model" yaml:"model" json:"model"`
 	IsDefault bool   `mapstructure:"is_default" yaml:"is_default" json:"is_default"`
+	// BaseURL overrides the provider endpoint, e.g. a local TGI container
+	// at http://localhost:8080/v1.
+	BaseURL string `mapstructure:"base_url" yaml:"base_url" json:"base_url,omitempty"`
 
 	// CustomPrompt CustomPrompt `json:"custom_prompt,-"`
 }
 
+// keyMask stands in for the hidden part of an API key.
+const keyMask = "****"
+
+// MaskAPIKey hides key, keeping its last four characters when it is long
+// enough for that to give nothing away.
+func MaskAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) < 12 {
+		return keyMask
+	}
+	return keyMask + key[len(key)-4:]
+}
+
+// UnmaskAPIKey returns stored when key is empty or is stored masked, as the
+// dashboard sends back the keys it was given.
+func UnmaskAPIKey(key, stored string) string {
+	if key == "" || key == MaskAPIKey(stored) {
+		return stored
+	}
+	return key
+}
+
+type AiRoute struct {
+	Task     PromptType `mapstructure:"task" yaml:"task" json:"task"`
+	Provider string     `mapstructure:"provider" yaml:"provider" json:"provider"`
+	Model    string     `mapstructure:"model" yaml:"model" json:"model"`
+}
+
 type AiConfigResponse struct {
 	Models       []AiOptions    `json:"models"`
 	CustomPrompt []CustomPrompt `json:"custom_prompt"`
+	Fallback     []string       `json:"fallback"`
+	Routes       []AiRoute      `json:"routes"`
+	// Variables lists the prompt template variables; it is ignored on update.
+	Variables map[string]string `json:"variables,omitempty"`
 }
 
 type PromptType string
@@ -45,6 +115,15 @@ type PromptType string
 var (
 	ProjectPrompt PromptType = "project"
 	SummaryPrompt PromptType = "summary"
+	// RewritePrompt routes free-form rewrites of resume fields.
+	RewritePrompt PromptType = "rewrite"
+	// TailorPrompt routes tailoring a resume to a job description.
+	TailorPrompt PromptType = "tailor"
+	// TranslatePrompt routes translating a resume into another language.
+	TranslatePrompt PromptType = "translate"
+	// EmbedPrompt routes embeddings; the model must be an embedding model
+	// such as nomic-embed-text.
+	EmbedPrompt PromptType = "embed"
 )
 
 type CustomPrompt struct {
@@ -61,8 +140,6 @@ type Prompt struct {
 	Role    string `json:"role"`
 }
 
-var config AppConfig
-
 func LoadConfig() (AppConfig, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -96,30 +173,40 @@ func LoadConfig() (AppConfig, error) {
 		return AppConfig{}, nil
 	}
 
-	// Unmarshal into struct
-	err = viper.Unmarshal(&config)
+	// Unmarshal into a fresh struct so keys removed from the file do not
+	// keep the values of an earlier load
+	var cfg AppConfig
+	err = viper.Unmarshal(&cfg)
 	if err != nil {
 		return AppConfig{}, nil
 	}
 
-	return config, nil
+	return cfg, nil
 }
 
 // SaveConfig writes the AppConfig to disk
 func SaveConfig(cfg *AppConfig) error {
 	home, _ := os.UserHomeDir()
 	configPath := filepath.Join(home, ".gitresume", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(configPath), os.ModePerm); err != nil {
+		return err
+	}
 
+	// every field is written, so clearing a value clears it on disk
 	v := viper.New()
 	v.SetConfigType("yaml")
-	if cfg.User.Name != "" && cfg.User.Email != "" {
-		v.Set("user.name", cfg.User.Name)
-		v.Set("user.email", cfg.User.Email)
-	}
-
-	if len(cfg.AiOptions) > 0 {
-		v.Set("ai_options", cfg.AiOptions)
-	}
+	v.Set("user.name", cfg.User.Name)
+	v.Set("user.email", cfg.User.Email)
+	v.Set("ai_options", cfg.AiOptions)
+	v.Set("ai_fallback", cfg.AiFallback)
+	v.Set("ai_routes", cfg.AiRoutes)
+	v.Set("ai_timeout", cfg.AiTimeout)
+	v.Set("ai_pricing", cfg.AiPricing)
+	v.Set("ai_budget.monthly_limit", cfg.AiBudget.MonthlyLimit)
+	v.Set("ai_lint", cfg.AiLint)
+	v.Set("ai_local_only", cfg.AiLocalOnly)
+	v.Set("ai_audit_retention", cfg.AiAuditRetention)
+	v.Set("database", cfg.Database)
 
 	return v.WriteConfigAs(configPath)
 }
@@ -147,9 +234,10 @@ func UpdateAIConfig(conf AiOptions) error {
 	if len(cfg.AiOptions) > 0 {
 		for i := range cfg.AiOptions {
 			if strings.EqualFold(cfg.AiOptions[i].Name, conf.Name) {
-				cfg.AiOptions[i].ApiKey = conf.ApiKey
+				cfg.AiOptions[i].ApiKey = UnmaskAPIKey(conf.ApiKey, cfg.AiOptions[i].ApiKey)
 				cfg.AiOptions[i].Model = conf.Model
 				cfg.AiOptions[i].IsDefault = conf.IsDefault
+				cfg.AiOptions[i].BaseURL = conf.BaseURL
 				isFound = true
 			} else {
 				cfg.AiOptions[i].IsDefault = false
@@ -167,6 +255,134 @@ func UpdateAIConfig(conf AiOptions) error {
 	return nil
 }
 
+// UpdateAIBudget sets the monthly spending cap for paid providers.
+func UpdateAIBudget(limit float64) error {
+	if limit < 0 {
+		return errors.New("budget cannot be negative")
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AiBudget.MonthlyLimit = limit
+	return SaveConfig(&cfg)
+}
+
+// UpdateUser makes u the current user.
+func UpdateUser(u User) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.User = u
+	return SaveConfig(&cfg)
+}
+
+// UpdateAILocalOnly turns the local-only switch on or off.
+func UpdateAILocalOnly(on bool) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AiLocalOnly = on
+	return SaveConfig(&cfg)
+}
+
+// UpdateAIRouting replaces the fallback chain and per-task routes.
+func UpdateAIRouting(fallback []string, routes []AiRoute) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	for _, name := range fallback {
+		if _, ok := cfg.provider(name); !ok {
+			return fmt.Errorf("unknown AI provider %q in fallback chain", name)
+		}
+	}
+	for _, r := range routes {
+		if r.Task == "" {
+			return errors.New("AI route is missing a task")
+		}
+		if _, ok := cfg.provider(r.Provider); !ok {
+			return fmt.Errorf("unknown AI provider %q for task %q", r.Provider, r.Task)
+		}
+	}
+	cfg.AiFallback = fallback
+	cfg.AiRoutes = routes
+	return SaveConfig(&cfg)
+}
+
+// ProvidersFor returns the providers to try for a prompt type, in order: the
+// routed provider (or the default one), followed by the fallback chain.
+func (c AppConfig) ProvidersFor(task PromptType) []AiOptions {
+	var (
+		out  []AiOptions
+		seen = map[string]bool{}
+	)
+	add := func(o AiOptions) {
+		key := strings.ToLower(o.Name) + "/" + o.Model
+		if o.Name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, o)
+	}
+
+	routed := false
+	for _, r := range c.AiRoutes {
+		if r.Task != task {
+			continue
+		}
+		if o, ok := c.provider(r.Provider); ok {
+			if r.Model != "" {
+				o.Model = r.Model
+			}
+			add(o)
+			routed = true
+		}
+	}
+	if !routed {
+		for _, o := range c.AiOptions {
+			if o.IsDefault {
+				add(o)
+			}
+		}
+	}
+	for _, name := range c.AiFallback {
+		if o, ok := c.provider(name); ok {
+			add(o)
+		}
+	}
+	return out
+}
+
+// Providers resolves "provider" or "provider/model" specs against the
+// configured providers, e.g. ["llama", "openai/gpt-5-mini"].
+func (c AppConfig) Providers(specs []string) ([]AiOptions, error) {
+	out := make([]AiOptions, 0, len(specs))
+	for _, spec := range specs {
+		name, model, _ := strings.Cut(strings.TrimSpace(spec), "/")
+		o, ok := c.provider(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown AI provider %q", name)
+		}
+		if model != "" {
+			o.Model = model
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (c AppConfig) provider(name string) (AiOptions, bool) {
+	for _, o := range c.AiOptions {
+		if strings.EqualFold(o.Name, name) {
+			return o, true
+		}
+	}
+	return AiOptions{}, false
+}
+
 // func AddProject(path string, user User) error {
 // 	cfg, err := LoadConfig()
 // 	if err != nil {
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	// "path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -27,3 +29,83 @@ func TestLoadAndSaveConfig(t *testing.T) {
 		t.Errorf("user email mismatch")
 	}
 }
+
+func TestSaveConfigClearsValues(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SaveConfig(&AppConfig{AiOptions: []AiOptions{{Name: "llama", Model: "llama3.2", IsDefault: true}, {Name: "openai", Model: "gpt-5-mini"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateAIRouting([]string{"llama", "openai"}, []AiRoute{{Task: SummaryPrompt, Provider: "openai"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateAILocalOnly(true); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.AiFallback) != 2 || len(cfg.AiRoutes) != 1 || !cfg.AiLocalOnly {
+		t.Fatalf("expected the routing and local-only switch to be saved, got %+v", cfg)
+	}
+
+	if err := UpdateAIRouting([]string{}, []AiRoute{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateAILocalOnly(false); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.AiFallback) != 0 || len(cfg.AiRoutes) != 0 || cfg.AiLocalOnly {
+		t.Errorf("expected the cleared values to stay cleared, got %+v", cfg)
+	}
+	if len(cfg.AiOptions) != 2 {
+		t.Errorf("expected the providers to be kept, got %+v", cfg.AiOptions)
+	}
+}
+
+func TestProvidersFor(t *testing.T) {
+	cfg := AppConfig{
+		AiOptions: []AiOptions{
+			{Name: "llama", Model: "llama3.2", IsDefault: true},
+			{Name: "openai", Model: "gpt-5-mini", ApiKey: "sk"},
+			{Name: "gemini", Model: "gemini-2.5-flash"},
+		},
+		AiFallback: []string{"llama", "gemini", "openai"},
+		AiRoutes:   []AiRoute{{Task: SummaryPrompt, Provider: "openai", Model: "gpt-5"}},
+	}
+
+	names := func(opts []AiOptions) []string {
+		var out []string
+		for _, o := range opts {
+			out = append(out, o.Name+"/"+o.Model)
+		}
+		return out
+	}
+
+	got := names(cfg.ProvidersFor(ProjectPrompt))
+	want := []string{"llama/llama3.2", "gemini/gemini-2.5-flash", "openai/gpt-5-mini"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("project: expected %v, got %v", want, got)
+	}
+
+	got = names(cfg.ProvidersFor(SummaryPrompt))
+	want = []string{"openai/gpt-5", "llama/llama3.2", "gemini/gemini-2.5-flash", "openai/gpt-5-mini"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary: expected %v, got %v", want, got)
+	}
+}
+
+func TestLanguageCode(t *testing.T) {
+	for in, want := range map[string]string{"de": "de", "DE": "de", "pt_br": "pt-BR", " fr ": "fr"} {
+		if got, err := LanguageCode(in); err != nil || got != want {
+			t.Errorf("LanguageCode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := LanguageCode("klingon"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("expected ErrUnsupportedLanguage, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+// Evidence kinds that can be matched against a job description.
+const (
+	EvidenceCommit         = "commit"
+	EvidenceResponsibility = "responsibility"
+	EvidenceProject        = "project"
+)
+
+// Evidence is a piece of the user's history that can back a job
+// requirement. Ref identifies it across runs, e.g. "summary:12" or "work:3:0".
+type Evidence struct {
+	Ref    string `json:"ref"`
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Text   string `json:"text"`
+}
+
+// Embedding is the stored vector of one piece of evidence. Hash is the hash
+// of Text, so edited evidence is embedded again.
+type Embedding struct {
+	ID int64 `json:"id"`
+	Evidence
+	Model  string    `json:"model"`
+	Hash   string    `json:"hash"`
+	Vector []float32 `json:"-"`
+}
+
+type EmbeddingMatch struct {
+	Evidence
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,24 @@
+package config
+
+// IntegrityCheck names a kind of problem `db check` looks for.
+type IntegrityCheck string
+
+const (
+	// CheckDuplicateCommits finds a commit stored more than once for a project.
+	CheckDuplicateCommits IntegrityCheck = "duplicate_commits"
+	// CheckOrphanedSummaries finds summaries whose project or commit is gone.
+	CheckOrphanedSummaries IntegrityCheck = "orphaned_summaries"
+	// CheckDanglingReferences finds rows that point at a missing row.
+	CheckDanglingReferences IntegrityCheck = "dangling_references"
+)
+
+// IntegrityIssue counts the rows of a table with one kind of problem.
+type IntegrityIssue struct {
+	Check IntegrityCheck `json:"check"`
+	Table string         `json:"table"`
+	// Reference is the column and the table it points at, for dangling
+	// references.
+	Reference string `json:"reference,omitempty"`
+	Count     int64  `json:"count"`
+	Repaired  bool   `json:"repaired"`
+}
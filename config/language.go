@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Languages maps the language codes a resume can be translated into to
+// the name used when prompting the model.
+var Languages = map[string]string{
+	"en":    "English",
+	"de":    "German",
+	"fr":    "French",
+	"es":    "Spanish",
+	"it":    "Italian",
+	"nl":    "Dutch",
+	"pt":    "European Portuguese",
+	"pt-BR": "Brazilian Portuguese",
+}
+
+// LanguageCode normalizes a code such as "DE" or "pt_br" to its key in
+// Languages.
+func LanguageCode(code string) (string, error) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	for k := range Languages {
+		if strings.EqualFold(k, code) {
+			return k, nil
+		}
+	}
+	codes := make([]string, 0, len(Languages))
+	for k := range Languages {
+		codes = append(codes, k)
+	}
+	sort.Strings(codes)
+	return "", fmt.Errorf("%w %q, use one of %s", ErrUnsupportedLanguage, code, strings.Join(codes, ", "))
+}
@@ -0,0 +1,12 @@
+package config
+
+// Migration is a database schema migration. AppliedAt is empty while it is
+// pending.
+type Migration struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt string `json:"applied_at,omitempty"`
+	// Reversible is false for migrations without a down script, which
+	// cannot be rolled back.
+	Reversible bool `json:"reversible"`
+}
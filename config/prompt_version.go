@@ -0,0 +1,44 @@
+package config
+
+// PromptVersion is a saved revision of a CustomPrompt. Every save creates a
+// new version; Active marks the one currently stored in prompts.
+type PromptVersion struct {
+	ID          int64      `json:"id"`
+	Title       PromptType `json:"title"`
+	Version     int        `json:"version"`
+	Temperature float32    `json:"temperature"`
+	MaxTokens   int        `json:"max_tokens"`
+	Prompts     []Prompt   `json:"prompts"`
+	Active      bool       `json:"active"`
+	CreatedAt   string     `json:"created_at"`
+}
+
+// CustomPrompt returns the version as a prompt that can be rendered or saved.
+func (v PromptVersion) CustomPrompt() CustomPrompt {
+	return CustomPrompt{
+		Title:       v.Title,
+		Temperature: v.Temperature,
+		MaxTokens:   v.MaxTokens,
+		Prompts:     v.Prompts,
+	}
+}
+
+// PromptEval is the result of running one prompt version on one model over
+// the evaluation sample. Ratios are in the range 0..1.
+type PromptEval struct {
+	ID          int64      `json:"id"`
+	RunID       string     `json:"run_id"`
+	Title       PromptType `json:"title"`
+	Version     int        `json:"version"`
+	Provider    string     `json:"provider"`
+	Model       string     `json:"model"`
+	Output      []string   `json:"output"`
+	Bullets     int        `json:"bullets"`
+	AvgLength   float64    `json:"avg_length"`
+	ActionVerbs float64    `json:"action_verbs"`
+	Quantified  float64    `json:"quantified"`
+	LatencyMs   int64      `json:"latency_ms"`
+	Cost        float64    `json:"cost"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   string     `json:"created_at"`
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of content search looks through.
+const (
+	SearchCommit     = "commit"
+	SearchSummary    = "summary"
+	SearchExperience = "experience"
+	SearchProject    = "project"
+)
+
+// SearchKinds lists every kind, in the order results of equal rank are shown.
+var SearchKinds = []string{SearchCommit, SearchSummary, SearchExperience, SearchProject}
+
+// SnippetOpen and SnippetClose surround the matched terms in a snippet.
+const (
+	SnippetOpen  = "<mark>"
+	SnippetClose = "</mark>"
+)
+
+// SearchQuery is a full-text search. Every word of Text must match; the
+// last one also matches as a prefix. Zero values mean no filter.
+type SearchQuery struct {
+	Text      string   `json:"q"`
+	ProjectID int      `json:"project_id,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+	// Since and Until bound the date of a result, as YYYY-MM-DD. That is the
+	// author date of a commit, the start date of a work experience and the
+	// creation date of anything else.
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// SearchResult is one match. ID is the ID of the commit, summary, work
+// experience or project it came from.
+type SearchResult struct {
+	Kind      string `json:"kind"`
+	ID        int64  `json:"id"`
+	ProjectID int    `json:"project_id,omitempty"`
+	Project   string `json:"project,omitempty"`
+	ResumeID  int64  `json:"resume_id,omitempty"`
+	// Title is the commit hash, the company or the project title.
+	Title   string `json:"title,omitempty"`
+	Snippet string `json:"snippet"`
+	Date    string `json:"date,omitempty"`
+	// Score is higher for better matches.
+	Score float64 `json:"score"`
+}
+
+// ValidSearchKind reports whether kind is one of SearchKinds.
+func ValidSearchKind(kind string) bool {
+	for _, k := range SearchKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the kinds, dates and limit of q.
+func (q SearchQuery) Validate() error {
+	for _, k := range q.Kinds {
+		if !ValidSearchKind(k) {
+			return fmt.Errorf("unknown search type %q, expected one of %v", k, SearchKinds)
+		}
+	}
+	for _, d := range []string{q.Since, q.Until} {
+		if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
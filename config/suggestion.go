@@ -0,0 +1,50 @@
+package config
+
+import "errors"
+
+// SuggestionState is where an AI suggestion is in review. Only accepted and
+// edited suggestions reach commit summaries and resumes.
+type SuggestionState string
+
+const (
+	SuggestionPending  SuggestionState = "pending"
+	SuggestionAccepted SuggestionState = "accepted"
+	SuggestionEdited   SuggestionState = "edited"
+	SuggestionRejected SuggestionState = "rejected"
+)
+
+var ErrInvalidSuggestionState = errors.New("state must be pending, accepted, edited or rejected")
+
+func (s SuggestionState) Valid() bool {
+	switch s {
+	case SuggestionPending, SuggestionAccepted, SuggestionEdited, SuggestionRejected:
+		return true
+	}
+	return false
+}
+
+// Suggestion is one piece of generated text with where it came from: the
+// commits it is based on, the prompt version and the model that wrote it.
+type Suggestion struct {
+	ID            int64           `json:"id"`
+	ProjectID     int             `json:"project_id"`
+	Task          PromptType      `json:"task"`
+	Provider      string          `json:"provider"`
+	Model         string          `json:"model"`
+	PromptVersion int             `json:"prompt_version"`
+	SourceCommits []string        `json:"source_commits"`
+	Text          string          `json:"text"`
+	EditedText    string          `json:"edited_text,omitempty"`
+	Confidence    float64         `json:"confidence"`
+	State         SuggestionState `json:"state"`
+	CreatedAt     string          `json:"created_at"`
+	ReviewedAt    string          `json:"reviewed_at,omitempty"`
+}
+
+// FinalText is the text that is used once the suggestion is accepted.
+func (s Suggestion) FinalText() string {
+	if s.State == SuggestionEdited && s.EditedText != "" {
+		return s.EditedText
+	}
+	return s.Text
+}
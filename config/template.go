@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var ErrInvalidPrompt = errors.New("invalid prompt template")
+
+// PromptData is the set of variables available to prompt templates, e.g.
+//
+//	Rewrite these commits from {{.ProjectName}} for a {{.Seniority}} {{.TargetRole}}:
+//	{{range .Commits}}- {{.}}
+//	{{end}}
+//
+// The legacy %content% placeholder is an alias for {{.Content}}, which
+// defaults to the commits joined one per line.
+type PromptData struct {
+	Content        string   `json:"content"`
+	Commits        []string `json:"commits"`
+	ProjectName    string   `json:"project_name"`
+	TechStack      []string `json:"tech_stack"`
+	TargetRole     string   `json:"target_role"`
+	Company        string   `json:"company"`
+	Seniority      string   `json:"seniority"`
+	ResumeTitle    string   `json:"resume_title"`
+	JobDescription string   `json:"job_description"`
+	Language       string   `json:"language"`
+}
+
+// PromptVariables documents PromptData for the dashboard.
+var PromptVariables = map[string]string{
+	"Content":        "Free-form input; defaults to the commits, one per line",
+	"Commits":        "Commit messages, each prefixed with its [hash]",
+	"ProjectName":    "Name of the project the commits belong to",
+	"TechStack":      "Languages and frameworks detected in the project",
+	"TargetRole":     "Role being applied for, e.g. Backend Engineer",
+	"Company":        "Company being applied to",
+	"Seniority":      "Seniority level, e.g. Senior",
+	"ResumeTitle":    "Title of the resume being edited",
+	"JobDescription": "Pasted job description",
+	"Language":       "Language the output should be written in",
+}
+
+var promptFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lines": func(s []string) string { return strings.Join(s, "\n") },
+}
+
+// samplePromptData exercises every variable during validation.
+var samplePromptData = PromptData{
+	Commits:        []string{"[a1b2c3d] feat(api): add endpoint"},
+	ProjectName:    "gitresume",
+	TechStack:      []string{"Go"},
+	TargetRole:     "Software Engineer",
+	Company:        "Acme",
+	Seniority:      "Senior",
+	ResumeTitle:    "Resume",
+	JobDescription: "We are hiring",
+	Language:       "English",
+}
+
+func parsePrompt(name, content string) (*template.Template, error) {
+	content = strings.ReplaceAll(content, "%content%", "{{.Content}}")
+	return template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(content)
+}
+
+// Validate parses every prompt and renders it against sample data so typos
+// in variable names are caught when the prompt is saved.
+func (c CustomPrompt) Validate() error {
+	if len(c.Prompts) == 0 {
+		return fmt.Errorf("%w: prompt cannot be empty", ErrInvalidPrompt)
+	}
+	_, err := c.Render(samplePromptData)
+	return err
+}
+
+// Render executes the prompt templates with data.
+func (c CustomPrompt) Render(data PromptData) ([]Prompt, error) {
+	if data.Content == "" {
+		data.Content = strings.Join(data.Commits, "\n")
+	}
+
+	out := make([]Prompt, 0, len(c.Prompts))
+	for i, p := range c.Prompts {
+		tmpl, err := parsePrompt(fmt.Sprintf("%s[%d]", c.Title, i), p.Content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+		}
+		out = append(out, Prompt{Role: p.Role, Content: buf.String()})
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateVariables(t *testing.T) {
+	p := CustomPrompt{
+		Title: ProjectPrompt,
+		Prompts: []Prompt{
+			{Role: "system", Content: "Write for a {{.Seniority}} {{.TargetRole}} at {{.Company}}."},
+			{Role: "user", Content: "{{.ProjectName}} ({{join .TechStack \", \"}}):\n{{lines .Commits}}"},
+		},
+	}
+	out, err := p.Render(PromptData{
+		Commits:     []string{"[a1] add api", "[b2] fix bug"},
+		ProjectName: "gitresume",
+		TechStack:   []string{"Go", "React"},
+		TargetRole:  "Backend Engineer",
+		Company:     "Acme",
+		Seniority:   "Senior",
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if out[0].Content != "Write for a Senior Backend Engineer at Acme." {
+		t.Errorf("unexpected system prompt: %q", out[0].Content)
+	}
+	if out[1].Content != "gitresume (Go, React):\n[a1] add api\n[b2] fix bug" {
+		t.Errorf("unexpected user prompt: %q", out[1].Content)
+	}
+	if out[1].Role != "user" {
+		t.Errorf("role not preserved: %q", out[1].Role)
+	}
+}
+
+func TestRenderLegacyContentPlaceholder(t *testing.T) {
+	p := CustomPrompt{Prompts: []Prompt{{Role: "user", Content: "Summarize: %content%"}}}
+
+	out, err := p.Render(PromptData{Commits: []string{"one", "two"}})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if out[0].Content != "Summarize: one\ntwo" {
+		t.Errorf("expected commits to fill %%content%%, got %q", out[0].Content)
+	}
+
+	out, _ = p.Render(PromptData{Content: "free text", Commits: []string{"ignored"}})
+	if out[0].Content != "Summarize: free text" {
+		t.Errorf("expected explicit content, got %q", out[0].Content)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"plain", "Rewrite these commits", false},
+		{"legacy", "Rewrite: %content%", false},
+		{"conditional", "{{if .Language}}In {{.Language}}{{end}}", false},
+		{"unknown variable", "For {{.Role}}", true},
+		{"syntax", "For {{.TargetRole", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CustomPrompt{Prompts: []Prompt{{Role: "user", Content: tt.content}}}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPrompt) {
+				t.Errorf("expected ErrInvalidPrompt, got %v", err)
+			}
+		})
+	}
+
+	if err := (CustomPrompt{}).Validate(); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("expected empty prompt error, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import "strings"
+
+// AiUsage is one provider call as recorded by the AI usage meter.
+// AiUsage is one call to an AI provider, made for UserID.
+type AiUsage struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	CreatedAt    string     `json:"created_at"`
+	Provider     string     `json:"provider"`
+	Model        string     `json:"model"`
+	PromptType   PromptType `json:"prompt_type"`
+	Project      string     `json:"project"`
+	Origin       string     `json:"origin"`
+	InputTokens  int        `json:"input_tokens"`
+	OutputTokens int        `json:"output_tokens"`
+	Estimated    bool       `json:"estimated"`
+	LatencyMs    int64      `json:"latency_ms"`
+	Success      bool       `json:"success"`
+	Error        string     `json:"error,omitempty"`
+	Cost         float64    `json:"cost"`
+}
+
+// AiPrice is the USD price per million tokens for a provider model. An empty
+// Model applies to every model of the provider.
+type AiPrice struct {
+	Provider string  `mapstructure:"provider" yaml:"provider" json:"provider"`
+	Model    string  `mapstructure:"model" yaml:"model" json:"model"`
+	Input    float64 `mapstructure:"input" yaml:"input" json:"input"`
+	Output   float64 `mapstructure:"output" yaml:"output" json:"output"`
+}
+
+type AiBudget struct {
+	// MonthlyLimit caps the estimated spend on paid providers per calendar
+	// month in USD. Zero means no cap.
+	MonthlyLimit float64 `mapstructure:"monthly_limit" yaml:"monthly_limit" json:"monthly_limit"`
+}
+
+// DefaultPricing is used for models missing from ai_pricing in config.yaml.
+var DefaultPricing = []AiPrice{
+	{Provider: "openai", Model: "gpt-5", Input: 1.25, Output: 10},
+	{Provider: "openai", Model: "gpt-5-mini", Input: 0.25, Output: 2},
+	{Provider: "openai", Model: "gpt-5-nano", Input: 0.05, Output: 0.4},
+	{Provider: "openai", Model: "gpt-4o-mini", Input: 0.15, Output: 0.6},
+	{Provider: "gemini", Model: "gemini-2.5-pro", Input: 1.25, Output: 10},
+	{Provider: "gemini", Model: "gemini-2.5-flash", Input: 0.3, Output: 2.5},
+	{Provider: "llama", Input: 0, Output: 0},
+	{Provider: "fake", Input: 0, Output: 0},
+}
+
+// PriceFor looks up the price of a provider model, preferring an exact model
+// match over a provider-wide entry and user pricing over the defaults.
+func PriceFor(pricing []AiPrice, provider, model string) (AiPrice, bool) {
+	for _, table := range [][]AiPrice{pricing, DefaultPricing} {
+		var wildcard *AiPrice
+		for i, p := range table {
+			if !strings.EqualFold(p.Provider, provider) {
+				continue
+			}
+			if strings.EqualFold(p.Model, model) {
+				return p, true
+			}
+			if p.Model == "" && wildcard == nil {
+				wildcard = &table[i]
+			}
+		}
+		if wildcard != nil {
+			return *wildcard, true
+		}
+	}
+	return AiPrice{}, false
+}
+
+// Cost returns the USD cost of a call with the given token counts.
+func (p AiPrice) Cost(input, output int) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1_000_000
+}
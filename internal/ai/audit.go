@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+var ErrLocalOnly = errors.New("ai_local_only is set: refusing a provider that is not on this machine")
+
+// AuditStore persists the audit log; database.IDatabase satisfies it.
+// Entries are logged for the user it acts as.
+type AuditStore interface {
+	UserID() int64
+	CreateAIAudit(a config.AiAudit) error
+	PruneAIAudit(before string) (int64, error)
+}
+
+// Auditor redacts and logs every request sent to a provider, and enforces
+// the local-only switch. Models built with ModelConfig.Audit go through it.
+type Auditor struct {
+	Store     AuditStore
+	LocalOnly bool
+	// Retention is how long entries are kept; older ones are pruned the
+	// first time the auditor logs a request.
+	Retention time.Duration
+
+	now   func() time.Time
+	prune sync.Once
+}
+
+func NewAuditor(cfg config.AppConfig, store AuditStore) *Auditor {
+	days := cfg.AiAuditRetention
+	if days <= 0 {
+		days = config.DefaultAuditRetention
+	}
+	return &Auditor{
+		Store:     store,
+		LocalOnly: cfg.AiLocalOnly,
+		Retention: time.Duration(days) * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// Endpoint is where requests to a provider are sent.
+func Endpoint(t ModelType, baseURL string) string {
+	switch t {
+	case Llama:
+		return aiHost
+	case OpenAI:
+		return "https://api.openai.com/v1"
+	case Gemini:
+		return "https://generativelanguage.googleapis.com/v1beta"
+	case HuggingFace:
+		if baseURL != "" {
+			return strings.TrimRight(baseURL, "/")
+		}
+		return HuggingFaceRouter
+	case Fake:
+		return "local"
+	}
+	return baseURL
+}
+
+// IsLocal reports whether requests to a provider stay on this machine.
+func IsLocal(t ModelType, baseURL string) bool {
+	endpoint := Endpoint(t, baseURL)
+	if endpoint == "local" {
+		return true
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// audit wraps a provider built from cfg, or refuses it when it is remote
+// and LocalOnly is set.
+func (a *Auditor) audit(model AiModel, cfg ModelConfig) (AiModel, error) {
+	if a.LocalOnly && !IsLocal(cfg.Type, cfg.BaseURL) {
+		return nil, fmt.Errorf("%w (%s)", ErrLocalOnly, cfg.Type)
+	}
+	return &AuditedModel{AiModel: model, Provider: cfg.Type, Model: cfg.Model, Endpoint: Endpoint(cfg.Type, cfg.BaseURL), Auditor: a}, nil
+}
+
+func (a *Auditor) auditEmbedder(e Embedder, cfg ModelConfig) (Embedder, error) {
+	if a.LocalOnly && !IsLocal(cfg.Type, cfg.BaseURL) {
+		return nil, fmt.Errorf("%w (%s)", ErrLocalOnly, cfg.Type)
+	}
+	return &AuditedEmbedder{Embedder: e, Provider: cfg.Type, Model: cfg.Model, Endpoint: Endpoint(cfg.Type, cfg.BaseURL), Auditor: a}, nil
+}
+
+// AuditedModel redacts prompts before they are sent and logs what was sent
+// with a hash of the response.
+type AuditedModel struct {
+	AiModel
+	Provider ModelType
+	Model    string
+	Endpoint string
+	Auditor  *Auditor
+}
+
+func (m *AuditedModel) Generate(ctx context.Context, message string) (string, error) {
+	message, n := Redact(message)
+	out, err := m.AiModel.Generate(ctx, message)
+	m.Auditor.record(ctx, m.Provider, m.Model, m.Endpoint, message, n, out, err)
+	return out, err
+}
+
+func (m *AuditedModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	sent := make([]config.Prompt, len(prompts))
+	total := 0
+	for i, p := range prompts {
+		var n int
+		p.Content, n = Redact(p.Content)
+		sent[i] = p
+		total += n
+	}
+	out, err := m.AiModel.Chat(ctx, sent)
+	m.Auditor.record(ctx, m.Provider, m.Model, m.Endpoint, formatPrompts(sent), total, strings.Join(out, "\n"), err)
+	return out, err
+}
+
+// LastUsage passes the provider's token counts through to the Meter, which
+// wraps the audited model.
+func (m *AuditedModel) LastUsage() TokenUsage {
+	return lastUsage(m.AiModel)
+}
+
+// AuditedEmbedder is AuditedModel for embedding requests.
+type AuditedEmbedder struct {
+	Embedder
+	Provider ModelType
+	Model    string
+	Endpoint string
+	Auditor  *Auditor
+}
+
+func (e *AuditedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	sent := make([]string, len(texts))
+	total := 0
+	for i, t := range texts {
+		var n int
+		sent[i], n = Redact(t)
+		total += n
+	}
+	vectors, err := e.Embedder.Embed(ctx, sent)
+	e.Auditor.record(ctx, e.Provider, e.Model, e.Endpoint, strings.Join(sent, "\n"), total, fmt.Sprint(vectors), err)
+	return vectors, err
+}
+
+func (e *AuditedEmbedder) LastUsage() TokenUsage {
+	return lastUsage(e.Embedder)
+}
+
+// lastUsage is the client's token count for its last call, or zero when
+// it does not report one.
+func lastUsage(client any) TokenUsage {
+	if r, ok := client.(UsageReporter); ok {
+		return r.LastUsage()
+	}
+	return TokenUsage{}
+}
+
+func formatPrompts(prompts []config.Prompt) string {
+	var b strings.Builder
+	for i, p := range prompts {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n%s", p.Role, p.Content)
+	}
+	return b.String()
+}
+
+func (a *Auditor) record(ctx context.Context, provider ModelType, model, endpoint, prompt string, redactions int, response string, callErr error) {
+	now := a.now().UTC()
+	// the log must never break the actual AI call
+	a.prune.Do(func() {
+		if a.Retention > 0 {
+			_, _ = a.Store.PruneAIAudit(now.Add(-a.Retention).Format(time.DateTime))
+		}
+	})
+
+	info := callInfo(ctx)
+	entry := config.AiAudit{
+		UserID:     a.Store.UserID(),
+		CreatedAt:  now.Format(time.DateTime),
+		Provider:   string(provider),
+		Model:      model,
+		Endpoint:   endpoint,
+		PromptType: info.Task,
+		Project:    info.Project,
+		Origin:     info.Origin,
+		Prompt:     prompt,
+		Redactions: redactions,
+		Success:    callErr == nil,
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	} else {
+		sum := sha256.Sum256([]byte(response))
+		entry.ResponseHash = hex.EncodeToString(sum[:])
+	}
+	_ = a.Store.CreateAIAudit(entry)
+}
+
+// secrets are replaced before text leaves the machine. Assignments such as
+// "password=hunter2" keep their name so the prompt still reads naturally.
+var secrets = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "[redacted private key]"},
+	{regexp.MustCompile(`\b(sk|pk|rk)-[A-Za-z0-9_-]{16,}`), "[redacted key]"},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`), "[redacted token]"},
+	{regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}`), "[redacted token]"},
+	{regexp.MustCompile(`\bhf_[A-Za-z0-9]{20,}`), "[redacted token]"},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), "[redacted token]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[redacted key]"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`), "[redacted key]"},
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{10,}=*`), "Bearer [redacted]"},
+	{regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key)(\s*[:=]\s*)("[^"]*"|'[^']*'|\S+)`), "$1$2[redacted]"},
+	{regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`), "$1[redacted]@"},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[email]"},
+}
+
+// Redact removes credentials and email addresses from text and reports how
+// many it replaced.
+func Redact(text string) (string, int) {
+	n := 0
+	for _, s := range secrets {
+		text = s.re.ReplaceAllStringFunc(text, func(m string) string {
+			n++
+			return s.re.ReplaceAllString(m, s.repl)
+		})
+	}
+	return text, n
+}
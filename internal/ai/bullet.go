@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/internal/lint"
+)
+
+// maxBulletRepairs is how many times the model is asked to fix output that
+// does not match BulletSchema before falling back to cleanOutput.
+const maxBulletRepairs = 2
+
+var ErrInvalidBullets = errors.New("model returned no usable bullet points")
+
+type Bullet struct {
+	Text          string   `json:"text"`
+	SourceCommits []string `json:"source_commits"`
+	Confidence    float64  `json:"confidence"`
+}
+
+type BulletResponse struct {
+	Bullets []Bullet `json:"bullets"`
+}
+
+// BulletSchema is the JSON schema providers are asked to follow when
+// turning commits into resume bullet points.
+var BulletSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"bullets": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"text": map[string]any{"type": "string"},
+					"source_commits": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"type": "string"},
+					},
+					"confidence": map[string]any{"type": "number"},
+				},
+				"required":             []string{"text", "source_commits", "confidence"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"bullets"},
+	"additionalProperties": false,
+}
+
+// ParseBullets decodes and validates a BulletResponse. Markdown code fences
+// around the JSON are tolerated.
+func ParseBullets(raw string) ([]Bullet, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var resp BulletResponse
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if len(resp.Bullets) == 0 {
+		return nil, errors.New(`"bullets" must contain at least one item`)
+	}
+	for i, b := range resp.Bullets {
+		if strings.TrimSpace(b.Text) == "" {
+			return nil, fmt.Errorf("bullets[%d].text is empty", i)
+		}
+		if b.Confidence < 0 || b.Confidence > 1 {
+			return nil, fmt.Errorf("bullets[%d].confidence must be between 0 and 1", i)
+		}
+		resp.Bullets[i].Text = strings.TrimSpace(bulletMarker.ReplaceAllString(b.Text, ""))
+	}
+	return resp.Bullets, nil
+}
+
+// GenerateBullets asks the model for bullets matching BulletSchema. Invalid
+// output is sent back with the validation error so the model can repair it;
+// if that keeps failing the last reply is cleaned up heuristically and the
+// bullets are returned with zero confidence.
+func GenerateBullets(ctx context.Context, model AiModel, prompts []config.Prompt) ([]Bullet, error) {
+	msgs := append([]config.Prompt{}, prompts...)
+
+	var raw string
+	for attempt := 0; attempt <= maxBulletRepairs; attempt++ {
+		resp, err := model.Chat(ctx, msgs)
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.Join(resp, "\n")
+
+		bullets, perr := ParseBullets(raw)
+		if perr == nil {
+			return bullets, nil
+		}
+		msgs = append(msgs,
+			config.Prompt{Role: string(Assistant), Content: raw},
+			config.Prompt{Role: string(User), Content: fmt.Sprintf(
+				"Your previous response was not valid (%v). Reply again with only a JSON object of the form "+
+					`{"bullets":[{"text":"...","source_commits":["<commit id>"],"confidence":0.0}]}`, perr)},
+		)
+	}
+
+	lines := cleanOutput(raw)
+	if len(lines) == 0 {
+		return nil, ErrInvalidBullets
+	}
+	bullets := make([]Bullet, 0, len(lines))
+	for _, l := range lines {
+		bullets = append(bullets, Bullet{Text: l, SourceCommits: []string{}})
+	}
+	return bullets, nil
+}
+
+// GenerateLintedBullets is GenerateBullets followed by ReviseBullets; it is
+// used when ai_lint is enabled in the config.
+func GenerateLintedBullets(ctx context.Context, model AiModel, prompts []config.Prompt) ([]Bullet, error) {
+	bullets, err := GenerateBullets(ctx, model, prompts)
+	if err != nil {
+		return nil, err
+	}
+	return ReviseBullets(ctx, model, prompts, bullets), nil
+}
+
+// ReviseBullets runs the linter over bullets and, when it warns, gives the
+// model one turn to fix them. The revision is kept only if it has fewer
+// warnings; a failed or worse revision returns bullets unchanged.
+func ReviseBullets(ctx context.Context, model AiModel, prompts []config.Prompt, bullets []Bullet) []Bullet {
+	diags := lint.List("bullets", BulletTexts(bullets))
+	if lint.Warnings(diags) == 0 {
+		return bullets
+	}
+
+	var issues []string
+	for _, d := range diags {
+		if d.Severity == lint.Warning {
+			issues = append(issues, fmt.Sprintf("- %s: %s", d.Field, d.Message))
+		}
+	}
+	previous, err := json.Marshal(BulletResponse{Bullets: bullets})
+	if err != nil {
+		return bullets
+	}
+	msgs := append(append([]config.Prompt{}, prompts...),
+		config.Prompt{Role: string(Assistant), Content: string(previous)},
+		config.Prompt{Role: string(User), Content: "Revise the bullets to fix these issues. " +
+			"Keep the facts and source_commits, and reply with the same JSON format.\n" + strings.Join(issues, "\n")},
+	)
+	resp, err := model.Chat(ctx, msgs)
+	if err != nil {
+		return bullets
+	}
+	revised, err := ParseBullets(strings.Join(resp, "\n"))
+	if err != nil || lint.Warnings(lint.List("bullets", BulletTexts(revised))) >= lint.Warnings(diags) {
+		return bullets
+	}
+	return revised
+}
+
+// BulletTexts returns only the text of each bullet.
+func BulletTexts(bullets []Bullet) []string {
+	texts := make([]string, 0, len(bullets))
+	for _, b := range bullets {
+		texts = append(texts, b.Text)
+	}
+	return texts
+}
+
+// CommitContent formats commits one per line, prefixed with the commit hash
+// so the model can cite it in source_commits.
+func CommitContent(commits []git.GitCommit) string {
+	return strings.Join(CommitLines(commits), "\n")
+}
+
+// CommitLines formats each commit as "[hash] message" so the model can cite
+// the commits a bullet is based on.
+func CommitLines(commits []git.GitCommit) []string {
+	lines := make([]string, 0, len(commits))
+	for _, c := range commits {
+		if c.Hash == "" {
+			lines = append(lines, c.Msg)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s", git.ShortHash(c.Hash), c.Msg))
+	}
+	return lines
+}
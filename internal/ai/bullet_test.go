@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+type scriptedModel struct {
+	replies []string
+	calls   [][]config.Prompt
+}
+
+func (s *scriptedModel) Generate(ctx context.Context, message string) (string, error) {
+	return "", nil
+}
+
+func (s *scriptedModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	s.calls = append(s.calls, prompts)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	return []string{reply}, nil
+}
+
+func TestParseBullets(t *testing.T) {
+	b, err := ParseBullets("```json\n{\"bullets\":[{\"text\":\"• Shipped SSO\",\"source_commits\":[\"abc\"],\"confidence\":0.8}]}\n```")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b[0].Text != "Shipped SSO" || b[0].SourceCommits[0] != "abc" {
+		t.Errorf("unexpected bullet: %+v", b[0])
+	}
+
+	if _, err := ParseBullets(`{"bullets":[{"text":"x","source_commits":[],"confidence":3}]}`); err == nil {
+		t.Error("expected confidence out of range to fail")
+	}
+	if _, err := ParseBullets(`{"bullets":[]}`); err == nil {
+		t.Error("expected empty bullets to fail")
+	}
+}
+
+func TestGenerateBullets_Repair(t *testing.T) {
+	m := &scriptedModel{replies: []string{
+		"Sure! Here you go",
+		`{"bullets":[{"text":"Cut build time by 40%","source_commits":["a1"],"confidence":0.7}]}`,
+	}}
+	b, err := GenerateBullets(context.Background(), m, []config.Prompt{{Role: "user", Content: "commits"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.calls) != 2 || len(m.calls[1]) != 3 {
+		t.Fatalf("expected one repair round trip, got %d calls", len(m.calls))
+	}
+	if b[0].Text != "Cut build time by 40%" {
+		t.Errorf("unexpected bullet: %+v", b[0])
+	}
+}
+
+func TestGenerateBullets_Fallback(t *testing.T) {
+	m := &scriptedModel{replies: []string{"Here are the transformed bullet points:\n• Added Redis caching"}}
+	b, err := GenerateBullets(context.Background(), m, []config.Prompt{{Role: "user", Content: "commits"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.calls) != maxBulletRepairs+1 {
+		t.Errorf("expected %d attempts, got %d", maxBulletRepairs+1, len(m.calls))
+	}
+	if len(b) != 1 || b[0].Text != "Added Redis caching" || b[0].Confidence != 0 {
+		t.Errorf("unexpected fallback bullets: %+v", b)
+	}
+}
+
+func TestReviseBullets(t *testing.T) {
+	weak := []Bullet{{Text: "Worked on the checkout service", SourceCommits: []string{"a1b2c3d"}, Confidence: 0.7}}
+	model := &scriptedModel{replies: []string{
+		`{"bullets":[{"text":"Rebuilt the checkout service, cutting errors by 30%","source_commits":["a1b2c3d"],"confidence":0.7}]}`,
+	}}
+	got := ReviseBullets(context.Background(), model, []config.Prompt{{Role: string(User), Content: "commits"}}, weak)
+	if len(model.calls) != 1 || got[0].Text != "Rebuilt the checkout service, cutting errors by 30%" {
+		t.Fatalf("expected the revision to be kept, got %+v", got)
+	}
+	if last := model.calls[0][len(model.calls[0])-1].Content; !strings.Contains(last, "Worked on") {
+		t.Errorf("expected the lint warnings in the revision prompt, got %q", last)
+	}
+
+	worse := &scriptedModel{replies: []string{
+		`{"bullets":[{"text":"I helped with the checkout service","source_commits":[],"confidence":0.5}]}`,
+	}}
+	if got := ReviseBullets(context.Background(), worse, nil, weak); got[0].Text != weak[0].Text {
+		t.Errorf("a revision with more warnings must be dropped, got %+v", got)
+	}
+
+	clean := []Bullet{{Text: "Cut checkout errors by 30%", SourceCommits: []string{}}}
+	untouched := &scriptedModel{replies: []string{"unused"}}
+	ReviseBullets(context.Background(), untouched, nil, clean)
+	if len(untouched.calls) != 0 {
+		t.Error("bullets without warnings should not be revised")
+	}
+}
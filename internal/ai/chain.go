@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+const defaultProviderTimeout = 45 * time.Second
+
+// ChainModel tries each model in order and returns the first successful
+// response. A provider that errors or exceeds Timeout hands over to the next.
+type ChainModel struct {
+	Models  []NamedModel
+	Timeout time.Duration
+
+	last AiModel
+}
+
+type NamedModel struct {
+	Name string
+	AiModel
+}
+
+func (c *ChainModel) Generate(ctx context.Context, message string) (string, error) {
+	var out string
+	err := c.try(ctx, func(ctx context.Context, m AiModel) error {
+		var err error
+		out, err = m.Generate(ctx, message)
+		return err
+	})
+	return out, err
+}
+
+func (c *ChainModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	var out []string
+	err := c.try(ctx, func(ctx context.Context, m AiModel) error {
+		var err error
+		out, err = m.Chat(ctx, prompts)
+		return err
+	})
+	return out, err
+}
+
+func (c *ChainModel) try(ctx context.Context, call func(context.Context, AiModel) error) error {
+	if len(c.Models) == 0 {
+		return errors.New("no AI provider configured")
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultProviderTimeout
+	}
+
+	var errs []error
+	for _, m := range c.Models {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attempt, cancel := context.WithTimeout(ctx, timeout)
+		err := call(attempt, m.AiModel)
+		cancel()
+		if err == nil {
+			c.last = m.AiModel
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.Name, err))
+	}
+	return fmt.Errorf("all AI providers failed: %w", errors.Join(errs...))
+}
+
+// ServedBy reports the provider and model that answered the last successful
+// call on m. It is only known for metered models, which every model built
+// with a Meter is.
+func ServedBy(m AiModel) (provider, model string) {
+	switch v := m.(type) {
+	case *ChainModel:
+		if v.last != nil {
+			return ServedBy(v.last)
+		}
+	case *MeteredModel:
+		return string(v.Provider), v.Model
+	case *AuditedModel:
+		return string(v.Provider), v.Model
+	}
+	return "", ""
+}
+
+// NewTaskModel builds the model for a prompt type from the app config: the
+// routed (or default) provider followed by the fallback chain. base carries
+// options shared by every provider, such as ResponseSchema.
+func NewTaskModel(cfg config.AppConfig, task config.PromptType, prompt config.CustomPrompt, base ModelConfig) (AiModel, error) {
+	providers := cfg.ProvidersFor(task)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no AI provider configured for %q, run 'gitresume init'", task)
+	}
+
+	chain := &ChainModel{Timeout: time.Duration(cfg.AiTimeout) * time.Second}
+	var refused error
+	for _, p := range providers {
+		mcfg := base
+		mcfg.Type = ModelType(p.Name)
+		mcfg.Model = p.Model
+		mcfg.APIKey = p.ApiKey
+		mcfg.BaseURL = p.BaseURL
+		mcfg.Temperature = prompt.Temperature
+		mcfg.MaxToken = prompt.MaxTokens
+
+		m, err := NewChatModel(mcfg)
+		if errors.Is(err, ErrLocalOnly) {
+			// the fallback chain may still hold a local provider
+			refused = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain.Models = append(chain.Models, NamedModel{Name: p.Name + "/" + p.Model, AiModel: m})
+	}
+	if len(chain.Models) == 0 {
+		return nil, refused
+	}
+	if len(chain.Models) == 1 {
+		return chain.Models[0].AiModel, nil
+	}
+	return chain, nil
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+type failingModel struct{ err error }
+
+func (f failingModel) Generate(ctx context.Context, message string) (string, error) {
+	return "", f.err
+}
+
+func (f failingModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	return nil, f.err
+}
+
+type slowModel struct{}
+
+func (slowModel) Generate(ctx context.Context, message string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (slowModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestChainModel_Fallback(t *testing.T) {
+	chain := &ChainModel{
+		Timeout: 10 * time.Millisecond,
+		Models: []NamedModel{
+			{Name: "llama", AiModel: failingModel{errors.New("connection refused")}},
+			{Name: "gemini", AiModel: slowModel{}},
+			{Name: "openai", AiModel: NewFake(ModelConfig{Script: []string{"from openai"}})},
+		},
+	}
+	resp, err := chain.Chat(context.Background(), testPrompt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp[0] != "from openai" {
+		t.Errorf("expected the third provider to answer, got %q", resp)
+	}
+}
+
+func TestChainModel_AllFail(t *testing.T) {
+	chain := &ChainModel{Models: []NamedModel{
+		{Name: "llama", AiModel: failingModel{errors.New("connection refused")}},
+		{Name: "openai", AiModel: failingModel{errors.New("invalid api key")}},
+	}}
+	_, err := chain.Chat(context.Background(), testPrompt)
+	if err == nil || !strings.Contains(err.Error(), "llama: connection refused") || !strings.Contains(err.Error(), "openai: invalid api key") {
+		t.Errorf("expected both provider errors, got %v", err)
+	}
+}
+
+func TestNewTaskModel_Routes(t *testing.T) {
+	cfg := config.AppConfig{
+		AiOptions: []config.AiOptions{
+			{Name: string(Fake), Model: "cheap", IsDefault: true},
+			{Name: string(Llama), Model: "llama3.2"},
+		},
+		AiFallback: []string{string(Llama)},
+	}
+	m, err := NewTaskModel(cfg, config.ProjectPrompt, config.CustomPrompt{}, ModelConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chain, ok := m.(*ChainModel)
+	if !ok || len(chain.Models) != 2 || chain.Models[0].Name != "fake/cheap" {
+		t.Errorf("unexpected chain: %+v", m)
+	}
+
+	if _, err := NewTaskModel(config.AppConfig{}, config.ProjectPrompt, config.CustomPrompt{}, ModelConfig{}); err == nil {
+		t.Error("expected an error without providers")
+	}
+}
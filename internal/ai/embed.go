@@ -0,0 +1,220 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// DefaultEmbedModel is used when no model is routed for config.EmbedPrompt,
+// since the chat models configured per provider cannot embed.
+var DefaultEmbedModel = map[ModelType]string{
+	Llama:  "nomic-embed-text",
+	OpenAI: "text-embedding-3-small",
+	Gemini: "text-embedding-004",
+	Fake:   "fake-embed",
+}
+
+var aiEmbeddingsEndpoint = "/api/embeddings"
+
+// EmbedderFor builds the embedder routed for config.EmbedPrompt, or the
+// default provider with its DefaultEmbedModel. It returns the model name so
+// stored vectors can be keyed by it.
+func EmbedderFor(cfg config.AppConfig, base ModelConfig) (Embedder, string, error) {
+	providers := cfg.ProvidersFor(config.EmbedPrompt)
+	if len(providers) == 0 {
+		return nil, "", errors.New("no AI provider configured for embeddings, run 'gitresume init'")
+	}
+	p := providers[0]
+
+	routed := false
+	for _, r := range cfg.AiRoutes {
+		if r.Task == config.EmbedPrompt && r.Model != "" {
+			routed = true
+		}
+	}
+	base.Type = ModelType(p.Name)
+	base.APIKey = p.ApiKey
+	base.BaseURL = p.BaseURL
+	base.Model = p.Model
+	if !routed {
+		base.Model = DefaultEmbedModel[base.Type]
+	}
+
+	e, err := NewEmbedder(base)
+	if err != nil {
+		return nil, "", err
+	}
+	return e, base.Model, nil
+}
+
+// Embed calls Ollama's embeddings endpoint, which takes one prompt per call.
+func (l *LlamaConfig) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		var resp struct {
+			Embedding []float32 `json:"embedding"`
+		}
+		data := map[string]any{"model": l.Model, "prompt": text}
+		if err := l.post(ctx, aiEmbeddingsEndpoint, data, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Embedding) == 0 {
+			return nil, fmt.Errorf("%s returned no embedding, is it an embedding model?", l.Model)
+		}
+		vectors = append(vectors, resp.Embedding)
+	}
+	return vectors, nil
+}
+
+func (o *OpenAIConfig) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if o.APIKey == "" {
+		return nil, errors.New("openAI Api Key is missing")
+	}
+	msg, _ := json.Marshal(map[string]any{"model": o.Model, "input": texts})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/embeddings", bytes.NewBuffer(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := doJSON(o.client, req, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("openai api error: %s", result.Error.Message)
+	}
+	o.usage = TokenUsage{InputTokens: result.Usage.PromptTokens}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("openai returned %d embeddings for %d texts", len(result.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai returned embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+func (g *GeminiCfg) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if g.APIKey == "" {
+		return nil, errors.New("Gemini API key is missing")
+	}
+	requests := make([]map[string]any, 0, len(texts))
+	for _, text := range texts {
+		requests = append(requests, map[string]any{
+			"model":   "models/" + g.Model,
+			"content": map[string]any{"parts": []Part{{Text: text}}},
+		})
+	}
+	msg, _ := json.Marshal(map[string]any{"requests": requests})
+
+	host := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:batchEmbedContents?key=%s", g.Model, g.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host, bytes.NewBuffer(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := doJSON(g.client, req, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("gemini api error: %s", result.Error.Message)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for i, e := range result.Embeddings {
+		vectors[i] = e.Values
+	}
+	return vectors, nil
+}
+
+// fakeDims is the size of the fake provider's vectors.
+const fakeDims = 256
+
+// Embed hashes the words of each text into a bag-of-words vector, so texts
+// sharing words score as similar without a model. Short words are skipped.
+func (f *FakeModel) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		v := make([]float32, fakeDims)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, w := range words {
+			if len(w) < 3 {
+				continue
+			}
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%fakeDims]++
+		}
+		var norm float64
+		for _, x := range v {
+			norm += float64(x * x)
+		}
+		if norm > 0 {
+			for i := range v {
+				v[i] /= float32(math.Sqrt(norm))
+			}
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response (status %d): %w", resp.StatusCode, err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// EvalSample is the fixed set of commits prompt versions are compared on, so
+// results stay comparable across runs.
+var EvalSample = []git.GitCommit{
+	{Hash: "e1a2b3c", Msg: "feat(auth): add OAuth2 login with GitHub and Google"},
+	{Hash: "e4d5f6a", Msg: "perf(db): add index on commits.project_id, list query 120ms -> 8ms"},
+	{Hash: "e7b8c9d", Msg: "fix(api): return 404 instead of 500 for missing resumes"},
+	{Hash: "f0a1b2c", Msg: "chore(ci): cache go modules in GitHub Actions"},
+	{Hash: "f3d4e5f", Msg: "feat(export): export resumes to PDF and Markdown"},
+	{Hash: "f6a7b8c", Msg: "refactor(ai): route prompts to providers with fallback"},
+	{Hash: "f9c0d1e", Msg: "test(server): cover resume handlers with httptest"},
+	{Hash: "a2b3c4d", Msg: "feat(ui): dashboard view for commit summaries"},
+}
+
+// EvalStore persists evaluation results; database.IDatabase satisfies it.
+type EvalStore interface {
+	CreatePromptEval(e config.PromptEval) error
+}
+
+// Evaluator runs prompt versions against models over a sample of commits.
+type Evaluator struct {
+	Store   EvalStore
+	Meter   *Meter
+	Audit   *Auditor
+	Commits []git.GitCommit
+	// Data supplies the template variables other than Commits.
+	Data config.PromptData
+}
+
+// SelectVersions picks the wanted version numbers from all, or the latest
+// two when none are given.
+func SelectVersions(all []config.PromptVersion, want []int) ([]config.PromptVersion, error) {
+	if len(want) == 0 {
+		return all[max(0, len(all)-2):], nil
+	}
+	selected := make([]config.PromptVersion, 0, len(want))
+	for _, n := range want {
+		i := slices.IndexFunc(all, func(v config.PromptVersion) bool { return v.Version == n })
+		if i < 0 {
+			return nil, fmt.Errorf("prompt has no version %d", n)
+		}
+		selected = append(selected, all[i])
+	}
+	return selected, nil
+}
+
+// Run evaluates every version on every model and stores the results under
+// a new run id.
+func (e *Evaluator) Run(ctx context.Context, versions []config.PromptVersion, models []config.AiOptions) (string, []config.PromptEval, error) {
+	if len(versions) == 0 || len(models) == 0 {
+		return "", nil, fmt.Errorf("nothing to evaluate: %d prompt versions, %d models", len(versions), len(models))
+	}
+	commits := e.Commits
+	if len(commits) == 0 {
+		commits = EvalSample
+	}
+	data := e.Data
+	data.Commits = CommitLines(commits)
+
+	runID := time.Now().UTC().Format("20060102-150405")
+	ctx = WithCallInfo(ctx, CallInfo{Task: versions[0].Title, Origin: "eval:" + runID})
+
+	var evals []config.PromptEval
+	for _, v := range versions {
+		prompts, err := v.CustomPrompt().Render(data)
+		if err != nil {
+			return "", nil, fmt.Errorf("version %d: %w", v.Version, err)
+		}
+		for _, m := range models {
+			res := e.evaluate(ctx, v, m, prompts)
+			res.RunID = runID
+			if err := e.Store.CreatePromptEval(res); err != nil {
+				return "", nil, err
+			}
+			evals = append(evals, res)
+		}
+	}
+	return runID, evals, nil
+}
+
+func (e *Evaluator) evaluate(ctx context.Context, v config.PromptVersion, m config.AiOptions, prompts []config.Prompt) config.PromptEval {
+	res := config.PromptEval{
+		Title:    v.Title,
+		Version:  v.Version,
+		Provider: m.Name,
+		Model:    m.Model,
+	}
+
+	mcfg := ModelConfig{
+		Type:        ModelType(m.Name),
+		Model:       m.Model,
+		APIKey:      m.ApiKey,
+		BaseURL:     m.BaseURL,
+		Temperature: v.Temperature,
+		MaxToken:    v.MaxTokens,
+		Audit:       e.Audit,
+	}
+	if v.Title == config.ProjectPrompt {
+		mcfg.ResponseSchema = BulletSchema
+	}
+	model, err := NewChatModel(mcfg)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	counted := &costCounter{AiModel: model, meter: e.Meter, provider: mcfg.Type, baseURL: m.BaseURL, model: m.Model}
+
+	start := time.Now()
+	var out []string
+	if v.Title == config.ProjectPrompt {
+		var bullets []Bullet
+		bullets, err = GenerateBullets(ctx, counted, prompts)
+		out = BulletTexts(bullets)
+	} else {
+		out, err = counted.Chat(ctx, prompts)
+	}
+	res.LatencyMs = time.Since(start).Milliseconds()
+	res.Cost = counted.cost
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Output = out
+	s := ScoreOutput(out)
+	res.Bullets, res.AvgLength, res.ActionVerbs, res.Quantified = s.Bullets, s.AvgLength, s.ActionVerbs, s.Quantified
+	return res
+}
+
+// costCounter meters every call of an evaluation, including bullet repair
+// retries, and adds up their cost.
+type costCounter struct {
+	AiModel
+	meter    *Meter
+	provider ModelType
+	baseURL  string
+	model    string
+	cost     float64
+}
+
+func (c *costCounter) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	if c.meter == nil {
+		return c.AiModel.Chat(ctx, prompts)
+	}
+	m := &MeteredModel{AiModel: c.AiModel, Provider: c.provider, Model: c.model, BaseURL: c.baseURL, Meter: c.meter}
+	out, err := m.Chat(ctx, prompts)
+	c.cost += m.LastCall().Cost
+	return out, err
+}
+
+type OutputScore struct {
+	Bullets     int
+	AvgLength   float64
+	ActionVerbs float64
+	Quantified  float64
+}
+
+var (
+	actionVerbs = map[string]bool{
+		"added": true, "architected": true, "automated": true, "built": true, "created": true,
+		"cut": true, "delivered": true, "designed": true, "developed": true, "drove": true,
+		"enabled": true, "engineered": true, "established": true, "fixed": true, "implemented": true,
+		"improved": true, "increased": true, "integrated": true, "introduced": true, "launched": true,
+		"led": true, "migrated": true, "optimized": true, "reduced": true, "refactored": true,
+		"resolved": true, "shipped": true, "simplified": true, "streamlined": true, "wrote": true,
+	}
+	quantity = regexp.MustCompile(`\d`)
+)
+
+// ScoreOutput measures bullet quality: how many bullets start with an action
+// verb and how many contain a number.
+func ScoreOutput(lines []string) OutputScore {
+	var s OutputScore
+	var total, verbs, quantified int
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		s.Bullets++
+		total += len(l)
+		if first := strings.ToLower(strings.Trim(strings.Fields(l)[0], ",.:;")); actionVerbs[first] {
+			verbs++
+		}
+		if quantity.MatchString(l) {
+			quantified++
+		}
+	}
+	if s.Bullets == 0 {
+		return s
+	}
+	n := float64(s.Bullets)
+	s.AvgLength = float64(total) / n
+	s.ActionVerbs = float64(verbs) / n
+	s.Quantified = float64(quantified) / n
+	return s
+}
+
+// EvalScore ranks a result. Action verbs and quantification count equally;
+// bullets outside the 40–200 character range that reads well on a resume
+// lose half a point.
+func EvalScore(e config.PromptEval) float64 {
+	if e.Error != "" || e.Bullets == 0 {
+		return 0
+	}
+	score := e.ActionVerbs + e.Quantified
+	if e.AvgLength < 40 || e.AvgLength > 200 {
+		score -= 0.5
+	}
+	return score
+}
+
+// Winner returns the best scoring result, preferring the cheaper and then
+// the faster one on ties.
+func Winner(evals []config.PromptEval) (config.PromptEval, bool) {
+	var ok []config.PromptEval
+	for _, e := range evals {
+		if e.Error == "" && e.Bullets > 0 {
+			ok = append(ok, e)
+		}
+	}
+	if len(ok) == 0 {
+		return config.PromptEval{}, false
+	}
+	sort.SliceStable(ok, func(i, j int) bool {
+		si, sj := EvalScore(ok[i]), EvalScore(ok[j])
+		if si != sj {
+			return si > sj
+		}
+		if ok[i].Cost != ok[j].Cost {
+			return ok[i].Cost < ok[j].Cost
+		}
+		return ok[i].LatencyMs < ok[j].LatencyMs
+	})
+	return ok[0], true
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+type memEvals struct {
+	evals []config.PromptEval
+}
+
+func (m *memEvals) CreatePromptEval(e config.PromptEval) error {
+	m.evals = append(m.evals, e)
+	return nil
+}
+
+func TestScoreOutput(t *testing.T) {
+	s := ScoreOutput([]string{
+		"Reduced list query latency from 120ms to 8ms",
+		"Built OAuth2 login",
+		"the export feature",
+		"",
+	})
+	if s.Bullets != 3 {
+		t.Fatalf("expected 3 bullets, got %d", s.Bullets)
+	}
+	if s.ActionVerbs < 0.66 || s.ActionVerbs > 0.67 {
+		t.Errorf("expected 2/3 action verbs, got %f", s.ActionVerbs)
+	}
+	if s.Quantified < 0.66 || s.Quantified > 0.67 {
+		t.Errorf("expected 2/3 quantified (OAuth2 has a digit), got %f", s.Quantified)
+	}
+}
+
+func TestWinner(t *testing.T) {
+	evals := []config.PromptEval{
+		{Version: 1, Provider: "openai", Bullets: 3, AvgLength: 80, ActionVerbs: 1, Quantified: 0.5, Cost: 0.01},
+		{Version: 2, Provider: "llama", Bullets: 3, AvgLength: 80, ActionVerbs: 1, Quantified: 0.5},
+		{Version: 3, Provider: "llama", Error: "timeout"},
+	}
+	w, ok := Winner(evals)
+	if !ok || w.Version != 2 {
+		t.Errorf("expected the cheaper tie to win, got %+v", w)
+	}
+	if _, ok := Winner(evals[2:]); ok {
+		t.Error("expected no winner when every run failed")
+	}
+}
+
+func TestSelectVersions(t *testing.T) {
+	all := []config.PromptVersion{{Version: 1}, {Version: 2}, {Version: 3}}
+	got, _ := SelectVersions(all, nil)
+	if len(got) != 2 || got[0].Version != 2 || got[1].Version != 3 {
+		t.Errorf("expected the latest two versions, got %+v", got)
+	}
+	if _, err := SelectVersions(all, []int{4}); err == nil {
+		t.Error("expected an error for a missing version")
+	}
+}
+
+func TestEvaluatorRun(t *testing.T) {
+	store := &memEvals{}
+	usage := &memUsage{}
+	ev := &Evaluator{Store: store, Meter: &Meter{Store: usage, now: time.Now}}
+
+	versions := []config.PromptVersion{
+		{Title: config.ProjectPrompt, Version: 1, Prompts: []config.Prompt{{Role: "user", Content: "{{lines .Commits}}"}}},
+		{Title: config.ProjectPrompt, Version: 2, Prompts: []config.Prompt{{Role: "user", Content: "%content%"}}},
+	}
+	models := []config.AiOptions{{Name: "fake", Model: "fake"}, {Name: "unknown"}}
+
+	runID, evals, err := ev.Run(context.Background(), versions, models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evals) != 4 || len(store.evals) != 4 {
+		t.Fatalf("expected 2 versions x 2 models, got %d results and %d stored", len(evals), len(store.evals))
+	}
+	for _, e := range evals {
+		if e.RunID != runID {
+			t.Errorf("result not tagged with run id: %+v", e)
+		}
+		switch e.Provider {
+		case "fake":
+			if e.Error != "" || e.Bullets != len(EvalSample) {
+				t.Errorf("expected one bullet per sample commit, got %+v", e)
+			}
+		case "unknown":
+			if e.Error == "" {
+				t.Errorf("expected an error for an unsupported provider, got %+v", e)
+			}
+		}
+	}
+	if len(usage.records) != 2 || usage.records[0].Origin != "eval:"+runID {
+		t.Errorf("expected fake calls to be metered under the run, got %+v", usage.records)
+	}
+}
@@ -3,6 +3,9 @@ package ai
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/iamhabbeboy/gitresume/config"
 )
@@ -12,13 +15,33 @@ type AiModel interface {
 	Chat(ctx context.Context, prompt []config.Prompt) ([]string, error)
 }
 
+// Embedder turns texts into vectors for semantic search, one per text.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
 type ChatRequest struct {
-	Model       string          `json:"model"`
-	Messages    []config.Prompt `json:"messages"`
-	Stream      bool            `json:"stream"`
-	Temperature float32         `json:"temperature"`
-	NumPredict  int             `json:"num_predict"`
-	MaxTokens   int             `json:"max_tokens"`
+	Model          string          `json:"model"`
+	Messages       []config.Prompt `json:"messages"`
+	Stream         bool            `json:"stream"`
+	Temperature    float32         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+	// Value carries the schema in TGI's grammar format.
+	Value map[string]any `json:"value,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
 }
 
 type ModelType string
@@ -28,6 +51,7 @@ const (
 	HuggingFace ModelType = "huggingface"
 	Llama       ModelType = "llama"
 	Gemini      ModelType = "gemini"
+	Fake        ModelType = "fake"
 )
 
 type Role string
@@ -38,24 +62,144 @@ const (
 	Assistant Role = "assistant"
 )
 
+// GenOptions are generation settings shared by Chat and Generate.
+type GenOptions struct {
+	// System is sent as the system prompt by Generate, and by Chat when the
+	// prompts do not already contain one.
+	System string
+	Stop   []string
+	// Seed makes sampling reproducible on providers that support it.
+	Seed *int
+}
+
 type ModelConfig struct {
 	Type        ModelType
 	Model       string
 	APIKey      string
 	Temperature float32
 	MaxToken    int
+	// BaseURL overrides the provider endpoint where supported.
+	BaseURL string
+	GenOptions
+	// ResponseSchema asks the provider for JSON output matching the schema
+	// instead of free text. Providers return the raw JSON unmodified.
+	ResponseSchema map[string]any
+	// HTTPClient overrides the client used to reach the provider, e.g. with a
+	// Recorder transport. Defaults to NewHTTPClient.
+	HTTPClient *http.Client
+	// Script holds canned replies for the fake provider, returned in order.
+	Script []string
+	// Meter records usage and enforces the budget when set.
+	Meter *Meter
+	// Audit logs what is sent to the provider and refuses remote providers
+	// in local-only mode when set.
+	Audit *Auditor
+}
+
+func NewChatModel(cfg ModelConfig) (AiModel, error) {
+	var model AiModel
+	switch cfg.Type {
+	case Llama:
+		model = NewLlama(cfg)
+	case OpenAI:
+		model = NewOpenAI(cfg)
+	case Gemini:
+		model = NewGemini(cfg)
+	case HuggingFace:
+		model = NewHuggingFace(cfg)
+	case Fake:
+		model = NewFake(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported AI model type: %s", cfg.Type)
+	}
+	if cfg.Audit != nil {
+		var err error
+		if model, err = cfg.Audit.audit(model, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Meter != nil {
+		return &MeteredModel{AiModel: model, Provider: cfg.Type, Model: cfg.Model, BaseURL: cfg.BaseURL, Meter: cfg.Meter}, nil
+	}
+	return model, nil
 }
 
-func NewChatModel(cfg ModelConfig) AiModel {
+// NewEmbedder returns the embedding client for a provider. cfg.Model must be
+// an embedding model; DefaultEmbedModel has one per provider.
+func NewEmbedder(cfg ModelConfig) (Embedder, error) {
+	var e Embedder
 	switch cfg.Type {
 	case Llama:
-		return NewLlama(cfg)
+		e = NewLlama(cfg)
 	case OpenAI:
-		return NewOpenAI(cfg)
+		e = NewOpenAI(cfg)
 	case Gemini:
-		return NewGemini(cfg)
+		e = NewGemini(cfg)
+	case Fake:
+		e = NewFake(cfg)
 	default:
-		fmt.Errorf("unsupported AI model type: %s", cfg.Type)
+		return nil, fmt.Errorf("%s does not support embeddings", cfg.Type)
+	}
+	if cfg.Audit != nil {
+		var err error
+		if e, err = cfg.Audit.auditEmbedder(e, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Meter != nil {
+		return &MeteredEmbedder{Embedder: e, Provider: cfg.Type, Model: cfg.Model, BaseURL: cfg.BaseURL, Meter: cfg.Meter}, nil
+	}
+	return e, nil
+}
+
+// withSystem prepends the configured system prompt unless the prompts
+// already carry one.
+func (o GenOptions) withSystem(prompts []config.Prompt) []config.Prompt {
+	if o.System == "" {
+		return prompts
+	}
+	for _, p := range prompts {
+		if Role(p.Role) == System {
+			return prompts
+		}
+	}
+	return append([]config.Prompt{{Role: string(System), Content: o.System}}, prompts...)
+}
+
+// generatePrompts turns a single-shot message into chat prompts for
+// providers without a separate completion API.
+func (o GenOptions) generatePrompts(message string) []config.Prompt {
+	return o.withSystem([]config.Prompt{{Role: string(User), Content: message}})
+}
+
+// NewHTTPClient returns the client providers use when ModelConfig.HTTPClient
+// is not set. If GITRESUME_AI_CASSETTE points at a cassette file, requests
+// are replayed from it (or recorded to it when GITRESUME_AI_RECORD is set)
+// so the CLI and dashboard can run without network access.
+func NewHTTPClient() *http.Client {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	path := os.Getenv("GITRESUME_AI_CASSETTE")
+	if path == "" {
+		return client
+	}
+	mode := ModeReplay
+	if os.Getenv("GITRESUME_AI_RECORD") != "" {
+		mode = ModeRecord
+	}
+	rec, err := NewRecorder(path, mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load cassette %s: %v\n", path, err)
+		return client
+	}
+	client.Transport = rec
+	return client
+}
+
+func httpClient(cfg ModelConfig) *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
 	}
-	return nil
+	return NewHTTPClient()
 }
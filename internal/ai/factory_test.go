@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+func mustModel(t *testing.T, cfg ModelConfig) AiModel {
+	t.Helper()
+	m, err := NewChatModel(cfg)
+	if err != nil {
+		t.Fatalf("NewChatModel failed: %v", err)
+	}
+	return m
+}
+
+func TestNewChatModel_UnknownType(t *testing.T) {
+	m, err := NewChatModel(ModelConfig{Type: "nope"})
+	if err == nil || m != nil {
+		t.Errorf("expected an error for an unknown type, got %v, %v", m, err)
+	}
+}
+
+func TestGenOptions_WithSystem(t *testing.T) {
+	opts := GenOptions{System: "be brief"}
+	got := opts.withSystem(testPrompt)
+	if len(got) != 2 || got[0].Role != string(System) || got[0].Content != "be brief" {
+		t.Errorf("expected the system prompt to be prepended, got %+v", got)
+	}
+
+	own := []config.Prompt{{Role: string(System), Content: "mine"}, testPrompt[0]}
+	if got := opts.withSystem(own); len(got) != 2 || got[0].Content != "mine" {
+		t.Errorf("expected an existing system prompt to win, got %+v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// FakeModel is a deterministic, offline provider. Scripted replies are
+// returned in order (the last one repeats); without a script the reply is
+// rendered from the prompt, one line per commit in the last user message.
+type FakeModel struct {
+	Script   []string
+	Template *template.Template
+	Schema   map[string]any
+
+	mu    sync.Mutex
+	calls int
+}
+
+type FakeLine struct {
+	Hash string
+	Msg  string
+}
+
+type fakeData struct {
+	Prompts []config.Prompt
+	Lines   []FakeLine
+}
+
+var (
+	commitLine = regexp.MustCompile(`^\[([^\]]+)\]\s*(.*)$`)
+
+	defaultFakeTemplate = template.Must(template.New("fake").Parse(
+		`{{range .Lines}}{{.Msg}}
+{{end}}`))
+)
+
+func NewFake(cfg ModelConfig) *FakeModel {
+	return &FakeModel{
+		Script:   cfg.Script,
+		Template: defaultFakeTemplate,
+		Schema:   cfg.ResponseSchema,
+	}
+}
+
+// Calls returns how many times the model has been invoked.
+func (f *FakeModel) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *FakeModel) Generate(ctx context.Context, message string) (string, error) {
+	resp, err := f.Chat(ctx, []config.Prompt{{Role: string(User), Content: message}})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(resp, "\n"), nil
+}
+
+func (f *FakeModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	if len(prompts) == 0 {
+		return nil, errors.New("no prompt supplied")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	n := f.calls
+	f.calls++
+	f.mu.Unlock()
+
+	if len(f.Script) > 0 {
+		return []string{f.Script[min(n, len(f.Script)-1)]}, nil
+	}
+
+	data := fakeData{Prompts: prompts, Lines: fakeLines(prompts)}
+	if reflect.DeepEqual(f.Schema, TailorSchema) {
+		return fakeTailoring(prompts)
+	}
+	if reflect.DeepEqual(f.Schema, TranslationSchema) {
+		return fakeTranslation(prompts)
+	}
+	if f.Schema != nil {
+		resp := BulletResponse{Bullets: []Bullet{}}
+		for _, l := range data.Lines {
+			src := []string{}
+			if l.Hash != "" {
+				src = append(src, l.Hash)
+			}
+			resp.Bullets = append(resp.Bullets, Bullet{Text: l.Msg, SourceCommits: src, Confidence: 1})
+		}
+		out, err := json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(out)}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := f.Template.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return cleanOutput(buf.String()), nil
+}
+
+// fakeTailoring keeps the resume as it is, except that skills the job
+// description mentions move to the front.
+func fakeTailoring(prompts []config.Prompt) ([]string, error) {
+	var content string
+	for _, p := range prompts {
+		if Role(p.Role) == User {
+			content = p.Content
+		}
+	}
+	jd, resume, ok := strings.Cut(strings.TrimPrefix(content, "Job description:\n"), "\n\nResume (JSON):\n")
+	if !ok {
+		return nil, errors.New("fake: no resume in the tailoring prompt")
+	}
+	resume, _, _ = strings.Cut(resume, "\n")
+	var in tailorInput
+	if err := json.Unmarshal([]byte(resume), &in); err != nil {
+		return nil, err
+	}
+
+	jd = strings.ToLower(jd)
+	p := TailorProposal{Summary: in.Summary, Skills: slices.Clone(in.Skills), Experiences: []TailoredExperience{}, Projects: []int64{}}
+	slices.SortStableFunc(p.Skills, func(a, b string) int {
+		ma, mb := strings.Contains(jd, strings.ToLower(a)), strings.Contains(jd, strings.ToLower(b))
+		switch {
+		case ma && !mb:
+			return -1
+		case mb && !ma:
+			return 1
+		}
+		return 0
+	})
+	for _, e := range in.Experiences {
+		p.Experiences = append(p.Experiences, TailoredExperience{ID: e.ID, Bullets: e.Bullets})
+	}
+	for _, pr := range in.Projects {
+		p.Projects = append(p.Projects, pr.ID)
+	}
+	out, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(out)}, nil
+}
+
+// fakeTranslation answers with the resume it was given, untranslated.
+func fakeTranslation(prompts []config.Prompt) ([]string, error) {
+	var content string
+	for _, p := range prompts {
+		if Role(p.Role) == User {
+			content = p.Content
+		}
+	}
+	_, resume, ok := strings.Cut(content, "Resume (JSON):\n")
+	if !ok {
+		return nil, errors.New("fake: no resume in the translation prompt")
+	}
+	resume, _, _ = strings.Cut(resume, "\n")
+	return []string{resume}, nil
+}
+
+// fakeLines splits the last user message into lines, picking up the
+// "[hash] message" format produced by CommitContent.
+func fakeLines(prompts []config.Prompt) []FakeLine {
+	var content string
+	for _, p := range prompts {
+		if Role(p.Role) == User {
+			content = p.Content
+		}
+	}
+
+	var lines []FakeLine
+	for _, l := range strings.Split(content, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if m := commitLine.FindStringSubmatch(l); m != nil {
+			lines = append(lines, FakeLine{Hash: m[1], Msg: m[2]})
+			continue
+		}
+		lines = append(lines, FakeLine{Msg: l})
+	}
+	return lines
+}
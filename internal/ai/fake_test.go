@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+func TestFake_Script(t *testing.T) {
+	m := mustModel(t, ModelConfig{Type: Fake, Script: []string{"first", "second"}})
+	for _, want := range []string{"first", "second", "second"} {
+		resp, err := m.Chat(context.Background(), testPrompt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp[0] != want {
+			t.Errorf("expected %q, got %q", want, resp[0])
+		}
+	}
+}
+
+func TestFake_Template(t *testing.T) {
+	commits := []git.GitCommit{{Hash: "a1", Msg: "add login page"}, {Hash: "b2", Msg: "fix CSRF check"}}
+	prompts := []config.Prompt{
+		{Role: string(System), Content: "You are a resume writer"},
+		{Role: string(User), Content: CommitContent(commits)},
+	}
+
+	resp, err := NewFake(ModelConfig{}).Chat(context.Background(), prompts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"Add login page", "Fix CSRF check"}; !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %q, got %q", want, resp)
+	}
+
+	bullets, err := GenerateBullets(context.Background(), NewFake(ModelConfig{ResponseSchema: BulletSchema}), prompts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bullets) != 2 || bullets[1].SourceCommits[0] != "b2" {
+		t.Errorf("unexpected bullets: %+v", bullets)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/iamhabbeboy/gitresume/config"
 )
@@ -21,16 +21,25 @@ type GeminiCfg struct {
 	APIKey string
 	Model  string
 	Prompt config.CustomPrompt
+	Schema map[string]any
+	opts   GenOptions
+	client *http.Client
+	usage  TokenUsage
 }
 
 type GeminiGenerationCfg struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature      float64        `json:"temperature,omitempty"`
+	MaxOutputTokens  int            `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType string         `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
+	StopSequences    []string       `json:"stopSequences,omitempty"`
+	Seed             *int           `json:"seed,omitempty"`
 }
 
 type GeminiChatRequest struct {
-	Contents         []GeminiMessage     `json:"contents"`
-	GenerationConfig GeminiGenerationCfg `json:"generationConfig"`
+	SystemInstruction *GeminiMessage      `json:"systemInstruction,omitempty"`
+	Contents          []GeminiMessage     `json:"contents"`
+	GenerationConfig  GeminiGenerationCfg `json:"generationConfig"`
 }
 
 type GeminiResponse struct {
@@ -41,6 +50,11 @@ type GeminiResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
 	Error *struct {
 		Code    int    `json:"code"`
 		Message string `json:"message"`
@@ -52,7 +66,7 @@ type Part struct {
 }
 
 type GeminiMessage struct {
-	Role  string `json:"role"`
+	Role  string `json:"role,omitempty"`
 	Parts []Part `json:"parts"`
 }
 
@@ -64,6 +78,9 @@ func NewGemini(cfg ModelConfig) *GeminiCfg {
 			MaxTokens:   cfg.MaxToken,
 			Temperature: cfg.Temperature,
 		},
+		Schema: cfg.ResponseSchema,
+		opts:   cfg.GenOptions,
+		client: httpClient(cfg),
 	}
 }
 
@@ -73,35 +90,67 @@ func (g *GeminiCfg) Chat(ctx context.Context, prompts []config.Prompt) ([]string
 		return nil, errors.New("no prompt supplied")
 	}
 
-	if g.APIKey == "" {
-		return nil, errors.New("Gemini API key is missing")
-	}
-	var systemText, userText string
-
-	for _, p := range prompts {
+	data := GeminiChatRequest{GenerationConfig: g.generationConfig()}
+	var system []Part
+	for _, p := range g.opts.withSystem(prompts) {
+		// Gemini calls the assistant "model" and takes the system prompt
+		// as an instruction beside the conversation
+		var role string
 		switch Role(p.Role) {
 		case System:
-			systemText += p.Content + "\n"
-		case User:
-			userText += p.Content + "\n"
+			system = append(system, Part{Text: p.Content})
+			continue
+		case Assistant:
+			role = "model"
+		default:
+			role = string(User)
+		}
+		// turns alternate, so consecutive messages of a role are one turn
+		if n := len(data.Contents); n > 0 && data.Contents[n-1].Role == role {
+			data.Contents[n-1].Parts = append(data.Contents[n-1].Parts, Part{Text: p.Content})
+			continue
 		}
+		data.Contents = append(data.Contents, GeminiMessage{Role: role, Parts: []Part{{Text: p.Content}}})
+	}
+	if len(system) > 0 {
+		data.SystemInstruction = &GeminiMessage{Parts: system}
+	}
+	if len(data.Contents) == 0 {
+		return nil, errors.New("no user prompt supplied")
+	}
+	if g.Schema != nil {
+		data.GenerationConfig.ResponseMimeType = "application/json"
+		data.GenerationConfig.ResponseSchema = geminiSchema(g.Schema)
 	}
-	// merged := strings.TrimSpace(userText)
 
-	data := GeminiChatRequest{
-		Contents: []GeminiMessage{
-			{
-				Role: string(User),
-				Parts: []Part{
-					{Text: systemText},
-					{Text: userText},
-				},
-			},
-		},
-		GenerationConfig: GeminiGenerationCfg{
-			Temperature:     float64(g.Prompt.Temperature),
-			MaxOutputTokens: g.Prompt.MaxTokens,
-		},
+	result, err := g.generateContent(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []string
+	for _, candidate := range result.Candidates {
+		for _, part := range candidate.Content.Parts {
+			responses = append(responses, part.Text)
+		}
+	}
+
+	return responses, nil
+}
+
+func (g *GeminiCfg) generationConfig() GeminiGenerationCfg {
+	return GeminiGenerationCfg{
+		Temperature:     float64(g.Prompt.Temperature),
+		MaxOutputTokens: g.Prompt.MaxTokens,
+		StopSequences:   g.opts.Stop,
+		Seed:            g.opts.Seed,
+	}
+}
+
+func (g *GeminiCfg) generateContent(ctx context.Context, data GeminiChatRequest) (GeminiResponse, error) {
+	var result GeminiResponse
+	if g.APIKey == "" {
+		return result, errors.New("Gemini API key is missing")
 	}
 
 	msg, _ := json.Marshal(data)
@@ -109,51 +158,91 @@ func (g *GeminiCfg) Chat(ctx context.Context, prompts []config.Prompt) ([]string
 	host := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", g.Model, g.APIKey)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host, bytes.NewBuffer(msg))
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
-	fmt.Println(string(body))
-
-	var result GeminiResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse gemini response: %w", err)
+		return result, fmt.Errorf("failed to parse gemini response: %w", err)
 	}
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("gemini api error: %s", result.Error.Message)
+		return result, fmt.Errorf("gemini api error: %s", result.Error.Message)
+	}
+
+	g.usage = TokenUsage{
+		InputTokens:  result.UsageMetadata.PromptTokenCount,
+		OutputTokens: result.UsageMetadata.CandidatesTokenCount,
 	}
 
 	if len(result.Candidates) == 0 {
-		return nil, fmt.Errorf("no candidates returned from gemini")
+		return result, fmt.Errorf("no candidates returned from gemini")
 	}
+	return result, nil
+}
 
-	var responses []string
-	for _, candidate := range result.Candidates {
-		for _, part := range candidate.Content.Parts {
-			responses = append(responses, part.Text)
+// geminiSchema converts a JSON schema into the OpenAPI subset accepted by
+// responseSchema, which has no additionalProperties keyword.
+func geminiSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		switch k {
+		case "additionalProperties":
+			continue
+		case "properties":
+			props := map[string]any{}
+			for name, p := range v.(map[string]any) {
+				props[name] = geminiSchema(p.(map[string]any))
+			}
+			out[k] = props
+		case "items":
+			out[k] = geminiSchema(v.(map[string]any))
+		default:
+			out[k] = v
 		}
 	}
+	return out
+}
 
-	return responses, nil
+// LastUsage implements UsageReporter.
+func (g *GeminiCfg) LastUsage() TokenUsage {
+	return g.usage
 }
 
-// Generate implements AiModel.
+// Generate implements AiModel. The system prompt is sent as a system
+// instruction and the reply parts of the first candidate are joined.
 func (g *GeminiCfg) Generate(ctx context.Context, message string) (string, error) {
-	return "", nil
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("no prompt supplied")
+	}
+	data := GeminiChatRequest{
+		Contents: []GeminiMessage{
+			{Role: string(User), Parts: []Part{{Text: message}}},
+		},
+		GenerationConfig: g.generationConfig(),
+	}
+	if g.opts.System != "" {
+		data.SystemInstruction = &GeminiMessage{Parts: []Part{{Text: g.opts.System}}}
+	}
+
+	result, err := g.generateContent(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for _, part := range result.Candidates[0].Content.Parts {
+		out.WriteString(part.Text)
+	}
+	return strings.TrimSpace(out.String()), nil
 }
@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// capture is a RoundTripper that records the request body and replies with
+// a fixed response.
+type capture struct {
+	body  map[string]any
+	reply string
+}
+
+func (c *capture) RoundTrip(req *http.Request) (*http.Response, error) {
+	b, _ := io.ReadAll(req.Body)
+	c.body = map[string]any{}
+	json.Unmarshal(b, &c.body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(c.reply)),
+		Request:    req,
+	}, nil
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	seed := 3
+	rt := &capture{reply: `{"choices":[{"message":{"role":"assistant","content":"  Shipped it.  "}}],"usage":{"prompt_tokens":9,"completion_tokens":3}}`}
+	m := NewOpenAI(ModelConfig{
+		Model: "gpt-5-mini", APIKey: "k",
+		GenOptions: GenOptions{System: "be brief", Stop: []string{"\n\n"}, Seed: &seed},
+		HTTPClient: &http.Client{Transport: rt},
+	})
+
+	out, err := m.Generate(context.Background(), "rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Shipped it." {
+		t.Errorf("expected trimmed text, got %q", out)
+	}
+	msgs := rt.body["messages"].([]any)
+	if len(msgs) != 2 || msgs[0].(map[string]any)["content"] != "be brief" {
+		t.Errorf("expected system then user message, got %v", msgs)
+	}
+	if rt.body["seed"] != float64(3) || rt.body["stop"] == nil {
+		t.Errorf("expected seed and stop, got %v", rt.body)
+	}
+	if _, ok := rt.body["response_format"]; ok {
+		t.Error("Generate should not request JSON output")
+	}
+}
+
+func TestGeminiGenerate(t *testing.T) {
+	rt := &capture{reply: `{"candidates":[{"content":{"parts":[{"text":"Led "},{"text":"the team."}]}}]}`}
+	m := NewGemini(ModelConfig{
+		Model: "gemini-2.5-flash", APIKey: "k",
+		GenOptions: GenOptions{System: "be brief", Stop: []string{"END"}},
+		HTTPClient: &http.Client{Transport: rt},
+	})
+
+	out, err := m.Generate(context.Background(), "rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Led the team." {
+		t.Errorf("expected joined parts, got %q", out)
+	}
+	if rt.body["systemInstruction"] == nil {
+		t.Errorf("expected a system instruction, got %v", rt.body)
+	}
+	gen := rt.body["generationConfig"].(map[string]any)
+	if stops := gen["stopSequences"].([]any); len(stops) != 1 || stops[0] != "END" {
+		t.Errorf("expected stop sequences, got %v", gen)
+	}
+}
+
+func TestGeminiChat_RepairRound(t *testing.T) {
+	rt := &capture{reply: `{"candidates":[{"content":{"parts":[{"text":"{}"}]}}]}`}
+	m := NewGemini(ModelConfig{Model: "gemini-2.5-flash", APIKey: "k", HTTPClient: &http.Client{Transport: rt}})
+
+	// the reply to repair has to reach the model as its own turn
+	_, err := m.Chat(context.Background(), []config.Prompt{
+		{Role: string(System), Content: "You write resumes."},
+		{Role: string(User), Content: "[a1] Add checkout"},
+		{Role: string(Assistant), Content: "not json"},
+		{Role: string(User), Content: "Reply with valid JSON."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys, _ := json.Marshal(rt.body["systemInstruction"])
+	if string(sys) != `{"parts":[{"text":"You write resumes."}]}` {
+		t.Errorf("expected the system prompt as an instruction, got %s", sys)
+	}
+	contents, _ := json.Marshal(rt.body["contents"])
+	want := `[{"parts":[{"text":"[a1] Add checkout"}],"role":"user"},{"parts":[{"text":"not json"}],"role":"model"},{"parts":[{"text":"Reply with valid JSON."}],"role":"user"}]`
+	if string(contents) != want {
+		t.Errorf("expected the turns in order, got %s", contents)
+	}
+}
+
+func TestGenerate_EmptyMessage(t *testing.T) {
+	for _, m := range []AiModel{
+		NewLlama(ModelConfig{}),
+		NewOpenAI(ModelConfig{APIKey: "k"}),
+		NewGemini(ModelConfig{APIKey: "k"}),
+	} {
+		if _, err := m.Generate(context.Background(), "  "); err == nil {
+			t.Errorf("%T: expected an error for an empty message", m)
+		}
+	}
+}
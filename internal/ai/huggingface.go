@@ -0,0 +1,166 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// HuggingFaceRouter is the hosted Inference API. A local Text Generation
+// Inference container serves the same protocol at http://localhost:8080/v1.
+const HuggingFaceRouter = "https://router.huggingface.co/v1"
+
+// HuggingFaceCfg talks to the TGI Messages API, which mirrors OpenAI's chat
+// completions. The API key is optional for local containers.
+type HuggingFaceCfg struct {
+	APIKey  string
+	Model   string              `json:"model"`
+	BaseURL string              `json:"base_url"`
+	Prompt  config.CustomPrompt `json:"prompt"`
+	Schema  map[string]any      `json:"schema"`
+	opts    GenOptions
+	client  *http.Client
+	usage   TokenUsage
+}
+
+func NewHuggingFace(cfg ModelConfig) *HuggingFaceCfg {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = HuggingFaceRouter
+	}
+	model := cfg.Model
+	if model == "" {
+		// TGI serves a single model and ignores the name
+		model = "tgi"
+	}
+	return &HuggingFaceCfg{
+		APIKey:  cfg.APIKey,
+		Model:   model,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Prompt: config.CustomPrompt{
+			MaxTokens:   cfg.MaxToken,
+			Temperature: cfg.Temperature,
+		},
+		Schema: cfg.ResponseSchema,
+		opts:   cfg.GenOptions,
+		client: httpClient(cfg),
+	}
+}
+
+// Generate implements AiModel by sending the message as one user turn.
+func (h *HuggingFaceCfg) Generate(ctx context.Context, message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("no prompt supplied")
+	}
+	result, err := h.complete(ctx, h.request(h.opts.generatePrompts(message)))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// Chat implements AiModel.
+func (h *HuggingFaceCfg) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	if len(prompts) == 0 {
+		return nil, errors.New("no prompt supplied")
+	}
+
+	data := h.request(h.opts.withSystem(prompts))
+	if h.Schema != nil {
+		// TGI constrains output with a grammar rather than OpenAI's json_schema
+		data.ResponseFormat = &ResponseFormat{Type: "json_object", Value: h.Schema}
+	}
+
+	result, err := h.complete(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []string
+	for _, choice := range result.Choices {
+		responses = append(responses, choice.Message.Content)
+	}
+	return responses, nil
+}
+
+// LastUsage implements UsageReporter.
+func (h *HuggingFaceCfg) LastUsage() TokenUsage {
+	return h.usage
+}
+
+func (h *HuggingFaceCfg) request(prompts []config.Prompt) ChatRequest {
+	return ChatRequest{
+		Stream:      false,
+		Messages:    prompts,
+		Model:       h.Model,
+		MaxTokens:   h.Prompt.MaxTokens,
+		Temperature: h.Prompt.Temperature,
+		Stop:        h.opts.Stop,
+		Seed:        h.opts.Seed,
+	}
+}
+
+func (h *HuggingFaceCfg) complete(ctx context.Context, data ChatRequest) (openAIResponse, error) {
+	var result openAIResponse
+
+	msg, _ := json.Marshal(data)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.BaseURL+"/chat/completions", bytes.NewBuffer(msg))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// TGI returns {"error": "...", "error_type": "..."}; the router
+		// returns OpenAI style {"error": {"message": "..."}}
+		var apiErr struct {
+			Error json.RawMessage `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		var text string
+		if json.Unmarshal(apiErr.Error, &text) != nil {
+			var obj struct {
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(apiErr.Error, &obj)
+			text = obj.Message
+		}
+		if text != "" {
+			return result, fmt.Errorf("huggingface error: %s", text)
+		}
+		return result, fmt.Errorf("huggingface returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("failed to decode huggingface response: %w", err)
+	}
+	h.usage = TokenUsage{
+		InputTokens:  result.Usage.PromptTokens,
+		OutputTokens: result.Usage.CompletionTokens,
+	}
+	if len(result.Choices) == 0 {
+		return result, errors.New("no response from huggingface")
+	}
+	return result, nil
+}
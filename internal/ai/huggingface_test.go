@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHuggingFace_LocalTGI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("expected no auth header for a local container, got %q", auth)
+		}
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "tgi" || req.MaxTokens != 200 || req.Temperature != 0.3 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_object" || req.ResponseFormat.Value == nil {
+			t.Errorf("expected a TGI grammar, got %+v", req.ResponseFormat)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"bullets\":[]}"}}],"usage":{"prompt_tokens":12,"completion_tokens":4}}`))
+	}))
+	defer srv.Close()
+
+	m, err := NewChatModel(ModelConfig{
+		Type: HuggingFace, BaseURL: srv.URL + "/v1/", Temperature: 0.3, MaxToken: 200,
+		ResponseSchema: BulletSchema,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := m.Chat(context.Background(), testPrompt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1 || out[0] != `{"bullets":[]}` {
+		t.Errorf("expected raw JSON, got %q", out)
+	}
+	if u := m.(UsageReporter).LastUsage(); u.InputTokens != 12 || u.OutputTokens != 4 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+}
+
+func TestHuggingFace_Errors(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error":"Input validation error: max_new_tokens too large","error_type":"validation"}`, "huggingface error: Input validation error: max_new_tokens too large"},
+		{`{"error":{"message":"Invalid credentials"}}`, "huggingface error: Invalid credentials"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer hf_test" {
+				t.Errorf("expected the API key to be sent")
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(tt.body))
+		}))
+		m := NewHuggingFace(ModelConfig{APIKey: "hf_test", Model: "meta-llama/Llama-3.1-8B-Instruct", BaseURL: srv.URL})
+		_, err := m.Generate(context.Background(), "hello")
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+		srv.Close()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
@@ -16,17 +17,48 @@ import (
 type LlamaConfig struct {
 	Model  string              `json:"model"`
 	Prompt config.CustomPrompt `json:"prompt"`
+	Schema map[string]any      `json:"schema"`
+	opts   GenOptions
+	client *http.Client
+	usage  TokenUsage
+}
+
+type GenerateRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	System  string         `json:"system,omitempty"`
+	Stream  bool           `json:"stream"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+// OllamaChatRequest is the body of /api/chat. Ollama reads the sampling
+// settings only from Options.
+type OllamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []config.Prompt `json:"messages"`
+	Stream   bool            `json:"stream"`
+	// Format is the JSON schema of a structured reply.
+	Format  map[string]any `json:"format,omitempty"`
+	Options map[string]any `json:"options,omitempty"`
 }
 
 type Response struct {
-	Response string `json:"response"`
+	Response        string `json:"response"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error,omitempty"`
 }
 
 type ChatResponse struct {
-	Message config.Prompt `json:"message"`
+	Message         config.Prompt `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
 }
 
 var (
+	bulletMarker = regexp.MustCompile(`^\s*[-*•]\s*`)
+	preamble     = preamblePattern()
+
 	aiHost             = "http://localhost:11434"
 	aiGenerateEndpoint = "/api/generate"
 	aiChatEndpoint     = "/api/chat"
@@ -40,87 +72,165 @@ func NewLlama(cfg ModelConfig) *LlamaConfig {
 			MaxTokens:   cfg.MaxToken,
 			Temperature: cfg.Temperature,
 		},
+		Schema: cfg.ResponseSchema,
+		opts:   cfg.GenOptions,
+		client: httpClient(cfg),
 	}
 }
 
+// Generate runs a single-shot completion on /api/generate.
 func (l *LlamaConfig) Generate(ctx context.Context, message string) (string, error) {
-	return "", nil
-}
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("no prompt supplied")
+	}
 
-func (l *LlamaConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
-	if len(prompts) == 0 {
-		return nil, errors.New("no prompt supplied")
+	data := GenerateRequest{
+		Model:   l.Model,
+		Prompt:  message,
+		System:  l.opts.System,
+		Stream:  false,
+		Options: l.options(),
+	}
+
+	var resp Response
+	if err := l.post(ctx, aiGenerateEndpoint, data, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("ollama error: %s", resp.Error)
+	}
+	l.usage = TokenUsage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
 	}
+	return strings.TrimSpace(resp.Response), nil
+}
 
-	data := ChatRequest{
-		Stream:      false,
-		Messages:    prompts,
-		Model:       l.Model,
-		NumPredict:  l.Prompt.MaxTokens,
-		Temperature: l.Prompt.Temperature,
+// options holds the settings Ollama expects under "options" for both
+// /api/chat and /api/generate.
+func (l *LlamaConfig) options() map[string]any {
+	opts := map[string]any{"temperature": l.Prompt.Temperature}
+	if l.Prompt.MaxTokens > 0 {
+		opts["num_predict"] = l.Prompt.MaxTokens
+	}
+	if len(l.opts.Stop) > 0 {
+		opts["stop"] = l.opts.Stop
+	}
+	if l.opts.Seed != nil {
+		opts["seed"] = *l.opts.Seed
 	}
+	return opts
+}
 
+func (l *LlamaConfig) post(ctx context.Context, endpoint string, data, out any) error {
 	msg, _ := json.Marshal(data)
 
-	res, err := http.Post(aiHost+aiChatEndpoint, "application/json", bytes.NewBuffer(msg))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, aiHost+endpoint, bytes.NewBuffer(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
 
+	res, err := l.client.Do(req)
 	if err != nil {
-		return nil, err
+		return ollamaUnavailable(aiHost, err)
 	}
 	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return ollamaError(res.StatusCode, body, l.Model)
+	}
+
+	if err = json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	return nil
+}
+
+func (l *LlamaConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	if len(prompts) == 0 {
+		return nil, errors.New("no prompt supplied")
+	}
+
+	data := OllamaChatRequest{
+		Stream:   false,
+		Messages: l.opts.withSystem(prompts),
+		Model:    l.Model,
+		Format:   l.Schema,
+		Options:  l.options(),
 	}
 
 	var resp ChatResponse
-	err = json.Unmarshal(body, &resp)
+	if err := l.post(ctx, aiChatEndpoint, data, &resp); err != nil {
+		return nil, err
+	}
+	l.usage = TokenUsage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+	}
+	if l.Schema != nil {
+		return []string{resp.Message.Content}, nil
+	}
 	output := cleanOutput(resp.Message.Content)
 	return output, nil
 }
 
-func cleanOutput(output string) []string {
-	ignorePhrases := []string{
-		"Note: i've kept the bullet points concise, using action verbs and focusing on impact.",
-		"here's the transformed bullet point:",
-		"here is the transformed bullet point:",
-		"here’s the transformed bullet point:", // with typographic apostrophe
-		"transformed bullet point:",
-		"bullet point:",
-		"here are two possible bullet points:",
-		"here are some possible bullet points:",
-		"possible bullet points:",
-		"the transformed version is:",
-		"here are the transformed bullet points:",
-		"here's the improved bullet point:",
-		"here is the improved bullet point:",
-		"improved bullet point:",
-		"Here are the transformed resume bullet points:",
-		"Here is the transformed resume",
-		"Here is the transformed commit message into a resume",
-		"Here is a polished resume",
-		"Here's a possible transformation:",
-	}
-
-	// Normalize to lowercase for easier matching
-	lowered := strings.ToLower(output)
+// LastUsage implements UsageReporter.
+func (l *LlamaConfig) LastUsage() TokenUsage {
+	return l.usage
+}
+
+// ignorePhrases are preambles small local models like to put in front of the
+// bullets. They are only needed when the model is not running in JSON mode.
+var ignorePhrases = []string{
+	"Note: i've kept the bullet points concise, using action verbs and focusing on impact.",
+	"here's the transformed bullet point:",
+	"here is the transformed bullet point:",
+	"transformed bullet point:",
+	"bullet point:",
+	"here are two possible bullet points:",
+	"here are some possible bullet points:",
+	"possible bullet points:",
+	"the transformed version is:",
+	"here are the transformed bullet points:",
+	"here's the improved bullet point:",
+	"here is the improved bullet point:",
+	"improved bullet point:",
+	"Here are the transformed resume bullet points:",
+	"Here is the transformed resume",
+	"Here is the transformed commit message into a resume",
+	"Here is a polished resume",
+	"Here's a possible transformation:",
+}
+
+func preamblePattern() *regexp.Regexp {
+	quoted := make([]string, 0, len(ignorePhrases))
 	for _, phrase := range ignorePhrases {
-		lowered = strings.ReplaceAll(lowered, phrase, "")
+		q := regexp.QuoteMeta(phrase)
+		// accept the typographic apostrophe as well
+		q = strings.ReplaceAll(q, "'", "['’]")
+		quoted = append(quoted, q)
 	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
 
-	// Split into lines
-	lines := strings.Split(lowered, "\n")
-	re := regexp.MustCompile(`^\s*[-*•]\s*`)
+// cleanOutput is the heuristic fallback for models that ignore the JSON
+// format. Matching is case-insensitive so proper nouns keep their casing.
+func cleanOutput(output string) []string {
+	stripped := preamble.ReplaceAllString(output, "")
 
 	var cleaned []string
-	for _, line := range lines {
+	for _, line := range strings.Split(stripped, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
 		// Remove leading bullet markers (-, *, •)
-		line = re.ReplaceAllString(line, "")
+		line = strings.TrimSpace(bulletMarker.ReplaceAllString(line, ""))
 
 		// Capitalize first letter if present
 		if len(line) > 0 {
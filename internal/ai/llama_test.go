@@ -1,17 +1,24 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"reflect"
+
 	// "fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
 )
 
-// TestGetStream_Success tests when the server returns a valid JSON response
-func TestGetStream_Success(t *testing.T) {
+var testPrompt = []config.Prompt{{Role: string(User), Content: "test message"}}
+
+// TestChat_Success tests when the server returns a valid JSON response
+func TestChat_Success(t *testing.T) {
 	// Mock server
-	expectedResp := Response{Response: "Hello World"}
+	expectedResp := ChatResponse{Message: config.Prompt{Role: "assistant", Content: "Here are the transformed bullet points:\n• Migrated Kafka consumers to Go"}}
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			t.Errorf("expected POST method, got %s", r.Method)
@@ -28,14 +35,77 @@ func TestGetStream_Success(t *testing.T) {
 	aiHost = mockServer.URL
 
 	// Call function
-	llama := NewLlama()
-	resp, err := llama.Generate("test message")
+	llama := NewLlama(ModelConfig{Model: AIModel})
+	resp, err := llama.Chat(context.Background(), testPrompt)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Migrated Kafka consumers to Go"}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %q, got %q", want, resp)
+	}
+}
+
+// TestChat_Schema tests that JSON mode sends the schema and skips cleanOutput
+func TestChat_Schema(t *testing.T) {
+	body := `{"bullets":[{"text":"Built the API","source_commits":["a1"],"confidence":0.9}]}`
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Format == nil {
+			t.Errorf("expected format to be set")
+		}
+		if req.Options["temperature"] != 0.5 || req.Options["num_predict"] != float64(200) {
+			t.Errorf("expected temperature and num_predict in options, got %v", req.Options)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Message: config.Prompt{Content: body}})
+	}))
+	defer mockServer.Close()
+
+	aiHost = mockServer.URL
+
+	llama := NewLlama(ModelConfig{Model: AIModel, Temperature: 0.5, MaxToken: 200, ResponseSchema: BulletSchema})
+	resp, err := llama.Chat(context.Background(), testPrompt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp) != 1 || resp[0] != body {
+		t.Errorf("expected raw JSON, got %q", resp)
+	}
+}
+
+// TestGenerate_Success tests single-shot completion with shared options
+func TestGenerate_Success(t *testing.T) {
+	seed := 7
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != aiGenerateEndpoint {
+			t.Errorf("expected path %s, got %s", aiGenerateEndpoint, r.URL.Path)
+		}
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Prompt != "rewrite this" || req.System != "be brief" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if req.Options["seed"] != float64(7) || req.Options["num_predict"] != float64(100) {
+			t.Errorf("expected seed and num_predict in options, got %v", req.Options)
+		}
+		json.NewEncoder(w).Encode(Response{Response: " Rewritten.\n", PromptEvalCount: 5, EvalCount: 2})
+	}))
+	defer mockServer.Close()
+
+	aiHost = mockServer.URL
 
+	llama := NewLlama(ModelConfig{Model: AIModel, MaxToken: 100, GenOptions: GenOptions{System: "be brief", Seed: &seed}})
+	resp, err := llama.Generate(context.Background(), "rewrite this")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if resp != expectedResp.Response {
-		t.Errorf("expected %q, got %q", expectedResp.Response, resp)
+	if resp != "Rewritten." {
+		t.Errorf("expected %q, got %q", "Rewritten.", resp)
+	}
+	if u := llama.LastUsage(); u.InputTokens != 5 || u.OutputTokens != 2 {
+		t.Errorf("unexpected usage: %+v", u)
 	}
 }
 
@@ -55,14 +125,22 @@ func TestGetStream_Success(t *testing.T) {
 // 	}
 // }
 
-// TestGetStream_HTTPError tests when server is unreachable
-func TestGetStream_HTTPError(t *testing.T) {
+// TestChat_HTTPError tests when server is unreachable
+func TestChat_HTTPError(t *testing.T) {
 	// Set aiHost to invalid URL to simulate error
 	aiHost = "http://127.0.0.1:0"
 
-	llama := NewLlama()
-	_, err := llama.Generate("any message")
+	llama := NewLlama(ModelConfig{Model: AIModel})
+	_, err := llama.Chat(context.Background(), testPrompt)
 	if err == nil {
 		t.Errorf("expected error when server is unreachable, got nil")
 	}
 }
+
+func TestCleanOutput_KeepsCase(t *testing.T) {
+	got := cleanOutput("Here’s the transformed bullet point:\n- Integrated GitHub OAuth with PostgreSQL")
+	want := []string{"Integrated GitHub OAuth with PostgreSQL"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
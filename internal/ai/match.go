@@ -0,0 +1,349 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+const (
+	// DefaultMatchThreshold is the similarity a requirement needs to count
+	// as covered by the user's evidence.
+	DefaultMatchThreshold = 0.5
+	maxRequirements       = 40
+	embedBatchSize        = 64
+)
+
+var ErrEmptyJobDescription = errors.New("job description is empty")
+
+// EmbeddingStore keeps evidence vectors; database.IDatabase satisfies it.
+type EmbeddingStore interface {
+	GetEmbeddings(model string) ([]config.Embedding, error)
+	SaveEmbeddings(e []config.Embedding) error
+	DeleteEmbeddings(model string, refs []string) error
+	SearchEmbeddings(model string, vector []float32, limit int) ([]config.EmbeddingMatch, error)
+}
+
+// EvidenceStore is the part of the database evidence is collected from.
+type EvidenceStore interface {
+	GetAllProject(limit, offset int) ([]git.Project, error)
+	GetAllCommitSummary(projectID int) ([]git.CustomUpdateCommit, error)
+	GetResumes() ([]git.Resume, error)
+	GetResume(ID int64) (git.Resume, error)
+}
+
+type RequirementMatch struct {
+	Requirement string                 `json:"requirement"`
+	Covered     bool                   `json:"covered"`
+	Best        *config.EmbeddingMatch `json:"best,omitempty"`
+}
+
+type MatchReport struct {
+	Model        string                  `json:"model"`
+	Matches      []config.EmbeddingMatch `json:"matches"`
+	Requirements []RequirementMatch      `json:"requirements"`
+	// Missing lists the requirements no evidence covers.
+	Missing []string `json:"missing"`
+}
+
+// Matcher ranks the user's evidence against a job description.
+type Matcher struct {
+	Embedder Embedder
+	// Model keys the stored vectors; vectors of different models are not
+	// comparable.
+	Model     string
+	Store     EmbeddingStore
+	Threshold float64
+}
+
+// NewMatcher builds a matcher on the embedder configured for
+// config.EmbedPrompt. meter and audit may be nil.
+func NewMatcher(cfg config.AppConfig, store EmbeddingStore, meter *Meter, audit *Auditor) (*Matcher, error) {
+	embedder, model, err := EmbedderFor(cfg, ModelConfig{Meter: meter, Audit: audit})
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{Embedder: embedder, Model: model, Store: store}, nil
+}
+
+// CollectEvidence gathers commit summaries (or raw commits for projects
+// without any), project tech stacks, and the responsibilities and projects
+// of a resume. A resumeID of 0 collects from every resume.
+func CollectEvidence(db EvidenceStore, resumeID int64) ([]config.Evidence, error) {
+	var evidence []config.Evidence
+
+	projects, err := db.GetAllProject(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID < projects[j].ID })
+	for _, p := range projects {
+		if stack := git.ParseTechStack(p.Technologies).Names(); len(stack) > 0 {
+			evidence = append(evidence, config.Evidence{
+				Ref:    fmt.Sprintf("project:%d", p.ID),
+				Kind:   config.EvidenceProject,
+				Source: p.Name,
+				Text:   fmt.Sprintf("%s, built with %s", p.Name, strings.Join(stack, ", ")),
+			})
+		}
+
+		summaries, err := db.GetAllCommitSummary(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range summaries {
+			// GetAllCommitSummary returns the summary id in ProjectID.
+			evidence = append(evidence, config.Evidence{
+				Ref:    fmt.Sprintf("summary:%d", s.ProjectID),
+				Kind:   config.EvidenceCommit,
+				Source: p.Name,
+				Text:   s.Msg,
+			})
+		}
+		if len(summaries) > 0 {
+			continue
+		}
+		for _, c := range p.Commits {
+			evidence = append(evidence, config.Evidence{
+				Ref:    fmt.Sprintf("commit:%d", c.ID),
+				Kind:   config.EvidenceCommit,
+				Source: p.Name,
+				Text:   c.Msg,
+			})
+		}
+	}
+
+	ids := []int64{resumeID}
+	if resumeID == 0 {
+		resumes, err := db.GetResumes()
+		if err != nil {
+			return nil, err
+		}
+		ids = ids[:0]
+		for _, r := range resumes {
+			ids = append(ids, r.ID)
+		}
+	}
+	for _, id := range ids {
+		r, err := db.GetResume(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range r.WorkExperiences {
+			for i, line := range util.HTMLListItems(w.Responsibilities) {
+				evidence = append(evidence, config.Evidence{
+					Ref:    fmt.Sprintf("work:%d:%d", w.ID, i),
+					Kind:   config.EvidenceResponsibility,
+					Source: w.Company,
+					Text:   line,
+				})
+			}
+		}
+		for _, p := range r.ProjectWorkedOn {
+			text := p.Title
+			if p.Description != "" {
+				text += ": " + p.Description
+			}
+			if p.Technologies != "" {
+				text += " (" + p.Technologies + ")"
+			}
+			evidence = append(evidence, config.Evidence{
+				Ref:    fmt.Sprintf("resume-project:%d", p.ID),
+				Kind:   config.EvidenceProject,
+				Source: p.Title,
+				Text:   text,
+			})
+		}
+	}
+
+	// The same text can show up in several resumes; keep the first.
+	seen := map[string]bool{}
+	unique := evidence[:0]
+	for _, e := range evidence {
+		if e.Text = strings.TrimSpace(e.Text); e.Text == "" || seen[e.Ref] {
+			continue
+		}
+		seen[e.Ref] = true
+		unique = append(unique, e)
+	}
+	return unique, nil
+}
+
+var numbered = regexp.MustCompile(`^\d+[.)]\s+`)
+
+// ExtractRequirements splits a job description into the lines and sentences
+// worth matching on, dropping headings and fragments under three words.
+func ExtractRequirements(jd string) []string {
+	var (
+		out  []string
+		seen = map[string]bool{}
+	)
+	for _, line := range strings.Split(jd, "\n") {
+		line = strings.TrimSpace(bulletMarker.ReplaceAllString(line, ""))
+		line = numbered.ReplaceAllString(line, "")
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		for _, s := range strings.SplitAfter(line, ". ") {
+			s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "."))
+			key := strings.ToLower(s)
+			if len(strings.Fields(s)) < 3 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, s)
+			if len(out) == maxRequirements {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// Index embeds new or edited evidence and drops vectors of evidence that no
+// longer exists, so searches only see the current history.
+func (m *Matcher) Index(ctx context.Context, evidence []config.Evidence) error {
+	stored, err := m.Store.GetEmbeddings(m.Model)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool, len(evidence))
+	for _, e := range evidence {
+		current[e.Ref] = true
+	}
+	hashes := make(map[string]string, len(stored))
+	var stale []string
+	for _, e := range stored {
+		hashes[e.Ref] = e.Hash
+		if !current[e.Ref] {
+			stale = append(stale, e.Ref)
+		}
+	}
+	if len(stale) > 0 {
+		if err := m.Store.DeleteEmbeddings(m.Model, stale); err != nil {
+			return err
+		}
+	}
+
+	var todo []config.Embedding
+	for _, e := range evidence {
+		h := textHash(e.Text)
+		if hashes[e.Ref] != h {
+			todo = append(todo, config.Embedding{Evidence: e, Model: m.Model, Hash: h})
+		}
+	}
+	for start := 0; start < len(todo); start += embedBatchSize {
+		batch := todo[start:min(start+embedBatchSize, len(todo))]
+		texts := make([]string, len(batch))
+		for i, e := range batch {
+			texts[i] = e.Text
+		}
+		vectors, err := m.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+		if err := m.Store.SaveEmbeddings(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchJob indexes the user's whole history, then matches the job
+// description against the evidence of one resume (all when resumeID is 0)
+// plus the git projects.
+func (m *Matcher) MatchJob(ctx context.Context, db EvidenceStore, jd string, resumeID int64, top int) (MatchReport, error) {
+	all, err := CollectEvidence(db, 0)
+	if err != nil {
+		return MatchReport{}, err
+	}
+	if err := m.Index(ctx, all); err != nil {
+		return MatchReport{}, err
+	}
+	within := all
+	if resumeID > 0 {
+		if within, err = CollectEvidence(db, resumeID); err != nil {
+			return MatchReport{}, err
+		}
+	}
+	return m.Match(ctx, jd, within, top)
+}
+
+// Match returns the top indexed evidence for the whole job description and
+// the best evidence for each requirement in it. Only evidence in within is
+// considered; call Index first.
+func (m *Matcher) Match(ctx context.Context, jd string, within []config.Evidence, top int) (MatchReport, error) {
+	report := MatchReport{Model: m.Model, Matches: []config.EmbeddingMatch{}, Missing: []string{}}
+	if strings.TrimSpace(jd) == "" {
+		return report, ErrEmptyJobDescription
+	}
+	threshold := m.Threshold
+	if threshold <= 0 {
+		threshold = DefaultMatchThreshold
+	}
+	refs := make(map[string]bool, len(within))
+	for _, e := range within {
+		refs[e.Ref] = true
+	}
+
+	reqs := ExtractRequirements(jd)
+	vectors, err := m.Embedder.Embed(ctx, append([]string{jd}, reqs...))
+	if err != nil {
+		return report, err
+	}
+
+	if report.Matches, err = m.search(vectors[0], refs, top); err != nil {
+		return report, err
+	}
+	for i, req := range reqs {
+		best, err := m.search(vectors[i+1], refs, 1)
+		if err != nil {
+			return report, err
+		}
+		rm := RequirementMatch{Requirement: req}
+		if len(best) > 0 {
+			rm.Best = &best[0]
+			rm.Covered = best[0].Score >= threshold
+		}
+		if !rm.Covered {
+			report.Missing = append(report.Missing, req)
+		}
+		report.Requirements = append(report.Requirements, rm)
+	}
+	return report, nil
+}
+
+func (m *Matcher) search(vector []float32, refs map[string]bool, limit int) ([]config.EmbeddingMatch, error) {
+	ranked, err := m.Store.SearchEmbeddings(m.Model, vector, 0)
+	if err != nil {
+		return nil, err
+	}
+	matches := []config.EmbeddingMatch{}
+	for _, r := range ranked {
+		if !refs[r.Ref] {
+			continue
+		}
+		matches = append(matches, r)
+		if limit > 0 && len(matches) == limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func textHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
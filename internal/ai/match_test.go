@@ -0,0 +1,243 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+// memEmbeddings is an in-memory EmbeddingStore.
+type memEmbeddings struct {
+	rows  map[string]config.Embedding
+	saved int
+}
+
+func (m *memEmbeddings) GetEmbeddings(model string) ([]config.Embedding, error) {
+	var out []config.Embedding
+	for _, e := range m.rows {
+		if e.Model == model {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *memEmbeddings) SaveEmbeddings(e []config.Embedding) error {
+	if m.rows == nil {
+		m.rows = map[string]config.Embedding{}
+	}
+	for _, x := range e {
+		m.rows[x.Ref] = x
+	}
+	m.saved += len(e)
+	return nil
+}
+
+func (m *memEmbeddings) DeleteEmbeddings(model string, refs []string) error {
+	for _, r := range refs {
+		delete(m.rows, r)
+	}
+	return nil
+}
+
+func (m *memEmbeddings) SearchEmbeddings(model string, vector []float32, limit int) ([]config.EmbeddingMatch, error) {
+	var out []config.EmbeddingMatch
+	for _, e := range m.rows {
+		out = append(out, config.EmbeddingMatch{Evidence: e.Evidence, Score: util.Cosine(vector, e.Vector)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// evidenceDB is an in-memory EvidenceStore.
+type evidenceDB struct {
+	projects  []git.Project
+	summaries map[int][]git.CustomUpdateCommit
+	resumes   map[int64]git.Resume
+}
+
+func (d *evidenceDB) GetAllProject(limit, offset int) ([]git.Project, error) { return d.projects, nil }
+func (d *evidenceDB) GetAllCommitSummary(id int) ([]git.CustomUpdateCommit, error) {
+	return d.summaries[id], nil
+}
+func (d *evidenceDB) GetResume(id int64) (git.Resume, error) { return d.resumes[id], nil }
+func (d *evidenceDB) GetResumes() ([]git.Resume, error) {
+	var out []git.Resume
+	for _, r := range d.resumes {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func sampleEvidenceDB() *evidenceDB {
+	return &evidenceDB{
+		projects: []git.Project{
+			{ID: 1, Name: "gitresume", Technologies: `{"stack":{"Go":10}}`, Commits: []git.GitCommit{{ID: 7, Msg: "raw commit"}}},
+			{ID: 2, Name: "shop", Commits: []git.GitCommit{{ID: 9, Msg: "Add Stripe checkout"}}},
+		},
+		summaries: map[int][]git.CustomUpdateCommit{
+			1: {{ProjectID: 3, GitCommit: git.GitCommit{Msg: "Tuned PostgreSQL queries for the commit list"}}},
+		},
+		resumes: map[int64]git.Resume{
+			1: {ID: 1, WorkExperiences: []git.WorkExperience{{ID: 4, Company: "Acme",
+				Responsibilities: "<ul><li>Led Kubernetes migration of billing services</li><li>Mentored engineers</li></ul>"}}},
+			2: {ID: 2, ProjectWorkedOn: []git.ProjectWorkedOn{{ID: 5, Title: "Blog", Description: "Static site", Technologies: "Hugo"}}},
+		},
+	}
+}
+
+func TestCollectEvidence(t *testing.T) {
+	got, err := CollectEvidence(sampleEvidenceDB(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, e := range got {
+		refs = append(refs, e.Ref)
+	}
+	want := []string{"project:1", "summary:3", "commit:9", "work:4:0", "work:4:1", "resume-project:5"}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("refs = %v, want %v", refs, want)
+	}
+
+	got, _ = CollectEvidence(sampleEvidenceDB(), 2)
+	if last := got[len(got)-1]; last.Text != "Blog: Static site (Hugo)" || last.Kind != config.EvidenceProject {
+		t.Errorf("unexpected resume project evidence %+v", last)
+	}
+}
+
+func TestExtractRequirements(t *testing.T) {
+	jd := `Senior Backend Engineer
+
+Requirements:
+- 5+ years building services in Go.
+- Experience with PostgreSQL. Comfortable with Kubernetes in production.
+1. Experience with PostgreSQL
+Nice`
+	got := ExtractRequirements(jd)
+	want := []string{
+		"Senior Backend Engineer",
+		"5+ years building services in Go",
+		"Experience with PostgreSQL",
+		"Comfortable with Kubernetes in production",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractRequirements = %q, want %q", got, want)
+	}
+}
+
+func TestMatcher_MatchJob(t *testing.T) {
+	store := &memEmbeddings{}
+	m := &Matcher{Embedder: NewFake(ModelConfig{}), Model: "fake-embed", Store: store, Threshold: 0.3}
+	db := sampleEvidenceDB()
+	jd := "Tuned PostgreSQL queries at scale.\nLed a Kubernetes migration before.\nFluent in Haskell and OCaml compilers."
+
+	report, err := m.MatchJob(context.Background(), db, jd, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Matches) != 3 {
+		t.Fatalf("expected top 3 matches, got %d", len(report.Matches))
+	}
+	if len(report.Requirements) != 3 {
+		t.Fatalf("expected 3 requirements, got %+v", report.Requirements)
+	}
+	if r := report.Requirements[0]; !r.Covered || r.Best.Ref != "summary:3" {
+		t.Errorf("PostgreSQL requirement should be backed by the summary, got %+v", r)
+	}
+	if r := report.Requirements[1]; !r.Covered || r.Best.Ref != "work:4:0" {
+		t.Errorf("Kubernetes requirement should be backed by the responsibility, got %+v", r)
+	}
+	if !reflect.DeepEqual(report.Missing, []string{"Fluent in Haskell and OCaml compilers"}) {
+		t.Errorf("missing = %q", report.Missing)
+	}
+
+	// A second run embeds nothing new; scoping to resume 2 drops resume 1.
+	saved := store.saved
+	report, err = m.MatchJob(context.Background(), db, jd, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.saved != saved {
+		t.Errorf("unchanged evidence was embedded again")
+	}
+	for _, mt := range report.Matches {
+		if mt.Kind == config.EvidenceResponsibility {
+			t.Errorf("resume 1 evidence leaked into a resume 2 match: %+v", mt)
+		}
+	}
+
+	// Deleted evidence is pruned from the store.
+	db.resumes[1] = git.Resume{ID: 1}
+	if _, err := m.MatchJob(context.Background(), db, jd, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.rows["work:4:0"]; ok {
+		t.Error("stale evidence was not pruned")
+	}
+
+	if _, err := m.Match(context.Background(), " ", nil, 0); err != ErrEmptyJobDescription {
+		t.Errorf("expected ErrEmptyJobDescription, got %v", err)
+	}
+}
+
+func TestEmbed_Providers(t *testing.T) {
+	rt := &capture{reply: `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`}
+	o := NewOpenAI(ModelConfig{Model: "text-embedding-3-small", APIKey: "k", HTTPClient: &http.Client{Transport: rt}})
+	v, err := o.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, [][]float32{{1, 0}, {0, 1}}) {
+		t.Errorf("openai vectors should follow the index, got %v", v)
+	}
+
+	rt = &capture{reply: `{"embeddings":[{"values":[0.5,0.5]}]}`}
+	g := NewGemini(ModelConfig{Model: "text-embedding-004", APIKey: "k", HTTPClient: &http.Client{Transport: rt}})
+	if v, err = g.Embed(context.Background(), []string{"a"}); err != nil || len(v) != 1 {
+		t.Fatalf("gemini embed = %v, %v", v, err)
+	}
+	req := rt.body["requests"].([]any)[0].(map[string]any)
+	if req["model"] != "models/text-embedding-004" {
+		t.Errorf("unexpected gemini request %v", req)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"embedding":[0.1,0.2,0.3]}`))
+	}))
+	defer server.Close()
+	aiHost = server.URL
+	l := NewLlama(ModelConfig{Model: "nomic-embed-text"})
+	if v, err = l.Embed(context.Background(), []string{"a", "b"}); err != nil || len(v) != 2 || len(v[0]) != 3 {
+		t.Errorf("ollama embed = %v, %v", v, err)
+	}
+
+	if _, err := NewEmbedder(ModelConfig{Type: HuggingFace}); err == nil {
+		t.Error("huggingface should not support embeddings")
+	}
+}
+
+func TestEmbedderFor(t *testing.T) {
+	cfg := config.AppConfig{AiOptions: []config.AiOptions{{Name: "llama", Model: "llama3.2", IsDefault: true}}}
+	if _, model, err := EmbedderFor(cfg, ModelConfig{}); err != nil || model != "nomic-embed-text" {
+		t.Errorf("default provider should use its embedding model, got %q, %v", model, err)
+	}
+	cfg.AiRoutes = []config.AiRoute{{Task: config.EmbedPrompt, Provider: "llama", Model: "mxbai-embed-large"}}
+	if _, model, _ := EmbedderFor(cfg, ModelConfig{}); model != "mxbai-embed-large" {
+		t.Errorf("routed model should win, got %q", model)
+	}
+}
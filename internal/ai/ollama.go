@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+var (
+	ErrOllamaUnavailable = errors.New("ollama is not running")
+	ErrModelNotPulled    = errors.New("model is not pulled")
+)
+
+type OllamaModel struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// PullProgress is one line of the /api/pull stream.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Percent returns the download progress of the current layer, or -1 when
+// the step has no size.
+func (p PullProgress) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	return int(p.Completed * 100 / p.Total)
+}
+
+// OllamaClient manages the models of the local Ollama server.
+type OllamaClient struct {
+	Host   string
+	client *http.Client
+}
+
+func NewOllamaClient() *OllamaClient {
+	client := NewHTTPClient()
+	// pulls stream for minutes; callers bound them with the context instead
+	client.Timeout = 0
+	return &OllamaClient{Host: aiHost, client: client}
+}
+
+// ListModels returns the locally pulled models from /api/tags.
+func (o *OllamaClient) ListModels(ctx context.Context) ([]OllamaModel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.Host+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, ollamaUnavailable(o.Host, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, ollamaError(res.StatusCode, body, "")
+	}
+
+	var tags struct {
+		Models []OllamaModel `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama models: %w", err)
+	}
+	return tags.Models, nil
+}
+
+// HasModel reports whether name is pulled. A name without a tag matches
+// the ":latest" tag, as in the ollama CLI.
+func (o *OllamaClient) HasModel(ctx context.Context, name string) (bool, error) {
+	models, err := o.ListModels(ctx)
+	if err != nil {
+		return false, err
+	}
+	return ModelPulled(models, name), nil
+}
+
+// ConfiguredOllamaModel is the llama model set in config.yaml, or AIModel.
+func ConfiguredOllamaModel(cfg config.AppConfig) string {
+	if o, err := cfg.Providers([]string{string(Llama)}); err == nil && o[0].Model != "" {
+		return o[0].Model
+	}
+	return AIModel
+}
+
+// ModelPulled reports whether name is among models; an untagged name means
+// its :latest tag.
+func ModelPulled(models []OllamaModel, name string) bool {
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	for _, m := range models {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PullModel downloads a model, calling progress for every status update.
+func (o *OllamaClient) PullModel(ctx context.Context, name string, progress func(PullProgress)) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("model name is required")
+	}
+	msg, _ := json.Marshal(map[string]any{"model": name, "stream": true})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.Host+"/api/pull", bytes.NewBuffer(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return ollamaUnavailable(o.Host, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return ollamaError(res.StatusCode, body, name)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var p PullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		if p.Error != "" {
+			return ollamaError(http.StatusOK, scanner.Bytes(), name)
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return scanner.Err()
+}
+
+// ollamaError turns an Ollama error body into an error that tells the user
+// what to do next.
+func ollamaError(status int, body []byte, model string) error {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	msg := apiErr.Error
+	if msg == "" {
+		msg = strings.TrimSpace(string(body))
+	}
+	lower := strings.ToLower(msg)
+
+	switch {
+	case strings.Contains(lower, "not found") && (status == http.StatusNotFound || strings.Contains(lower, "model")):
+		if model == "" {
+			return fmt.Errorf("%w: %s, run 'gitresume ai pull <model>'", ErrModelNotPulled, msg)
+		}
+		return fmt.Errorf("%w: %s, run 'gitresume ai pull %s'", ErrModelNotPulled, model, model)
+	case strings.Contains(lower, "file does not exist") || strings.Contains(lower, "pull model manifest"):
+		return fmt.Errorf("ollama could not find model %q in the registry, check the name at https://ollama.com/library", model)
+	case strings.Contains(lower, "requires more system memory"):
+		return fmt.Errorf("ollama: %s, pick a smaller model with 'gitresume ai models'", msg)
+	case msg == "":
+		return fmt.Errorf("ollama returned status %d", status)
+	}
+	return fmt.Errorf("ollama error: %s", msg)
+}
+
+func ollamaUnavailable(host string, err error) error {
+	var netErr interface{ Timeout() bool }
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return err
+	}
+	return fmt.Errorf("%w at %s, start it with 'ollama serve': %v", ErrOllamaUnavailable, host, err)
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaClient_ListAndHasModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest","size":2019393189},{"name":"qwen2.5:7b","size":4683087332}]}`))
+	}))
+	defer srv.Close()
+
+	o := NewOllamaClient()
+	o.Host = srv.URL
+	models, err := o.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].Size != 2019393189 {
+		t.Errorf("unexpected models: %+v", models)
+	}
+	for name, want := range map[string]bool{"llama3.2": true, "qwen2.5:7b": true, "qwen2.5": false, "mistral": false} {
+		if got := ModelPulled(models, name); got != want {
+			t.Errorf("ModelPulled(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestOllamaClient_PullModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join([]string{
+			`{"status":"pulling manifest"}`,
+			`{"status":"pulling dde5aa3fc5ff","digest":"sha256:dde5","total":200,"completed":50}`,
+			`{"status":"pulling dde5aa3fc5ff","digest":"sha256:dde5","total":200,"completed":200}`,
+			`{"status":"success"}`,
+		}, "\n")))
+	}))
+	defer srv.Close()
+
+	o := NewOllamaClient()
+	o.Host = srv.URL
+	var seen []int
+	err := o.PullModel(context.Background(), "llama3.2", func(p PullProgress) {
+		seen = append(seen, p.Percent())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{-1, 25, 100, -1}; len(seen) != len(want) || seen[1] != 25 || seen[2] != 100 {
+		t.Errorf("expected progress %v, got %v", want, seen)
+	}
+}
+
+func TestOllamaClient_PullUnknownModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"error":"pull model manifest: file does not exist"}`))
+	}))
+	defer srv.Close()
+
+	o := NewOllamaClient()
+	o.Host = srv.URL
+	err := o.PullModel(context.Background(), "nope", nil)
+	if err == nil || !strings.Contains(err.Error(), "ollama.com/library") {
+		t.Errorf("expected a registry hint, got %v", err)
+	}
+}
+
+func TestChat_ModelNotPulled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"llama3.2\" not found, try pulling it first"}`))
+	}))
+	defer srv.Close()
+	aiHost = srv.URL
+
+	_, err := NewLlama(ModelConfig{Model: "llama3.2"}).Chat(context.Background(), testPrompt)
+	if !errors.Is(err, ErrModelNotPulled) || !strings.Contains(err.Error(), "gitresume ai pull llama3.2") {
+		t.Errorf("expected an actionable pull error, got %v", err)
+	}
+}
+
+func TestChat_OllamaNotRunning(t *testing.T) {
+	aiHost = "http://127.0.0.1:0"
+	_, err := NewLlama(ModelConfig{Model: "llama3.2"}).Chat(context.Background(), testPrompt)
+	if !errors.Is(err, ErrOllamaUnavailable) {
+		t.Errorf("expected ErrOllamaUnavailable, got %v", err)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/iamhabbeboy/gitresume/config"
 	// "github.com/openai/openai-go/option"
@@ -18,6 +18,10 @@ type OpenAIConfig struct {
 	APIKey string
 	Model  string              `json:"model"`
 	Prompt config.CustomPrompt `json:"prompt"`
+	Schema map[string]any      `json:"schema"`
+	opts   GenOptions
+	client *http.Client
+	usage  TokenUsage
 }
 
 type openAIResponse struct {
@@ -32,6 +36,11 @@ type openAIResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
 }
 
 func NewOpenAI(cfg ModelConfig) *OpenAIConfig {
@@ -42,11 +51,23 @@ func NewOpenAI(cfg ModelConfig) *OpenAIConfig {
 			MaxTokens:   cfg.MaxToken,
 			Temperature: cfg.Temperature,
 		},
+		Schema: cfg.ResponseSchema,
+		opts:   cfg.GenOptions,
+		client: httpClient(cfg),
 	}
 }
 
+// Generate runs a single-shot completion. OpenAI has no separate completion
+// API for chat models, so the message is sent as one user turn.
 func (o *OpenAIConfig) Generate(ctx context.Context, message string) (string, error) {
-	return "", nil
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("no prompt supplied")
+	}
+	result, err := o.complete(ctx, o.request(o.opts.generatePrompts(message)))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
 }
 
 func (o *OpenAIConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
@@ -54,16 +75,47 @@ func (o *OpenAIConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]str
 		return nil, errors.New("no prompt supplied")
 	}
 
-	if o.APIKey == "" {
-		return nil, errors.New("openAI Api Key is missing")
+	data := o.request(o.opts.withSystem(prompts))
+	if o.Schema != nil {
+		data.ResponseFormat = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchema{
+				Name:   "response",
+				Strict: true,
+				Schema: o.Schema,
+			},
+		}
+	}
+
+	result, err := o.complete(ctx, data)
+	if err != nil {
+		return nil, err
 	}
 
-	data := ChatRequest{
+	var responses []string
+	for _, choice := range result.Choices {
+		responses = append(responses, choice.Message.Content)
+	}
+
+	return responses, nil
+}
+
+func (o *OpenAIConfig) request(prompts []config.Prompt) ChatRequest {
+	return ChatRequest{
 		Stream:      false,
 		Messages:    prompts,
 		Model:       o.Model,
 		MaxTokens:   o.Prompt.MaxTokens,
 		Temperature: o.Prompt.Temperature,
+		Stop:        o.opts.Stop,
+		Seed:        o.opts.Seed,
+	}
+}
+
+func (o *OpenAIConfig) complete(ctx context.Context, data ChatRequest) (openAIResponse, error) {
+	var result openAIResponse
+	if o.APIKey == "" {
+		return result, errors.New("openAI Api Key is missing")
 	}
 
 	msg, _ := json.Marshal(data)
@@ -72,22 +124,19 @@ func (o *OpenAIConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]str
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host, bytes.NewBuffer(msg))
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+o.APIKey)
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	resp, err := client.Do(req)
+	resp, err := o.client.Do(req)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
 	// 🟡 Handle non-200 responses first
@@ -102,24 +151,27 @@ func (o *OpenAIConfig) Chat(ctx context.Context, prompts []config.Prompt) ([]str
 		}
 		_ = json.Unmarshal(body, &apiErr)
 		if apiErr.Error.Message != "" {
-			return nil, fmt.Errorf("openai error: %s (type: %s, code: %s)", apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code)
+			return result, fmt.Errorf("openai error: %s (type: %s, code: %s)", apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code)
 		}
-		return nil, fmt.Errorf("openai returned status %d: %s", resp.StatusCode, string(body))
+		return result, fmt.Errorf("openai returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var result openAIResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode openai response: %w", err)
+		return result, fmt.Errorf("failed to decode openai response: %w", err)
 	}
 
-	if len(result.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openAI")
+	o.usage = TokenUsage{
+		InputTokens:  result.Usage.PromptTokens,
+		OutputTokens: result.Usage.CompletionTokens,
 	}
 
-	var responses []string
-	for _, choice := range result.Choices {
-		responses = append(responses, choice.Message.Content)
+	if len(result.Choices) == 0 {
+		return result, fmt.Errorf("no response from openAI")
 	}
+	return result, nil
+}
 
-	return responses, nil
+// LastUsage implements UsageReporter.
+func (o *OpenAIConfig) LastUsage() TokenUsage {
+	return o.usage
 }
@@ -0,0 +1,17 @@
+package ai
+
+import (
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// PromptDataFor fills the template variables that can be derived from a
+// project and resume. Job-specific fields are left for the caller.
+func PromptDataFor(p git.Project, r git.Resume) config.PromptData {
+	return config.PromptData{
+		Commits:     CommitLines(p.Commits),
+		ProjectName: p.Name,
+		TechStack:   git.ParseTechStack(p.Technologies).Names(),
+		ResumeTitle: r.Title,
+	}
+}
@@ -0,0 +1,199 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type RecordMode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails on a miss.
+	ModeReplay RecordMode = iota
+	// ModeRecord forwards requests to the network and appends them to the
+	// cassette.
+	ModeRecord
+)
+
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// secretParams are stripped from recorded URLs so cassettes can be committed.
+var secretParams = []string{"key", "api_key"}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body"`
+}
+
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records provider traffic to a JSON
+// cassette and replays it, so provider clients can be tested without
+// network access. Requests are matched on method, redacted URL and body.
+type Recorder struct {
+	Path      string
+	Mode      RecordMode
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+func NewRecorder(path string, mode RecordMode) (*Recorder, error) {
+	r := &Recorder{
+		Path:      path,
+		Mode:      mode,
+		Transport: http.DefaultTransport,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) || mode == ModeReplay {
+			return nil, err
+		}
+		return r, nil
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	recReq := RecordedRequest{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Body:   string(body),
+	}
+
+	if r.Mode == ModeReplay {
+		return r.replay(req, recReq)
+	}
+
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recReq,
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: map[string]string{"Content-Type": resp.Header.Get("Content-Type")},
+			Body:    string(respBody),
+		},
+	})
+	r.used = append(r.used, true)
+	// a response that did not make it into the cassette would be missing
+	// on replay, so recording fails outright
+	if err := r.save(); err != nil {
+		return nil, fmt.Errorf("failed to save cassette %s: %w", r.Path, err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recReq RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != recReq.Method || in.Request.URL != recReq.URL {
+			continue
+		}
+		if !sameBody(in.Request.Body, recReq.Body) {
+			continue
+		}
+		r.used[i] = true
+
+		header := http.Header{}
+		for k, v := range in.Response.Headers {
+			header.Set(k, v)
+		}
+		return &http.Response{
+			StatusCode: in.Response.Status,
+			Status:     fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			Header:     header,
+			Body:       io.NopCloser(bytes.NewBufferString(in.Response.Body)),
+			Request:    req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recReq.Method, recReq.URL)
+}
+
+func (r *Recorder) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, data, 0644)
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	for _, p := range secretParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// sameBody compares JSON bodies structurally so key order does not matter.
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var ja, jb any
+	if json.Unmarshal([]byte(a), &ja) != nil || json.Unmarshal([]byte(b), &jb) != nil {
+		return false
+	}
+	ca, _ := json.Marshal(ja)
+	cb, _ := json.Marshal(jb)
+	return bytes.Equal(ca, cb)
+}
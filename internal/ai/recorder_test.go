@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+var cassettePrompt = []config.Prompt{
+	{Role: "system", Content: "You are a professional resume writer."},
+	{Role: "user", Content: "Transform these commit messages into resume bullet points: [a1b2c3d] feat(api): Add new endpoint to get commit logs"},
+}
+
+func replayModel(t *testing.T, cassette string, typ ModelType, model string) AiModel {
+	t.Helper()
+	rec, err := NewRecorder("testdata/cassettes/"+cassette, ModeReplay)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+	return mustModel(t, ModelConfig{
+		Type:           typ,
+		Model:          model,
+		APIKey:         "test-key",
+		Temperature:    0.5,
+		MaxToken:       400,
+		ResponseSchema: BulletSchema,
+		HTTPClient:     &http.Client{Transport: rec},
+	})
+}
+
+func TestRecorder_ReplayProviders(t *testing.T) {
+	aiHost = "http://localhost:11434"
+
+	tests := []struct {
+		cassette string
+		typ      ModelType
+		model    string
+	}{
+		{"ollama_chat.json", Llama, "llama3.2"},
+		{"openai_chat.json", OpenAI, "gpt-5-mini"},
+		{"gemini_chat.json", Gemini, "gemini-2.5-flash"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			m := replayModel(t, tt.cassette, tt.typ, tt.model)
+			bullets, err := GenerateBullets(context.Background(), m, cassettePrompt)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(bullets) != 1 || bullets[0].SourceCommits[0] != "a1b2c3d" {
+				t.Errorf("unexpected bullets: %+v", bullets)
+			}
+		})
+	}
+}
+
+func TestRecorder_Miss(t *testing.T) {
+	m := replayModel(t, "openai_chat.json", OpenAI, "gpt-4o")
+	_, err := m.Chat(context.Background(), cassettePrompt)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction, got %v", err)
+	}
+}
+
+func TestRecorder_SaveFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(filepath.Join(dir, "cassette.json"), ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cassette's directory cannot be created under a regular file
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rec.Path = filepath.Join(dir, "file", "cassette.json")
+	resp, err := (&http.Client{Transport: rec}).Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected an error when the cassette cannot be saved")
+	}
+	if resp != nil {
+		t.Errorf("expected no response with the error, got %+v", resp)
+	}
+}
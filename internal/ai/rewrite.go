@@ -0,0 +1,39 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RewriteSystem is the system prompt for free-form rewrites of resume fields.
+const RewriteSystem = "You are an expert resume editor. Rewrite the text you are given following the instruction. Keep the facts, do not invent numbers, and reply with the rewritten text only, without quotes, headings or explanations."
+
+const defaultRewriteInstruction = "Make it clearer and more impactful while keeping it concise."
+
+// Rewrite asks the model for a single-shot rewrite of one resume field, e.g.
+// a summary or a work experience description. The model should be created
+// with RewriteSystem as its system prompt.
+func Rewrite(ctx context.Context, model AiModel, field, text, instruction string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("nothing to rewrite")
+	}
+	if strings.TrimSpace(instruction) == "" {
+		instruction = defaultRewriteInstruction
+	}
+	if field == "" {
+		field = "text"
+	}
+
+	msg := fmt.Sprintf("Resume field: %s\nInstruction: %s\n\nText:\n%s", field, instruction, text)
+	out, err := model.Generate(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	out = strings.Trim(strings.TrimSpace(out), `"`)
+	if out == "" {
+		return "", errors.New("the model returned an empty rewrite")
+	}
+	return out, nil
+}
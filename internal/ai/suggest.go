@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// Suggest turns bullets generated by model into pending suggestions for
+// review, each carrying the provenance in base (project, task, prompt
+// version) and the commits, provider and model it came from.
+func Suggest(model AiModel, bullets []Bullet, base config.Suggestion) []config.Suggestion {
+	provider, name := ServedBy(model)
+	out := make([]config.Suggestion, 0, len(bullets))
+	for _, b := range bullets {
+		s := base
+		s.Provider = provider
+		s.Model = name
+		s.Text = b.Text
+		s.SourceCommits = b.SourceCommits
+		s.Confidence = b.Confidence
+		s.State = config.SuggestionPending
+		out = append(out, s)
+	}
+	return out
+}
+
+// PromptVersionStore lists saved prompt versions; database.IDatabase
+// satisfies it.
+type PromptVersionStore interface {
+	GetPromptVersions(title config.PromptType) ([]config.PromptVersion, error)
+}
+
+// ActivePromptVersion is the version number of the prompt currently in use
+// for task, or 0 when it has never been saved.
+func ActivePromptVersion(store PromptVersionStore, task config.PromptType) int {
+	versions, err := store.GetPromptVersions(task)
+	if err != nil {
+		return 0
+	}
+	for _, v := range versions {
+		if v.Active {
+			return v.Version
+		}
+	}
+	return 0
+}
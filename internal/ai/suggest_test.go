@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+type memVersions []config.PromptVersion
+
+func (m memVersions) GetPromptVersions(title config.PromptType) ([]config.PromptVersion, error) {
+	return m, nil
+}
+
+func TestSuggest(t *testing.T) {
+	meter := NewMeter(config.AppConfig{}, &memUsage{})
+	chain := &ChainModel{Models: []NamedModel{
+		{Name: "llama", AiModel: failingModel{errors.New("connection refused")}},
+		{Name: "fake", AiModel: &MeteredModel{AiModel: NewFake(ModelConfig{ResponseSchema: BulletSchema}), Provider: Fake, Model: "fake-1", Meter: meter}},
+	}}
+
+	prompts := []config.Prompt{{Role: string(User), Content: "[a1b2c3d] Add checkout\n[e4f5a6b] Fix totals"}}
+	bullets, err := GenerateBullets(context.Background(), chain, prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Suggest(chain, bullets, config.Suggestion{ProjectID: 4, Task: config.ProjectPrompt, PromptVersion: 3})
+	if len(got) != 2 {
+		t.Fatalf("expected a suggestion per bullet, got %+v", got)
+	}
+	s := got[0]
+	if s.State != config.SuggestionPending || s.ProjectID != 4 || s.PromptVersion != 3 || s.Provider != "fake" || s.Model != "fake-1" {
+		t.Errorf("provenance was not recorded: %+v", s)
+	}
+	if s.Text != "Add checkout" || !reflect.DeepEqual(s.SourceCommits, []string{"a1b2c3d"}) {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+}
+
+func TestActivePromptVersion(t *testing.T) {
+	store := memVersions{{Version: 1}, {Version: 2, Active: true}, {Version: 3}}
+	if v := ActivePromptVersion(store, config.ProjectPrompt); v != 2 {
+		t.Errorf("expected version 2, got %d", v)
+	}
+	if v := ActivePromptVersion(memVersions{}, config.ProjectPrompt); v != 0 {
+		t.Errorf("expected 0 without versions, got %d", v)
+	}
+}
@@ -0,0 +1,205 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+// TailorSystem is the system prompt for tailoring a resume to a job.
+const TailorSystem = "You are an expert resume editor. Tailor the resume you are given to the job description. " +
+	"Put the most relevant bullets first and rewrite them to use the job's language where the facts allow it. " +
+	"Never invent experience, employers, numbers or skills that are not in the resume. Reply with JSON only."
+
+var ErrInvalidTailoring = errors.New("model returned no usable tailoring")
+
+// TailorProposal is the model's answer. Experiences and projects refer to
+// the ids in the resume it was given.
+type TailorProposal struct {
+	Summary     string               `json:"summary"`
+	Skills      []string             `json:"skills"`
+	Experiences []TailoredExperience `json:"experiences"`
+	Projects    []int64              `json:"projects"`
+}
+
+type TailoredExperience struct {
+	ID      int64    `json:"id"`
+	Bullets []string `json:"bullets"`
+}
+
+// TailorSchema is the JSON schema of a TailorProposal.
+var TailorSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"summary": map[string]any{"type": "string"},
+		"skills":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"experiences": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":      map[string]any{"type": "integer"},
+					"bullets": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+				"required":             []string{"id", "bullets"},
+				"additionalProperties": false,
+			},
+		},
+		"projects": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+	},
+	"required":             []string{"summary", "skills", "experiences", "projects"},
+	"additionalProperties": false,
+}
+
+// tailorInput is the part of the resume the model sees.
+type tailorInput struct {
+	Summary     string                `json:"summary"`
+	Skills      []string              `json:"skills"`
+	Experiences []tailorExperience    `json:"experiences"`
+	Projects    []git.ProjectWorkedOn `json:"projects"`
+}
+
+type tailorExperience struct {
+	ID      int64    `json:"id"`
+	Company string   `json:"company"`
+	Role    string   `json:"role"`
+	Bullets []string `json:"bullets"`
+}
+
+// Tailor proposes a version of r tailored to job. Nothing is saved: the
+// result is an unsaved copy linked to r and job, to be reviewed with
+// git.DiffResumes first. The model should be created with TailorSchema and
+// TailorSystem.
+func Tailor(ctx context.Context, model AiModel, r git.Resume, job git.Job) (git.Resume, error) {
+	if strings.TrimSpace(job.Description) == "" {
+		return git.Resume{}, ErrEmptyJobDescription
+	}
+
+	in := tailorInput{Summary: r.Profile.ProfessionalSummary, Skills: r.Skills, Projects: r.ProjectWorkedOn}
+	for _, w := range r.WorkExperiences {
+		in.Experiences = append(in.Experiences, tailorExperience{w.ID, w.Company, w.Role, util.HTMLListItems(w.Responsibilities)})
+	}
+	resume, _ := json.Marshal(in)
+	msgs := []config.Prompt{{Role: string(User), Content: fmt.Sprintf(
+		"Job description:\n%s\n\nResume (JSON):\n%s\n\n"+
+			"Reply with a JSON object: summary, skills reordered by relevance, experiences with their id and "+
+			"reordered, rewritten bullets, and the ids of the relevant projects in order.", job.Description, resume)}}
+
+	var perr error
+	for attempt := 0; attempt <= maxBulletRepairs; attempt++ {
+		resp, err := model.Chat(ctx, msgs)
+		if err != nil {
+			return git.Resume{}, err
+		}
+		raw := strings.Join(resp, "\n")
+		var p TailorProposal
+		if p, perr = ParseTailoring(raw); perr == nil {
+			out := ApplyTailoring(r, p)
+			out.ID = 0
+			out.ParentID = r.ID
+			out.JobID = job.ID
+			out.Version = r.Version + 1
+			out.Title = tailoredTitle(r.Title, job)
+			return out, nil
+		}
+		msgs = append(msgs,
+			config.Prompt{Role: string(Assistant), Content: raw},
+			config.Prompt{Role: string(User), Content: fmt.Sprintf(
+				"Your previous response was not valid (%v). Reply again with only the JSON object.", perr)},
+		)
+	}
+	return git.Resume{}, fmt.Errorf("%w: %v", ErrInvalidTailoring, perr)
+}
+
+// ParseTailoring decodes a TailorProposal, tolerating Markdown code fences.
+func ParseTailoring(raw string) (TailorProposal, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var p TailorProposal
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if p.Summary == "" && len(p.Skills) == 0 && len(p.Experiences) == 0 && len(p.Projects) == 0 {
+		return p, errors.New("the proposal is empty")
+	}
+	return p, nil
+}
+
+// ApplyTailoring returns a copy of r with the proposal applied. Only what is
+// already in r is kept: skills and projects are reordered or dropped but
+// never added, and unknown ids are ignored.
+func ApplyTailoring(r git.Resume, p TailorProposal) git.Resume {
+	out := r
+	if s := strings.TrimSpace(p.Summary); s != "" {
+		out.Summary = s
+		out.Profile.ProfessionalSummary = s
+	}
+
+	if len(p.Skills) > 0 {
+		skills := make([]string, 0, len(r.Skills))
+		for _, want := range p.Skills {
+			i := slices.IndexFunc(r.Skills, func(s string) bool { return strings.EqualFold(s, want) })
+			if i >= 0 && !slices.Contains(skills, r.Skills[i]) {
+				skills = append(skills, r.Skills[i])
+			}
+		}
+		// skills the model left out keep their place at the end
+		for _, s := range r.Skills {
+			if !slices.Contains(skills, s) {
+				skills = append(skills, s)
+			}
+		}
+		out.Skills = skills
+	}
+
+	out.WorkExperiences = slices.Clone(r.WorkExperiences)
+	for _, e := range p.Experiences {
+		i := slices.IndexFunc(out.WorkExperiences, func(w git.WorkExperience) bool { return w.ID == e.ID })
+		var bullets []string
+		for _, b := range e.Bullets {
+			if b = strings.TrimSpace(bulletMarker.ReplaceAllString(b, "")); b != "" {
+				bullets = append(bullets, b)
+			}
+		}
+		if i >= 0 && len(bullets) > 0 {
+			out.WorkExperiences[i].Responsibilities = util.HTMLList(bullets)
+		}
+	}
+
+	var projects []git.ProjectWorkedOn
+	for _, id := range p.Projects {
+		i := slices.IndexFunc(r.ProjectWorkedOn, func(pr git.ProjectWorkedOn) bool { return pr.ID == id })
+		if i >= 0 && !slices.ContainsFunc(projects, func(pr git.ProjectWorkedOn) bool { return pr.ID == id }) {
+			projects = append(projects, r.ProjectWorkedOn[i])
+		}
+	}
+	if len(projects) > 0 {
+		out.ProjectWorkedOn = projects
+	} else {
+		out.ProjectWorkedOn = slices.Clone(r.ProjectWorkedOn)
+	}
+	return out
+}
+
+func tailoredTitle(title string, job git.Job) string {
+	target := job.Title
+	if job.Company != "" {
+		target = strings.TrimSpace(target + " at " + job.Company)
+	}
+	if target == "" {
+		return title + " (tailored)"
+	}
+	return fmt.Sprintf("%s (%s)", title, target)
+}
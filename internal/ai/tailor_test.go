@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+func sampleResume() git.Resume {
+	return git.Resume{
+		ID:      3,
+		Title:   "Backend",
+		Version: 2,
+		Skills:  []string{"Go", "React", "PostgreSQL"},
+		Profile: git.Profile{ProfessionalSummary: "Engineer."},
+		WorkExperiences: []git.WorkExperience{
+			{ID: 4, Company: "Acme", Role: "Engineer", Responsibilities: "<ul><li>Built the UI</li><li>Tuned queries</li></ul>"},
+		},
+		ProjectWorkedOn: []git.ProjectWorkedOn{{ID: 5, Title: "Blog"}, {ID: 6, Title: "Queue"}},
+	}
+}
+
+func TestApplyTailoring(t *testing.T) {
+	r := sampleResume()
+	got := ApplyTailoring(r, TailorProposal{
+		Summary:     "Backend engineer focused on databases.",
+		Skills:      []string{"postgresql", "Rust", "Go"},
+		Experiences: []TailoredExperience{{ID: 4, Bullets: []string{"- Tuned PostgreSQL queries", "Built the UI"}}, {ID: 99, Bullets: []string{"x"}}},
+		Projects:    []int64{6, 42, 6},
+	})
+
+	if !reflect.DeepEqual(got.Skills, []string{"PostgreSQL", "Go", "React"}) {
+		t.Errorf("skills should only be reordered, got %q", got.Skills)
+	}
+	if b := util.HTMLListItems(got.WorkExperiences[0].Responsibilities); !reflect.DeepEqual(b, []string{"Tuned PostgreSQL queries", "Built the UI"}) {
+		t.Errorf("unexpected bullets %q", b)
+	}
+	if len(got.ProjectWorkedOn) != 1 || got.ProjectWorkedOn[0].ID != 6 {
+		t.Errorf("expected only project 6, got %+v", got.ProjectWorkedOn)
+	}
+	if got.Summary != "Backend engineer focused on databases." {
+		t.Errorf("summary was not overridden: %q", got.Summary)
+	}
+	if r.WorkExperiences[0].Responsibilities != sampleResume().WorkExperiences[0].Responsibilities {
+		t.Error("the original resume was modified")
+	}
+
+	// unknown project ids keep the original projects
+	if got = ApplyTailoring(r, TailorProposal{Projects: []int64{42}}); len(got.ProjectWorkedOn) != 2 {
+		t.Errorf("expected the original projects, got %+v", got.ProjectWorkedOn)
+	}
+}
+
+func TestTailor(t *testing.T) {
+	model := NewFake(ModelConfig{Script: []string{
+		"not json",
+		"```json\n" + `{"summary":"Databases.","skills":["PostgreSQL"],"experiences":[],"projects":[5]}` + "\n```",
+	}})
+	got, err := Tailor(context.Background(), model, sampleResume(), git.Job{ID: 8, Title: "DBA", Company: "Globex", Description: "Own PostgreSQL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Calls() != 2 {
+		t.Errorf("expected one repair, got %d calls", model.Calls())
+	}
+	if got.ID != 0 || got.ParentID != 3 || got.JobID != 8 || got.Version != 3 || got.Title != "Backend (DBA at Globex)" {
+		t.Errorf("unexpected links %+v", got)
+	}
+	if got.Skills[0] != "PostgreSQL" || len(got.ProjectWorkedOn) != 1 {
+		t.Errorf("proposal was not applied: %+v", got)
+	}
+
+	if _, err := Tailor(context.Background(), model, sampleResume(), git.Job{}); !errors.Is(err, ErrEmptyJobDescription) {
+		t.Errorf("expected ErrEmptyJobDescription, got %v", err)
+	}
+	// without a script the fake moves the skills the job mentions first
+	fake := NewFake(ModelConfig{ResponseSchema: TailorSchema})
+	got, err = Tailor(context.Background(), fake, sampleResume(), git.Job{Description: "We run PostgreSQL and React"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Skills, []string{"React", "PostgreSQL", "Go"}) {
+		t.Errorf("unexpected fake tailoring %q", got.Skills)
+	}
+
+	bad := NewFake(ModelConfig{Script: []string{"{}"}})
+	if _, err := Tailor(context.Background(), bad, sampleResume(), git.Job{Description: "x"}); !errors.Is(err, ErrInvalidTailoring) {
+		t.Errorf("expected ErrInvalidTailoring, got %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+// TranslateSystem is the system prompt for translating a resume.
+const TranslateSystem = "You are a professional translator of resumes. Translate the resume you are given into the target language " +
+	"the way a native recruiter would write it. Keep technology names, product names, company names, people and places " +
+	"exactly as written, and keep numbers and dates unchanged. Reply with JSON only."
+
+var ErrInvalidTranslation = errors.New("model returned no usable translation")
+
+// Translation holds the translatable text of a resume. It is both what the
+// model is sent and what it answers; items refer to the resume's ids.
+// Skills, company names and project titles are never sent, so they cannot
+// change.
+type Translation struct {
+	Summary     string                 `json:"summary"`
+	Experiences []TranslatedExperience `json:"experiences"`
+	Projects    []TranslatedProject    `json:"projects"`
+	Volunteers  []TranslatedVolunteer  `json:"volunteers"`
+	Education   []TranslatedEducation  `json:"education"`
+}
+
+type TranslatedExperience struct {
+	ID      int64    `json:"id"`
+	Role    string   `json:"role"`
+	Bullets []string `json:"bullets"`
+}
+
+type TranslatedProject struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+}
+
+type TranslatedVolunteer struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type TranslatedEducation struct {
+	ID           int64  `json:"id"`
+	Degree       string `json:"degree"`
+	FieldOfStudy string `json:"field_of_study"`
+}
+
+// TranslationSchema is the JSON schema of a Translation.
+var TranslationSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"summary": map[string]any{"type": "string"},
+		"experiences": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":      map[string]any{"type": "integer"},
+					"role":    map[string]any{"type": "string"},
+					"bullets": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+				"required":             []string{"id", "role", "bullets"},
+				"additionalProperties": false,
+			},
+		},
+		"projects": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":          map[string]any{"type": "integer"},
+					"description": map[string]any{"type": "string"},
+				},
+				"required":             []string{"id", "description"},
+				"additionalProperties": false,
+			},
+		},
+		"volunteers": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":          map[string]any{"type": "integer"},
+					"title":       map[string]any{"type": "string"},
+					"description": map[string]any{"type": "string"},
+				},
+				"required":             []string{"id", "title", "description"},
+				"additionalProperties": false,
+			},
+		},
+		"education": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":             map[string]any{"type": "integer"},
+					"degree":         map[string]any{"type": "string"},
+					"field_of_study": map[string]any{"type": "string"},
+				},
+				"required":             []string{"id", "degree", "field_of_study"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"summary", "experiences", "projects", "volunteers", "education"},
+	"additionalProperties": false,
+}
+
+// Translate returns an unsaved copy of r in language, linked to r as its
+// parent. A reply that drops or translates a skill, technology or company
+// name is sent back for repair. The model should be created with
+// TranslationSchema and TranslateSystem.
+func Translate(ctx context.Context, model AiModel, r git.Resume, language string) (git.Resume, error) {
+	code, err := config.LanguageCode(language)
+	if err != nil {
+		return git.Resume{}, err
+	}
+	name := config.Languages[code]
+
+	in := TranslationOf(r)
+	resume, _ := json.Marshal(in)
+	msgs := []config.Prompt{{Role: string(User), Content: fmt.Sprintf(
+		"Translate this resume into %s. Reply with the same JSON object with every text translated and the ids unchanged.\n\n"+
+			"Resume (JSON):\n%s", name, resume)}}
+	terms := protectedTerms(r)
+
+	var perr error
+	for attempt := 0; attempt <= maxBulletRepairs; attempt++ {
+		resp, err := model.Chat(ctx, msgs)
+		if err != nil {
+			return git.Resume{}, err
+		}
+		raw := strings.Join(resp, "\n")
+		var t Translation
+		if t, perr = ParseTranslation(raw); perr == nil {
+			perr = checkTerms(in, t, terms)
+		}
+		if perr == nil {
+			out := ApplyTranslation(r, t)
+			out.ID = 0
+			out.ParentID = r.ID
+			out.Language = code
+			out.Title = fmt.Sprintf("%s (%s)", r.Title, name)
+			return out, nil
+		}
+		msgs = append(msgs,
+			config.Prompt{Role: string(Assistant), Content: raw},
+			config.Prompt{Role: string(User), Content: fmt.Sprintf(
+				"Your previous response was not valid (%v). Reply again with only the JSON object.", perr)},
+		)
+	}
+	return git.Resume{}, fmt.Errorf("%w: %v", ErrInvalidTranslation, perr)
+}
+
+// TranslationOf collects the translatable text of r.
+func TranslationOf(r git.Resume) Translation {
+	summary := r.Summary
+	if summary == "" {
+		summary = r.Profile.ProfessionalSummary
+	}
+	t := Translation{
+		Summary:     summary,
+		Experiences: []TranslatedExperience{},
+		Projects:    []TranslatedProject{},
+		Volunteers:  []TranslatedVolunteer{},
+		Education:   []TranslatedEducation{},
+	}
+	for _, w := range r.WorkExperiences {
+		t.Experiences = append(t.Experiences, TranslatedExperience{w.ID, w.Role, util.HTMLListItems(w.Responsibilities)})
+	}
+	for _, p := range r.ProjectWorkedOn {
+		t.Projects = append(t.Projects, TranslatedProject{p.ID, p.Description})
+	}
+	for _, v := range r.Volunteers {
+		t.Volunteers = append(t.Volunteers, TranslatedVolunteer{v.ID, v.Title, v.Description})
+	}
+	for _, e := range r.Education {
+		t.Education = append(t.Education, TranslatedEducation{e.ID, e.Degree, e.FieldOfStudy})
+	}
+	return t
+}
+
+// ParseTranslation decodes a Translation, tolerating Markdown code fences.
+func ParseTranslation(raw string) (Translation, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var t Translation
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return t, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if t.Summary == "" && len(t.Experiences) == 0 && len(t.Projects) == 0 && len(t.Volunteers) == 0 && len(t.Education) == 0 {
+		return t, errors.New("the translation is empty")
+	}
+	return t, nil
+}
+
+// ApplyTranslation returns a copy of r with the translated text. Items are
+// matched by id; unknown ids and empty text are ignored, so anything the
+// model left out stays in the original language.
+func ApplyTranslation(r git.Resume, t Translation) git.Resume {
+	out := r
+	if s := strings.TrimSpace(t.Summary); s != "" {
+		out.Summary = s
+		out.Profile.ProfessionalSummary = s
+	}
+
+	out.WorkExperiences = slices.Clone(r.WorkExperiences)
+	for _, e := range t.Experiences {
+		i := slices.IndexFunc(out.WorkExperiences, func(w git.WorkExperience) bool { return w.ID == e.ID })
+		if i < 0 {
+			continue
+		}
+		setText(&out.WorkExperiences[i].Role, e.Role)
+		var bullets []string
+		for _, b := range e.Bullets {
+			if b = strings.TrimSpace(bulletMarker.ReplaceAllString(b, "")); b != "" {
+				bullets = append(bullets, b)
+			}
+		}
+		if len(bullets) > 0 {
+			out.WorkExperiences[i].Responsibilities = util.HTMLList(bullets)
+		}
+	}
+
+	out.ProjectWorkedOn = slices.Clone(r.ProjectWorkedOn)
+	for _, p := range t.Projects {
+		if i := slices.IndexFunc(out.ProjectWorkedOn, func(pr git.ProjectWorkedOn) bool { return pr.ID == p.ID }); i >= 0 {
+			setText(&out.ProjectWorkedOn[i].Description, p.Description)
+		}
+	}
+
+	out.Volunteers = slices.Clone(r.Volunteers)
+	for _, v := range t.Volunteers {
+		if i := slices.IndexFunc(out.Volunteers, func(vl git.Volunteer) bool { return vl.ID == v.ID }); i >= 0 {
+			setText(&out.Volunteers[i].Title, v.Title)
+			setText(&out.Volunteers[i].Description, v.Description)
+		}
+	}
+
+	out.Education = slices.Clone(r.Education)
+	for _, e := range t.Education {
+		if i := slices.IndexFunc(out.Education, func(ed git.Education) bool { return ed.ID == e.ID }); i >= 0 {
+			setText(&out.Education[i].Degree, e.Degree)
+			setText(&out.Education[i].FieldOfStudy, e.FieldOfStudy)
+		}
+	}
+	return out
+}
+
+func setText(dst *string, s string) {
+	if s = strings.TrimSpace(s); s != "" {
+		*dst = s
+	}
+}
+
+// protectedTerms are the names a translation must keep as written: skills,
+// project technologies and titles, and employers.
+func protectedTerms(r git.Resume) []string {
+	var terms []string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if len(s) > 1 && !slices.ContainsFunc(terms, func(t string) bool { return strings.EqualFold(t, s) }) {
+			terms = append(terms, s)
+		}
+	}
+	for _, s := range r.Skills {
+		add(s)
+	}
+	for _, p := range r.ProjectWorkedOn {
+		add(p.Title)
+		for _, t := range strings.Split(p.Technologies, ",") {
+			add(t)
+		}
+	}
+	for _, w := range r.WorkExperiences {
+		add(w.Company)
+	}
+	return terms
+}
+
+// checkTerms reports protected terms that appear in the original text of
+// an item but not in its translation.
+func checkTerms(in, out Translation, terms []string) error {
+	type pair struct{ label, from, to string }
+	var pairs []pair
+	pairs = append(pairs, pair{"summary", in.Summary, out.Summary})
+	for _, e := range in.Experiences {
+		i := slices.IndexFunc(out.Experiences, func(o TranslatedExperience) bool { return o.ID == e.ID })
+		if i >= 0 {
+			pairs = append(pairs, pair{fmt.Sprintf("experience %d", e.ID),
+				strings.Join(e.Bullets, "\n"), strings.Join(out.Experiences[i].Bullets, "\n")})
+		}
+	}
+	for _, p := range in.Projects {
+		i := slices.IndexFunc(out.Projects, func(o TranslatedProject) bool { return o.ID == p.ID })
+		if i >= 0 {
+			pairs = append(pairs, pair{fmt.Sprintf("project %d", p.ID), p.Description, out.Projects[i].Description})
+		}
+	}
+
+	var missing []string
+	for _, p := range pairs {
+		if strings.TrimSpace(p.to) == "" {
+			continue
+		}
+		from, to := strings.ToLower(p.from), strings.ToLower(p.to)
+		for _, t := range terms {
+			if lt := strings.ToLower(t); strings.Contains(from, lt) && !strings.Contains(to, lt) {
+				missing = append(missing, fmt.Sprintf("%q in %s", t, p.label))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("keep these names exactly as written: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
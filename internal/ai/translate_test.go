@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+func TestApplyTranslation(t *testing.T) {
+	r := sampleResume()
+	r.Education = []git.Education{{ID: 7, School: "TU Berlin", Degree: "BSc", FieldOfStudy: "Computer Science"}}
+	got := ApplyTranslation(r, Translation{
+		Summary:     "Ingenieur.",
+		Experiences: []TranslatedExperience{{ID: 4, Role: "Ingenieur", Bullets: []string{"- Die UI gebaut"}}, {ID: 99, Role: "x"}},
+		Projects:    []TranslatedProject{{ID: 5, Description: "Ein Blog"}},
+		Education:   []TranslatedEducation{{ID: 7, Degree: "", FieldOfStudy: "Informatik"}},
+	})
+
+	w := got.WorkExperiences[0]
+	if w.Role != "Ingenieur" || w.Company != "Acme" || w.Responsibilities != "<ul><li>Die UI gebaut</li></ul>" {
+		t.Errorf("unexpected experience %+v", w)
+	}
+	if got.Summary != "Ingenieur." || got.ProjectWorkedOn[0].Description != "Ein Blog" || got.ProjectWorkedOn[0].Title != "Blog" {
+		t.Errorf("unexpected translation %+v", got)
+	}
+	if e := got.Education[0]; e.Degree != "BSc" || e.FieldOfStudy != "Informatik" || e.School != "TU Berlin" {
+		t.Errorf("empty text should keep the original, got %+v", e)
+	}
+	if !reflect.DeepEqual(got.Skills, r.Skills) {
+		t.Errorf("skills must not change, got %q", got.Skills)
+	}
+	if r.WorkExperiences[0].Role != "Engineer" {
+		t.Error("the original resume was modified")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	r := sampleResume()
+	r.WorkExperiences[0].Responsibilities = "<ul><li>Moved the queries to PostgreSQL</li></ul>"
+	model := &scriptedModel{replies: []string{
+		`{"summary":"Ingenieur.","experiences":[{"id":4,"role":"Ingenieur","bullets":["Abfragen nach Postgres verschoben"]}],"projects":[],"volunteers":[],"education":[]}`,
+		`{"summary":"Ingenieur.","experiences":[{"id":4,"role":"Ingenieur","bullets":["Abfragen nach PostgreSQL verschoben"]}],"projects":[],"volunteers":[],"education":[]}`,
+	}}
+
+	got, err := Translate(context.Background(), model, r, "DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.calls) != 2 {
+		t.Fatalf("expected a repair turn for the renamed technology, got %d calls", len(model.calls))
+	}
+	if repair := model.calls[1][len(model.calls[1])-1].Content; !strings.Contains(repair, "PostgreSQL") {
+		t.Errorf("the repair prompt should name the term, got %q", repair)
+	}
+	if got.Language != "de" || got.ParentID != 3 || got.ID != 0 || got.Title != "Backend (German)" {
+		t.Errorf("unexpected language version %+v", got)
+	}
+	if got.WorkExperiences[0].Responsibilities != "<ul><li>Abfragen nach PostgreSQL verschoben</li></ul>" {
+		t.Errorf("unexpected bullets %q", got.WorkExperiences[0].Responsibilities)
+	}
+
+	if _, err := Translate(context.Background(), model, r, "xx"); !errors.Is(err, config.ErrUnsupportedLanguage) {
+		t.Errorf("expected ErrUnsupportedLanguage, got %v", err)
+	}
+}
+
+func TestTranslateFake(t *testing.T) {
+	model := NewFake(ModelConfig{ResponseSchema: TranslationSchema})
+	got, err := Translate(context.Background(), model, sampleResume(), "pt-BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Language != "pt-BR" || got.WorkExperiences[0].Responsibilities != "<ul><li>Built the UI</li><li>Tuned queries</li></ul>" {
+		t.Errorf("unexpected fake translation %+v", got)
+	}
+}
@@ -0,0 +1,322 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+var (
+	ErrBudgetExceeded = errors.New("monthly AI budget exceeded")
+	// ErrUnknownCost is returned while a budget is set for a paid model
+	// that has no ai_pricing entry, since its spend cannot be tracked.
+	ErrUnknownCost = errors.New("no price is known for this paid AI model")
+)
+
+type TokenUsage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// UsageReporter is implemented by providers that read token counts from the
+// API response. Others are estimated from the text length.
+type UsageReporter interface {
+	LastUsage() TokenUsage
+}
+
+// UsageStore persists metered calls; database.IDatabase satisfies it.
+// Calls are recorded for the user it acts as, while the budget caps what
+// every user spends.
+type UsageStore interface {
+	UserID() int64
+	CreateAIUsage(u config.AiUsage) error
+	GetAIUsageCost(since string) (float64, error)
+}
+
+// Meter records every provider call to Store and enforces the monthly
+// budget on paid providers.
+type Meter struct {
+	Store   UsageStore
+	Pricing []config.AiPrice
+	Budget  config.AiBudget
+	now     func() time.Time
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+func NewMeter(cfg config.AppConfig, store UsageStore) *Meter {
+	return &Meter{
+		Store:   store,
+		Pricing: cfg.AiPricing,
+		Budget:  cfg.AiBudget,
+		now:     time.Now,
+	}
+}
+
+type callInfoKey struct{}
+
+// CallInfo describes why a provider is being called. It travels on the
+// context so the meter can attribute usage without changing AiModel.
+type CallInfo struct {
+	Task    config.PromptType
+	Project string
+	Origin  string
+}
+
+func WithCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+func callInfo(ctx context.Context) CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(CallInfo)
+	return info
+}
+
+// MeteredModel wraps a provider and records each call with the Meter.
+type MeteredModel struct {
+	AiModel
+	Provider ModelType
+	Model    string
+	BaseURL  string
+	Meter    *Meter
+
+	last config.AiUsage
+}
+
+// LastCall returns the usage recorded for the most recent call.
+func (m *MeteredModel) LastCall() config.AiUsage {
+	return m.last
+}
+
+func (m *MeteredModel) Generate(ctx context.Context, message string) (string, error) {
+	if err := m.Meter.checkBudget(m.Provider, m.BaseURL, m.Model); err != nil {
+		return "", err
+	}
+	start := m.Meter.now()
+	out, err := m.AiModel.Generate(ctx, message)
+	m.record(ctx, start, message, out, err)
+	return out, err
+}
+
+func (m *MeteredModel) Chat(ctx context.Context, prompts []config.Prompt) ([]string, error) {
+	if err := m.Meter.checkBudget(m.Provider, m.BaseURL, m.Model); err != nil {
+		return nil, err
+	}
+	start := m.Meter.now()
+	out, err := m.AiModel.Chat(ctx, prompts)
+
+	var in strings.Builder
+	for _, p := range prompts {
+		in.WriteString(p.Content)
+	}
+	m.record(ctx, start, in.String(), strings.Join(out, "\n"), err)
+	return out, err
+}
+
+func (m *MeteredModel) record(ctx context.Context, start time.Time, input, output string, callErr error) {
+	m.last = m.Meter.record(ctx, m.AiModel, m.Provider, m.Model, start, input, output, callErr)
+}
+
+// MeteredEmbedder is MeteredModel for embedding requests.
+type MeteredEmbedder struct {
+	Embedder
+	Provider ModelType
+	Model    string
+	BaseURL  string
+	Meter    *Meter
+}
+
+func (e *MeteredEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.Meter.checkBudget(e.Provider, e.BaseURL, e.Model); err != nil {
+		return nil, err
+	}
+	start := e.Meter.now()
+	vectors, err := e.Embedder.Embed(ctx, texts)
+	e.Meter.record(ctx, e.Embedder, e.Provider, e.Model, start, strings.Join(texts, "\n"), "", err)
+	return vectors, err
+}
+
+// record stores a call made through client, taking the token counts from it
+// when it is a UsageReporter.
+func (m *Meter) record(ctx context.Context, client any, provider ModelType, model string, start time.Time, input, output string, callErr error) config.AiUsage {
+	info := callInfo(ctx)
+	u := config.AiUsage{
+		UserID:     m.Store.UserID(),
+		CreatedAt:  start.UTC().Format(time.DateTime),
+		Provider:   string(provider),
+		Model:      model,
+		PromptType: info.Task,
+		Project:    info.Project,
+		Origin:     info.Origin,
+		LatencyMs:  m.now().Sub(start).Milliseconds(),
+		Success:    callErr == nil,
+	}
+	if callErr != nil {
+		u.Error = callErr.Error()
+	}
+
+	var tokens TokenUsage
+	if r, ok := client.(UsageReporter); ok && callErr == nil {
+		tokens = r.LastUsage()
+	}
+	if tokens.InputTokens == 0 && tokens.OutputTokens == 0 {
+		tokens = TokenUsage{InputTokens: EstimateTokens(input)}
+		if callErr == nil {
+			tokens.OutputTokens = EstimateTokens(output)
+		}
+		u.Estimated = true
+	}
+	u.InputTokens = tokens.InputTokens
+	u.OutputTokens = tokens.OutputTokens
+	if price, ok := config.PriceFor(m.Pricing, u.Provider, u.Model); ok {
+		u.Cost = price.Cost(u.InputTokens, u.OutputTokens)
+	}
+
+	// usage accounting must never break the actual AI call
+	_ = m.Store.CreateAIUsage(u)
+	return u
+}
+
+// checkBudget refuses calls to paid providers once this month's recorded
+// spend has reached the configured cap. A paid model without a price would
+// be recorded at $0, so it is refused while a cap is set and warned about
+// otherwise.
+func (m *Meter) checkBudget(provider ModelType, baseURL, model string) error {
+	if !IsPaid(m.Pricing, provider, baseURL, model) {
+		return nil
+	}
+	if _, ok := config.PriceFor(m.Pricing, string(provider), model); !ok {
+		if m.Budget.MonthlyLimit > 0 {
+			return fmt.Errorf("%w: add %s/%s to ai_pricing in config.yaml", ErrUnknownCost, provider, model)
+		}
+		m.warnUnpriced(provider, model)
+		return nil
+	}
+	if m.Budget.MonthlyLimit <= 0 {
+		return nil
+	}
+	spent, err := m.MonthToDate()
+	if err != nil {
+		return err
+	}
+	if spent >= m.Budget.MonthlyLimit {
+		return fmt.Errorf("%w: spent $%.2f of $%.2f this month", ErrBudgetExceeded, spent, m.Budget.MonthlyLimit)
+	}
+	return nil
+}
+
+func (m *Meter) warnUnpriced(provider ModelType, model string) {
+	key := string(provider) + "/" + model
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.warned[key] {
+		return
+	}
+	if m.warned == nil {
+		m.warned = map[string]bool{}
+	}
+	m.warned[key] = true
+	fmt.Fprintf(os.Stderr, "warning: no price is known for %s, so its cost is recorded as $0; add it to ai_pricing in config.yaml\n", key)
+}
+
+// MonthToDate returns the recorded cost since the start of the current
+// calendar month (UTC).
+func (m *Meter) MonthToDate() (float64, error) {
+	now := m.now().UTC()
+	return m.Spent(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// Spent returns the recorded cost of every user's calls since the given
+// time.
+func (m *Meter) Spent(since time.Time) (float64, error) {
+	return m.Store.GetAIUsageCost(since.UTC().Format(time.DateTime))
+}
+
+// IsPaid reports whether calls to the provider cost money. Providers on this
+// machine (see IsLocal) are free; remote ones are paid unless priced at zero.
+func IsPaid(pricing []config.AiPrice, provider ModelType, baseURL, model string) bool {
+	if IsLocal(provider, baseURL) {
+		return false
+	}
+	price, ok := config.PriceFor(pricing, string(provider), model)
+	return !ok || price.Input > 0 || price.Output > 0
+}
+
+// EstimateTokens approximates the token count of text at ~4 characters per
+// token, which is close enough for English prose and code.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
+type UsageSummary struct {
+	Key          string  `json:"key"`
+	Calls        int     `json:"calls"`
+	Failures     int     `json:"failures"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+	Cost         float64 `json:"cost"`
+}
+
+// SummarizeUsage groups usage records by "provider", "day" or "project".
+func SummarizeUsage(records []config.AiUsage, by string) ([]UsageSummary, error) {
+	var keyOf func(config.AiUsage) string
+	switch by {
+	case "provider", "":
+		keyOf = func(u config.AiUsage) string { return u.Provider + "/" + u.Model }
+	case "day":
+		keyOf = func(u config.AiUsage) string {
+			if len(u.CreatedAt) >= 10 {
+				return u.CreatedAt[:10]
+			}
+			return u.CreatedAt
+		}
+	case "project":
+		keyOf = func(u config.AiUsage) string {
+			if u.Project == "" {
+				return "(none)"
+			}
+			return u.Project
+		}
+	default:
+		return nil, fmt.Errorf("unknown grouping %q, expected provider, day or project", by)
+	}
+
+	groups := map[string]*UsageSummary{}
+	var latency = map[string]int64{}
+	for _, u := range records {
+		k := keyOf(u)
+		s, ok := groups[k]
+		if !ok {
+			s = &UsageSummary{Key: k}
+			groups[k] = s
+		}
+		s.Calls++
+		if !u.Success {
+			s.Failures++
+		}
+		s.InputTokens += u.InputTokens
+		s.OutputTokens += u.OutputTokens
+		s.Cost += u.Cost
+		latency[k] += u.LatencyMs
+	}
+
+	out := make([]UsageSummary, 0, len(groups))
+	for k, s := range groups {
+		s.AvgLatencyMs = latency[k] / int64(s.Calls)
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
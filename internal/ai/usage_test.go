@@ -0,0 +1,160 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+type memUsage struct{ records []config.AiUsage }
+
+func (m *memUsage) UserID() int64 { return 1 }
+
+func (m *memUsage) CreateAIUsage(u config.AiUsage) error {
+	m.records = append(m.records, u)
+	return nil
+}
+
+func (m *memUsage) GetAIUsageCost(since string) (float64, error) {
+	var cost float64
+	for _, r := range m.records {
+		if r.CreatedAt >= since {
+			cost += r.Cost
+		}
+	}
+	return cost, nil
+}
+
+func TestMeter_RecordsProviderUsage(t *testing.T) {
+	store := &memUsage{}
+	rec, err := NewRecorder("testdata/cassettes/openai_chat.json", ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mustModel(t, ModelConfig{
+		Type: OpenAI, Model: "gpt-5-mini", APIKey: "test-key", Temperature: 0.5, MaxToken: 400,
+		ResponseSchema: BulletSchema,
+		HTTPClient:     &http.Client{Transport: rec},
+		Meter:          NewMeter(config.AppConfig{}, store),
+	})
+
+	ctx := WithCallInfo(context.Background(), CallInfo{Task: config.ProjectPrompt, Project: "gitresume", Origin: "test"})
+	if _, err := m.Chat(ctx, cassettePrompt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(store.records))
+	}
+	u := store.records[0]
+	if u.InputTokens != 74 || u.OutputTokens != 41 || u.Estimated {
+		t.Errorf("expected API token counts, got %+v", u)
+	}
+	if u.UserID != 1 || u.Project != "gitresume" || u.PromptType != config.ProjectPrompt || !u.Success {
+		t.Errorf("unexpected attribution: %+v", u)
+	}
+	if want := (74*0.25 + 41*2) / 1_000_000; u.Cost != want {
+		t.Errorf("expected cost %v, got %v", want, u.Cost)
+	}
+}
+
+func TestMeter_BudgetBlocksPaidProviders(t *testing.T) {
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	store := &memUsage{records: []config.AiUsage{
+		{CreatedAt: "2025-09-30 23:00:00", Provider: "openai", Cost: 50},
+		{CreatedAt: "2025-10-02 10:00:00", Provider: "openai", Cost: 4.5},
+		{CreatedAt: "2025-10-03 10:00:00", Provider: "gemini", Cost: 0.6},
+	}}
+	meter := NewMeter(config.AppConfig{AiBudget: config.AiBudget{MonthlyLimit: 5}}, store)
+	meter.now = func() time.Time { return now }
+
+	paid := mustModel(t, ModelConfig{Type: OpenAI, Model: "gpt-5-mini", Meter: meter})
+	if _, err := paid.Chat(context.Background(), testPrompt); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+
+	local := mustModel(t, ModelConfig{Type: Fake, Script: []string{"ok"}, Meter: meter})
+	if _, err := local.Chat(context.Background(), testPrompt); err != nil {
+		t.Errorf("local providers should ignore the budget, got %v", err)
+	}
+}
+
+func TestMeter_RecordsEmbeddings(t *testing.T) {
+	store := &memUsage{}
+	cfg := config.AppConfig{AiPricing: []config.AiPrice{{Provider: "openai", Model: "text-embedding-3-small", Input: 0.02}}}
+	rt := &capture{reply: `{"data":[{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":12}}`}
+	e, err := NewEmbedder(ModelConfig{
+		Type: OpenAI, Model: "text-embedding-3-small", APIKey: "k",
+		HTTPClient: &http.Client{Transport: rt},
+		Meter:      NewMeter(cfg, store),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithCallInfo(context.Background(), CallInfo{Task: config.EmbedPrompt, Origin: "test"})
+	if _, err := e.Embed(ctx, []string{"Go developer"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.records) != 1 {
+		t.Fatalf("expected the embedding call to be recorded, got %+v", store.records)
+	}
+	if u := store.records[0]; u.InputTokens != 12 || u.Estimated || u.PromptType != config.EmbedPrompt || u.Cost != 12*0.02/1_000_000 {
+		t.Errorf("unexpected embedding usage %+v", u)
+	}
+
+	cfg.AiBudget.MonthlyLimit = 0.000_000_1
+	e, _ = NewEmbedder(ModelConfig{Type: OpenAI, Model: "text-embedding-3-small", APIKey: "k", HTTPClient: &http.Client{Transport: rt}, Meter: NewMeter(cfg, store)})
+	if _, err := e.Embed(ctx, []string{"Go developer"}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected embeddings to respect the budget, got %v", err)
+	}
+}
+
+func TestMeter_UnpricedAndLocalModels(t *testing.T) {
+	store := &memUsage{}
+	budget := NewMeter(config.AppConfig{AiBudget: config.AiBudget{MonthlyLimit: 5}}, store)
+
+	// a TGI container on this machine costs nothing, priced or not
+	if err := budget.checkBudget(HuggingFace, "http://localhost:8080/v1", "tgi"); err != nil {
+		t.Errorf("local endpoints should ignore the budget, got %v", err)
+	}
+
+	unpriced := mustModel(t, ModelConfig{Type: OpenAI, Model: "gpt-9", Meter: budget})
+	if _, err := unpriced.Chat(context.Background(), testPrompt); !errors.Is(err, ErrUnknownCost) {
+		t.Errorf("expected ErrUnknownCost under a budget, got %v", err)
+	}
+	if err := NewMeter(config.AppConfig{}, store).checkBudget(OpenAI, "", "gpt-9"); err != nil {
+		t.Errorf("an unpriced model should only be warned about without a budget, got %v", err)
+	}
+	priced := NewMeter(config.AppConfig{
+		AiBudget:  config.AiBudget{MonthlyLimit: 5},
+		AiPricing: []config.AiPrice{{Provider: "openai", Model: "gpt-9", Input: 1, Output: 2}},
+	}, store)
+	if err := priced.checkBudget(OpenAI, "", "gpt-9"); err != nil {
+		t.Errorf("expected a priced model under budget to be allowed, got %v", err)
+	}
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	records := []config.AiUsage{
+		{CreatedAt: "2025-10-01 10:00:00", Provider: "llama", Model: "llama3.2", Project: "api", LatencyMs: 100, Success: true},
+		{CreatedAt: "2025-10-01 11:00:00", Provider: "openai", Model: "gpt-5-mini", Project: "api", LatencyMs: 300, Cost: 0.01, Success: true},
+		{CreatedAt: "2025-10-02 09:00:00", Provider: "openai", Model: "gpt-5-mini", LatencyMs: 500, Cost: 0.02},
+	}
+	byDay, err := SummarizeUsage(records, "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDay) != 2 || byDay[0].Key != "2025-10-01" || byDay[0].Calls != 2 || byDay[0].AvgLatencyMs != 200 {
+		t.Errorf("unexpected day summary: %+v", byDay)
+	}
+	byProvider, _ := SummarizeUsage(records, "provider")
+	if byProvider[1].Key != "openai/gpt-5-mini" || byProvider[1].Failures != 1 || byProvider[1].Cost != 0.03 {
+		t.Errorf("unexpected provider summary: %+v", byProvider)
+	}
+	if _, err := SummarizeUsage(records, "week"); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// CommitBatchSize is how many commits a CommitWriter stores per
+// transaction.
+const CommitBatchSize = 1000
+
+// CommitWriter stores commits as they are streamed from git log, a batch at
+// a time, so seeding a large repository holds one batch in memory and no
+// transaction grows without bound. The project is created with the first
+// batch, so a repository without commits leaves nothing behind.
+type CommitWriter struct {
+	db      IDatabase
+	project git.Project
+	size    int
+	batch   []git.GitCommit
+
+	// Seen and Added count the commits written and those that were new.
+	Seen, Added int
+	// Progress, when set, is called after each batch is stored.
+	Progress func(seen, added int)
+}
+
+// NewCommitWriter writes commits to project, which is created when it has
+// no ID. size is the batch size; 0 means CommitBatchSize.
+func NewCommitWriter(db IDatabase, project git.Project, size int) *CommitWriter {
+	if size <= 0 {
+		size = CommitBatchSize
+	}
+	project.Commits = nil
+	return &CommitWriter{db: db, project: project, size: size, batch: make([]git.GitCommit, 0, size)}
+}
+
+// Write queues c, oldest commit first, and stores the batch once it is full.
+func (w *CommitWriter) Write(c git.GitCommit) error {
+	w.batch = append(w.batch, c)
+	w.Seen++
+	if len(w.batch) < w.size {
+		return nil
+	}
+	return w.Flush()
+}
+
+// Flush stores the queued commits.
+func (w *CommitWriter) Flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	if w.project.ID == 0 {
+		if err := w.db.CreateProject(w.project); err != nil {
+			return err
+		}
+		p, err := w.db.GetProjectByName(w.project.Name)
+		if err != nil {
+			return err
+		}
+		w.project.ID = p.ID
+	}
+	n, err := w.db.AddCommits(w.project.ID, w.batch)
+	if err != nil {
+		return err
+	}
+	w.Added += n
+	w.batch = w.batch[:0]
+	if w.Progress != nil {
+		w.Progress(w.Seen, w.Added)
+	}
+	return nil
+}
+
+// fullHashLen is the length of a full SHA-1 commit hash. Seeds before
+// CommitLogFormat used full hashes stored abbreviated ones.
+const fullHashLen = 40
+
+// ExpandHashes replaces the abbreviated hashes stored for p with the full
+// hashes git lists for them, in the database and in p.Commits. It reads
+// the history only when p has abbreviated hashes.
+func ExpandHashes(db IDatabase, p *git.Project, g *git.GitUtil, email string) error {
+	short := map[string]bool{}
+	lengths := map[int]bool{}
+	for _, c := range p.Commits {
+		if len(c.Hash) < fullHashLen {
+			short[c.Hash] = true
+			lengths[len(c.Hash)] = true
+		}
+	}
+	if len(short) == 0 {
+		return nil
+	}
+	full := map[string]string{}
+	err := g.EachCommit(email, "", func(c git.GitCommit) error {
+		for n := range lengths {
+			if n <= len(c.Hash) && short[c.Hash[:n]] {
+				full[c.Hash[:n]] = c.Hash
+			}
+		}
+		return nil
+	})
+	if err != nil || len(full) == 0 {
+		return err
+	}
+	if _, err := db.ExpandCommitHashes(p.ID, full); err != nil {
+		return err
+	}
+	for i, c := range p.Commits {
+		if h, ok := full[c.Hash]; ok {
+			p.Commits[i].Hash = h
+		}
+	}
+	return nil
+}
+
+// Rescan is what RescanProject changed.
+type Rescan struct {
+	Seen    int `json:"seen"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// RescanProject reads the whole history of a project again, from path when
+// it is set and from where the project was seeded otherwise. It stores the
+// commits earlier seeds missed, say ones merged from an older branch, and
+// refreshes the tech stack. Abbreviated hashes stored by older seeds are
+// expanded first. With prune set it also deletes the commits git
+// no longer lists, with their summaries, as after a rebase, but only when
+// git lists at least one of the stored commits. Pruning holds the hashes of
+// the stored commits in memory.
+func RescanProject(db IDatabase, id int, path, email string, prune bool, progress func(seen, added int)) (Rescan, error) {
+	p, err := db.GetProject(id)
+	if err != nil {
+		return Rescan{}, err
+	}
+	if path != "" {
+		p.Path = path
+	}
+	g := git.NewGitUtil(p.Path)
+	if !g.IsGitRepo() {
+		return Rescan{}, fmt.Errorf("%s is not a git repository, pass the project's new path if it moved", p.Path)
+	}
+	if err := ExpandHashes(db, &p, g, email); err != nil {
+		return Rescan{}, err
+	}
+
+	tech, err := g.GetStacks(email)
+	if err != nil {
+		return Rescan{}, err
+	}
+	techJSON, _ := json.Marshal(tech)
+	p.Technologies = string(techJSON)
+	if err := db.UpdateProject(p); err != nil {
+		return Rescan{}, err
+	}
+
+	stored := make(map[string]bool, len(p.Commits))
+	for _, c := range p.Commits {
+		stored[c.Hash] = true
+	}
+	w := NewCommitWriter(db, p, 0)
+	w.Progress = progress
+	matched := 0
+	err = g.EachCommit(email, "", func(c git.GitCommit) error {
+		if stored[c.Hash] {
+			delete(stored, c.Hash)
+			matched++
+		}
+		return w.Write(c)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	r := Rescan{Seen: w.Seen, Added: w.Added}
+	if err != nil || !prune || len(stored) == 0 {
+		return r, err
+	}
+	// a changed email would otherwise prune the whole history
+	if w.Seen == 0 {
+		return r, fmt.Errorf("git log lists no commits by %s, not pruning", email)
+	}
+	// and so would another repository at the project's path
+	if matched == 0 {
+		return r, fmt.Errorf("none of the %d stored commits is in %s, not pruning", len(stored), p.Path)
+	}
+	gone := make([]string, 0, len(stored))
+	for h := range stored {
+		gone = append(gone, h)
+	}
+	r.Removed, err = db.DeleteCommits(p.ID, gone)
+	return r, err
+}
@@ -0,0 +1,208 @@
+package database_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+func TestCommitWriter(t *testing.T) {
+	db := opener(database.SqliteDB)(t)
+	uID, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = db.ForUser(uID)
+
+	empty := database.NewCommitWriter(db, git.Project{Name: "empty"}, 2)
+	if err := empty.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := db.GetProjectByName("empty"); p.ID != 0 {
+		t.Errorf("expected no project without commits, got %+v", p)
+	}
+
+	var batches [][2]int
+	w := database.NewCommitWriter(db, git.Project{Name: "shop", Path: "/src/shop"}, 2)
+	w.Progress = func(seen, added int) { batches = append(batches, [2]int{seen, added}) }
+	log := "a1=2024-03-01=Init\nb2=2024-03-02=Add checkout\nc3=2024-03-03=Fix tax\n"
+	if err := git.ParseCommits(strings.NewReader(log), w.Write); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(batches) != "[[2 2] [3 3]]" {
+		t.Errorf("expected a batch of 2 and one of 1, got %v", batches)
+	}
+	p, err := db.GetProjectByName("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Path != "/src/shop" || len(p.Commits) != 3 || p.Commits[0].Hash != "a1" {
+		t.Errorf("unexpected project %+v", p)
+	}
+}
+
+// syntheticLog prints git log output for n commits without a repository.
+type syntheticLog struct {
+	n, i int
+	buf  []byte
+}
+
+func (l *syntheticLog) Read(p []byte) (int, error) {
+	for len(l.buf) < len(p) && l.i < l.n {
+		l.buf = fmt.Appendf(l.buf, "%07x=2024-01-01=Change number %d of the synthetic history\n", l.i, l.i)
+		l.i++
+	}
+	if len(l.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
+
+// maxSeedHeap bounds the Go heap while seeding. Parsing the 200k commits
+// into one slice takes about 48 MiB on its own.
+const maxSeedHeap = 32 << 20
+
+func BenchmarkSeedLargeRepo(b *testing.B) {
+	const commits = 200_000
+	for range b.N {
+		b.StopTimer()
+		b.Setenv("HOME", b.TempDir())
+		db, err := database.NewDB(database.SqliteDB)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := db.Migrate(); err != nil {
+			b.Fatal(err)
+		}
+		uID, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"})
+		if err != nil {
+			b.Fatal(err)
+		}
+		runtime.GC()
+		b.StartTimer()
+
+		var peak uint64
+		w := database.NewCommitWriter(db.ForUser(uID), git.Project{Name: "monorepo"}, 0)
+		w.Progress = func(int, int) {
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+			peak = max(peak, m.HeapAlloc)
+		}
+		if err := git.ParseCommits(&syntheticLog{n: commits}, w.Write); err != nil {
+			b.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		if w.Added != commits {
+			b.Errorf("expected %d commits stored, got %d", commits, w.Added)
+		}
+		if peak > maxSeedHeap {
+			b.Errorf("heap reached %d MiB, over the %d MiB bound", peak>>20, maxSeedHeap>>20)
+		}
+		b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MiB")
+		db.Close()
+		b.StartTimer()
+	}
+}
+
+func TestRescanProject(t *testing.T) {
+	db := opener(database.SqliteDB)(t)
+	uID, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = db.ForUser(uID)
+
+	repo := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	for _, msg := range []string{"Init", "Add checkout", "Fix tax"} {
+		run("commit", "-q", "--allow-empty", "-m", msg)
+	}
+	first := run("log", "--reverse", "--pretty=format:%H")
+	first = strings.Fields(first)[0]
+
+	// seeded once with abbreviated hashes, before a rebase dropped a commit
+	err = db.CreateProject(git.Project{Name: "shop", Path: "/old/place", Commits: []git.GitCommit{
+		{Hash: "0dead00", Msg: "Rebased away"}, {Hash: first[:7], Msg: "Init"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := db.GetProjectByName("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := database.RescanProject(db, p.ID, "", "ada@example.com", false, nil); err == nil {
+		t.Error("expected an error rescanning a path that is not a repository")
+	}
+	r, err := database.RescanProject(db, p.ID, repo, "ada@example.com", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != (database.Rescan{Seen: 3, Added: 2}) {
+		t.Errorf("expected the two missed commits to be added, got %+v", r)
+	}
+	if _, err := database.RescanProject(db, p.ID, "", "bob@example.com", true, nil); err == nil {
+		t.Error("expected no pruning when git log lists no commits")
+	}
+	if r, err = database.RescanProject(db, p.ID, "", "ada@example.com", true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if r != (database.Rescan{Seen: 3, Removed: 1}) {
+		t.Errorf("expected the rebased commit to be removed, got %+v", r)
+	}
+
+	p, err = db.GetProject(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Path != repo || len(p.Commits) != 3 || p.Commits[0].Hash != first || p.Commits[0].ID != 1 {
+		t.Errorf("unexpected project after rescanning %+v", p)
+	}
+
+	// another repository at the path shares no commits with the project
+	err = db.CreateProject(git.Project{Name: "blog", Path: repo, Commits: []git.GitCommit{{Hash: strings.Repeat("b", 40), Msg: "Write a post"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blog, err := db.GetProjectByName("blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.RescanProject(db, blog.ID, "", "ada@example.com", true, nil); err == nil {
+		t.Error("expected no pruning when none of the stored commits is listed")
+	}
+	if blog, err = db.GetProject(blog.ID); err != nil || blog.Commits[0].Msg != "Write a post" {
+		t.Errorf("expected the stored commit to be kept, got %+v, %v", blog.Commits, err)
+	}
+}
@@ -0,0 +1,32 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/database/databasetest"
+)
+
+func opener(name database.DBName) databasetest.Opener {
+	return func(t *testing.T) database.IDatabase {
+		t.Helper()
+		t.Setenv("HOME", t.TempDir())
+		db, err := database.NewDB(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+}
+
+func TestSqliteConformance(t *testing.T) {
+	databasetest.Run(t, opener(database.SqliteDB))
+}
+
+func TestBoltConformance(t *testing.T) {
+	databasetest.Run(t, opener(database.BoltDB))
+}
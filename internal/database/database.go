@@ -5,7 +5,6 @@ import (
 
 	"github.com/iamhabbeboy/gitresume/config"
 	"github.com/iamhabbeboy/gitresume/internal/database/drivers"
-	"github.com/iamhabbeboy/gitresume/internal/git"
 )
 
 var (
@@ -13,40 +12,9 @@ var (
 	once     sync.Once
 )
 
-type IDatabase interface {
-	Migrate() error
-	Delete(key string) error
-	Close() error
-	CreateProject(data git.Project) error
-	GetResume(ID int64) (git.Resume, error)
-	GetResumes() ([]git.Resume, error)
-	DeleteResume(rID int64) error
-	GetUser(email string) (git.Profile, error)
-	CreateUser(data git.Profile) (int64, error)
-	GetUserByID(uID int32) (git.Profile, error)
-	GetCommitById(id int) (git.GitCommit, error)
-	UpdateUser(uID int64, req git.Profile) error
-	CreateResume(r git.Resume) (git.Resume, error)
-	GetProjectByName(name string) (git.Project, error)
-	UpsertCommit(commits []git.CustomUpdateCommit) error
-	UpdateResume(uID int64, req git.Resume) (int64, error)
-	GetAllProject(limit, offset int) ([]git.Project, error)
-
-	DeleteEducation(eID int64) error
-	CreateOrUpdateEducation(rID int64, data []git.Education) ([]int64, error)
-	GetAllCommitSummary(projectID int) ([]git.CustomUpdateCommit, error)
-
-	DeleteWorkExperience(wID int64) error
-	CreateOrUpdateWorkExperiences(rID int64, w []git.WorkExperience) ([]int64, error)
-
-	CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error
-	GetLLmPromptConfig() ([]config.CustomPrompt, error)
-
-	CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]int64, error)
-	CreateOrUpdateProjectOn(rID int64, v []git.ProjectWorkedOn) ([]int64, error)
-	DeleteProjectWorkedOn(pID int64) error
-	DeleteVolunteer(pID int64) error
-}
+// IDatabase is the store every command and handler works on. It is
+// declared with the drivers so that their ForUser can return it.
+type IDatabase = drivers.IDatabase
 
 type DBName string
 
@@ -68,7 +36,7 @@ func NewDB(name DBName) (IDatabase, error) {
 
 func GetInstance() IDatabase {
 	once.Do(func() {
-		db, err := NewDB(SqliteDB)
+		db, err := NewDB(configuredDB())
 		if err != nil {
 			panic(err)
 		}
@@ -77,3 +45,49 @@ func GetInstance() IDatabase {
 	return instance
 	// return nil
 }
+
+// ForConfiguredUser scopes db to the user named by user.email in
+// config.yaml. db is returned unscoped when there is no such user yet.
+func ForConfiguredUser(db IDatabase) IDatabase {
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg.User.Email == "" {
+		return db
+	}
+	u, err := db.GetUser(cfg.User.Email)
+	if err != nil || u.ID == 0 {
+		return db
+	}
+	return db.ForUser(int64(u.ID))
+}
+
+// Owner is the user who runs this machine: the one named by user.email in
+// config.yaml, or the first user when it names none. Settings shared by
+// every user, such as the AI providers and their keys, are theirs to change.
+// It returns 0 when there are no users yet.
+func Owner(db IDatabase) (int64, error) {
+	cfg, err := config.LoadConfig()
+	if err == nil && cfg.User.Email != "" {
+		u, err := db.GetUser(cfg.User.Email)
+		if err != nil {
+			return 0, err
+		}
+		if u.ID != 0 {
+			return int64(u.ID), nil
+		}
+	}
+	users, err := db.GetUsers()
+	if err != nil || len(users) == 0 {
+		return 0, err
+	}
+	return int64(users[0].ID), nil
+}
+
+// configuredDB is the driver named by "database" in config.yaml, SQLite
+// unless it says bolt.
+func configuredDB() DBName {
+	cfg, err := config.LoadConfig()
+	if err == nil && DBName(cfg.Database) == BoltDB {
+		return BoltDB
+	}
+	return SqliteDB
+}
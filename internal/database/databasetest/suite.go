@@ -0,0 +1,1064 @@
+// Package databasetest is a conformance suite for database.IDatabase
+// implementations. Every driver runs it, so they stay interchangeable.
+package databasetest
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// Opener returns a new, empty, migrated database for t. It should keep its
+// files under t.TempDir() and close the database in t.Cleanup.
+type Opener func(t *testing.T) database.IDatabase
+
+// Run runs the suite, opening a new database for every subtest.
+func Run(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, database.IDatabase)
+	}{
+		{"Users", testUsers},
+		{"Authenticate", testAuthenticate},
+		{"UserScoping", testUserScoping},
+		{"Projects", testProjects},
+		{"SeedSkipsKnownCommits", testSeedSkipsKnownCommits},
+		{"AddCommits", testAddCommits},
+		{"ManageProjects", testManageProjects},
+		{"ExpandCommitHashes", testExpandCommitHashes},
+		{"CommitSummaries", testCommitSummaries},
+		{"Resumes", testResumes},
+		{"WorkExperiences", testWorkExperiences},
+		{"Education", testEducation},
+		{"Volunteering", testVolunteering},
+		{"ProjectsWorkedOn", testProjectsWorkedOn},
+		{"DeleteResumeCascades", testDeleteResumeCascades},
+		{"ResumeRevisions", testResumeRevisions},
+		{"Jobs", testJobs},
+		{"Prompts", testPrompts},
+		{"Suggestions", testSuggestions},
+		{"Embeddings", testEmbeddings},
+		{"AIAudit", testAIAudit},
+		{"Search", testSearch},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, open(t))
+		})
+	}
+}
+
+// must fails t if err is set and returns v otherwise:
+//
+//	id := must(db.CreateJob(j))(t)
+func must[T any](v T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedUser creates the user projects, resumes and jobs belong to and
+// returns db scoped to them.
+func seedUser(t *testing.T, db database.IDatabase) database.IDatabase {
+	t.Helper()
+	id := must(db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"}))(t)
+	return db.ForUser(id)
+}
+
+func testUsers(t *testing.T, db database.IDatabase) {
+	id := must(db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"}))(t)
+	if id != 1 {
+		t.Errorf("expected the first user to get ID 1, got %d", id)
+	}
+	if _, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com"}); err == nil {
+		t.Error("expected an error for a duplicate email")
+	}
+
+	u := must(db.GetUser("ada@example.com"))(t)
+	if u.ID != 1 || u.Name != "Ada" {
+		t.Errorf("GetUser = %+v", u)
+	}
+	if u = must(db.GetUser("nobody@example.com"))(t); u.ID != 0 {
+		t.Errorf("expected no user, got %+v", u)
+	}
+
+	check(t, db.UpdateUser(1, git.Profile{Location: "Berlin", Links: []string{"https://ada.dev"}}))
+	// empty fields are left alone
+	check(t, db.UpdateUser(1, git.Profile{Phone: "555"}))
+	u = must(db.GetUserByID(1))(t)
+	want := git.Profile{ID: 1, Name: "Ada", Email: "ada@example.com", Location: "Berlin", Phone: "555", Links: []string{"https://ada.dev"}}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("GetUserByID =\n%+v\nwant\n%+v", u, want)
+	}
+	if u = must(db.GetUserByID(9))(t); u.ID != 0 {
+		t.Errorf("expected no user, got %+v", u)
+	}
+}
+
+func testAuthenticate(t *testing.T, db database.IDatabase) {
+	id := must(db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"}))(t)
+	must(db.CreateUser(git.Profile{Name: "Bob", Email: "bob@example.com", PasswordHash: "hunter2"}))(t)
+	if _, err := db.CreateUser(git.Profile{Name: "Eve", Email: "eve@example.com"}); err == nil {
+		t.Error("expected an error for an empty password")
+	}
+
+	u := must(db.Authenticate("ada@example.com", "secret"))(t)
+	if int64(u.ID) != id || u.Name != "Ada" || u.PasswordHash != "" {
+		t.Errorf("Authenticate = %+v", u)
+	}
+	for _, c := range [][2]string{{"ada@example.com", "hunter2"}, {"nobody@example.com", "secret"}, {"ada@example.com", ""}} {
+		if _, err := db.Authenticate(c[0], c[1]); !errors.Is(err, config.ErrInvalidCredentials) {
+			t.Errorf("Authenticate(%q, %q) = %v, want invalid credentials", c[0], c[1], err)
+		}
+	}
+
+	check(t, db.SetPassword(id, "correct horse"))
+	if _, err := db.Authenticate("ada@example.com", "secret"); err == nil {
+		t.Error("expected the old password to stop working")
+	}
+	must(db.Authenticate("ada@example.com", "correct horse"))(t)
+	if err := db.SetPassword(99, "secret"); err == nil {
+		t.Error("expected an error for a missing user")
+	}
+
+	users := must(db.GetUsers())(t)
+	if len(users) != 2 || users[0].Email != "ada@example.com" || users[1].Email != "bob@example.com" || users[0].PasswordHash != "" {
+		t.Errorf("GetUsers = %+v", users)
+	}
+}
+
+// testUserScoping checks that a user never sees or changes another user's
+// data.
+func testUserScoping(t *testing.T, db database.IDatabase) {
+	if err := db.CreateProject(git.Project{Name: "shop"}); err == nil {
+		t.Error("expected an error creating a project without a user")
+	}
+	ada := seedUser(t, db)
+	bob := db.ForUser(must(db.CreateUser(git.Profile{Name: "Bob", Email: "bob@example.com", PasswordHash: "hunter2"}))(t))
+	if ada.UserID() == 0 || ada.UserID() == bob.UserID() || db.UserID() != 0 {
+		t.Fatalf("unexpected user IDs %d, %d and %d", ada.UserID(), bob.UserID(), db.UserID())
+	}
+
+	check(t, ada.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "a1", Msg: "Add checkout"}}}))
+	p := must(ada.GetProjectByName("shop"))(t)
+	r := must(ada.CreateResume(git.Resume{Title: "Backend", Version: 1}))(t)
+	work := must(ada.CreateOrUpdateWorkExperiences(r.ID, []git.WorkExperience{{Company: "Acme", Responsibilities: "Owned checkout"}}))(t)
+	job := must(ada.CreateJob(git.Job{Title: "SRE", Company: "Initech"}))(t)
+	check(t, ada.CreateOrUpdateLLmPrompt(config.CustomPrompt{Title: config.ProjectPrompt, Temperature: 0.5, MaxTokens: 400, Prompts: []config.Prompt{{Role: "user", Content: "{{.Content}}"}}}))
+	sg := must(ada.CreateSuggestions([]config.Suggestion{{ProjectID: p.ID, Text: "Built checkout"}}))(t)
+	check(t, ada.SaveEmbeddings([]config.Embedding{{Evidence: config.Evidence{Ref: "a", Text: "a"}, Model: "m", Hash: "1", Vector: []float32{1}}}))
+	check(t, ada.CreateAIUsage(config.AiUsage{Provider: "openai", Model: "gpt-5", Cost: 0.25}))
+	check(t, ada.CreateAIAudit(config.AiAudit{Provider: "openai", Model: "gpt-5", Prompt: "Summarise checkout"}))
+	audit := must(ada.GetAIAudit("", 0))(t)
+	if len(audit) != 1 || audit[0].UserID != ada.UserID() {
+		t.Fatalf("expected ada's audit entry, got %+v", audit)
+	}
+
+	if ps := must(bob.GetAllProject(10, 0))(t); len(ps) != 0 {
+		t.Errorf("expected no projects for bob, got %+v", ps)
+	}
+	if got := must(bob.GetProjectByName("shop"))(t); got.ID != 0 {
+		t.Errorf("bob found ada's project %+v", got)
+	}
+	// the same project name is fine for another user
+	check(t, bob.CreateProject(git.Project{Name: "shop"}))
+	if got := must(bob.GetProjectByName("shop"))(t); got.ID == p.ID || len(got.Commits) != 0 {
+		t.Errorf("bob's shop should be his own, got %+v", got)
+	}
+	if _, err := bob.CreateSuggestions([]config.Suggestion{{ProjectID: p.ID, Text: "Stolen"}}); err == nil {
+		t.Error("expected an error for a suggestion on another user's project")
+	}
+
+	if _, err := bob.GetResume(r.ID); err == nil {
+		t.Error("bob read ada's resume")
+	}
+	if rs := must(bob.GetResumes())(t); len(rs) != 0 {
+		t.Errorf("expected no resumes for bob, got %+v", rs)
+	}
+	if _, err := bob.CreateOrUpdateWorkExperiences(r.ID, []git.WorkExperience{{Company: "Evil"}}); err == nil {
+		t.Error("bob added to ada's resume")
+	}
+	bob.DeleteWorkExperience(work[0])
+	bob.DeleteResume(r.ID)
+	if got := must(ada.GetResume(r.ID))(t); len(got.WorkExperiences) != 1 {
+		t.Errorf("bob changed ada's resume: %+v", got)
+	}
+	if revs := must(bob.GetResumeRevisions(r.ID))(t); len(revs) != 0 {
+		t.Errorf("bob read ada's resume history: %+v", revs)
+	}
+
+	if _, err := bob.GetJob(job); err == nil {
+		t.Error("bob read ada's job")
+	}
+	if prompts := must(bob.GetLLmPromptConfig())(t); len(prompts) != 0 {
+		t.Errorf("expected no prompts for bob, got %+v", prompts)
+	}
+	if versions := must(bob.GetPromptVersions(config.ProjectPrompt))(t); len(versions) != 0 {
+		t.Errorf("expected no prompt versions for bob, got %+v", versions)
+	}
+	if s := must(bob.GetSuggestions(0, ""))(t); len(s) != 0 {
+		t.Errorf("expected no suggestions for bob, got %+v", s)
+	}
+	if _, err := bob.GetSuggestion(sg[0]); err == nil {
+		t.Error("bob read ada's suggestion")
+	}
+	if err := bob.ReviewSuggestion(sg[0], config.SuggestionAccepted, ""); err == nil {
+		t.Error("bob reviewed ada's suggestion")
+	}
+	if e := must(bob.GetEmbeddings("m"))(t); len(e) != 0 {
+		t.Errorf("expected no embeddings for bob, got %+v", e)
+	}
+	if u := must(bob.GetAIUsage(""))(t); len(u) != 0 {
+		t.Errorf("expected no AI usage for bob, got %+v", u)
+	}
+	if a := must(bob.GetAIAudit("", 0))(t); len(a) != 0 {
+		t.Errorf("expected no audit entries for bob, got %+v", a)
+	}
+	if _, err := bob.GetAIAuditEntry(audit[0].ID); err == nil {
+		t.Error("bob read ada's prompt")
+	}
+	// the budget covers every user's calls
+	check(t, bob.CreateAIUsage(config.AiUsage{Provider: "openai", Model: "gpt-5", Cost: 0.5}))
+	if cost := must(bob.GetAIUsageCost(""))(t); cost != 0.75 {
+		t.Errorf("expected a cost of 0.75 for the machine, got %v", cost)
+	}
+	if res := must(bob.Search(config.SearchQuery{Text: "checkout"}))(t); len(res) != 0 {
+		t.Errorf("bob's search found ada's data: %+v", res)
+	}
+	if res := must(ada.Search(config.SearchQuery{Text: "checkout"}))(t); len(res) != 2 {
+		t.Errorf("expected ada's commit and experience, got %+v", res)
+	}
+}
+
+func testProjects(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	// git log order: newest first
+	check(t, db.CreateProject(git.Project{
+		Name:         "shop",
+		Path:         "/src/shop",
+		Technologies: "Go",
+		Commits:      []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}},
+	}))
+	check(t, db.CreateProject(git.Project{Name: "blog", Path: "/src/blog"}))
+	// seeding a known project adds its new commits
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "c3", Msg: "Fix tax"}}}))
+
+	p := must(db.GetProjectByName("shop"))(t)
+	if p.ID != 1 || p.Name != "shop" || p.Path != "/src/shop" {
+		t.Errorf("GetProjectByName = %+v", p)
+	}
+	if hashes := commitHashes(p.Commits); !reflect.DeepEqual(hashes, []string{"a1", "b2", "c3"}) {
+		t.Errorf("expected commits oldest first, got %v", hashes)
+	}
+	if p = must(db.GetProjectByName("nope"))(t); p.ID != 0 {
+		t.Errorf("expected no project, got %+v", p)
+	}
+
+	c := must(db.GetCommitById(3))(t)
+	if c.Msg != "Fix tax" {
+		t.Errorf("GetCommitById = %+v", c)
+	}
+
+	projects := must(db.GetAllProject(0, 0))(t)
+	if len(projects) != 2 || projects[0].Name != "shop" || projects[1].Name != "blog" {
+		t.Fatalf("expected projects in creation order, got %+v", projects)
+	}
+	if projects[0].Technologies != "Go" || len(projects[0].Commits) != 3 || projects[0].Commits[0].Msg != "Init" {
+		t.Errorf("unexpected project %+v", projects[0])
+	}
+	if projects[1].Commits == nil || len(projects[1].Commits) != 0 {
+		t.Errorf("expected an empty commit list, got %#v", projects[1].Commits)
+	}
+}
+
+func testSeedSkipsKnownCommits(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}}}))
+	// a seed that failed halfway is run again, repeating a hash in the batch
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{
+		{Hash: "c3", Msg: "Fix tax"}, {Hash: "b2", Msg: "Add checkout"}, {Hash: "c3", Msg: "Fix tax"},
+	}}))
+	p := must(db.GetProjectByName("shop"))(t)
+	if hashes := commitHashes(p.Commits); !reflect.DeepEqual(hashes, []string{"a1", "b2", "c3"}) {
+		t.Errorf("expected every commit once, got %v", hashes)
+	}
+	// the same hash in another project is another commit
+	check(t, db.CreateProject(git.Project{Name: "fork", Commits: []git.GitCommit{{Hash: "a1", Msg: "Init"}}}))
+	if p := must(db.GetProjectByName("fork"))(t); len(p.Commits) != 1 {
+		t.Errorf("expected the fork's own commit, got %+v", p.Commits)
+	}
+
+	if issues := must(db.CheckIntegrity(false))(t); len(issues) != 0 {
+		t.Errorf("expected no integrity issues, got %+v", issues)
+	}
+}
+
+func testAddCommits(t *testing.T, db database.IDatabase) {
+	ada := seedUser(t, db)
+	check(t, ada.CreateProject(git.Project{Name: "shop"}))
+	p := must(ada.GetProjectByName("shop"))(t)
+
+	if n := must(ada.AddCommits(p.ID, []git.GitCommit{{Hash: "a1", Msg: "Init"}, {Hash: "b2", Msg: "Add checkout", Date: "2024-03-01"}}))(t); n != 2 {
+		t.Errorf("expected 2 new commits, got %d", n)
+	}
+	if n := must(ada.AddCommits(p.ID, []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "c3", Msg: "Fix tax"}}))(t); n != 1 {
+		t.Errorf("expected 1 new commit, got %d", n)
+	}
+	p = must(ada.GetProjectByName("shop"))(t)
+	if hashes := commitHashes(p.Commits); !reflect.DeepEqual(hashes, []string{"a1", "b2", "c3"}) {
+		t.Errorf("expected the commits in the order given, got %v", hashes)
+	}
+	if p.Commits[1].Date != "2024-03-01" {
+		t.Errorf("expected the commit date to be kept, got %+v", p.Commits[1])
+	}
+
+	bob := db.ForUser(must(db.CreateUser(git.Profile{Name: "Bob", Email: "bob@example.com", PasswordHash: "hunter2"}))(t))
+	if _, err := bob.AddCommits(p.ID, []git.GitCommit{{Hash: "d4", Msg: "Sneak in"}}); err == nil {
+		t.Error("expected an error adding commits to another user's project")
+	}
+}
+
+func testManageProjects(t *testing.T, db database.IDatabase) {
+	ada := seedUser(t, db)
+	// git log order: the newest commit first
+	check(t, ada.CreateProject(git.Project{Name: "shop", Path: "/src/shop", Commits: []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}}}))
+	check(t, ada.CreateProject(git.Project{Name: "blog"}))
+	shop := must(ada.GetProjectByName("shop"))(t)
+	blog := must(ada.GetProjectByName("blog"))(t)
+	check(t, ada.UpsertCommit([]git.CustomUpdateCommit{
+		{ProjectID: shop.ID, GitCommit: git.GitCommit{ID: shop.Commits[0].ID, Msg: "Set up the repo"}},
+		{ProjectID: shop.ID, GitCommit: git.GitCommit{ID: shop.Commits[1].ID, Msg: "Built the checkout"}},
+	}))
+	must(ada.CreateSuggestions([]config.Suggestion{{ProjectID: shop.ID, Text: "Built checkout"}}))(t)
+
+	listed := must(ada.ListProjects(false))(t)
+	if len(listed) != 2 || listed[0].Name != "shop" || listed[0].CommitCount != 2 || listed[0].Commits != nil || listed[1].CommitCount != 0 {
+		t.Errorf("ListProjects = %+v", listed)
+	}
+
+	// rename
+	shop.Name = "store"
+	check(t, ada.UpdateProject(shop))
+	if p := must(ada.GetProject(shop.ID))(t); p.Name != "store" || p.Path != "/src/shop" || len(p.Commits) != 2 {
+		t.Errorf("GetProject after rename = %+v", p)
+	}
+	if p := must(ada.GetProjectByName("shop"))(t); p.ID != 0 {
+		t.Errorf("the old name still finds %+v", p)
+	}
+	shop.Name = "blog"
+	if err := ada.UpdateProject(shop); !errors.Is(err, config.ErrProjectExists) {
+		t.Errorf("expected ErrProjectExists renaming onto another project, got %v", err)
+	}
+	shop.Name = " "
+	if err := ada.UpdateProject(shop); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+	shop.Name = "store"
+
+	// archive
+	check(t, ada.ArchiveProject(shop.ID, true))
+	if ps := must(ada.GetAllProject(0, 0))(t); len(ps) != 1 || ps[0].ID != blog.ID {
+		t.Errorf("expected only the blog once the shop is archived, got %+v", ps)
+	}
+	if ps := must(ada.ListProjects(false))(t); len(ps) != 1 {
+		t.Errorf("expected archived projects to be left out, got %+v", ps)
+	}
+	if ps := must(ada.ListProjects(true))(t); len(ps) != 2 || !ps[0].Archived {
+		t.Errorf("expected the archived shop to be listed, got %+v", ps)
+	}
+	if p := must(ada.GetProject(shop.ID))(t); !p.Archived || len(p.Commits) != 2 {
+		t.Errorf("an archived project keeps its commits, got %+v", p)
+	}
+	check(t, ada.ArchiveProject(shop.ID, false))
+	if ps := must(ada.GetAllProject(0, 0))(t); len(ps) != 2 {
+		t.Errorf("expected the shop back, got %+v", ps)
+	}
+
+	// another user cannot touch the projects
+	bob := db.ForUser(must(db.CreateUser(git.Profile{Name: "Bob", Email: "bob@example.com", PasswordHash: "hunter2"}))(t))
+	if _, err := bob.GetProject(shop.ID); err == nil {
+		t.Error("expected an error getting another user's project")
+	}
+	if err := bob.UpdateProject(shop); err == nil {
+		t.Error("expected an error renaming another user's project")
+	}
+	if err := bob.ArchiveProject(shop.ID, true); err == nil {
+		t.Error("expected an error archiving another user's project")
+	}
+	if err := bob.DeleteProject(shop.ID); err == nil {
+		t.Error("expected an error deleting another user's project")
+	}
+	if _, err := bob.DeleteCommits(shop.ID, []string{"a1"}); err == nil {
+		t.Error("expected an error deleting another user's commits")
+	}
+
+	// commits go with their summaries
+	if n := must(ada.DeleteCommits(shop.ID, []string{"a1", "zz"}))(t); n != 1 {
+		t.Errorf("expected 1 commit deleted, got %d", n)
+	}
+	if got := summaryMsgs(t, ada, shop.ID); !reflect.DeepEqual(got, []string{"Built the checkout"}) {
+		t.Errorf("expected the deleted commit's summary to go, got %v", got)
+	}
+
+	// and projects with everything in them
+	check(t, ada.DeleteProject(shop.ID))
+	if _, err := ada.GetProject(shop.ID); err == nil {
+		t.Error("expected the deleted project to be gone")
+	}
+	if got := summaryMsgs(t, ada, shop.ID); len(got) != 0 {
+		t.Errorf("expected the summaries to be deleted, got %v", got)
+	}
+	if sg := must(ada.GetSuggestions(shop.ID, ""))(t); len(sg) != 0 {
+		t.Errorf("expected the suggestions to be deleted, got %+v", sg)
+	}
+	if err := ada.DeleteProject(shop.ID); err == nil {
+		t.Error("expected an error deleting a project twice")
+	}
+	check(t, ada.Delete("blog"))
+	if ps := must(ada.ListProjects(true))(t); len(ps) != 0 {
+		t.Errorf("expected no projects left, got %+v", ps)
+	}
+	if issues := must(db.CheckIntegrity(false))(t); len(issues) != 0 {
+		t.Errorf("expected no integrity issues, got %+v", issues)
+	}
+}
+
+func testExpandCommitHashes(t *testing.T, db database.IDatabase) {
+	ada := seedUser(t, db)
+	fullA, fullB := "a1b2c3d"+strings.Repeat("0", 33), "b2c3d4e"+strings.Repeat("1", 33)
+	// seeded with abbreviated hashes, then a rescan stored a1 again
+	check(t, ada.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "b2c3d4e", Msg: "Add checkout"}, {Hash: "a1b2c3d", Msg: "Init"}}}))
+	shop := must(ada.GetProjectByName("shop"))(t)
+	must(ada.AddCommits(shop.ID, []git.GitCommit{{Hash: fullA, Msg: "Init"}}))(t)
+	dup := must(ada.GetProject(shop.ID))(t).Commits[2]
+	check(t, ada.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: shop.ID, GitCommit: git.GitCommit{ID: dup.ID, Msg: "Set up the repo"}}}))
+
+	bob := db.ForUser(must(db.CreateUser(git.Profile{Name: "Bob", Email: "bob@example.com", PasswordHash: "hunter2"}))(t))
+	if _, err := bob.ExpandCommitHashes(shop.ID, map[string]string{"a1b2c3d": fullA}); err == nil {
+		t.Error("expected an error expanding another user's commits")
+	}
+
+	n := must(ada.ExpandCommitHashes(shop.ID, map[string]string{"a1b2c3d": fullA, "b2c3d4e": fullB, "zz": fullB}))(t)
+	if n != 2 {
+		t.Errorf("expected 2 hashes expanded, got %d", n)
+	}
+	p := must(ada.GetProject(shop.ID))(t)
+	if hashes := commitHashes(p.Commits); !reflect.DeepEqual(hashes, []string{fullA, fullB}) {
+		t.Errorf("expected the full hashes and no duplicate, got %v", hashes)
+	}
+	if got := summaryMsgs(t, ada, shop.ID); !reflect.DeepEqual(got, []string{"Set up the repo"}) {
+		t.Errorf("expected the duplicate's summary to be kept, got %v", got)
+	}
+	if issues := must(db.CheckIntegrity(false))(t); len(issues) != 0 {
+		t.Errorf("expected no integrity issues, got %+v", issues)
+	}
+}
+
+func commitHashes(commits []git.GitCommit) []string {
+	hashes := make([]string, 0, len(commits))
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+	return hashes
+}
+
+func summaryMsgs(t *testing.T, db database.IDatabase, projectID int) []string {
+	t.Helper()
+	msgs := []string{}
+	for _, s := range must(db.GetAllCommitSummary(projectID))(t) {
+		msgs = append(msgs, s.Msg)
+	}
+	return msgs
+}
+
+func testCommitSummaries(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}}}))
+	check(t, db.CreateProject(git.Project{Name: "blog"}))
+
+	check(t, db.UpsertCommit([]git.CustomUpdateCommit{
+		{ProjectID: 1, GitCommit: git.GitCommit{Msg: "Built the checkout"}},
+		{ProjectID: 1, GitCommit: git.GitCommit{Msg: "Wrote the tests"}},
+	}))
+	check(t, db.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: 2, GitCommit: git.GitCommit{Msg: "Started a blog"}}}))
+	if got := summaryMsgs(t, db, 1); !reflect.DeepEqual(got, []string{"Built the checkout", "Wrote the tests"}) {
+		t.Errorf("expected summaries in the order saved, got %v", got)
+	}
+
+	// hand-written summaries are replaced as a set, per project
+	check(t, db.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: 1, GitCommit: git.GitCommit{Msg: "Shipped the checkout"}}}))
+	if got := summaryMsgs(t, db, 1); !reflect.DeepEqual(got, []string{"Shipped the checkout"}) {
+		t.Errorf("expected the summaries to be replaced, got %v", got)
+	}
+	if got := summaryMsgs(t, db, 2); !reflect.DeepEqual(got, []string{"Started a blog"}) {
+		t.Errorf("another project's summaries changed: %v", got)
+	}
+
+	// summaries of a commit are upserted
+	for _, msg := range []string{"Set up the repo", "Set up the repository"} {
+		check(t, db.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: 1, GitCommit: git.GitCommit{ID: 1, Msg: msg}}}))
+	}
+	if got := summaryMsgs(t, db, 1); !reflect.DeepEqual(got, []string{"Shipped the checkout", "Set up the repository"}) {
+		t.Errorf("expected one summary for the commit, got %v", got)
+	}
+	if got := summaryMsgs(t, db, 3); len(got) != 0 {
+		t.Errorf("expected no summaries for an unknown project, got %v", got)
+	}
+}
+
+func testResumes(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	check(t, db.UpdateUser(1, git.Profile{ProfessionalSummary: "Generalist."}))
+
+	r := must(db.CreateResume(git.Resume{Title: "Backend", Version: 1, Skills: []string{"Go", "SQL"}}))(t)
+	if r.ID != 1 {
+		t.Errorf("expected the first resume to get ID 1, got %d", r.ID)
+	}
+	bare := must(db.CreateResume(git.Resume{Title: "Frontend", Version: 1}))(t)
+
+	got := must(db.GetResume(r.ID))(t)
+	if got.Title != "Backend" || got.Version != 1 || !reflect.DeepEqual(got.Skills, []string{"Go", "SQL"}) {
+		t.Errorf("GetResume = %+v", got)
+	}
+	if got.Profile.Name != "Ada" || got.Profile.ProfessionalSummary != "Generalist." {
+		t.Errorf("expected the user's profile, got %+v", got.Profile)
+	}
+	if got.WorkExperiences == nil || got.Education == nil || got.Volunteers == nil || got.ProjectWorkedOn == nil {
+		t.Errorf("expected empty, non-nil sections, got %+v", got)
+	}
+	if got = must(db.GetResume(bare.ID))(t); got.Skills == nil {
+		t.Error("expected empty, non-nil skills")
+	}
+	if _, err := db.GetResume(99); err == nil {
+		t.Error("expected an error for a missing resume")
+	}
+
+	must(db.UpdateResume(r.ID, git.Resume{Title: "Platform", Profile: git.Profile{Location: "Berlin"}}))(t)
+	// an update without a title or skills keeps them
+	must(db.UpdateResume(r.ID, git.Resume{Profile: git.Profile{Phone: "555"}}))(t)
+	got = must(db.GetResume(r.ID))(t)
+	if got.Title != "Platform" || len(got.Skills) != 2 || got.Profile.Location != "Berlin" || got.Profile.Phone != "555" {
+		t.Errorf("unexpected updated resume %+v", got)
+	}
+
+	child := must(db.CreateResume(git.Resume{Title: "Platform (de)", Version: 1, ParentID: r.ID, Language: "de", Summary: "Generalistin."}))(t)
+	got = must(db.GetResume(child.ID))(t)
+	if got.ParentID != r.ID || got.Language != "de" || got.Profile.ProfessionalSummary != "Generalistin." {
+		t.Errorf("unexpected linked resume %+v", got)
+	}
+
+	resumes := must(db.GetResumes())(t)
+	var titles []string
+	for _, v := range resumes {
+		titles = append(titles, v.Title)
+	}
+	if !reflect.DeepEqual(titles, []string{"Platform", "Frontend", "Platform (de)"}) {
+		t.Errorf("expected resumes in creation order, got %v", titles)
+	}
+}
+
+// testSection checks the shared create, update and delete behaviour of a
+// resume section. item builds the nth item with the given ID and name;
+// name reads it back.
+func testSection[T any](
+	t *testing.T,
+	db database.IDatabase,
+	save func(rID int64, items []T) ([]int64, error),
+	remove func(id int64) error,
+	section func(r git.Resume) []T,
+	item func(id int64, name string) T,
+	name func(T) string,
+) {
+	r := must(db.CreateResume(git.Resume{Title: "Backend", Version: 1}))(t)
+	other := must(db.CreateResume(git.Resume{Title: "Frontend", Version: 1}))(t)
+
+	if _, err := save(r.ID, nil); err == nil {
+		t.Error("expected an error for no items")
+	}
+
+	ids := must(save(r.ID, []T{item(0, "first"), item(0, "second")}))(t)
+	if len(ids) != 2 || ids[0] == 0 || ids[1] <= ids[0] {
+		t.Fatalf("expected two increasing IDs, got %v", ids)
+	}
+	names := func(rID int64) []string {
+		t.Helper()
+		out := []string{}
+		for _, v := range section(must(db.GetResume(rID))(t)) {
+			out = append(out, name(v))
+		}
+		return out
+	}
+
+	// saving an item with its ID updates it in place
+	again := must(save(r.ID, []T{item(ids[0], "first, edited"), item(0, "third")}))(t)
+	if len(again) != 2 || again[0] != ids[0] {
+		t.Errorf("expected the updated item to keep ID %d, got %v", ids[0], again)
+	}
+	if got := names(r.ID); !reflect.DeepEqual(got, []string{"first, edited", "second", "third"}) {
+		t.Errorf("unexpected items %v", got)
+	}
+
+	// an ID from another resume is not updated there
+	must(save(other.ID, []T{item(ids[1], "stolen")}))(t)
+	if got := names(r.ID); got[1] != "second" {
+		t.Errorf("saving to another resume changed this one: %v", got)
+	}
+	if got := names(other.ID); !reflect.DeepEqual(got, []string{"stolen"}) {
+		t.Errorf("unexpected items on the other resume %v", got)
+	}
+
+	check(t, remove(ids[1]))
+	if got := names(r.ID); !reflect.DeepEqual(got, []string{"first, edited", "third"}) {
+		t.Errorf("unexpected items after delete %v", got)
+	}
+}
+
+func testWorkExperiences(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	testSection(t, db, db.CreateOrUpdateWorkExperiences, db.DeleteWorkExperience,
+		func(r git.Resume) []git.WorkExperience { return r.WorkExperiences },
+		func(id int64, name string) git.WorkExperience {
+			return git.WorkExperience{ID: id, Company: name, Role: "Engineer", Responsibilities: "<ul><li>Shipped</li></ul>"}
+		},
+		func(w git.WorkExperience) string { return w.Company },
+	)
+}
+
+func testEducation(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	testSection(t, db, db.CreateOrUpdateEducation, db.DeleteEducation,
+		func(r git.Resume) []git.Education { return r.Education },
+		func(id int64, name string) git.Education {
+			return git.Education{ID: id, School: name, Degree: "BSc"}
+		},
+		func(e git.Education) string { return e.School },
+	)
+}
+
+func testVolunteering(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	testSection(t, db, db.CreateOrUpdateVolunteering, db.DeleteVolunteer,
+		func(r git.Resume) []git.Volunteer { return r.Volunteers },
+		func(id int64, name string) git.Volunteer {
+			return git.Volunteer{ID: id, Title: name, Description: "Mentoring"}
+		},
+		func(v git.Volunteer) string { return v.Title },
+	)
+}
+
+func testProjectsWorkedOn(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	testSection(t, db, db.CreateOrUpdateProjectOn, db.DeleteProjectWorkedOn,
+		func(r git.Resume) []git.ProjectWorkedOn { return r.ProjectWorkedOn },
+		func(id int64, name string) git.ProjectWorkedOn {
+			return git.ProjectWorkedOn{ID: id, Title: name, Description: "A side project"}
+		},
+		func(p git.ProjectWorkedOn) string { return p.Title },
+	)
+}
+
+func testDeleteResumeCascades(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	parent := must(db.CreateResume(git.Resume{Title: "Backend", Version: 1}))(t)
+	child := must(db.CreateResume(git.Resume{Title: "Backend (de)", Version: 1, ParentID: parent.ID, Language: "de"}))(t)
+	work := must(db.CreateOrUpdateWorkExperiences(parent.ID, []git.WorkExperience{{Company: "Acme"}}))(t)
+	must(db.CreateOrUpdateEducation(parent.ID, []git.Education{{School: "MIT"}}))(t)
+	must(db.CreateOrUpdateWorkExperiences(child.ID, []git.WorkExperience{{Company: "Acme GmbH"}}))(t)
+
+	check(t, db.DeleteResume(parent.ID))
+	if _, err := db.GetResume(parent.ID); err == nil {
+		t.Error("expected an error for a deleted resume")
+	}
+	got := must(db.GetResume(child.ID))(t)
+	if got.ParentID != 0 {
+		t.Errorf("expected the link to the deleted resume to be cleared, got %d", got.ParentID)
+	}
+	if len(got.WorkExperiences) != 1 || got.WorkExperiences[0].Company != "Acme GmbH" {
+		t.Errorf("the child resume lost its sections: %+v", got.WorkExperiences)
+	}
+	if resumes := must(db.GetResumes())(t); len(resumes) != 1 || resumes[0].ID != child.ID {
+		t.Errorf("unexpected resumes after delete %+v", resumes)
+	}
+
+	// the deleted resume's sections went with it: their IDs are not
+	// updated when saved to the child
+	ids := must(db.CreateOrUpdateWorkExperiences(child.ID, []git.WorkExperience{{ID: work[0], Company: "Initech"}}))(t)
+	if ids[0] == work[0] {
+		t.Errorf("work experience %d outlived its resume", work[0])
+	}
+}
+
+func testJobs(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	if jobs := must(db.GetJobs())(t); jobs == nil || len(jobs) != 0 {
+		t.Errorf("expected an empty, non-nil job list, got %#v", jobs)
+	}
+	first := must(db.CreateJob(git.Job{Title: "DBA", Company: "Globex", Description: "Own PostgreSQL"}))(t)
+	second := must(db.CreateJob(git.Job{Title: "SRE", Company: "Initech"}))(t)
+
+	j := must(db.GetJob(first))(t)
+	if j.ID != first || j.Company != "Globex" || j.Description != "Own PostgreSQL" || j.CreatedAt == "" {
+		t.Errorf("GetJob = %+v", j)
+	}
+	if _, err := db.GetJob(99); err == nil {
+		t.Error("expected an error for a missing job")
+	}
+	jobs := must(db.GetJobs())(t)
+	if len(jobs) != 2 || jobs[0].ID != second || jobs[1].ID != first {
+		t.Errorf("expected jobs newest first, got %+v", jobs)
+	}
+}
+
+func testPrompts(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	save := func(content string) {
+		t.Helper()
+		check(t, db.CreateOrUpdateLLmPrompt(config.CustomPrompt{
+			Title:       config.ProjectPrompt,
+			Temperature: 0.5,
+			MaxTokens:   400,
+			Prompts:     []config.Prompt{{Role: "user", Content: content}},
+		}))
+	}
+	save("v1 {{.Content}}")
+	save("v1 {{.Content}}") // unchanged saves do not create a version
+	save("v2 {{.Content}}")
+
+	if err := db.CreateOrUpdateLLmPrompt(config.CustomPrompt{Title: config.ProjectPrompt, Prompts: []config.Prompt{{Role: "user", Content: "{{.Nope}}"}}}); err == nil {
+		t.Error("expected an invalid template to be rejected")
+	}
+
+	versions := must(db.GetPromptVersions(config.ProjectPrompt))(t)
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 2 {
+		t.Fatalf("expected versions 1 and 2 in order, got %+v", versions)
+	}
+	if versions[0].Active || !versions[1].Active {
+		t.Errorf("expected version 2 to be active, got %+v", versions)
+	}
+
+	check(t, db.PromotePromptVersion(config.ProjectPrompt, 1))
+	prompts := must(db.GetLLmPromptConfig())(t)
+	if len(prompts) != 1 || prompts[0].Prompts[0].Content != "v1 {{.Content}}" {
+		t.Errorf("expected version 1 to be the prompt, got %+v", prompts)
+	}
+	if versions = must(db.GetPromptVersions(config.ProjectPrompt))(t); len(versions) != 2 || !versions[0].Active {
+		t.Errorf("promoting must not create a version: %+v", versions)
+	}
+	if err := db.PromotePromptVersion(config.ProjectPrompt, 7); err == nil {
+		t.Error("expected an error for a missing version")
+	}
+
+	if evals := must(db.GetPromptEvals(""))(t); len(evals) != 0 {
+		t.Errorf("expected no evaluations, got %+v", evals)
+	}
+	for _, run := range []string{"run-1", "run-1", "run-2"} {
+		check(t, db.CreatePromptEval(config.PromptEval{RunID: run, Title: config.ProjectPrompt, Version: 1, Provider: "fake"}))
+	}
+	if evals := must(db.GetPromptEvals(""))(t); len(evals) != 1 || evals[0].RunID != "run-2" {
+		t.Errorf("expected the latest run, got %+v", evals)
+	}
+	if evals := must(db.GetPromptEvals("run-1"))(t); len(evals) != 2 {
+		t.Errorf("expected two results for run-1, got %+v", evals)
+	}
+}
+
+func testSuggestions(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "a1", Msg: "Add checkout"}}}))
+	check(t, db.CreateProject(git.Project{Name: "blog"}))
+
+	ids := must(db.CreateSuggestions([]config.Suggestion{
+		{ProjectID: 1, Task: config.ProjectPrompt, SourceCommits: []string{"a1"}, Text: "Built the checkout"},
+		{ProjectID: 1, Task: config.ProjectPrompt, Text: "Did things"},
+		{ProjectID: 2, Task: config.ProjectPrompt, Text: "Started a blog"},
+	}))(t)
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 IDs, got %v", ids)
+	}
+
+	all := must(db.GetSuggestions(0, ""))(t)
+	if len(all) != 3 || all[0].ID != ids[2] || all[2].ID != ids[0] {
+		t.Errorf("expected suggestions newest first, got %+v", all)
+	}
+	if got := must(db.GetSuggestions(1, config.SuggestionPending))(t); len(got) != 2 {
+		t.Errorf("expected 2 pending suggestions for project 1, got %+v", got)
+	}
+	if got := must(db.GetSuggestions(2, config.SuggestionAccepted))(t); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty, non-nil list, got %#v", got)
+	}
+
+	check(t, db.ReviewSuggestion(ids[0], config.SuggestionAccepted, ""))
+	check(t, db.ReviewSuggestion(ids[1], config.SuggestionRejected, ""))
+	summaries := must(db.GetAllCommitSummary(1))(t)
+	if len(summaries) != 1 || summaries[0].Msg != "Built the checkout" || !reflect.DeepEqual(summaries[0].SourceCommits, []string{"a1"}) {
+		t.Errorf("unexpected summaries after review %+v", summaries)
+	}
+
+	// reviewing again replaces the summary rather than adding one
+	check(t, db.ReviewSuggestion(ids[0], config.SuggestionEdited, "Built the Stripe checkout"))
+	if got := summaryMsgs(t, db, 1); !reflect.DeepEqual(got, []string{"Built the Stripe checkout"}) {
+		t.Errorf("unexpected summaries after edit %v", got)
+	}
+	s := must(db.GetSuggestion(ids[0]))(t)
+	if s.State != config.SuggestionEdited || s.FinalText() != "Built the Stripe checkout" || s.ReviewedAt == "" {
+		t.Errorf("unexpected edited suggestion %+v", s)
+	}
+
+	check(t, db.ReviewSuggestion(ids[0], config.SuggestionPending, ""))
+	if got := summaryMsgs(t, db, 1); len(got) != 0 {
+		t.Errorf("reopening should withdraw the summary, got %v", got)
+	}
+	if s = must(db.GetSuggestion(ids[0]))(t); s.ReviewedAt != "" {
+		t.Errorf("expected a reopened suggestion to be unreviewed, got %+v", s)
+	}
+
+	if err := db.ReviewSuggestion(ids[0], "maybe", ""); err != config.ErrInvalidSuggestionState {
+		t.Errorf("expected ErrInvalidSuggestionState, got %v", err)
+	}
+	if err := db.ReviewSuggestion(99, config.SuggestionAccepted, ""); err == nil {
+		t.Error("expected an error for a missing suggestion")
+	}
+	if _, err := db.GetSuggestion(99); err == nil {
+		t.Error("expected an error for a missing suggestion")
+	}
+}
+
+func testEmbeddings(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	evidence := func(ref string) config.Evidence {
+		return config.Evidence{Ref: ref, Kind: config.EvidenceCommit, Text: ref}
+	}
+	check(t, db.SaveEmbeddings([]config.Embedding{
+		{Evidence: evidence("a"), Model: "m", Hash: "1", Vector: []float32{1, 0}},
+		{Evidence: evidence("b"), Model: "m", Hash: "1", Vector: []float32{0, 1}},
+		{Evidence: evidence("a"), Model: "other", Hash: "1", Vector: []float32{1, 1}},
+	}))
+	// saving a ref again replaces its vector
+	check(t, db.SaveEmbeddings([]config.Embedding{{Evidence: evidence("a"), Model: "m", Hash: "2", Vector: []float32{0.6, 0.8}}}))
+
+	got := must(db.GetEmbeddings("m"))(t)
+	if len(got) != 2 || got[0].Ref != "a" || got[0].Hash != "2" || !reflect.DeepEqual(got[0].Vector, []float32{0.6, 0.8}) {
+		t.Errorf("GetEmbeddings = %+v", got)
+	}
+
+	matches := must(db.SearchEmbeddings("m", []float32{0, 1}, 0))(t)
+	if len(matches) != 2 || matches[0].Ref != "b" || matches[1].Ref != "a" {
+		t.Errorf("expected the closest match first, got %+v", matches)
+	}
+	if matches = must(db.SearchEmbeddings("m", []float32{0, 1}, 1))(t); len(matches) != 1 {
+		t.Errorf("expected the limit to apply, got %+v", matches)
+	}
+
+	check(t, db.DeleteEmbeddings("m", []string{"a"}))
+	if got = must(db.GetEmbeddings("m"))(t); len(got) != 1 || got[0].Ref != "b" {
+		t.Errorf("unexpected embeddings after delete %+v", got)
+	}
+	if got = must(db.GetEmbeddings("other"))(t); len(got) != 1 {
+		t.Errorf("deleting must only affect its model, got %+v", got)
+	}
+}
+
+func testAIAudit(t *testing.T, db database.IDatabase) {
+	for _, a := range []config.AiAudit{
+		{Provider: "openai", Model: "gpt-5", PromptType: config.ProjectPrompt, Prompt: "old", CreatedAt: "2026-01-01 10:00:00"},
+		{Provider: "llama", Model: "llama3.2", PromptType: config.SummaryPrompt, Prompt: "mid", CreatedAt: "2026-02-01 10:00:00"},
+		{Provider: "llama", Model: "llama3.2", PromptType: config.SummaryPrompt, Prompt: "new", CreatedAt: "2026-03-01 10:00:00"},
+	} {
+		check(t, db.CreateAIAudit(a))
+	}
+
+	entries := must(db.GetAIAudit("2026-01-15 00:00:00", 0))(t)
+	if len(entries) != 2 || entries[0].Prompt != "new" || entries[1].Prompt != "mid" {
+		t.Errorf("expected entries since the date, newest first, got %+v", entries)
+	}
+	if entries = must(db.GetAIAudit("", 1))(t); len(entries) != 1 || entries[0].Prompt != "new" {
+		t.Errorf("expected the limit to apply, got %+v", entries)
+	}
+	e := must(db.GetAIAuditEntry(entries[0].ID))(t)
+	if e.Prompt != "new" || e.Provider != "llama" {
+		t.Errorf("GetAIAuditEntry = %+v", e)
+	}
+
+	if n := must(db.PruneAIAudit("2026-02-15 00:00:00"))(t); n != 2 {
+		t.Errorf("expected 2 pruned entries, got %d", n)
+	}
+	if entries = must(db.GetAIAudit("", 0))(t); len(entries) != 1 {
+		t.Errorf("unexpected entries after pruning %+v", entries)
+	}
+
+	check(t, db.CreateAIUsage(config.AiUsage{Provider: "llama", Model: "llama3.2", CreatedAt: "2026-03-02 10:00:00"}))
+	check(t, db.CreateAIUsage(config.AiUsage{Provider: "openai", Model: "gpt-5", CreatedAt: "2026-03-01 10:00:00"}))
+	usage := must(db.GetAIUsage("2026-03-01 00:00:00"))(t)
+	if len(usage) != 2 || usage[0].Provider != "openai" {
+		t.Errorf("expected usage oldest first, got %+v", usage)
+	}
+}
+
+func searchTitles(results []config.SearchResult) []string {
+	titles := []string{}
+	for _, r := range results {
+		titles = append(titles, r.Kind+":"+r.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func testSearch(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	check(t, db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{
+		{Hash: "h1", Msg: "Add checkout flow", Date: "2025-01-10"},
+		{Hash: "h0", Msg: "Init repo", Date: "2024-06-01"},
+	}}))
+	check(t, db.CreateProject(git.Project{Name: "blog", Commits: []git.GitCommit{
+		{Hash: "b1", Msg: "Fix checkout typo in post", Date: "2025-03-01"},
+	}}))
+	check(t, db.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: 1, GitCommit: git.GitCommit{Msg: "Built the checkout service"}}}))
+	r := must(db.CreateResume(git.Resume{Title: "Backend", Version: 1}))(t)
+	work := must(db.CreateOrUpdateWorkExperiences(r.ID, []git.WorkExperience{{
+		Company: "Acme", Role: "Engineer", StartDate: "2023-01-01",
+		Responsibilities: "<ul><li>Owned checkout payments</li></ul>",
+	}}))(t)
+	worked := must(db.CreateOrUpdateProjectOn(r.ID, []git.ProjectWorkedOn{{Title: "Tracker", Description: "<p>Time tracking app</p>"}}))(t)
+
+	search := func(q config.SearchQuery) []string {
+		t.Helper()
+		return searchTitles(must(db.Search(q))(t))
+	}
+
+	results := must(db.Search(config.SearchQuery{Text: "Checkout"}))(t)
+	if got := searchTitles(results); !reflect.DeepEqual(got, []string{"commit:b1", "commit:h1", "experience:Acme", "summary:"}) {
+		t.Fatalf("expected every kind that mentions checkout, got %v", got)
+	}
+	for _, res := range results {
+		if !strings.Contains(res.Snippet, config.SnippetOpen) || strings.Contains(res.Snippet, "li>") {
+			t.Errorf("expected a marked snippet without the editor's HTML, got %q", res.Snippet)
+		}
+		if res.Kind == config.SearchCommit && res.Project == "" {
+			t.Errorf("expected the commit's project, got %+v", res)
+		}
+		if res.Kind == config.SearchExperience && res.ResumeID != r.ID {
+			t.Errorf("expected the experience's resume, got %+v", res)
+		}
+	}
+
+	if got := search(config.SearchQuery{Text: "checkout payments"}); !reflect.DeepEqual(got, []string{"experience:Acme"}) {
+		t.Errorf("expected every word to be required, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "fix check"}); !reflect.DeepEqual(got, []string{"commit:b1"}) {
+		t.Errorf("expected the last word to match as a prefix, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "tracking", Kinds: []string{config.SearchProject}}); !reflect.DeepEqual(got, []string{"project:Tracker"}) {
+		t.Errorf("expected the project, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "checkout", ProjectID: 2}); !reflect.DeepEqual(got, []string{"commit:b1"}) {
+		t.Errorf("expected only the blog project, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "checkout", Kinds: []string{config.SearchCommit}, Since: "2025-02-01"}); !reflect.DeepEqual(got, []string{"commit:b1"}) {
+		t.Errorf("expected commits since the date, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "init", Until: "2024-12-31"}); !reflect.DeepEqual(got, []string{"commit:h0"}) {
+		t.Errorf("expected commits until the date, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "checkout", Limit: 2}); len(got) != 2 {
+		t.Errorf("expected the limit to apply, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: `"checkout*" ^(`}); len(got) != 4 {
+		t.Errorf("expected query syntax to be read as words, got %v", got)
+	}
+	if got := must(db.Search(config.SearchQuery{Text: " !? "}))(t); got == nil || len(got) != 0 {
+		t.Errorf("expected no results for an empty query, got %#v", got)
+	}
+
+	// edits and deletes are searchable straight away
+	must(db.CreateOrUpdateWorkExperiences(r.ID, []git.WorkExperience{{ID: work[0], Company: "Acme", Role: "Engineer", Responsibilities: "Owned billing"}}))(t)
+	if got := search(config.SearchQuery{Text: "checkout", Kinds: []string{config.SearchExperience}}); len(got) != 0 {
+		t.Errorf("expected the edited experience not to match, got %v", got)
+	}
+	if got := search(config.SearchQuery{Text: "billing"}); !reflect.DeepEqual(got, []string{"experience:Acme"}) {
+		t.Errorf("expected the edited experience to match, got %v", got)
+	}
+	check(t, db.DeleteProjectWorkedOn(worked[0]))
+	if got := search(config.SearchQuery{Text: "tracking"}); len(got) != 0 {
+		t.Errorf("expected the deleted project not to match, got %v", got)
+	}
+}
+
+func revisionNotes(t *testing.T, db database.IDatabase, rID int64) []string {
+	t.Helper()
+	notes := []string{}
+	for i, r := range must(db.GetResumeRevisions(rID))(t) {
+		if r.Revision != i+1 || r.Resume != nil {
+			t.Errorf("unexpected revision in list %+v", r)
+		}
+		notes = append(notes, r.Note)
+	}
+	return notes
+}
+
+func testResumeRevisions(t *testing.T, db database.IDatabase) {
+	db = seedUser(t, db)
+	r := must(db.CreateResume(git.Resume{Title: "Backend", Version: 1, Skills: []string{"Go"}}))(t)
+	must(db.UpdateResume(r.ID, git.Resume{Title: "Platform"}))(t)
+	// a save that changes nothing records nothing
+	must(db.UpdateResume(r.ID, git.Resume{Title: "Platform"}))(t)
+	work := must(db.CreateOrUpdateWorkExperiences(r.ID, []git.WorkExperience{{Company: "Acme", Role: "Engineer", Responsibilities: "<ul><li>Ran Kafka</li></ul>"}}))(t)
+	must(db.CreateOrUpdateEducation(r.ID, []git.Education{{School: "MIT", Degree: "BSc"}}))(t)
+	check(t, db.DeleteWorkExperience(work[0]))
+
+	want := []string{"Created", "Updated details", "Saved work experience", "Saved education", "Deleted work experience"}
+	if notes := revisionNotes(t, db, r.ID); !reflect.DeepEqual(notes, want) {
+		t.Fatalf("expected revisions %v, got %v", want, notes)
+	}
+
+	rev := must(db.GetResumeRevision(r.ID, 3))(t)
+	if rev.Resume == nil || rev.Resume.Title != "Platform" || len(rev.Resume.WorkExperiences) != 1 || len(rev.Resume.Education) != 0 {
+		t.Fatalf("unexpected snapshot %+v", rev.Resume)
+	}
+	if rev.Resume.Profile.Name != "Ada" || rev.Resume.Profile.PasswordHash != "" {
+		t.Errorf("expected the profile without its password, got %+v", rev.Resume.Profile)
+	}
+	if _, err := db.GetResumeRevision(r.ID, 99); err == nil {
+		t.Error("expected an error for a missing revision")
+	}
+
+	check(t, db.RestoreResumeRevision(r.ID, 3))
+	got := must(db.GetResume(r.ID))(t)
+	if got.Title != "Platform" || len(got.WorkExperiences) != 1 || got.WorkExperiences[0].ID != work[0] || len(got.Education) != 0 {
+		t.Errorf("expected revision 3 back with its IDs, got %+v", got)
+	}
+	if notes := revisionNotes(t, db, r.ID); len(notes) != 6 || notes[5] != "Restored revision 3" {
+		t.Errorf("expected the restore to be recorded, got %v", notes)
+	}
+	if changes := git.DiffRevisions(*rev.Resume, *must(db.GetResumeRevision(r.ID, 6))(t).Resume); len(changes) != 0 {
+		t.Errorf("expected the restored revision to match, got %+v", changes)
+	}
+
+	// restoring the first revision clears what was added since
+	check(t, db.RestoreResumeRevision(r.ID, 1))
+	got = must(db.GetResume(r.ID))(t)
+	if got.Title != "Backend" || len(got.WorkExperiences) != 0 || !reflect.DeepEqual(got.Skills, []string{"Go"}) {
+		t.Errorf("expected revision 1 back, got %+v", got)
+	}
+
+	check(t, db.DeleteResume(r.ID))
+	if revisions := must(db.GetResumeRevisions(r.ID))(t); len(revisions) != 0 {
+		t.Errorf("expected revisions to go with the resume, got %+v", revisions)
+	}
+}
@@ -1,15 +1,19 @@
 package drivers
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
-
-	"path/filepath"
-
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/iamhabbeboy/gitresume/config"
 	"github.com/iamhabbeboy/gitresume/internal/git"
 	"github.com/iamhabbeboy/gitresume/util"
@@ -18,9 +22,15 @@ import (
 
 const DEV_COMMIT_DB_FILE = "gitresume_bolt.db"
 
+// Db stores every table of the SQLite schema as a bucket of JSON records
+// keyed by a big-endian sequence ID, so IDs match what SQLite would assign.
+// It needs no cgo.
 type Db struct {
 	Db   *bolt.DB
 	Name string
+	// uID is the user projects, resumes, jobs and prompts are read and
+	// written for; 0 until ForUser picks one.
+	uID int64
 }
 
 type KV struct {
@@ -28,176 +38,484 @@ type KV struct {
 	Value string `json:"value"`
 }
 
+var (
+	usersBucket          = []byte("users")
+	projectsBucket       = []byte(util.PROJECT_BUCKET)
+	commitsBucket        = []byte("commits")
+	summariesBucket      = []byte("commit_summary")
+	resumesBucket        = []byte("resumes")
+	jobsBucket           = []byte("jobs")
+	workBucket           = []byte("work_experiences")
+	educationBucket      = []byte("educations")
+	volunteeringBucket   = []byte("volunteering")
+	projectWorkedBucket  = []byte("project_worked_on")
+	promptsBucket        = []byte("prompts")
+	promptVersionsBucket = []byte("prompt_versions")
+	promptEvalsBucket    = []byte("prompt_evals")
+	usageBucket          = []byte("ai_usage")
+	auditBucket          = []byte("ai_audit")
+	embeddingsBucket     = []byte("embeddings")
+	suggestionsBucket    = []byte("suggestions")
+	revisionsBucket      = []byte("resume_revisions")
+)
+
+var boltBuckets = [][]byte{
+	usersBucket, projectsBucket, commitsBucket, summariesBucket, resumesBucket, jobsBucket,
+	workBucket, educationBucket, volunteeringBucket, projectWorkedBucket, promptsBucket,
+	promptVersionsBucket, promptEvalsBucket, usageBucket, auditBucket, embeddingsBucket, suggestionsBucket,
+	revisionsBucket,
+}
+
+// Records that need more than the shared types carry.
+type (
+	boltProject struct {
+		git.Project
+		UserID    int64  `json:"user_id"`
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	boltCommit struct {
+		git.GitCommit
+		ProjectID int `json:"project_id"`
+	}
+	boltSummary struct {
+		ID           int64  `json:"id"`
+		ProjectID    int    `json:"project_id"`
+		CommitID     int    `json:"commit_id"`
+		Summary      string `json:"summary"`
+		SuggestionID int64  `json:"suggestion_id"`
+		CreatedAt    string `json:"created_at"`
+		UpdatedAt    string `json:"updated_at"`
+	}
+	boltJob struct {
+		git.Job
+		UserID int64 `json:"user_id"`
+	}
+	boltPrompt struct {
+		config.CustomPrompt
+		UserID int64 `json:"user_id"`
+	}
+	boltPromptVersion struct {
+		config.PromptVersion
+		UserID int64 `json:"user_id"`
+	}
+	boltPromptEval struct {
+		config.PromptEval
+		UserID int64 `json:"user_id"`
+	}
+	boltSuggestion struct {
+		config.Suggestion
+		UserID int64 `json:"user_id"`
+	}
+	boltSection[T any] struct {
+		ResumeID int64 `json:"resume_id"`
+		Item     T     `json:"item"`
+	}
+	boltEmbedding struct {
+		config.Embedding
+		Vector []float32 `json:"vector"`
+		UserID int64     `json:"user_id"`
+	}
+	boltRevision struct {
+		git.ResumeRevision
+		Snapshot string `json:"snapshot"`
+	}
+)
+
 func NewBolt() (*Db, error) {
-	name := util.PROJECT_BUCKET
-	store := filepath.Join(os.Getenv("HOME"), ".gitresume", DEV_COMMIT_DB_FILE)
-	db, _ := bolt.Open(store, 0600, nil)
-	return &Db{Db: db, Name: name}, nil
+	home, _ := os.UserHomeDir()
+	store := filepath.Join(home, "."+util.APP_NAME, DEV_COMMIT_DB_FILE)
+	if err := os.MkdirAll(filepath.Dir(store), 0755); err != nil {
+		return nil, err
+	}
+	// bbolt locks the file; fail instead of waiting forever on another process
+	db, err := bolt.Open(store, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	d := &Db{Db: db, Name: util.PROJECT_BUCKET}
+	if err := d.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return d, nil
 }
 
 func (d *Db) Close() error {
 	return d.Db.Close()
 }
 
+// Migrate creates the buckets. Records are JSON, so new fields need no
+// migration.
 func (d *Db) Migrate() error {
-	return nil
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if err := adoptLegacyProjects(tx); err != nil {
+			return err
+		}
+		return adoptUnowned(tx)
+	})
 }
 
-func (c *Db) CreateOrUpdateWorkExperiences(rID int64, w []git.WorkExperience) ([]int64, error) {
-	return nil, nil
+// firstBoltUser is the ID of the oldest user, or 1, the ID the first user
+// will get.
+func firstBoltUser(tx *bolt.Tx) int64 {
+	if k, _ := tx.Bucket(usersBucket).Cursor().First(); k != nil {
+		return int64(binary.BigEndian.Uint64(k))
+	}
+	return 1
 }
 
-func (c *Db) DeleteWorkExperience(wID int64) error {
+// ownedBuckets hold records that got an owner after they were first
+// stored.
+var ownedBuckets = [][]byte{promptsBucket, promptVersionsBucket, promptEvalsBucket, embeddingsBucket, suggestionsBucket,
+	usageBucket, auditBucket}
+
+// adoptUnowned gives the records of ownedBuckets stored without a user to
+// the first user, as migration 0004 does in SQLite.
+func adoptUnowned(tx *bolt.Tx) error {
+	owner, err := json.Marshal(firstBoltUser(tx))
+	if err != nil {
+		return err
+	}
+	for _, name := range ownedBuckets {
+		b := tx.Bucket(name)
+		adopted := map[string][]byte{}
+		err := b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+			var rec map[string]json.RawMessage
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if id := string(rec["user_id"]); id != "" && id != "0" {
+				return nil
+			}
+			rec["user_id"] = owner
+			data, err := json.Marshal(rec)
+			adopted[string(k)] = data
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for k, v := range adopted {
+			if err := b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (s *Db) UpdateResume(uID int64, req git.Resume) (int64, error) {
-	return 0, nil
-}
+// adoptLegacyProjects converts projects stored by older versions, one
+// sub-bucket per project keyed by a uuid, into numbered records.
+func adoptLegacyProjects(tx *bolt.Tx) error {
+	projects := tx.Bucket(projectsBucket)
+	var legacy [][]byte
+	err := projects.ForEach(func(k, v []byte) error {
+		if v == nil {
+			legacy = append(legacy, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-func (s *Db) UpdateUser(uID int64, req git.Profile) error {
+	for _, k := range legacy {
+		sub := projects.Bucket(k)
+		p := git.Project{Name: string(sub.Get([]byte("name")))}
+		// commit:N keys hold the commits in git log order, newest first
+		byIndex := map[int]git.GitCommit{}
+		err := sub.ForEach(func(ck, cv []byte) error {
+			n, ok := strings.CutPrefix(string(ck), "commit:")
+			if !ok {
+				return nil
+			}
+			i, err := strconv.Atoi(n)
+			if err != nil {
+				return nil
+			}
+			var commit git.GitCommit
+			if err := json.Unmarshal(cv, &commit); err != nil {
+				return err
+			}
+			byIndex[i] = commit
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		order := make([]int, 0, len(byIndex))
+		for i := range byIndex {
+			order = append(order, i)
+		}
+		sort.Ints(order)
+		for _, i := range order {
+			p.Commits = append(p.Commits, byIndex[i])
+		}
+		if err := projects.DeleteBucket(k); err != nil {
+			return err
+		}
+		if err := createBoltProject(tx, firstBoltUser(tx), p); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (c *Db) DeleteResume(rID int64) error {
-	return nil
+func (d *Db) MigrationStatus() ([]config.Migration, error) {
+	return nil, nil
 }
 
-func (s *Db) GetResumes() ([]git.Resume, error) {
-	return nil, nil
+func (d *Db) Rollback(steps int) ([]config.Migration, error) {
+	return nil, errors.New("the bolt database has no schema migrations")
 }
 
-func (d *Db) GetUser(email string) (git.Profile, error) {
-	return git.Profile{}, nil
+// CheckIntegrity looks for duplicate commits, orphaned commit summaries and
+// records that reference a missing record, and fixes them with repair set.
+// Users are not followed: projects adopted before any user existed belong to
+// the first user to be created.
+func (d *Db) CheckIntegrity(repair bool) ([]config.IntegrityIssue, error) {
+	var issues []config.IntegrityIssue
+	check := func(tx *bolt.Tx) error {
+		issues = []config.IntegrityIssue{}
+		for _, c := range []func(*bolt.Tx, bool) ([]config.IntegrityIssue, error){
+			boltDuplicateCommits, boltDanglingReferences, boltOrphanedSummaries,
+		} {
+			found, err := c(tx, repair)
+			if err != nil {
+				return err
+			}
+			issues = append(issues, found...)
+		}
+		return nil
+	}
+	if repair {
+		return issues, d.Db.Update(check)
+	}
+	return issues, d.Db.View(check)
 }
 
-func (d *Db) GetUserByID(uID int32) (git.Profile, error) {
-	return git.Profile{}, nil
+func boltDuplicateCommits(tx *bolt.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	commits := tx.Bucket(commitsBucket)
+	rows, err := all[boltCommit](commits, nil)
+	if err != nil {
+		return nil, err
+	}
+	first := map[string]int{}
+	kept := map[int]int{}
+	for _, c := range rows {
+		k := fmt.Sprintf("%d/%s", c.ProjectID, c.Hash)
+		if id, ok := first[k]; ok {
+			kept[c.ID] = id
+		} else {
+			first[k] = c.ID
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+
+	if repair {
+		// keep the first copy; its summary wins over those of the others
+		summaries := tx.Bucket(summariesBucket)
+		sums, err := all[boltSummary](summaries, nil)
+		if err != nil {
+			return nil, err
+		}
+		has := map[int]bool{}
+		for _, s := range sums {
+			has[s.CommitID] = true
+		}
+		for _, s := range sums {
+			id, ok := kept[s.CommitID]
+			if !ok {
+				continue
+			}
+			if has[id] {
+				err = summaries.Delete(itob(s.ID))
+			} else {
+				s.CommitID, has[id] = id, true
+				err = put(summaries, s.ID, s)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		for id := range kept {
+			if err := commits.Delete(itob(int64(id))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return []config.IntegrityIssue{{Check: config.CheckDuplicateCommits, Table: string(commitsBucket), Count: int64(len(kept)), Repaired: repair}}, nil
 }
 
-func (d *Db) GetResume(ID int64) (git.Resume, error) {
-	return git.Resume{}, nil
+// boltRefs are the references CheckIntegrity follows, parents first so a
+// repair also removes the children of removed records.
+var boltRefs = []struct {
+	bucket []byte
+	field  string
+	parent []byte
+}{
+	{commitsBucket, "project_id", projectsBucket},
+	{workBucket, "resume_id", resumesBucket},
+	{educationBucket, "resume_id", resumesBucket},
+	{volunteeringBucket, "resume_id", resumesBucket},
+	{projectWorkedBucket, "resume_id", resumesBucket},
+	{revisionsBucket, "resume_id", resumesBucket},
 }
 
-func (c *Db) DeleteEducation(wID int64) error {
-	return nil
+func boltDanglingReferences(tx *bolt.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	issues := []config.IntegrityIssue{}
+	for _, ref := range boltRefs {
+		parent := tx.Bucket(ref.parent)
+		dangling := func(r map[string]json.RawMessage) bool {
+			var id int64
+			if err := json.Unmarshal(r[ref.field], &id); err != nil {
+				return false
+			}
+			return id != 0 && parent.Get(itob(id)) == nil
+		}
+		n, err := countOrDelete(tx.Bucket(ref.bucket), dangling, repair)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			issues = append(issues, config.IntegrityIssue{
+				Check:     config.CheckDanglingReferences,
+				Table:     string(ref.bucket),
+				Reference: ref.field + " -> " + string(ref.parent),
+				Count:     n,
+				Repaired:  repair,
+			})
+		}
+	}
+	return issues, nil
 }
 
-func (s *Db) CreateOrUpdateEducation(rID int64, data []git.Education) ([]int64, error) {
-	return nil, nil
+func boltOrphanedSummaries(tx *bolt.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	projects, commits := tx.Bucket(projectsBucket), tx.Bucket(commitsBucket)
+	orphaned := func(s boltSummary) bool {
+		return projects.Get(itob(int64(s.ProjectID))) == nil ||
+			(s.CommitID != 0 && commits.Get(itob(int64(s.CommitID))) == nil)
+	}
+	n, err := countOrDelete(tx.Bucket(summariesBucket), orphaned, repair)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	return []config.IntegrityIssue{{Check: config.CheckOrphanedSummaries, Table: string(summariesBucket), Count: n, Repaired: repair}}, nil
 }
 
-func (d *Db) CreateUser(data git.Profile) (int64, error) {
-	return 0, nil
+// countOrDelete counts the records of b that match, deleting them too when
+// remove is set.
+func countOrDelete[T any](b *bolt.Bucket, match func(T) bool, remove bool) (int64, error) {
+	if remove {
+		return deleteWhere(b, match)
+	}
+	rows, err := all(b, match)
+	return int64(len(rows)), err
 }
 
-func (d *Db) CreateResume(r git.Resume) (git.Resume, error) {
-	return git.Resume{}, nil
+func itob(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
 }
 
-func (d *Db) GetProjectByName(name string) (git.Project, error) {
-	return git.Project{}, nil
+func boltNow() string {
+	return time.Now().UTC().Format(time.DateTime)
 }
 
-func (d *Db) Delete(key string) error {
-	err := d.Db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(d.Name))
-		err := b.Delete([]byte(key))
-		return err
-	})
+func nextID(b *bolt.Bucket) (int64, error) {
+	seq, err := b.NextSequence()
+	return int64(seq), err
+}
 
+func put(b *bolt.Bucket, id int64, v any) error {
+	data, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
-	return nil
+	return b.Put(itob(id), data)
 }
 
-func (d *Db) GetCommitById(id int) (git.GitCommit, error) {
-	return git.GitCommit{}, nil
-}
-
-func (s *Db) UpsertCommit(commits []git.CustomUpdateCommit) error {
-	return nil
+func get[T any](b *bolt.Bucket, id int64) (T, bool, error) {
+	var v T
+	data := b.Get(itob(id))
+	if data == nil {
+		return v, false, nil
+	}
+	return v, true, json.Unmarshal(data, &v)
 }
 
-func (s *Db) GetAllCommitSummary(projectID int) ([]git.CustomUpdateCommit, error) {
-	return nil, nil
+// all decodes the records of b in ID order, keeping those keep accepts; a
+// nil keep accepts every record.
+func all[T any](b *bolt.Bucket, keep func(T) bool) ([]T, error) {
+	var out []T
+	err := b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			// a nested bucket left by the old project layout
+			return nil
+		}
+		var r T
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		if keep == nil || keep(r) {
+			out = append(out, r)
+		}
+		return nil
+	})
+	return out, err
 }
 
-func (d *Db) CreateProject(data git.Project) error {
-	key := uuid.New().String()
-	err := d.Db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(d.Name))
-		if err != nil {
+// deleteWhere removes the records of b that match and returns how many.
+func deleteWhere[T any](b *bolt.Bucket, match func(T) bool) (int64, error) {
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		var r T
+		if err := json.Unmarshal(v, &r); err != nil {
 			return err
 		}
-		sub, _ := bucket.CreateBucketIfNotExists([]byte(key))
-		sub.Put([]byte("name"), []byte(data.Name))
-
-		for k, v := range data.Commits {
-			data, _ := json.Marshal(v)
-			index := []byte(fmt.Appendf(nil, "commit:%d", k+1))
-			sub.Put(index, []byte(data))
+		if match(r) {
+			keys = append(keys, k)
 		}
 		return nil
 	})
-
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(keys)), nil
 }
 
-func (d *Db) GetAllProject(limit, offset int) ([]git.Project, error) {
-	var results []git.Project
-
-	err := d.Db.View(func(tx *bolt.Tx) error {
-		root := tx.Bucket([]byte(d.Name))
-		if root == nil {
-			return nil // no projects yet
+// Delete removes the user's project named key, like DeleteProject.
+func (d *Db) Delete(key string) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		found, err := all(tx.Bucket(projectsBucket), func(p boltProject) bool { return p.Name == key && p.UserID == d.uID })
+		if err != nil {
+			return err
 		}
-
-		return root.ForEach(func(k, v []byte) error {
-			// k = sub-bucket name (project ID), v = nil since it's a bucket
-			if v != nil {
-				return nil
-			}
-
-			sub := root.Bucket(k)
-			if sub == nil {
-				return nil
-			}
-
-			// get project name
-			name := string(sub.Get([]byte("name")))
-
-			// get commits
-			var commits []git.GitCommit
-			c := sub.Cursor()
-			for ck, cv := c.First(); ck != nil; ck, cv = c.Next() {
-				if string(ck) == "name" {
-					continue
-				}
-				if len(ck) >= 7 && string(ck[:7]) == "commit:" {
-					var commit git.GitCommit
-					if err := json.Unmarshal(cv, &commit); err != nil {
-						return err
-					}
-					commits = append(commits, commit)
-				}
-			}
-			_id, _ := strconv.Atoi(string(k))
-			results = append(results, git.Project{
-				ID:      _id, // 👈 the sub-bucket key (project ID)
-				Name:    name,
-				Commits: commits,
-			})
-
-			return nil
-		})
+		if len(found) == 0 {
+			return fmt.Errorf("project %q not found", key)
+		}
+		return deleteBoltProject(tx, found[0].ID)
 	})
-
-	return results, err
 }
 
 func (d *Db) GetAll() (error, []KV) {
@@ -219,26 +537,1739 @@ func (d *Db) GetAll() (error, []KV) {
 	return nil, result
 }
 
-func (d *Db) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
-	return nil
+func (d *Db) GetUser(email string) (git.Profile, error) {
+	var p git.Profile
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		users, err := all(tx.Bucket(usersBucket), func(u git.Profile) bool { return u.Email == email })
+		if err != nil || len(users) == 0 {
+			return err
+		}
+		p = git.Profile{ID: users[0].ID, Name: users[0].Name}
+		return nil
+	})
+	return p, err
 }
 
-func (d *Db) GetLLmPromptConfig() ([]config.CustomPrompt, error) {
-	return nil, nil
+func (d *Db) CreateUser(data git.Profile) (int64, error) {
+	hash, err := hashPassword(data.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		dup, err := all(b, func(u git.Profile) bool { return u.Email == data.Email })
+		if err != nil {
+			return err
+		}
+		if len(dup) > 0 {
+			return fmt.Errorf("a user with email %s already exists", data.Email)
+		}
+		if id, err = nextID(b); err != nil {
+			return err
+		}
+		return put(b, id, git.Profile{ID: int32(id), Name: data.Name, Email: data.Email, PasswordHash: hash})
+	})
+	return id, err
 }
 
-func (d *Db) CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]int64, error) {
-	return nil, nil
+// ForUser returns a handle on the same file that reads and writes as the
+// user uID.
+func (d *Db) ForUser(uID int64) IDatabase {
+	c := *d
+	c.uID = uID
+	return &c
 }
 
-func (d *Db) CreateOrUpdateProjectOn(rID int64, v []git.ProjectWorkedOn) ([]int64, error) {
-	return nil, nil
+func (d *Db) UserID() int64 {
+	return d.uID
 }
 
-func (d *Db) DeleteProjectWorkedOn(pID int64) error {
-	return nil
+// owner is the user new records belong to.
+func (d *Db) owner() (int64, error) {
+	if d.uID == 0 {
+		return 0, errNoUser
+	}
+	return d.uID, nil
 }
 
-func (d *Db) DeleteVolunteer(pID int64) error {
-	return nil
+func (d *Db) GetUsers() ([]git.Profile, error) {
+	users := []git.Profile{}
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all[git.Profile](tx.Bucket(usersBucket), nil)
+		for _, u := range rows {
+			users = append(users, git.Profile{ID: u.ID, Name: u.Name, Email: u.Email})
+		}
+		return err
+	})
+	return users, err
+}
+
+func (d *Db) Authenticate(email, password string) (git.Profile, error) {
+	var (
+		u      git.Profile
+		rehash bool
+	)
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		found, err := all(tx.Bucket(usersBucket), func(u git.Profile) bool { return u.Email == email })
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return config.ErrInvalidCredentials
+		}
+		if rehash, err = checkPassword(found[0].PasswordHash, password); err != nil {
+			return err
+		}
+		u = git.Profile{ID: found[0].ID, Name: found[0].Name, Email: found[0].Email}
+		return nil
+	})
+	if err != nil {
+		return git.Profile{}, err
+	}
+	if rehash {
+		err = d.SetPassword(int64(u.ID), password)
+	}
+	return u, err
+}
+
+func (d *Db) SetPassword(uID int64, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		u, ok, err := get[git.Profile](b, uID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("record with ID not found")
+		}
+		u.PasswordHash = hash
+		return put(b, uID, u)
+	})
+}
+
+func (d *Db) GetUserByID(id int32) (git.Profile, error) {
+	var p git.Profile
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		u, _, err := get[git.Profile](tx.Bucket(usersBucket), int64(id))
+		u.PasswordHash = ""
+		p = u
+		return err
+	})
+	return p, err
+}
+
+func (d *Db) UpdateUser(uID int64, req git.Profile) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		return updateBoltUser(tx, uID, req)
+	})
+}
+
+func updateBoltUser(tx *bolt.Tx, uID int64, req git.Profile) error {
+	b := tx.Bucket(usersBucket)
+	u, ok, err := get[git.Profile](b, uID)
+	if err != nil || !ok {
+		return err
+	}
+	if req.Name != "" {
+		u.Name = req.Name
+	}
+	if req.Location != "" {
+		u.Location = req.Location
+	}
+	if req.Phone != "" {
+		u.Phone = req.Phone
+	}
+	if req.ProfessionalSummary != "" {
+		u.ProfessionalSummary = req.ProfessionalSummary
+	}
+	if req.Links != nil {
+		u.Links = req.Links
+	}
+	return put(b, uID, u)
+}
+
+func (d *Db) CreateProject(data git.Project) error {
+	uID, err := d.owner()
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		return createBoltProject(tx, uID, data)
+	})
+}
+
+func createBoltProject(tx *bolt.Tx, uID int64, data git.Project) error {
+	projects := tx.Bucket(projectsBucket)
+	found, err := all(projects, func(p boltProject) bool { return p.Name == data.Name && p.UserID == uID })
+	if err != nil {
+		return err
+	}
+
+	var prjID int
+	if len(found) > 0 {
+		prjID = found[0].ID
+	} else {
+		id, err := nextID(projects)
+		if err != nil {
+			return err
+		}
+		prjID = int(id)
+		now := boltNow()
+		p := boltProject{
+			Project:   git.Project{ID: prjID, Name: data.Name, Path: data.Path, Technologies: data.Technologies},
+			UserID:    uID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := put(projects, id, p); err != nil {
+			return err
+		}
+	}
+
+	// git log lists the newest commit first; store the oldest first
+	commits := slices.Clone(data.Commits)
+	slices.Reverse(commits)
+	_, err = addBoltCommits(tx, prjID, commits)
+	return err
+}
+
+func (d *Db) AddCommits(projectID int, commits []git.GitCommit) (int, error) {
+	uID, err := d.owner()
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	err = d.Db.Update(func(tx *bolt.Tx) error {
+		ok, err := boltOwnsProject(tx, uID, projectID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("project %d not found", projectID)
+		}
+		added, err = addBoltCommits(tx, projectID, commits)
+		return err
+	})
+	return added, err
+}
+
+// addBoltCommits stores commits in order and skips those already stored,
+// say by a seed that failed halfway.
+func addBoltCommits(tx *bolt.Tx, prjID int, commits []git.GitCommit) (int, error) {
+	if len(commits) == 0 {
+		return 0, nil
+	}
+	b := tx.Bucket(commitsBucket)
+	stored, err := all(b, func(c boltCommit) bool { return c.ProjectID == prjID })
+	if err != nil {
+		return 0, err
+	}
+	seen := make(map[string]bool, len(stored))
+	for _, c := range stored {
+		seen[c.Hash] = true
+	}
+	added := 0
+	for _, v := range commits {
+		if seen[v.Hash] {
+			continue
+		}
+		seen[v.Hash] = true
+		id, err := nextID(b)
+		if err != nil {
+			return added, err
+		}
+		now := boltNow()
+		c := boltCommit{GitCommit: git.GitCommit{ID: int(id), Hash: v.Hash, Msg: v.Msg, Date: v.Date, CreatedAt: now, UpdatedAt: now}, ProjectID: prjID}
+		if err := put(b, id, c); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// boltOwnsProject reports whether the project belongs to the user.
+func boltOwnsProject(tx *bolt.Tx, uID int64, prjID int) (bool, error) {
+	p, ok, err := get[boltProject](tx.Bucket(projectsBucket), int64(prjID))
+	return ok && p.UserID == uID, err
+}
+
+// boltOwnsResume reports whether the resume belongs to the user.
+func boltOwnsResume(tx *bolt.Tx, uID int64, rID int64) (bool, error) {
+	r, ok, err := get[git.Resume](tx.Bucket(resumesBucket), rID)
+	return ok && int64(r.UserID) == uID, err
+}
+
+func projectCommits(tx *bolt.Tx, prjID int) ([]git.GitCommit, error) {
+	rows, err := all(tx.Bucket(commitsBucket), func(c boltCommit) bool { return c.ProjectID == prjID })
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]git.GitCommit, 0, len(rows))
+	for _, c := range rows {
+		commits = append(commits, c.GitCommit)
+	}
+	return commits, nil
+}
+
+func (d *Db) GetProjectByName(name string) (git.Project, error) {
+	var p git.Project
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		found, err := all(tx.Bucket(projectsBucket), func(p boltProject) bool { return p.Name == name && p.UserID == d.uID })
+		if err != nil || len(found) == 0 {
+			return err
+		}
+		p = found[0].Project
+		p.Commits, err = projectCommits(tx, p.ID)
+		return err
+	})
+	return p, err
+}
+
+func (d *Db) GetAllProject(limit, offset int) ([]git.Project, error) {
+	var projects []git.Project
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(projectsBucket), func(p boltProject) bool { return p.UserID == d.uID && !p.Archived })
+		if err != nil {
+			return err
+		}
+		if offset > 0 {
+			rows = rows[min(offset, len(rows)):]
+		}
+		if limit > 0 && len(rows) > limit {
+			rows = rows[:limit]
+		}
+		for _, r := range rows {
+			p := r.Project
+			if p.Commits, err = projectCommits(tx, p.ID); err != nil {
+				return err
+			}
+			projects = append(projects, p)
+		}
+		return nil
+	})
+	return projects, err
+}
+
+// ownedProject returns project id when it belongs to the user and an error
+// otherwise.
+func ownedProject(tx *bolt.Tx, uID int64, id int) (boltProject, error) {
+	p, ok, err := get[boltProject](tx.Bucket(projectsBucket), int64(id))
+	if err != nil {
+		return p, err
+	}
+	if !ok || p.UserID != uID {
+		return p, fmt.Errorf("project %d not found", id)
+	}
+	return p, nil
+}
+
+func (d *Db) GetProject(id int) (git.Project, error) {
+	var p git.Project
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		found, err := ownedProject(tx, d.uID, id)
+		if err != nil {
+			return err
+		}
+		p = found.Project
+		p.Commits, err = projectCommits(tx, p.ID)
+		return err
+	})
+	return p, err
+}
+
+func (d *Db) ListProjects(archived bool) ([]git.Project, error) {
+	projects := []git.Project{}
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(projectsBucket), func(p boltProject) bool {
+			return p.UserID == d.uID && (archived || !p.Archived)
+		})
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		counts := map[int]int{}
+		err = tx.Bucket(commitsBucket).ForEach(func(_, v []byte) error {
+			var c boltCommit
+			if v == nil {
+				return nil
+			}
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			counts[c.ProjectID]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			p := r.Project
+			p.Commits = nil
+			p.CommitCount = counts[p.ID]
+			projects = append(projects, p)
+		}
+		return nil
+	})
+	return projects, err
+}
+
+func (d *Db) UpdateProject(p git.Project) error {
+	if p.Name = strings.TrimSpace(p.Name); p.Name == "" {
+		return errors.New("project name cannot be empty")
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		found, err := ownedProject(tx, d.uID, p.ID)
+		if err != nil {
+			return err
+		}
+		b := tx.Bucket(projectsBucket)
+		taken, err := all(b, func(o boltProject) bool { return o.UserID == d.uID && o.Name == p.Name && o.ID != p.ID })
+		if err != nil {
+			return err
+		}
+		if len(taken) > 0 {
+			return fmt.Errorf("%w: %s", config.ErrProjectExists, p.Name)
+		}
+		found.Name, found.Path, found.Technologies = p.Name, p.Path, p.Technologies
+		found.UpdatedAt = boltNow()
+		return put(b, int64(found.ID), found)
+	})
+}
+
+func (d *Db) ArchiveProject(id int, archived bool) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		p, err := ownedProject(tx, d.uID, id)
+		if err != nil {
+			return err
+		}
+		p.Archived = archived
+		p.UpdatedAt = boltNow()
+		return put(tx.Bucket(projectsBucket), int64(id), p)
+	})
+}
+
+func (d *Db) DeleteProject(id int) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		if _, err := ownedProject(tx, d.uID, id); err != nil {
+			return err
+		}
+		return deleteBoltProject(tx, id)
+	})
+}
+
+// deleteBoltProject removes a project with its commits, summaries and
+// suggestions.
+func deleteBoltProject(tx *bolt.Tx, id int) error {
+	if err := tx.Bucket(projectsBucket).Delete(itob(int64(id))); err != nil {
+		return err
+	}
+	if _, err := deleteWhere(tx.Bucket(commitsBucket), func(c boltCommit) bool { return c.ProjectID == id }); err != nil {
+		return err
+	}
+	if _, err := deleteWhere(tx.Bucket(summariesBucket), func(s boltSummary) bool { return s.ProjectID == id }); err != nil {
+		return err
+	}
+	_, err := deleteWhere(tx.Bucket(suggestionsBucket), func(s boltSuggestion) bool { return s.ProjectID == id })
+	return err
+}
+
+func (d *Db) DeleteCommits(projectID int, hashes []string) (int, error) {
+	gone := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		gone[h] = true
+	}
+	deleted := 0
+	err := d.Db.Update(func(tx *bolt.Tx) error {
+		if _, err := ownedProject(tx, d.uID, projectID); err != nil {
+			return err
+		}
+		commits, err := all(tx.Bucket(commitsBucket), func(c boltCommit) bool { return c.ProjectID == projectID && gone[c.Hash] })
+		if err != nil {
+			return err
+		}
+		ids := make(map[int]bool, len(commits))
+		for _, c := range commits {
+			ids[c.ID] = true
+		}
+		n, err := deleteWhere(tx.Bucket(commitsBucket), func(c boltCommit) bool { return ids[c.ID] })
+		if err != nil {
+			return err
+		}
+		deleted = int(n)
+		_, err = deleteWhere(tx.Bucket(summariesBucket), func(s boltSummary) bool { return ids[s.CommitID] })
+		return err
+	})
+	return deleted, err
+}
+
+func (d *Db) ExpandCommitHashes(projectID int, full map[string]string) (int, error) {
+	expanded := 0
+	err := d.Db.Update(func(tx *bolt.Tx) error {
+		if _, err := ownedProject(tx, d.uID, projectID); err != nil {
+			return err
+		}
+		b := tx.Bucket(commitsBucket)
+		commits, err := all(b, func(c boltCommit) bool { return c.ProjectID == projectID })
+		if err != nil {
+			return err
+		}
+		byHash := make(map[string]boltCommit, len(commits))
+		for _, c := range commits {
+			byHash[c.Hash] = c
+		}
+		// a rescan may have stored the commit again; its summary moves to
+		// the first copy unless that has one
+		kept := map[int]int{}
+		for short, hash := range full {
+			c, ok := byHash[short]
+			if !ok {
+				continue
+			}
+			if dup, ok := byHash[hash]; ok {
+				kept[dup.ID] = c.ID
+				if err := b.Delete(itob(int64(dup.ID))); err != nil {
+					return err
+				}
+			}
+			c.Hash = hash
+			if err := put(b, int64(c.ID), c); err != nil {
+				return err
+			}
+			expanded++
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+		summaries := tx.Bucket(summariesBucket)
+		sums, err := all[boltSummary](summaries, nil)
+		if err != nil {
+			return err
+		}
+		has := map[int]bool{}
+		for _, s := range sums {
+			has[s.CommitID] = true
+		}
+		for _, s := range sums {
+			id, ok := kept[s.CommitID]
+			if !ok {
+				continue
+			}
+			if has[id] {
+				err = summaries.Delete(itob(s.ID))
+			} else {
+				s.CommitID, has[id] = id, true
+				err = put(summaries, s.ID, s)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return expanded, err
+}
+
+func (d *Db) GetCommitById(id int) (git.GitCommit, error) {
+	var c boltCommit
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		found, ok, err := get[boltCommit](tx.Bucket(commitsBucket), int64(id))
+		if err != nil || !ok {
+			return err
+		}
+		if owned, err := boltOwnsProject(tx, d.uID, found.ProjectID); err != nil || !owned {
+			return err
+		}
+		c = found
+		return nil
+	})
+	return c.GitCommit, err
+}
+
+func (d *Db) UpsertCommit(commits []git.CustomUpdateCommit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		for _, c := range commits {
+			owned, err := boltOwnsProject(tx, d.uID, c.ProjectID)
+			if err != nil {
+				return err
+			}
+			if !owned {
+				return fmt.Errorf("project %d not found", c.ProjectID)
+			}
+		}
+		b := tx.Bucket(summariesBucket)
+		if f := commits[0]; f.GitCommit.ID == 0 {
+			// summaries accepted from suggestions are managed by their review
+			_, err := deleteWhere(b, func(s boltSummary) bool {
+				return s.ProjectID == f.ProjectID && s.CommitID == 0 && s.SuggestionID == 0
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, v := range commits {
+			now := boltNow()
+			s := boltSummary{ProjectID: v.ProjectID, CommitID: v.GitCommit.ID, Summary: v.Msg, CreatedAt: now, UpdatedAt: now}
+			if v.GitCommit.ID != 0 {
+				found, err := all(b, func(o boltSummary) bool { return o.ProjectID == v.ProjectID && o.CommitID == v.GitCommit.ID })
+				if err != nil {
+					return err
+				}
+				if len(found) > 0 {
+					s.ID, s.CreatedAt, s.SuggestionID = found[0].ID, found[0].CreatedAt, found[0].SuggestionID
+				}
+			}
+			if s.ID == 0 {
+				id, err := nextID(b)
+				if err != nil {
+					return err
+				}
+				s.ID = id
+			}
+			if err := put(b, s.ID, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *Db) GetAllCommitSummary(projectID int) ([]git.CustomUpdateCommit, error) {
+	var summaries []git.CustomUpdateCommit
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		if owned, err := boltOwnsProject(tx, d.uID, projectID); err != nil || !owned {
+			return err
+		}
+		rows, err := all(tx.Bucket(summariesBucket), func(s boltSummary) bool { return s.ProjectID == projectID })
+		if err != nil {
+			return err
+		}
+		for _, s := range rows {
+			sources := []string{}
+			if s.SuggestionID != 0 {
+				sg, ok, err := get[boltSuggestion](tx.Bucket(suggestionsBucket), s.SuggestionID)
+				if err != nil {
+					return err
+				}
+				if ok && sg.SourceCommits != nil {
+					sources = sg.SourceCommits
+				}
+			}
+			summaries = append(summaries, git.CustomUpdateCommit{
+				// matches the SQLite driver, which reports the summary ID here
+				ProjectID:     int(s.ID),
+				GitCommit:     git.GitCommit{Msg: s.Summary, CreatedAt: s.CreatedAt},
+				SourceCommits: sources,
+			})
+		}
+		return nil
+	})
+	return summaries, err
+}
+
+func (d *Db) CreateResume(r git.Resume) (git.Resume, error) {
+	uID, err := d.owner()
+	if err != nil {
+		return git.Resume{}, err
+	}
+	var id int64
+	err = d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(resumesBucket)
+		var err error
+		if id, err = nextID(b); err != nil {
+			return err
+		}
+		now := boltNow()
+		skills := r.Skills
+		if skills == nil {
+			skills = []string{}
+		}
+		err = put(b, id, git.Resume{
+			ID:        id,
+			UserID:    int32(uID),
+			Version:   r.Version,
+			Title:     r.Title,
+			Skills:    skills,
+			ParentID:  r.ParentID,
+			JobID:     r.JobID,
+			Summary:   r.Summary,
+			Language:  r.Language,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		return saveBoltRevision(tx, id, noteCreated)
+	})
+	if err != nil {
+		return git.Resume{}, err
+	}
+	return git.Resume{ID: id}, nil
+}
+
+func (d *Db) GetResume(ID int64) (git.Resume, error) {
+	var r git.Resume
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		owned, err := boltOwnsResume(tx, d.uID, ID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return errors.New("record with ID not found")
+		}
+		r, err = boltResume(tx, ID)
+		return err
+	})
+	if err != nil {
+		return git.Resume{}, err
+	}
+	return r, nil
+}
+
+func boltResume(tx *bolt.Tx, ID int64) (git.Resume, error) {
+	r, ok, err := get[git.Resume](tx.Bucket(resumesBucket), ID)
+	if err != nil {
+		return r, err
+	}
+	if !ok {
+		return r, errors.New("record with ID not found")
+	}
+
+	u, _, err := get[git.Profile](tx.Bucket(usersBucket), int64(r.UserID))
+	if err != nil {
+		return r, err
+	}
+	r.Profile = git.Profile{
+		Name:                u.Name,
+		Email:               strings.TrimSpace(u.Email),
+		Location:            strings.TrimSpace(u.Location),
+		Phone:               strings.TrimSpace(u.Phone),
+		Links:               u.Links,
+		ProfessionalSummary: u.ProfessionalSummary,
+	}
+	if r.Summary != "" {
+		r.Profile.ProfessionalSummary = r.Summary
+	}
+	if r.Skills == nil {
+		r.Skills = []string{}
+	}
+
+	if r.WorkExperiences, err = sectionOf[git.WorkExperience](tx.Bucket(workBucket), ID); err != nil {
+		return r, err
+	}
+	if r.Education, err = sectionOf[git.Education](tx.Bucket(educationBucket), ID); err != nil {
+		return r, err
+	}
+	for i := range r.Education {
+		r.Education[i].ResumeID = ID
+	}
+	if r.Volunteers, err = sectionOf[git.Volunteer](tx.Bucket(volunteeringBucket), ID); err != nil {
+		return r, err
+	}
+	r.ProjectWorkedOn, err = sectionOf[git.ProjectWorkedOn](tx.Bucket(projectWorkedBucket), ID)
+	return r, err
+}
+
+func (d *Db) GetResumes() ([]git.Resume, error) {
+	var resumes []git.Resume
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(resumesBucket), func(r git.Resume) bool { return int64(r.UserID) == d.uID })
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if r.Skills == nil {
+				r.Skills = []string{}
+			}
+			resumes = append(resumes, git.Resume{
+				ID:              r.ID,
+				Title:           r.Title,
+				Version:         r.Version,
+				Skills:          r.Skills,
+				PublishedAt:     r.PublishedAt,
+				ParentID:        r.ParentID,
+				JobID:           r.JobID,
+				Language:        r.Language,
+				CreatedAt:       r.CreatedAt,
+				WorkExperiences: []git.WorkExperience{},
+				Education:       []git.Education{},
+			})
+		}
+		return nil
+	})
+	return resumes, err
+}
+
+func (d *Db) UpdateResume(rID int64, req git.Resume) (int64, error) {
+	err := d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(resumesBucket)
+		r, ok, err := get[git.Resume](b, rID)
+		if err != nil {
+			return err
+		}
+		ok = ok && int64(r.UserID) == d.uID
+
+		// A resume with its own summary keeps edits to it instead of
+		// changing the profile every other resume shares.
+		if r.Summary != "" && req.Summary == "" {
+			req.Summary = req.Profile.ProfessionalSummary
+		}
+		if req.Summary != "" {
+			req.Profile.ProfessionalSummary = ""
+		}
+		if !reflect.DeepEqual(req.Profile, git.Profile{}) {
+			if err := updateBoltUser(tx, d.uID, req.Profile); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			return nil
+		}
+
+		if req.Title != "" {
+			r.Title = req.Title
+		}
+		if req.Skills != nil {
+			r.Skills = req.Skills
+		}
+		if req.Summary != "" {
+			r.Summary = req.Summary
+		}
+		r.UpdatedAt = boltNow()
+		if err := put(b, rID, r); err != nil {
+			return err
+		}
+		return saveBoltRevision(tx, rID, noteUpdated)
+	})
+	return 0, err
+}
+
+// DeleteResume removes a resume with its sections. Resumes tailored or
+// translated from it lose the link, as ON DELETE SET NULL does in SQLite.
+func (d *Db) DeleteResume(rID int64) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		if owned, err := boltOwnsResume(tx, d.uID, rID); err != nil || !owned {
+			return err
+		}
+		b := tx.Bucket(resumesBucket)
+		if err := b.Delete(itob(rID)); err != nil {
+			return err
+		}
+		children, err := all(b, func(r git.Resume) bool { return r.ParentID == rID })
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			c.ParentID = 0
+			if err := put(b, c.ID, c); err != nil {
+				return err
+			}
+		}
+
+		ofResume := func(bucket []byte) error {
+			_, err := deleteWhere(tx.Bucket(bucket), func(s boltSection[json.RawMessage]) bool { return s.ResumeID == rID })
+			return err
+		}
+		for _, bucket := range [][]byte{workBucket, educationBucket, volunteeringBucket, projectWorkedBucket, revisionsBucket} {
+			if err := ofResume(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sectionOf returns the items of a resume section in ID order, never nil.
+func sectionOf[T any](b *bolt.Bucket, rID int64) ([]T, error) {
+	rows, err := all(b, func(s boltSection[T]) bool { return s.ResumeID == rID })
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, 0, len(rows))
+	for _, s := range rows {
+		items = append(items, s.Item)
+	}
+	return items, nil
+}
+
+// saveSection updates the items whose ID already belongs to the resume and
+// inserts the rest, returning the ID of every item.
+func (d *Db) saveSection(bucket []byte, rID int64, n int, item func(i int, id int64) (any, int64)) ([]int64, error) {
+	ids := make([]int64, 0, n)
+	err := d.Db.Update(func(tx *bolt.Tx) error {
+		owned, err := boltOwnsResume(tx, d.uID, rID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return errors.New("record with ID not found")
+		}
+		b := tx.Bucket(bucket)
+		for i := 0; i < n; i++ {
+			_, id := item(i, 0)
+			if id != 0 {
+				existing, ok, err := get[boltSection[json.RawMessage]](b, id)
+				if err != nil {
+					return err
+				}
+				if !ok || existing.ResumeID != rID {
+					id = 0
+				}
+			}
+			if id == 0 {
+				var err error
+				if id, err = nextID(b); err != nil {
+					return err
+				}
+			}
+			v, _ := item(i, id)
+			if err := put(b, id, v); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return saveBoltRevision(tx, rID, sectionNotes[string(bucket)][0])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// deleteByID deletes an item of a resume section and records a revision
+// of the resume it belonged to.
+func (d *Db) deleteByID(bucket []byte, id int64) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		item, ok, err := get[boltSection[json.RawMessage]](b, id)
+		if err != nil || !ok {
+			return err
+		}
+		if owned, err := boltOwnsResume(tx, d.uID, item.ResumeID); err != nil || !owned {
+			return err
+		}
+		if err := b.Delete(itob(id)); err != nil {
+			return err
+		}
+		return saveBoltRevision(tx, item.ResumeID, sectionNotes[string(bucket)][1])
+	})
+}
+
+func (d *Db) CreateOrUpdateWorkExperiences(rID int64, w []git.WorkExperience) ([]int64, error) {
+	if len(w) == 0 {
+		return nil, errors.New("no work experience found")
+	}
+	return d.saveSection(workBucket, rID, len(w), func(i int, id int64) (any, int64) {
+		if id == 0 {
+			return nil, w[i].ID
+		}
+		wk := w[i]
+		wk.ID = id
+		return boltSection[git.WorkExperience]{ResumeID: rID, Item: wk}, id
+	})
+}
+
+func (d *Db) DeleteWorkExperience(wID int64) error {
+	return d.deleteByID(workBucket, wID)
+}
+
+func (d *Db) CreateOrUpdateEducation(rID int64, edus []git.Education) ([]int64, error) {
+	if len(edus) == 0 {
+		return nil, errors.New("invalid education")
+	}
+	return d.saveSection(educationBucket, rID, len(edus), func(i int, id int64) (any, int64) {
+		if id == 0 {
+			return nil, edus[i].ID
+		}
+		e := edus[i]
+		e.ID, e.ResumeID = id, rID
+		return boltSection[git.Education]{ResumeID: rID, Item: e}, id
+	})
+}
+
+func (d *Db) DeleteEducation(eID int64) error {
+	return d.deleteByID(educationBucket, eID)
+}
+
+func (d *Db) CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]int64, error) {
+	if len(v) == 0 {
+		return nil, errors.New("invalid data")
+	}
+	return d.saveSection(volunteeringBucket, rID, len(v), func(i int, id int64) (any, int64) {
+		if id == 0 {
+			return nil, v[i].ID
+		}
+		vol := v[i]
+		vol.ID = id
+		return boltSection[git.Volunteer]{ResumeID: rID, Item: vol}, id
+	})
+}
+
+func (d *Db) DeleteVolunteer(vID int64) error {
+	return d.deleteByID(volunteeringBucket, vID)
+}
+
+func (d *Db) CreateOrUpdateProjectOn(rID int64, p []git.ProjectWorkedOn) ([]int64, error) {
+	if len(p) == 0 {
+		return nil, errors.New("invalid data")
+	}
+	return d.saveSection(projectWorkedBucket, rID, len(p), func(i int, id int64) (any, int64) {
+		if id == 0 {
+			return nil, p[i].ID
+		}
+		pr := p[i]
+		pr.ID = id
+		return boltSection[git.ProjectWorkedOn]{ResumeID: rID, Item: pr}, id
+	})
+}
+
+func (d *Db) DeleteProjectWorkedOn(pID int64) error {
+	return d.deleteByID(projectWorkedBucket, pID)
+}
+
+// saveBoltRevision records the resume as it is now, unless nothing changed
+// since its last revision.
+func saveBoltRevision(tx *bolt.Tx, rID int64, note string) error {
+	r, err := boltResume(tx, rID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := resumeSnapshot(r)
+	if err != nil {
+		return err
+	}
+	b := tx.Bucket(revisionsBucket)
+	revisions, err := all(b, func(v boltRevision) bool { return v.ResumeID == rID })
+	if err != nil {
+		return err
+	}
+	last := boltRevision{}
+	if len(revisions) > 0 {
+		last = revisions[len(revisions)-1]
+		if last.Snapshot == snapshot {
+			return nil
+		}
+	}
+	id, err := nextID(b)
+	if err != nil {
+		return err
+	}
+	return put(b, id, boltRevision{
+		ResumeRevision: git.ResumeRevision{ID: id, ResumeID: rID, Revision: last.Revision + 1, Note: note, CreatedAt: boltNow()},
+		Snapshot:       snapshot,
+	})
+}
+
+func (d *Db) GetResumeRevisions(rID int64) ([]git.ResumeRevision, error) {
+	revisions := []git.ResumeRevision{}
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		if owned, err := boltOwnsResume(tx, d.uID, rID); err != nil || !owned {
+			return err
+		}
+		rows, err := all(tx.Bucket(revisionsBucket), func(v boltRevision) bool { return v.ResumeID == rID })
+		for _, v := range rows {
+			revisions = append(revisions, v.ResumeRevision)
+		}
+		return err
+	})
+	return revisions, err
+}
+
+func (d *Db) GetResumeRevision(rID int64, revision int) (git.ResumeRevision, error) {
+	var rev git.ResumeRevision
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		owned, err := boltOwnsResume(tx, d.uID, rID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return errNoRevision(rID, revision)
+		}
+		rev, err = boltResumeRevision(tx, rID, revision)
+		return err
+	})
+	return rev, err
+}
+
+func boltResumeRevision(tx *bolt.Tx, rID int64, revision int) (git.ResumeRevision, error) {
+	found, err := all(tx.Bucket(revisionsBucket), func(v boltRevision) bool { return v.ResumeID == rID && v.Revision == revision })
+	if err != nil {
+		return git.ResumeRevision{}, err
+	}
+	if len(found) == 0 {
+		return git.ResumeRevision{}, errNoRevision(rID, revision)
+	}
+	rev := found[0].ResumeRevision
+	rev.Resume = &git.Resume{}
+	return rev, json.Unmarshal([]byte(found[0].Snapshot), rev.Resume)
+}
+
+// RestoreResumeRevision puts the resume's own fields and sections back as
+// they were at revision, keeping the IDs of their items. The profile is
+// shared by every resume, so it is left alone.
+func (d *Db) RestoreResumeRevision(rID int64, revision int) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		owned, err := boltOwnsResume(tx, d.uID, rID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return errNoRevision(rID, revision)
+		}
+		rev, err := boltResumeRevision(tx, rID, revision)
+		if err != nil {
+			return err
+		}
+		snap := rev.Resume
+
+		b := tx.Bucket(resumesBucket)
+		r, ok, err := get[git.Resume](b, rID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("record with ID not found")
+		}
+		r.Title, r.Skills, r.Summary, r.Language = snap.Title, snap.Skills, snap.Summary, snap.Language
+		r.UpdatedAt = boltNow()
+		if err := put(b, rID, r); err != nil {
+			return err
+		}
+
+		for bucket := range sectionNotes {
+			_, err := deleteWhere(tx.Bucket([]byte(bucket)), func(s boltSection[json.RawMessage]) bool { return s.ResumeID == rID })
+			if err != nil {
+				return err
+			}
+		}
+		for _, v := range snap.WorkExperiences {
+			if err := put(tx.Bucket(workBucket), v.ID, boltSection[git.WorkExperience]{ResumeID: rID, Item: v}); err != nil {
+				return err
+			}
+		}
+		for _, v := range snap.Education {
+			if err := put(tx.Bucket(educationBucket), v.ID, boltSection[git.Education]{ResumeID: rID, Item: v}); err != nil {
+				return err
+			}
+		}
+		for _, v := range snap.Volunteers {
+			if err := put(tx.Bucket(volunteeringBucket), v.ID, boltSection[git.Volunteer]{ResumeID: rID, Item: v}); err != nil {
+				return err
+			}
+		}
+		for _, v := range snap.ProjectWorkedOn {
+			if err := put(tx.Bucket(projectWorkedBucket), v.ID, boltSection[git.ProjectWorkedOn]{ResumeID: rID, Item: v}); err != nil {
+				return err
+			}
+		}
+		return saveBoltRevision(tx, rID, fmt.Sprintf(noteRestore, revision))
+	})
+}
+
+func (d *Db) CreateJob(j git.Job) (int64, error) {
+	uID, err := d.owner()
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		var err error
+		if id, err = nextID(b); err != nil {
+			return err
+		}
+		j.ID, j.CreatedAt = id, boltNow()
+		return put(b, id, boltJob{Job: j, UserID: uID})
+	})
+	return id, err
+}
+
+func (d *Db) GetJob(id int64) (git.Job, error) {
+	var j boltJob
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		var (
+			ok  bool
+			err error
+		)
+		j, ok, err = get[boltJob](tx.Bucket(jobsBucket), id)
+		if err == nil && (!ok || j.UserID != d.uID) {
+			err = errors.New("record with ID not found")
+		}
+		return err
+	})
+	if err != nil {
+		return git.Job{}, err
+	}
+	return j.Job, nil
+}
+
+func (d *Db) GetJobs() ([]git.Job, error) {
+	jobs := []git.Job{}
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(jobsBucket), func(j boltJob) bool { return j.UserID == d.uID })
+		for i := len(rows) - 1; i >= 0; i-- {
+			jobs = append(jobs, rows[i].Job)
+		}
+		return err
+	})
+	return jobs, err
+}
+
+func (d *Db) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	uID, err := d.owner()
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		if err := savePrompt(tx, uID, cfg); err != nil {
+			return err
+		}
+		return createBoltPromptVersion(tx, uID, cfg)
+	})
+}
+
+// savePrompt stores cfg as the user's active prompt of its title.
+func savePrompt(tx *bolt.Tx, uID int64, cfg config.CustomPrompt) error {
+	b := tx.Bucket(promptsBucket)
+	found, err := all(b, func(p boltPrompt) bool { return p.Title == cfg.Title && p.UserID == uID })
+	if err != nil {
+		return err
+	}
+	p := config.CustomPrompt{Title: cfg.Title, Temperature: cfg.Temperature, MaxTokens: cfg.MaxTokens, Prompts: cfg.Prompts}
+	if len(found) > 0 {
+		p.ID = found[0].ID
+	} else {
+		id, err := nextID(b)
+		if err != nil {
+			return err
+		}
+		p.ID = int(id)
+	}
+	return put(b, int64(p.ID), boltPrompt{CustomPrompt: p, UserID: uID})
+}
+
+func samePrompt(a, b []config.Prompt) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+// createBoltPromptVersion records a save as the next version of the prompt,
+// unless it is identical to the latest version.
+func createBoltPromptVersion(tx *bolt.Tx, uID int64, cfg config.CustomPrompt) error {
+	b := tx.Bucket(promptVersionsBucket)
+	versions, err := all(b, func(v boltPromptVersion) bool { return v.Title == cfg.Title && v.UserID == uID })
+	if err != nil {
+		return err
+	}
+	latest := 0
+	if n := len(versions); n > 0 {
+		last := versions[n-1]
+		if samePrompt(last.Prompts, cfg.Prompts) && last.Temperature == cfg.Temperature && last.MaxTokens == cfg.MaxTokens {
+			return nil
+		}
+		latest = last.Version
+	}
+	id, err := nextID(b)
+	if err != nil {
+		return err
+	}
+	return put(b, id, boltPromptVersion{
+		PromptVersion: config.PromptVersion{
+			ID:          id,
+			Title:       cfg.Title,
+			Version:     latest + 1,
+			Temperature: cfg.Temperature,
+			MaxTokens:   cfg.MaxTokens,
+			Prompts:     cfg.Prompts,
+			CreatedAt:   boltNow(),
+		},
+		UserID: uID,
+	})
+}
+
+func (d *Db) GetLLmPromptConfig() ([]config.CustomPrompt, error) {
+	var prompts []config.CustomPrompt
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(promptsBucket), func(p boltPrompt) bool { return p.UserID == d.uID })
+		for _, p := range rows {
+			prompts = append(prompts, p.CustomPrompt)
+		}
+		return err
+	})
+	return prompts, err
+}
+
+func (d *Db) GetPromptVersions(title config.PromptType) ([]config.PromptVersion, error) {
+	var versions []config.PromptVersion
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		active, err := all(tx.Bucket(promptsBucket), func(p boltPrompt) bool { return p.Title == title && p.UserID == d.uID })
+		if err != nil {
+			return err
+		}
+		rows, err := all(tx.Bucket(promptVersionsBucket), func(v boltPromptVersion) bool { return v.Title == title && v.UserID == d.uID })
+		for _, v := range rows {
+			versions = append(versions, v.PromptVersion)
+		}
+		if err != nil || len(active) == 0 {
+			return err
+		}
+		sort.SliceStable(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+		a := active[0]
+		for i := len(versions) - 1; i >= 0; i-- {
+			v := versions[i]
+			if samePrompt(v.Prompts, a.Prompts) && v.Temperature == a.Temperature && v.MaxTokens == a.MaxTokens {
+				versions[i].Active = true
+				break
+			}
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// PromotePromptVersion makes a stored version the active prompt without
+// creating a new version.
+func (d *Db) PromotePromptVersion(title config.PromptType, version int) error {
+	uID, err := d.owner()
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		found, err := all(tx.Bucket(promptVersionsBucket), func(v boltPromptVersion) bool {
+			return v.Title == title && v.Version == version && v.UserID == uID
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("%s prompt has no version %d", title, version)
+		}
+		return savePrompt(tx, uID, found[0].CustomPrompt())
+	})
+}
+
+func (d *Db) CreatePromptEval(e config.PromptEval) error {
+	uID, err := d.owner()
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(promptEvalsBucket)
+		id, err := nextID(b)
+		if err != nil {
+			return err
+		}
+		e.ID, e.CreatedAt = id, boltNow()
+		return put(b, id, boltPromptEval{PromptEval: e, UserID: uID})
+	})
+}
+
+// GetPromptEvals returns the results of an evaluation run, or of the most
+// recent run when runID is empty.
+func (d *Db) GetPromptEvals(runID string) ([]config.PromptEval, error) {
+	var evals []config.PromptEval
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(promptEvalsBucket), func(e boltPromptEval) bool { return e.UserID == d.uID })
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		if runID == "" {
+			runID = rows[len(rows)-1].RunID
+		}
+		for _, e := range rows {
+			if e.RunID == runID {
+				evals = append(evals, e.PromptEval)
+			}
+		}
+		return nil
+	})
+	return evals, err
+}
+
+func (d *Db) CreateAIUsage(u config.AiUsage) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usageBucket)
+		id, err := nextID(b)
+		if err != nil {
+			return err
+		}
+		u.ID = id
+		u.UserID = d.uID
+		if u.CreatedAt == "" {
+			u.CreatedAt = boltNow()
+		}
+		return put(b, id, u)
+	})
+}
+
+func (d *Db) GetAIUsage(since string) ([]config.AiUsage, error) {
+	var usage []config.AiUsage
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		var err error
+		usage, err = all(tx.Bucket(usageBucket), func(u config.AiUsage) bool { return u.CreatedAt >= since && u.UserID == d.uID })
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].CreatedAt < usage[j].CreatedAt })
+		return err
+	})
+	return usage, err
+}
+
+func (d *Db) GetAIUsageCost(since string) (float64, error) {
+	var cost float64
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		usage, err := all(tx.Bucket(usageBucket), func(u config.AiUsage) bool { return u.CreatedAt >= since })
+		for _, u := range usage {
+			cost += u.Cost
+		}
+		return err
+	})
+	return cost, err
+}
+
+func (d *Db) CreateAIAudit(a config.AiAudit) error {
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		id, err := nextID(b)
+		if err != nil {
+			return err
+		}
+		a.ID = id
+		a.UserID = d.uID
+		if a.CreatedAt == "" {
+			a.CreatedAt = boltNow()
+		}
+		return put(b, id, a)
+	})
+}
+
+func (d *Db) GetAIAudit(since string, limit int) ([]config.AiAudit, error) {
+	var entries []config.AiAudit
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		var err error
+		entries, err = all(tx.Bucket(auditBucket), func(a config.AiAudit) bool { return a.CreatedAt >= since && a.UserID == d.uID })
+		return err
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].CreatedAt != entries[j].CreatedAt {
+			return entries[i].CreatedAt > entries[j].CreatedAt
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, err
+}
+
+func (d *Db) GetAIAuditEntry(id int64) (config.AiAudit, error) {
+	var a config.AiAudit
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		var (
+			ok  bool
+			err error
+		)
+		a, ok, err = get[config.AiAudit](tx.Bucket(auditBucket), id)
+		if err == nil && (!ok || a.UserID != d.uID) {
+			a = config.AiAudit{}
+			err = errors.New("record with ID not found")
+		}
+		return err
+	})
+	return a, err
+}
+
+func (d *Db) PruneAIAudit(before string) (int64, error) {
+	var n int64
+	err := d.Db.Update(func(tx *bolt.Tx) error {
+		var err error
+		n, err = deleteWhere(tx.Bucket(auditBucket), func(a config.AiAudit) bool { return a.CreatedAt < before })
+		return err
+	})
+	return n, err
+}
+
+func (d *Db) CreateSuggestions(sg []config.Suggestion) ([]int64, error) {
+	uID, err := d.owner()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(sg))
+	err = d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(suggestionsBucket)
+		for _, v := range sg {
+			if v.ProjectID != 0 {
+				owned, err := boltOwnsProject(tx, uID, v.ProjectID)
+				if err != nil {
+					return err
+				}
+				if !owned {
+					return fmt.Errorf("project %d not found", v.ProjectID)
+				}
+			}
+			id, err := nextID(b)
+			if err != nil {
+				return err
+			}
+			v.ID, v.CreatedAt, v.ReviewedAt, v.EditedText = id, boltNow(), "", ""
+			if v.State == "" {
+				v.State = config.SuggestionPending
+			}
+			if v.SourceCommits == nil {
+				v.SourceCommits = []string{}
+			}
+			if err := put(b, id, boltSuggestion{Suggestion: v, UserID: uID}); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetSuggestions lists suggestions, newest first. A zero projectID or empty
+// state matches any.
+func (d *Db) GetSuggestions(projectID int, state config.SuggestionState) ([]config.Suggestion, error) {
+	out := []config.Suggestion{}
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(suggestionsBucket), func(s boltSuggestion) bool {
+			return s.UserID == d.uID && (projectID == 0 || s.ProjectID == projectID) && (state == "" || s.State == state)
+		})
+		for i := len(rows) - 1; i >= 0; i-- {
+			out = append(out, rows[i].Suggestion)
+		}
+		return err
+	})
+	return out, err
+}
+
+func (d *Db) GetSuggestion(id int64) (config.Suggestion, error) {
+	var sg boltSuggestion
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		var (
+			ok  bool
+			err error
+		)
+		sg, ok, err = get[boltSuggestion](tx.Bucket(suggestionsBucket), id)
+		if err == nil && (!ok || sg.UserID != d.uID) {
+			err = errors.New("record with ID not found")
+		}
+		return err
+	})
+	return sg.Suggestion, err
+}
+
+// ReviewSuggestion moves a suggestion to state and keeps its commit summary
+// in step: accepted and edited text is written to commit_summary, anything
+// else removes it again. text is only used by the edited state.
+func (d *Db) ReviewSuggestion(id int64, state config.SuggestionState, text string) error {
+	if !state.Valid() {
+		return config.ErrInvalidSuggestionState
+	}
+	if state == config.SuggestionEdited && strings.TrimSpace(text) == "" {
+		return errors.New("edited suggestions need the edited text")
+	}
+	if state != config.SuggestionEdited {
+		text = ""
+	}
+
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(suggestionsBucket)
+		sg, ok, err := get[boltSuggestion](b, id)
+		if err != nil {
+			return err
+		}
+		if !ok || sg.UserID != d.uID {
+			return errors.New("record with ID not found")
+		}
+		sg.State, sg.EditedText, sg.ReviewedAt = state, text, boltNow()
+		if state == config.SuggestionPending {
+			sg.ReviewedAt = ""
+		}
+		if err := put(b, id, sg); err != nil {
+			return err
+		}
+
+		summaries := tx.Bucket(summariesBucket)
+		if _, err := deleteWhere(summaries, func(s boltSummary) bool { return s.SuggestionID == id }); err != nil {
+			return err
+		}
+		if (state == config.SuggestionAccepted || state == config.SuggestionEdited) && sg.ProjectID != 0 {
+			sid, err := nextID(summaries)
+			if err != nil {
+				return err
+			}
+			content := sg.Text
+			if sg.EditedText != "" {
+				content = sg.EditedText
+			}
+			now := boltNow()
+			return put(summaries, sid, boltSummary{ID: sid, ProjectID: sg.ProjectID, Summary: content, SuggestionID: id, CreatedAt: now, UpdatedAt: now})
+		}
+		return nil
+	})
+}
+
+func (d *Db) GetEmbeddings(model string) ([]config.Embedding, error) {
+	var embeddings []config.Embedding
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		rows, err := all(tx.Bucket(embeddingsBucket), func(e boltEmbedding) bool { return e.Model == model && e.UserID == d.uID })
+		for _, r := range rows {
+			e := r.Embedding
+			e.Vector = r.Vector
+			embeddings = append(embeddings, e)
+		}
+		return err
+	})
+	return embeddings, err
+}
+
+func (d *Db) SaveEmbeddings(embeddings []config.Embedding) error {
+	uID, err := d.owner()
+	if err != nil {
+		return err
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(embeddingsBucket)
+		for _, e := range embeddings {
+			found, err := all(b, func(o boltEmbedding) bool { return o.Ref == e.Ref && o.Model == e.Model && o.UserID == uID })
+			if err != nil {
+				return err
+			}
+			if len(found) > 0 {
+				e.ID = found[0].ID
+			} else if e.ID, err = nextID(b); err != nil {
+				return err
+			}
+			if err := put(b, e.ID, boltEmbedding{Embedding: e, Vector: e.Vector, UserID: uID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *Db) DeleteEmbeddings(model string, refs []string) error {
+	drop := make(map[string]bool, len(refs))
+	for _, r := range refs {
+		drop[r] = true
+	}
+	return d.Db.Update(func(tx *bolt.Tx) error {
+		_, err := deleteWhere(tx.Bucket(embeddingsBucket), func(e boltEmbedding) bool {
+			return e.Model == model && e.UserID == d.uID && drop[e.Ref]
+		})
+		return err
+	})
+}
+
+func (d *Db) SearchEmbeddings(model string, vector []float32, limit int) ([]config.EmbeddingMatch, error) {
+	embeddings, err := d.GetEmbeddings(model)
+	if err != nil {
+		return nil, err
+	}
+	return rankEmbeddings(embeddings, vector, limit), nil
+}
+
+// Search scans the buckets; bbolt has no full-text index, so it scores
+// matches the way SQLite does without FTS5.
+func (d *Db) Search(q config.SearchQuery) ([]config.SearchResult, error) {
+	terms := searchTerms(q.Text)
+	results := []config.SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	add := func(r config.SearchResult, body string) {
+		if q.ProjectID != 0 && r.ProjectID != q.ProjectID {
+			return
+		}
+		if (q.Since != "" && r.Date < q.Since) || (q.Until != "" && (r.Date == "" || r.Date > q.Until)) {
+			return
+		}
+		if r.Score, r.Snippet = matchText(body, terms); r.Score > 0 {
+			results = append(results, r)
+		}
+	}
+	day := func(ts string) string { return ts[:min(len(ts), 10)] }
+
+	err := d.Db.View(func(tx *bolt.Tx) error {
+		projects, err := all(tx.Bucket(projectsBucket), func(p boltProject) bool { return p.UserID == d.uID })
+		if err != nil {
+			return err
+		}
+		names, mine := map[int]string{}, map[int]bool{}
+		for _, p := range projects {
+			names[p.ID], mine[p.ID] = p.Name, true
+		}
+		resumes, err := all(tx.Bucket(resumesBucket), func(r git.Resume) bool { return int64(r.UserID) == d.uID })
+		if err != nil {
+			return err
+		}
+		owned := map[int64]bool{}
+		for _, r := range resumes {
+			owned[r.ID] = true
+		}
+
+		if wantKind(q.Kinds, config.SearchCommit) {
+			commits, err := all(tx.Bucket(commitsBucket), func(c boltCommit) bool { return mine[c.ProjectID] })
+			if err != nil {
+				return err
+			}
+			for _, c := range commits {
+				date := c.Date
+				if date == "" {
+					date = day(c.CreatedAt)
+				}
+				add(config.SearchResult{Kind: config.SearchCommit, ID: int64(c.ID), ProjectID: c.ProjectID, Project: names[c.ProjectID], Title: git.ShortHash(c.Hash), Date: date}, c.Msg)
+			}
+		}
+		if wantKind(q.Kinds, config.SearchSummary) {
+			summaries, err := all(tx.Bucket(summariesBucket), func(s boltSummary) bool { return mine[s.ProjectID] })
+			if err != nil {
+				return err
+			}
+			for _, s := range summaries {
+				add(config.SearchResult{Kind: config.SearchSummary, ID: s.ID, ProjectID: s.ProjectID, Project: names[s.ProjectID], Date: day(s.CreatedAt)}, s.Summary)
+			}
+		}
+		if wantKind(q.Kinds, config.SearchExperience) && q.ProjectID == 0 {
+			work, err := all(tx.Bucket(workBucket), func(w boltSection[git.WorkExperience]) bool { return owned[w.ResumeID] })
+			if err != nil {
+				return err
+			}
+			for _, w := range work {
+				add(config.SearchResult{Kind: config.SearchExperience, ID: w.Item.ID, ResumeID: w.ResumeID, Title: w.Item.Company, Date: day(w.Item.StartDate)},
+					w.Item.Role+" "+w.Item.Company+" "+w.Item.Responsibilities)
+			}
+		}
+		if wantKind(q.Kinds, config.SearchProject) && q.ProjectID == 0 {
+			worked, err := all(tx.Bucket(projectWorkedBucket), func(p boltSection[git.ProjectWorkedOn]) bool { return owned[p.ResumeID] })
+			if err != nil {
+				return err
+			}
+			for _, p := range worked {
+				add(config.SearchResult{Kind: config.SearchProject, ID: p.Item.ID, ResumeID: p.ResumeID, Title: p.Item.Title}, p.Item.Title+" "+p.Item.Description)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankResults(results, q.Limit), nil
 }
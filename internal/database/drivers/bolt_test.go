@@ -2,9 +2,24 @@ package drivers
 
 import (
 	"testing"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	bolt "go.etcd.io/bbolt"
 )
 
+func newTestBolt(t *testing.T) *Db {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewBolt()
+	if err != nil {
+		t.Fatalf("failed to create bolt db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
 func TestNewBoltAndClose(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	db, err := NewBolt()
 	if err != nil {
 		t.Fatalf("failed to create bolt db: %v", err)
@@ -18,4 +33,94 @@ func TestNewBoltAndClose(t *testing.T) {
 	}
 }
 
-// TODO: Add more complete tests for CRUD and error handling with temp files or mocks.
+func TestBoltAdoptsLegacyProjects(t *testing.T) {
+	db := newTestBolt(t)
+	err := db.Db.Update(func(tx *bolt.Tx) error {
+		sub, err := tx.Bucket(projectsBucket).CreateBucket([]byte("0b6e4c1e-8f0a-4a43-9b8e-0d7c2b0b9f11"))
+		if err != nil {
+			return err
+		}
+		sub.Put([]byte("name"), []byte("legacy"))
+		sub.Put([]byte("commit:1"), []byte(`{"hash":"b2","message":"Second"}`))
+		return sub.Put([]byte("commit:2"), []byte(`{"hash":"a1","message":"First"}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// with no users yet the project goes to the first one
+	p, err := db.ForUser(1).(*Db).GetProjectByName("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 1 || len(p.Commits) != 2 || p.Commits[0].Hash != "a1" {
+		t.Errorf("unexpected adopted project %+v", p)
+	}
+}
+
+func TestBoltCheckIntegrity(t *testing.T) {
+	db := newTestBolt(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	uID, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := db.ForUser(uID).(*Db)
+	if err := ada.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// what a seed before commits were deduplicated could leave behind
+	err = db.Db.Update(func(tx *bolt.Tx) error {
+		if err := put(tx.Bucket(commitsBucket), 10, boltCommit{GitCommit: git.GitCommit{ID: 10, Hash: "a1", Msg: "Init"}, ProjectID: 1}); err != nil {
+			return err
+		}
+		summaries := tx.Bucket(summariesBucket)
+		if err := put(summaries, 1, boltSummary{ID: 1, ProjectID: 1, CommitID: 10, Summary: "Set up the repo"}); err != nil {
+			return err
+		}
+		if err := put(summaries, 2, boltSummary{ID: 2, ProjectID: 99, Summary: "Gone"}); err != nil {
+			return err
+		}
+		return put(tx.Bucket(workBucket), 1, boltSection[git.WorkExperience]{ResumeID: 42, Item: git.WorkExperience{ID: 1, Company: "Ghost"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := db.CheckIntegrity(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected a duplicate, a dangling work experience and an orphaned summary, got %+v", issues)
+	}
+	if issues, err = db.CheckIntegrity(true); err != nil || len(issues) != 3 || !issues[0].Repaired {
+		t.Fatalf("unexpected repair %+v (%v)", issues, err)
+	}
+	if issues, err = db.CheckIntegrity(false); err != nil || len(issues) != 0 {
+		t.Errorf("expected a clean store after repairing, got %+v (%v)", issues, err)
+	}
+
+	p, err := ada.GetProjectByName("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Commits) != 2 {
+		t.Errorf("expected the duplicate commit to be removed, got %+v", p.Commits)
+	}
+	err = db.Db.View(func(tx *bolt.Tx) error {
+		summaries, err := all[boltSummary](tx.Bucket(summariesBucket), nil)
+		if len(summaries) != 1 || summaries[0].CommitID != 1 {
+			t.Errorf("expected the summary to move to the kept commit, got %+v", summaries)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,138 @@
+package drivers
+
+import (
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+var (
+	_ IDatabase = (*sqliteDB)(nil)
+	_ IDatabase = (*Db)(nil)
+)
+
+type IDatabase interface {
+	// Migrate applies pending schema migrations.
+	Migrate() error
+	// MigrationStatus lists the schema migrations and when each was applied.
+	MigrationStatus() ([]config.Migration, error)
+	// Rollback reverts the last steps migrations and returns them.
+	Rollback(steps int) ([]config.Migration, error)
+	// CheckIntegrity reports duplicate commits, orphaned commit summaries
+	// and references to missing rows, and fixes them when repair is set.
+	CheckIntegrity(repair bool) ([]config.IntegrityIssue, error)
+	// Delete removes the user's project named key, like DeleteProject.
+	Delete(key string) error
+	Close() error
+
+	// ForUser returns a handle on the same store that reads and writes as
+	// user uID.
+	// Projects, resumes, jobs, prompts, suggestions, embeddings, AI usage
+	// and the audit log belong to a user.
+	ForUser(uID int64) IDatabase
+	// UserID is the user the handle acts as, 0 when none is selected.
+	UserID() int64
+	GetUsers() ([]git.Profile, error)
+	// Authenticate returns the user with email when password matches, or
+	// config.ErrInvalidCredentials.
+	Authenticate(email, password string) (git.Profile, error)
+	SetPassword(uID int64, password string) error
+
+	CreateProject(data git.Project) error
+	// AddCommits stores commits of a project in the order given, skipping
+	// those it already has, and returns how many were new.
+	AddCommits(projectID int, commits []git.GitCommit) (int, error)
+	// DeleteCommits removes the commits of a project with these hashes,
+	// and their summaries, and returns how many there were.
+	DeleteCommits(projectID int, hashes []string) (int, error)
+	// ExpandCommitHashes replaces the abbreviated hashes of a project's
+	// commits with the full ones in full, which is keyed by the stored
+	// hash. A commit stored again under its full hash is merged into the
+	// first copy, as CheckIntegrity does. It returns how many it replaced.
+	ExpandCommitHashes(projectID int, full map[string]string) (int, error)
+	// GetProject returns one of the user's projects with its commits.
+	GetProject(id int) (git.Project, error)
+	// ListProjects lists the user's projects with their CommitCount but
+	// without their commits, including archived ones when archived is set.
+	ListProjects(archived bool) ([]git.Project, error)
+	// UpdateProject saves the name, path and technologies of a project. It
+	// returns config.ErrProjectExists when the user has another project
+	// with the name.
+	UpdateProject(p git.Project) error
+	// ArchiveProject leaves a project out of GetAllProject, or puts it back.
+	ArchiveProject(id int, archived bool) error
+	// DeleteProject removes a project with its commits, summaries and
+	// suggestions.
+	DeleteProject(id int) error
+	GetResume(ID int64) (git.Resume, error)
+	GetResumes() ([]git.Resume, error)
+	CreateJob(j git.Job) (int64, error)
+	GetJob(id int64) (git.Job, error)
+	GetJobs() ([]git.Job, error)
+	DeleteResume(rID int64) error
+	GetUser(email string) (git.Profile, error)
+	CreateUser(data git.Profile) (int64, error)
+	GetUserByID(uID int32) (git.Profile, error)
+	GetCommitById(id int) (git.GitCommit, error)
+	UpdateUser(uID int64, req git.Profile) error
+	CreateResume(r git.Resume) (git.Resume, error)
+	GetProjectByName(name string) (git.Project, error)
+	UpsertCommit(commits []git.CustomUpdateCommit) error
+	UpdateResume(uID int64, req git.Resume) (int64, error)
+	// GetAllProject lists the user's projects that are not archived, with
+	// their commits.
+	GetAllProject(limit, offset int) ([]git.Project, error)
+
+	// GetResumeRevisions lists the revisions of a resume, oldest first,
+	// without their snapshots. Every save of a resume or one of its
+	// sections records a revision.
+	GetResumeRevisions(rID int64) ([]git.ResumeRevision, error)
+	GetResumeRevision(rID int64, revision int) (git.ResumeRevision, error)
+	// RestoreResumeRevision puts a resume and its sections back as they
+	// were at revision and records that as a new revision.
+	RestoreResumeRevision(rID int64, revision int) error
+
+	DeleteEducation(eID int64) error
+	CreateOrUpdateEducation(rID int64, data []git.Education) ([]int64, error)
+	GetAllCommitSummary(projectID int) ([]git.CustomUpdateCommit, error)
+
+	DeleteWorkExperience(wID int64) error
+	CreateOrUpdateWorkExperiences(rID int64, w []git.WorkExperience) ([]int64, error)
+
+	CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error
+	GetLLmPromptConfig() ([]config.CustomPrompt, error)
+	GetPromptVersions(title config.PromptType) ([]config.PromptVersion, error)
+	PromotePromptVersion(title config.PromptType, version int) error
+	CreatePromptEval(e config.PromptEval) error
+	GetPromptEvals(runID string) ([]config.PromptEval, error)
+
+	CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]int64, error)
+	CreateOrUpdateProjectOn(rID int64, v []git.ProjectWorkedOn) ([]int64, error)
+	DeleteProjectWorkedOn(pID int64) error
+	DeleteVolunteer(pID int64) error
+
+	CreateAIUsage(u config.AiUsage) error
+	GetAIUsage(since string) ([]config.AiUsage, error)
+	// GetAIUsageCost sums the cost of every user's calls since a
+	// "YYYY-MM-DD HH:MM:SS" time, for the machine-wide budget.
+	GetAIUsageCost(since string) (float64, error)
+	CreateAIAudit(a config.AiAudit) error
+	// GetAIAudit lists audit entries since a "YYYY-MM-DD HH:MM:SS" time,
+	// newest first; limit 0 means all.
+	GetAIAudit(since string, limit int) ([]config.AiAudit, error)
+	GetAIAuditEntry(id int64) (config.AiAudit, error)
+	// PruneAIAudit deletes entries older than before and returns how many.
+	PruneAIAudit(before string) (int64, error)
+
+	GetEmbeddings(model string) ([]config.Embedding, error)
+	SaveEmbeddings(e []config.Embedding) error
+	DeleteEmbeddings(model string, refs []string) error
+	SearchEmbeddings(model string, vector []float32, limit int) ([]config.EmbeddingMatch, error)
+	// Search finds q in commits, summaries, work experiences and projects,
+	// best match first.
+	Search(q config.SearchQuery) ([]config.SearchResult, error)
+
+	CreateSuggestions(s []config.Suggestion) ([]int64, error)
+	GetSuggestions(projectID int, state config.SuggestionState) ([]config.Suggestion, error)
+	GetSuggestion(id int64) (config.Suggestion, error)
+	ReviewSuggestion(id int64, state config.SuggestionState, text string) error
+}
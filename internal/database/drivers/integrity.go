@@ -0,0 +1,197 @@
+package drivers
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// integrityCheck finds, and with repair set fixes, one kind of problem.
+type integrityCheck func(tx *sql.Tx, repair bool) ([]config.IntegrityIssue, error)
+
+// CheckIntegrity looks for duplicate commits, orphaned commit summaries and
+// rows that reference a missing row. With repair set it fixes them in one
+// transaction, after backing up the database.
+func (s *sqliteDB) CheckIntegrity(repair bool) ([]config.IntegrityIssue, error) {
+	issues, err := s.checkIntegrity(false)
+	if err != nil || !repair || len(issues) == 0 {
+		return issues, err
+	}
+	if err := s.backup(); err != nil {
+		return nil, fmt.Errorf("backup before repairing: %w", err)
+	}
+	return s.checkIntegrity(true)
+}
+
+func (s *sqliteDB) checkIntegrity(repair bool) ([]config.IntegrityIssue, error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	issues := []config.IntegrityIssue{}
+	// duplicates first, so their summaries move before orphans are removed
+	for _, check := range []integrityCheck{duplicateCommits, orphanedSummaries, danglingReferences} {
+		found, err := check(tx, repair)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	if !repair {
+		return issues, nil
+	}
+	return issues, tx.Commit()
+}
+
+const duplicateCommit = `EXISTS (
+	SELECT 1 FROM commits d WHERE d.project_id = commits.project_id AND d.hash = commits.hash AND d.id < commits.id
+)`
+
+func duplicateCommits(tx *sql.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	var n int64
+	if err := tx.QueryRow("SELECT COUNT(*) FROM commits WHERE " + duplicateCommit).Scan(&n); err != nil || n == 0 {
+		return nil, err
+	}
+	if repair {
+		// keep the first copy; its summary wins over those of the others
+		_, err := tx.Exec(`
+		UPDATE OR IGNORE commit_summary
+		SET commit_id = (
+			SELECT MIN(d.id) FROM commits c
+			JOIN commits d ON d.project_id = c.project_id AND d.hash = c.hash
+			WHERE c.id = commit_summary.commit_id
+		)
+		WHERE commit_id IN (SELECT id FROM commits WHERE ` + duplicateCommit + `)`)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM commit_summary WHERE commit_id IN (SELECT id FROM commits WHERE ` + duplicateCommit + `)`); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM commits WHERE " + duplicateCommit); err != nil {
+			return nil, err
+		}
+	}
+	return []config.IntegrityIssue{{Check: config.CheckDuplicateCommits, Table: "commits", Count: n, Repaired: repair}}, nil
+}
+
+const orphanedSummary = `NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = commit_summary.project_id)
+	OR (commit_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM commits c WHERE c.id = commit_summary.commit_id))`
+
+func orphanedSummaries(tx *sql.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	var n int64
+	if err := tx.QueryRow("SELECT COUNT(*) FROM commit_summary WHERE " + orphanedSummary).Scan(&n); err != nil || n == 0 {
+		return nil, err
+	}
+	if repair {
+		if _, err := tx.Exec("DELETE FROM commit_summary WHERE " + orphanedSummary); err != nil {
+			return nil, err
+		}
+	}
+	return []config.IntegrityIssue{{Check: config.CheckOrphanedSummaries, Table: "commit_summary", Count: n, Repaired: repair}}, nil
+}
+
+type foreignKey struct {
+	column, parent, onDelete string
+}
+
+// danglingReferences uses PRAGMA foreign_key_check, which finds what
+// databases written before foreign keys were enforced may hold. Repairing
+// clears references declared ON DELETE SET NULL and deletes the other rows.
+func danglingReferences(tx *sql.Tx, repair bool) ([]config.IntegrityIssue, error) {
+	type violation struct {
+		table string
+		rowid int64
+		fkid  int
+	}
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	var found []violation
+	for rows.Next() {
+		var (
+			v      violation
+			rowid  sql.NullInt64
+			parent string
+		)
+		if err := rows.Scan(&v.table, &rowid, &parent, &v.fkid); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !rowid.Valid {
+			continue
+		}
+		// orphanedSummaries reports these
+		if v.table == "commit_summary" && (parent == "projects" || parent == "commits") {
+			continue
+		}
+		v.rowid = rowid.Int64
+		found = append(found, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]map[int]foreignKey{}
+	issues := []config.IntegrityIssue{}
+	index := map[string]int{}
+	for _, v := range found {
+		if _, ok := keys[v.table]; !ok {
+			if keys[v.table], err = foreignKeys(tx, v.table); err != nil {
+				return nil, err
+			}
+		}
+		fk := keys[v.table][v.fkid]
+		ref := fk.column + " -> " + fk.parent
+		i, ok := index[v.table+" "+ref]
+		if !ok {
+			i = len(issues)
+			index[v.table+" "+ref] = i
+			issues = append(issues, config.IntegrityIssue{Check: config.CheckDanglingReferences, Table: v.table, Reference: ref, Repaired: repair})
+		}
+		issues[i].Count++
+
+		if !repair {
+			continue
+		}
+		q := fmt.Sprintf(`DELETE FROM "%s" WHERE rowid = ?`, v.table)
+		if fk.onDelete == "SET NULL" {
+			q = fmt.Sprintf(`UPDATE "%s" SET "%s" = NULL WHERE rowid = ?`, v.table, fk.column)
+		}
+		if _, err := tx.Exec(q, v.rowid); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// foreignKeys maps the foreign key ids of table to their first column.
+func foreignKeys(tx *sql.Tx, table string) (map[int]foreignKey, error) {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA foreign_key_list("%s")`, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[int]foreignKey{}
+	for rows.Next() {
+		var (
+			id, seq               int
+			parent, from          string
+			to                    sql.NullString
+			onUpdate, onDelete, m string
+		)
+		if err := rows.Scan(&id, &seq, &parent, &from, &to, &onUpdate, &onDelete, &m); err != nil {
+			return nil, err
+		}
+		if seq == 0 {
+			keys[id] = foreignKey{column: from, parent: parent, onDelete: onDelete}
+		}
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,340 @@
+package drivers
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// Migrations live in sql/migrations as NNNN_name.up.sql, with an optional
+// NNNN_name.down.sql. They are applied in version order, each in its own
+// transaction, and recorded in schema_migrations. Never edit a migration
+// that has been released; add a new one instead.
+//
+//go:embed sql/migrations/*.sql
+var migrationFiles embed.FS
+
+// maxBackups is how many pre-migration copies of the database are kept.
+const maxBackups = 5
+
+type migration struct {
+	version  int
+	name     string
+	up, down string
+}
+
+var migrations = mustLoadMigrations(migrationFiles)
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+func mustLoadMigrations(fsys fs.FS) []migration {
+	ms, err := loadMigrations(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return ms
+}
+
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	paths, err := fs.Glob(fsys, "sql/migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*migration{}
+	for _, p := range paths {
+		m := migrationFile.FindStringSubmatch(filepath.Base(p))
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", p)
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &migration{version: version, name: m[2]}
+			byVersion[version] = mg
+		} else if mg.name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, mg.name, m[2])
+		}
+		if m[3] == "up" {
+			mg.up = string(body)
+		} else {
+			mg.down = string(body)
+		}
+	}
+
+	var ms []migration
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", m.version, m.name)
+		}
+		ms = append(ms, *m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].version < ms[j].version })
+	return ms, nil
+}
+
+// legacyColumns were added by Migrate before migrations were versioned.
+// A database from that time may have any of them, so they are only applied,
+// ignoring duplicates, when such a database is first adopted.
+var legacyColumns = []string{
+	"ALTER TABLE resumes ADD COLUMN parent_id INTEGER REFERENCES resumes(id) ON DELETE SET NULL",
+	"ALTER TABLE resumes ADD COLUMN job_id INTEGER REFERENCES jobs(id) ON DELETE SET NULL",
+	"ALTER TABLE resumes ADD COLUMN summary TEXT",
+	"ALTER TABLE resumes ADD COLUMN language TEXT",
+	"ALTER TABLE commit_summary ADD COLUMN suggestion_id INTEGER REFERENCES suggestions(id) ON DELETE SET NULL",
+}
+
+// preconditions hold what a migration needs from the data before it runs.
+// They fail with what to do about it rather than let the migration lose
+// data.
+var preconditions = map[int]func(tx *sql.Tx) error{
+	5: noDuplicateCommits,
+}
+
+func noDuplicateCommits(tx *sql.Tx) error {
+	var n int64
+	if err := tx.QueryRow("SELECT COUNT(*) FROM commits WHERE " + duplicateCommit).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%d commits are stored twice; run `gitresume db check --repair` and migrate again", n)
+	}
+	return nil
+}
+
+// Migrate applies every pending migration and then brings the full-text
+// search index up to date. The database file is copied to
+// ~/.gitresume/backups first unless it is new.
+func (s *sqliteDB) Migrate() error {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].version
+	for v := range applied {
+		if v > latest {
+			return fmt.Errorf("the database is at schema version %d but this gitresume only knows up to %d; upgrade gitresume", v, latest)
+		}
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return s.ensureSearchIndex()
+	}
+
+	legacy := false
+	if len(applied) == 0 {
+		if legacy, err = s.hasTable("users"); err != nil {
+			return err
+		}
+	}
+	if len(applied) > 0 || legacy {
+		if err := s.backup(); err != nil {
+			return fmt.Errorf("backup before migrating: %w", err)
+		}
+	}
+
+	for _, m := range pending {
+		if err := s.apply(m, legacy && m.version == migrations[0].version); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return s.ensureSearchIndex()
+}
+
+func (s *sqliteDB) apply(m migration, adopt bool) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if check, ok := preconditions[m.version]; ok {
+		if err := check(tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(m.up); err != nil {
+		return err
+	}
+	if adopt {
+		for _, stmt := range legacyColumns {
+			if _, err := tx.Exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rollback reverts the last steps applied migrations, newest first, and
+// returns them. Nothing is reverted if any of them has no down script.
+func (s *sqliteDB) Rollback(steps int) ([]config.Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+	var targets []migration
+	for i := len(status) - 1; i >= 0 && len(targets) < steps; i-- {
+		st := status[i]
+		if st.AppliedAt == "" {
+			continue
+		}
+		m, ok := findMigration(st.Version)
+		if !ok || m.down == "" {
+			return nil, fmt.Errorf("migration %d (%s) cannot be rolled back", st.Version, st.Name)
+		}
+		targets = append(targets, m)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no migrations have been applied")
+	}
+
+	if err := s.backup(); err != nil {
+		return nil, fmt.Errorf("backup before rolling back: %w", err)
+	}
+	var done []config.Migration
+	for _, m := range targets {
+		if err := s.revert(m); err != nil {
+			return done, fmt.Errorf("rollback %d (%s): %w", m.version, m.name, err)
+		}
+		done = append(done, config.Migration{Version: m.version, Name: m.name, Reversible: true})
+	}
+	return done, nil
+}
+
+func (s *sqliteDB) revert(m migration) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.down); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrationStatus lists every known migration in version order, plus any
+// applied migration this build does not know about.
+func (s *sqliteDB) MigrationStatus() ([]config.Migration, error) {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var status []config.Migration
+	for _, m := range migrations {
+		st := config.Migration{Version: m.version, Name: m.name, Reversible: m.down != ""}
+		if a, ok := applied[m.version]; ok {
+			st.AppliedAt = a.AppliedAt
+			delete(applied, m.version)
+		}
+		status = append(status, st)
+	}
+	for _, a := range applied {
+		status = append(status, a)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+func (s *sqliteDB) appliedMigrations() (map[int]config.Migration, error) {
+	_, err := s.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.conn.Query("SELECT version, name, strftime('%Y-%m-%d %H:%M:%S', applied_at) FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]config.Migration{}
+	for rows.Next() {
+		var m config.Migration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[m.Version] = m
+	}
+	return applied, rows.Err()
+}
+
+func (s *sqliteDB) hasTable(name string) (bool, error) {
+	var n int
+	err := s.conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	return n > 0, err
+}
+
+func findMigration(version int) (migration, bool) {
+	for _, m := range migrations {
+		if m.version == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// backup copies the database next to it in backups/ and keeps the newest
+// maxBackups copies.
+func (s *sqliteDB) backup() error {
+	if s.path == "" {
+		return nil
+	}
+	dir := filepath.Join(filepath.Dir(s.path), "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(s.path), ".db")
+	dest := filepath.Join(dir, fmt.Sprintf("%s-%s.db", name, time.Now().Format("20060102-150405.000000")))
+	if _, err := s.conn.Exec("VACUUM INTO ?", dest); err != nil {
+		return err
+	}
+	log.Printf("Backed up the database to %s", dest)
+
+	old, err := filepath.Glob(filepath.Join(dir, name+"-*.db"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(old)
+	for len(old) > maxBackups {
+		if err := os.Remove(old[0]); err != nil {
+			return err
+		}
+		old = old[1:]
+	}
+	return nil
+}
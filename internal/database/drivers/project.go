@@ -0,0 +1,205 @@
+package drivers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// getProject returns the user's project matching where, with its commits,
+// or an empty project when there is none.
+func (s *sqliteDB) getProject(where string, arg any) (git.Project, error) {
+	var (
+		p       git.Project
+		tech    sql.NullString
+		commits sql.NullString
+	)
+	query := `
+	SELECT p.id, p.name, p.path, p.technologies, p.archived_at IS NOT NULL, COALESCE((
+		SELECT json_group_array(json_object(
+			'commit_id', c.id,
+			'hash', c.hash,
+			'message', c.message,
+			'date', c.committed_at
+		))
+		FROM (SELECT * FROM commits WHERE project_id = p.id ORDER BY id) c
+	), '[]') AS commits
+	FROM projects p WHERE ` + where + ` AND p.user_id = ?`
+	err := s.conn.QueryRow(query, arg, s.uID).Scan(&p.ID, &p.Name, &p.Path, &tech, &p.Archived, &commits)
+	if err == sql.ErrNoRows {
+		return git.Project{}, nil
+	}
+	if err != nil {
+		return git.Project{}, err
+	}
+	p.Technologies = tech.String
+	if commits.Valid {
+		err = json.Unmarshal([]byte(commits.String), &p.Commits)
+	}
+	return p, err
+}
+
+func (s *sqliteDB) GetProject(id int) (git.Project, error) {
+	p, err := s.getProject("p.id = ?", id)
+	if err == nil && p.ID == 0 {
+		err = fmt.Errorf("project %d not found", id)
+	}
+	return p, err
+}
+
+func (s *sqliteDB) ListProjects(archived bool) ([]git.Project, error) {
+	rows, err := s.conn.Query(`
+	SELECT p.id, p.name, p.path, p.technologies, p.archived_at IS NOT NULL,
+		(SELECT COUNT(*) FROM commits c WHERE c.project_id = p.id)
+	FROM projects p
+	WHERE p.user_id = ? AND (? OR p.archived_at IS NULL)
+	ORDER BY p.id`, s.uID, archived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []git.Project{}
+	for rows.Next() {
+		var (
+			p    git.Project
+			tech sql.NullString
+		)
+		if err := rows.Scan(&p.ID, &p.Name, &p.Path, &tech, &p.Archived, &p.CommitCount); err != nil {
+			return nil, err
+		}
+		p.Technologies = tech.String
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+func (s *sqliteDB) UpdateProject(p git.Project) error {
+	if p.Name = strings.TrimSpace(p.Name); p.Name == "" {
+		return errors.New("project name cannot be empty")
+	}
+	if err := s.ownProject(p.ID); err != nil {
+		return err
+	}
+	var taken bool
+	err := s.conn.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE user_id = ? AND name = ? AND id != ?)", s.uID, p.Name, p.ID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("%w: %s", config.ErrProjectExists, p.Name)
+	}
+	_, err = s.conn.Exec("UPDATE projects SET name = ?, path = ?, technologies = ? WHERE id = ? AND user_id = ?",
+		p.Name, p.Path, p.Technologies, p.ID, s.uID)
+	return err
+}
+
+func (s *sqliteDB) ArchiveProject(id int, archived bool) error {
+	res, err := s.conn.Exec(`
+	UPDATE projects SET archived_at = CASE WHEN ? THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END
+	WHERE id = ? AND user_id = ?`, archived, id, s.uID)
+	return projectChanged(res, err, id)
+}
+
+// DeleteProject removes a project; the foreign keys remove its commits,
+// summaries and suggestions with it.
+func (s *sqliteDB) DeleteProject(id int) error {
+	res, err := s.conn.Exec("DELETE FROM projects WHERE id = ? AND user_id = ?", id, s.uID)
+	return projectChanged(res, err, id)
+}
+
+// Delete removes the user's project named key, like DeleteProject.
+func (s *sqliteDB) Delete(key string) error {
+	res, err := s.conn.Exec("DELETE FROM projects WHERE name = ? AND user_id = ?", key, s.uID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("project %q not found", key)
+	}
+	return nil
+}
+
+func (s *sqliteDB) ExpandCommitHashes(projectID int, full map[string]string) (int, error) {
+	if err := s.ownProject(projectID); err != nil {
+		return 0, err
+	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	expanded := 0
+	for short, hash := range full {
+		var id int64
+		err := tx.QueryRow("SELECT id FROM commits WHERE project_id = ? AND hash = ?", projectID, short).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		// a rescan may have stored the commit again; its summary moves to
+		// the first copy unless that has one
+		_, err = tx.Exec(`UPDATE OR IGNORE commit_summary SET commit_id = ?
+		WHERE commit_id IN (SELECT id FROM commits WHERE project_id = ? AND hash = ?)`, id, projectID, hash)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("DELETE FROM commits WHERE project_id = ? AND hash = ?", projectID, hash); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE commits SET hash = ? WHERE id = ?", hash, id); err != nil {
+			return 0, err
+		}
+		expanded++
+	}
+	return expanded, tx.Commit()
+}
+
+func projectChanged(res sql.Result, err error, id int) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("project %d not found", id)
+	}
+	return nil
+}
+
+func (s *sqliteDB) DeleteCommits(projectID int, hashes []string) (int, error) {
+	if err := s.ownProject(projectID); err != nil {
+		return 0, err
+	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM commits WHERE project_id = ? AND hash = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	deleted := 0
+	for _, h := range hashes {
+		res, err := stmt.Exec(projectID, h)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += int(n)
+	}
+	return deleted, tx.Commit()
+}
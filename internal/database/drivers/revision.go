@@ -0,0 +1,205 @@
+package drivers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// Notes of the revisions each kind of save records.
+const (
+	noteCreated = "Created"
+	noteUpdated = "Updated details"
+	noteRestore = "Restored revision %d"
+)
+
+// sectionNotes are the notes of saving to and deleting from each section
+// table or bucket.
+var sectionNotes = map[string][2]string{
+	"work_experiences":  {"Saved work experience", "Deleted work experience"},
+	"educations":        {"Saved education", "Deleted education"},
+	"volunteering":      {"Saved volunteering", "Deleted volunteering"},
+	"project_worked_on": {"Saved projects", "Deleted project"},
+}
+
+// resumeSnapshot encodes what a revision keeps of a resume. Timestamps are
+// dropped, so a save that changes nothing else matches the last revision.
+func resumeSnapshot(r git.Resume) (string, error) {
+	r.CreatedAt, r.UpdatedAt = "", ""
+	r.Profile.ID, r.Profile.PasswordHash = 0, ""
+	b, err := json.Marshal(r)
+	return string(b), err
+}
+
+func errNoRevision(rID int64, revision int) error {
+	return fmt.Errorf("resume %d has no revision %d", rID, revision)
+}
+
+// querier is what the database and a transaction have in common, so a
+// revision can be recorded in the transaction that made the change.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// saveRevision records the resume as it is now, unless nothing changed
+// since its last revision. Writers pass their transaction, so the change
+// and its revision are committed or rolled back together.
+func (s *sqliteDB) saveRevision(q querier, rID int64, note string) error {
+	r, err := s.getResume(q, rID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := resumeSnapshot(r)
+	if err != nil {
+		return err
+	}
+	var last string
+	err = q.QueryRow("SELECT snapshot FROM resume_revisions WHERE resume_id = ? ORDER BY revision DESC LIMIT 1", rID).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if last == snapshot {
+		return nil
+	}
+	_, err = q.Exec(`
+	INSERT INTO resume_revisions (resume_id, revision, note, snapshot)
+	VALUES (?, (SELECT COALESCE(MAX(revision), 0) + 1 FROM resume_revisions WHERE resume_id = ?), ?, ?)`,
+		rID, rID, note, snapshot)
+	return err
+}
+
+// deleteSectionItem deletes an item of a resume section and records a
+// revision of the resume it belonged to.
+func (s *sqliteDB) deleteSectionItem(table string, id int64) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var rID int64
+	err = tx.QueryRow("DELETE FROM "+table+" WHERE id = ? AND resume_id IN (SELECT id FROM resumes WHERE user_id = ?) RETURNING resume_id", id, s.uID).Scan(&rID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.saveRevision(tx, rID, sectionNotes[table][1]); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteDB) GetResumeRevisions(rID int64) ([]git.ResumeRevision, error) {
+	rows, err := s.conn.Query(`
+	SELECT v.id, v.resume_id, v.revision, COALESCE(v.note, ''), v.created_at
+	FROM resume_revisions v JOIN resumes r ON r.id = v.resume_id
+	WHERE v.resume_id = ? AND r.user_id = ? ORDER BY v.revision`, rID, s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []git.ResumeRevision{}
+	for rows.Next() {
+		var r git.ResumeRevision
+		if err := rows.Scan(&r.ID, &r.ResumeID, &r.Revision, &r.Note, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *sqliteDB) GetResumeRevision(rID int64, revision int) (git.ResumeRevision, error) {
+	var (
+		r        git.ResumeRevision
+		snapshot string
+	)
+	err := s.conn.QueryRow(`
+	SELECT v.id, v.resume_id, v.revision, COALESCE(v.note, ''), v.created_at, v.snapshot
+	FROM resume_revisions v JOIN resumes r ON r.id = v.resume_id
+	WHERE v.resume_id = ? AND v.revision = ? AND r.user_id = ?`, rID, revision, s.uID).
+		Scan(&r.ID, &r.ResumeID, &r.Revision, &r.Note, &r.CreatedAt, &snapshot)
+	if err == sql.ErrNoRows {
+		return r, errNoRevision(rID, revision)
+	}
+	if err != nil {
+		return r, err
+	}
+	r.Resume = &git.Resume{}
+	return r, json.Unmarshal([]byte(snapshot), r.Resume)
+}
+
+// RestoreResumeRevision puts the resume's own fields and sections back as
+// they were at revision, keeping the IDs of their items. The profile is
+// shared by every resume, so it is left alone.
+func (s *sqliteDB) RestoreResumeRevision(rID int64, revision int) error {
+	rev, err := s.GetResumeRevision(rID, revision)
+	if err != nil {
+		return err
+	}
+	r := rev.Resume
+	skills := r.Skills
+	if skills == nil {
+		skills = []string{}
+	}
+	skillJSON, _ := json.Marshal(skills)
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	UPDATE resumes SET title = ?, skills = ?, summary = NULLIF(?, ''), language = NULLIF(?, ''), updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?`, r.Title, string(skillJSON), r.Summary, r.Language, rID)
+	if err != nil {
+		return err
+	}
+	for table := range sectionNotes {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE resume_id = ?", rID); err != nil {
+			return err
+		}
+	}
+	for _, w := range r.WorkExperiences {
+		_, err := tx.Exec(`
+		INSERT INTO work_experiences (id, resume_id, company, role, location, start_date, end_date, responsibilities, is_translated, projects)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			w.ID, rID, w.Company, w.Role, w.Location, w.StartDate, w.EndDate, w.Responsibilities, w.IsTranslated, w.Projects)
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range r.Education {
+		_, err := tx.Exec("INSERT INTO educations (id, resume_id, school, degree, field_of_study, end_date) VALUES (?, ?, ?, ?, ?, ?)",
+			e.ID, rID, e.School, e.Degree, e.FieldOfStudy, e.EndDate)
+		if err != nil {
+			return err
+		}
+	}
+	for _, v := range r.Volunteers {
+		_, err := tx.Exec("INSERT INTO volunteering (id, resume_id, title, description, link) VALUES (?, ?, ?, ?, ?)",
+			v.ID, rID, v.Title, v.Description, v.Link)
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range r.ProjectWorkedOn {
+		_, err := tx.Exec("INSERT INTO project_worked_on (id, resume_id, title, description, technologies, link) VALUES (?, ?, ?, ?, ?, ?)",
+			p.ID, rID, p.Title, p.Description, p.Technologies, p.Link)
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.saveRevision(tx, rID, fmt.Sprintf(noteRestore, revision)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
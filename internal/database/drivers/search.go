@@ -0,0 +1,378 @@
+package drivers
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/iamhabbeboy/gitresume/config"
+)
+
+// searchSource is a table the full-text index covers. In body, "$." stands
+// for the row, so the same expression serves the triggers and the queries.
+type searchSource struct {
+	kind  string
+	table string
+	joins string
+	// cols selects user_id, project_id, project, resume_id, title and day, by
+	// name.
+	cols string
+	body string
+}
+
+// htmlTags are the tags the dashboard editor writes; the index drops them
+// so they neither match nor show up in snippets.
+var htmlTags = []string{"<ul>", "</ul>", "<ol>", "</ol>", "<li>", "</li>", "<p>", "</p>", "<br>", "<br/>", "<strong>", "</strong>", "<em>", "</em>"}
+
+func stripTagsSQL(expr string) string {
+	for _, tag := range htmlTags {
+		expr = fmt.Sprintf("replace(%s, '%s', ' ')", expr, tag)
+	}
+	return expr
+}
+
+// searchSources are indexed in this order; a row's rowid in search_index is
+// its ID times len(searchSources) plus the source's position.
+var searchSources = []searchSource{
+	{
+		kind:  config.SearchCommit,
+		table: "commits",
+		joins: "JOIN projects p ON p.id = x.project_id",
+		cols:  "p.user_id AS user_id, x.project_id AS project_id, p.name AS project, 0 AS resume_id, substr(x.hash, 1, 7) AS title, COALESCE(x.committed_at, date(x.created_at)) AS day",
+		body:  "$.message",
+	},
+	{
+		kind:  config.SearchSummary,
+		table: "commit_summary",
+		joins: "JOIN projects p ON p.id = x.project_id",
+		cols:  "p.user_id AS user_id, x.project_id AS project_id, p.name AS project, 0 AS resume_id, '' AS title, date(x.created_at) AS day",
+		body:  "$.summary",
+	},
+	{
+		kind:  config.SearchExperience,
+		table: "work_experiences",
+		joins: "JOIN resumes r ON r.id = x.resume_id",
+		cols:  "r.user_id AS user_id, 0 AS project_id, '' AS project, x.resume_id AS resume_id, COALESCE(x.company, '') AS title, substr(COALESCE(x.start_date, ''), 1, 10) AS day",
+		body:  stripTagsSQL("COALESCE($.role, '') || ' ' || COALESCE($.company, '') || ' ' || COALESCE($.responsibilities, '')"),
+	},
+	{
+		kind:  config.SearchProject,
+		table: "project_worked_on",
+		joins: "JOIN resumes r ON r.id = x.resume_id",
+		cols:  "r.user_id AS user_id, 0 AS project_id, '' AS project, x.resume_id AS resume_id, x.title AS title, date(x.created_at) AS day",
+		body:  stripTagsSQL("$.title || ' ' || COALESCE($.description, '')"),
+	},
+}
+
+func (src searchSource) bodyOf(alias string) string {
+	return strings.ReplaceAll(src.body, "$.", alias+".")
+}
+
+func (src searchSource) triggerNames() []string {
+	return []string{"search_" + src.table + "_ai", "search_" + src.table + "_au", "search_" + src.table + "_ad"}
+}
+
+// hasFTS5 reports whether this SQLite build has the FTS5 module. The
+// pure-Go driver always does; mattn/go-sqlite3 needs -tags sqlite_fts5.
+func (s *sqliteDB) hasFTS5() bool {
+	var on bool
+	err := s.conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&on)
+	return err == nil && on
+}
+
+// searchIndexReady reports whether search_index exists and its triggers
+// keep it in sync.
+func (s *sqliteDB) searchIndexReady() (bool, error) {
+	ok, err := s.hasTable("search_index")
+	if err != nil || !ok {
+		return false, err
+	}
+	var n int
+	err = s.conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'search\\_%' ESCAPE '\\'").Scan(&n)
+	return n == 3*len(searchSources), err
+}
+
+// dropSearchTriggers stops maintaining the index. A build without FTS5
+// cannot write to search_index, so its triggers would fail every insert.
+func (s *sqliteDB) dropSearchTriggers() error {
+	for _, src := range searchSources {
+		for _, name := range src.triggerNames() {
+			if _, err := s.conn.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ensureSearchIndex creates the full-text index and its triggers and fills
+// it from the tables. The index is derived data, so it lives outside the
+// versioned migrations: builds without FTS5 drop the triggers, and the next
+// build with FTS5 rebuilds the index.
+func (s *sqliteDB) ensureSearchIndex() error {
+	if !s.hasFTS5() {
+		return s.dropSearchTriggers()
+	}
+	ready, err := s.searchIndexReady()
+	if err != nil || ready {
+		return err
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		"CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(body, tokenize = 'porter unicode61')",
+		"DELETE FROM search_index",
+	}
+	n := len(searchSources)
+	for k, src := range searchSources {
+		names := src.triggerNames()
+		insert := fmt.Sprintf("INSERT INTO search_index (rowid, body) VALUES (new.id * %d + %d, %s);", n, k, src.bodyOf("new"))
+		remove := fmt.Sprintf("DELETE FROM search_index WHERE rowid = old.id * %d + %d;", n, k)
+		stmts = append(stmts,
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s BEGIN %s END", names[0], src.table, insert),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE ON %s BEGIN %s %s END", names[1], src.table, remove, insert),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER DELETE ON %s BEGIN %s END", names[2], src.table, remove),
+			fmt.Sprintf("INSERT INTO search_index (rowid, body) SELECT x.id * %d + %d, %s FROM %s x", n, k, src.bodyOf("x"), src.table),
+		)
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Search ranks commits, summaries, work experiences and projects against
+// q. It uses the FTS5 index when there is one and falls back to LIKE.
+func (s *sqliteDB) Search(q config.SearchQuery) ([]config.SearchResult, error) {
+	terms := searchTerms(q.Text)
+	if len(terms) == 0 {
+		return []config.SearchResult{}, nil
+	}
+	ready := false
+	if s.hasFTS5() {
+		var err error
+		if ready, err = s.searchIndexReady(); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		arms []string
+		args []any
+	)
+	n := len(searchSources)
+	for k, src := range searchSources {
+		if !wantKind(q.Kinds, src.kind) {
+			continue
+		}
+		if ready {
+			arms = append(arms, fmt.Sprintf(
+				"SELECT '%s' AS kind, x.id AS id, %s, h.snip AS snip, h.score AS score FROM hits h JOIN %s x ON h.rid = x.id * %d + %d %s",
+				src.kind, src.cols, src.table, n, k, src.joins))
+			continue
+		}
+		var like []string
+		for _, t := range terms {
+			like = append(like, src.bodyOf("x")+" LIKE ? ESCAPE '\\'")
+			args = append(args, "%"+escapeLike(t)+"%")
+		}
+		arms = append(arms, fmt.Sprintf(
+			"SELECT '%s' AS kind, x.id AS id, %s, %s AS snip, 0 AS score FROM %s x %s WHERE %s",
+			src.kind, src.cols, src.bodyOf("x"), src.table, src.joins, strings.Join(like, " AND ")))
+	}
+	if len(arms) == 0 {
+		return []config.SearchResult{}, nil
+	}
+	query := "SELECT kind, id, project_id, project, resume_id, title, day, snip, score FROM (" + strings.Join(arms, " UNION ALL ") + `)
+	WHERE user_id = ? AND (? = 0 OR project_id = ?) AND (? = '' OR day >= ?) AND (? = '' OR day <= ?)`
+	args = append(args, s.uID, q.ProjectID, q.ProjectID, q.Since, q.Since, q.Until, q.Until)
+	if ready {
+		query = fmt.Sprintf(`WITH hits AS MATERIALIZED (
+			SELECT rowid AS rid, snippet(search_index, 0, char(1), char(2), '…', 16) AS snip, -bm25(search_index) AS score
+			FROM search_index WHERE search_index MATCH ?
+		) %s ORDER BY score DESC, day DESC`, query)
+		args = append([]any{ftsQuery(terms)}, args...)
+		if q.Limit > 0 {
+			query += " LIMIT ?"
+			args = append(args, q.Limit)
+		}
+	}
+
+	rows, err := s.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []config.SearchResult{}
+	for rows.Next() {
+		var r config.SearchResult
+		if err := rows.Scan(&r.Kind, &r.ID, &r.ProjectID, &r.Project, &r.ResumeID, &r.Title, &r.Date, &r.Snippet, &r.Score); err != nil {
+			return nil, err
+		}
+		if ready {
+			r.Snippet = markSnippet(r.Snippet)
+		} else if r.Score, r.Snippet = matchText(r.Snippet, terms); r.Score == 0 {
+			continue
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !ready {
+		results = rankResults(results, q.Limit)
+	}
+	return results, nil
+}
+
+func wantKind(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// searchTerms splits a query into lower-case words, dropping punctuation
+// and FTS5 syntax.
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ftsQuery quotes every term, so they are matched as words rather than read
+// as FTS5 operators, and lets the last one match as a prefix.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	return strings.Join(quoted, " ") + "*"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// markSnippet turns an FTS5 snippet, with matches between \x01 and \x02,
+// into escaped HTML with the matches wrapped in <mark>.
+func markSnippet(s string) string {
+	s = html.EscapeString(strings.Join(strings.Fields(s), " "))
+	return strings.NewReplacer("\x01", config.SnippetOpen, "\x02", config.SnippetClose).Replace(s)
+}
+
+var anyTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText drops the HTML of rich-text fields.
+func plainText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(s, " "))), " ")
+}
+
+// snippetRadius is how many characters of context a snippet keeps on
+// either side of the first match.
+const snippetRadius = 60
+
+// matchText scores body by how often the terms occur in it, and cuts a
+// snippet around the first match. The score is 0 unless every term occurs.
+// It serves the drivers and builds without a full-text index.
+func matchText(body string, terms []string) (float64, string) {
+	text := []rune(plainText(body))
+	lower := []rune(strings.ToLower(string(text)))
+	if len(lower) != len(text) {
+		lower = text
+	}
+
+	score, first := 0.0, -1
+	for _, t := range terms {
+		n, at := countRunes(lower, []rune(t))
+		if n == 0 {
+			return 0, ""
+		}
+		score += float64(n)
+		if first < 0 || at < first {
+			first = at
+		}
+	}
+
+	start, end := max(0, first-snippetRadius), min(len(text), first+2*snippetRadius)
+	for start > 0 && !unicode.IsSpace(text[start-1]) {
+		start--
+	}
+	for end < len(text) && !unicode.IsSpace(text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		if n := matchAt(lower, i, terms); n > 0 && i+n <= end {
+			b.WriteString(config.SnippetOpen + html.EscapeString(string(text[i:i+n])) + config.SnippetClose)
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(string(text[i])))
+		i++
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return score, b.String()
+}
+
+func countRunes(s, sub []rune) (int, int) {
+	n, first := 0, -1
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			if first < 0 {
+				first = i
+			}
+			n++
+		}
+	}
+	return n, first
+}
+
+// matchAt returns the length of the longest term at s[i:], or 0.
+func matchAt(s []rune, i int, terms []string) int {
+	best := 0
+	for _, t := range terms {
+		r := []rune(t)
+		if len(r) > best && i+len(r) <= len(s) && string(s[i:i+len(r)]) == t {
+			best = len(r)
+		}
+	}
+	return best
+}
+
+// rankResults orders results by score, then newest first, and keeps at
+// most limit when limit is positive.
+func rankResults(results []config.SearchResult, limit int) []config.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Date > results[j].Date
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -1,3 +1,6 @@
+-- Everything here is IF NOT EXISTS: a database from before migrations were
+-- versioned is adopted by running this script over the tables and indexes
+-- it already has.
 CREATE TABLE IF NOT EXISTS users (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
@@ -42,6 +45,8 @@ CREATE TABLE IF NOT EXISTS commit_summary (
     project_id INTEGER NOT NULL,
     commit_id INTEGER,
     summary TEXT NOT NULL,
+    -- the reviewed AI suggestion this summary was accepted from
+    suggestion_id INTEGER REFERENCES suggestions(id) ON DELETE SET NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
@@ -55,17 +60,35 @@ CREATE INDEX IF NOT EXISTS idx_commit_summary_project_id ON commit_summary(proje
 
 CREATE TABLE IF NOT EXISTS resumes (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    user_id INTEGER NOT NULL, 
+    user_id INTEGER NOT NULL,
     version INTEGER NOT NULL,
     title TEXT DEFAULT 'Untitled Resume',
     skills TEXT,
     is_published BOOLEAN DEFAULT 0,
     published_at DATETIME,
+    -- a tailored or translated resume links to the resume it came from and
+    -- the job it targets; summary overrides the profile summary and
+    -- language is a code such as "de"
+    parent_id INTEGER REFERENCES resumes(id) ON DELETE SET NULL,
+    job_id INTEGER REFERENCES jobs(id) ON DELETE SET NULL,
+    summary TEXT,
+    language TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- Job descriptions resumes are tailored to.
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title TEXT,
+    company TEXT,
+    description TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 CREATE TABLE IF NOT EXISTS work_experiences (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     resume_id INTEGER NOT NULL,
@@ -79,7 +102,7 @@ CREATE TABLE IF NOT EXISTS work_experiences (
     is_translated BOOLEAN DEFAULT 0,
     FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
 );
-CREATE INDEX idx_work_experiences_resume_id ON work_experiences(resume_id);
+CREATE INDEX IF NOT EXISTS idx_work_experiences_resume_id ON work_experiences(resume_id);
 
 CREATE TABLE IF NOT EXISTS prompts (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -101,7 +124,7 @@ CREATE TABLE IF NOT EXISTS volunteering (
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
 );
-CREATE INDEX idx_volunteering_resume_id ON volunteering(resume_id);
+CREATE INDEX IF NOT EXISTS idx_volunteering_resume_id ON volunteering(resume_id);
 
 
 CREATE TABLE IF NOT EXISTS project_worked_on (
@@ -115,7 +138,7 @@ CREATE TABLE IF NOT EXISTS project_worked_on (
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
 );
-CREATE INDEX idx_project_worked_on_resume_id ON project_worked_on(resume_id);
+CREATE INDEX IF NOT EXISTS idx_project_worked_on_resume_id ON project_worked_on(resume_id);
 
 
 CREATE TABLE IF NOT EXISTS educations (
@@ -128,7 +151,90 @@ CREATE TABLE IF NOT EXISTS educations (
     end_date DATETIME,
     FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
 );
-CREATE INDEX idx_educations_resume_id ON educations(resume_id);
+CREATE INDEX IF NOT EXISTS idx_educations_resume_id ON educations(resume_id);
+
+-- Every request sent to an AI provider, with the prompt as sent after
+-- redaction, so users can see which text left the machine.
+CREATE TABLE IF NOT EXISTS ai_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    model TEXT,
+    endpoint TEXT,
+    prompt_type TEXT,
+    project TEXT,
+    origin TEXT,
+    prompt TEXT NOT NULL,
+    redactions INTEGER DEFAULT 0,
+    response_hash TEXT,
+    success BOOLEAN DEFAULT 1,
+    error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_ai_audit_created_at ON ai_audit(created_at);
+
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    temperature REAL DEFAULT 0.7,
+    max_tokens INTEGER DEFAULT 1024,
+    content TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (title, version)
+);
+
+CREATE TABLE IF NOT EXISTS prompt_evals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    provider TEXT NOT NULL,
+    model TEXT,
+    output TEXT,
+    bullets INTEGER DEFAULT 0,
+    avg_length REAL DEFAULT 0,
+    action_verbs REAL DEFAULT 0,
+    quantified REAL DEFAULT 0,
+    latency_ms INTEGER DEFAULT 0,
+    cost REAL DEFAULT 0,
+    error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_prompt_evals_run_id ON prompt_evals(run_id);
+
+-- Evidence vectors for job matching, stored as little-endian float32 blobs.
+CREATE TABLE IF NOT EXISTS embeddings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ref TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    source TEXT,
+    content TEXT NOT NULL,
+    model TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    vector BLOB NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(ref, model)
+);
+
+-- AI output waiting for review, with the commits, prompt version and model
+-- it came from. Accepted text is copied into commit_summary.
+CREATE TABLE IF NOT EXISTS suggestions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER,
+    task TEXT NOT NULL,
+    provider TEXT,
+    model TEXT,
+    prompt_version INTEGER DEFAULT 0,
+    source_commits TEXT,
+    content TEXT NOT NULL,
+    edited_content TEXT,
+    confidence REAL DEFAULT 0,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'accepted', 'edited', 'rejected')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_suggestions_project_id ON suggestions(project_id);
 
 
 -- Users
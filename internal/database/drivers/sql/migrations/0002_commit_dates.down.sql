@@ -0,0 +1 @@
+ALTER TABLE commits DROP COLUMN committed_at;
@@ -0,0 +1,2 @@
+-- the author date of each commit, so search can filter by when the work was done
+ALTER TABLE commits ADD COLUMN committed_at TEXT;
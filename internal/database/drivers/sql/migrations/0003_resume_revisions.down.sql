@@ -0,0 +1 @@
+DROP TABLE resume_revisions;
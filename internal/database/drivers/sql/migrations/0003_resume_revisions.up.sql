@@ -0,0 +1,11 @@
+-- an immutable snapshot of a resume, as JSON, after every save
+CREATE TABLE resume_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    resume_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    note TEXT,
+    snapshot TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (resume_id, revision),
+    FOREIGN KEY (resume_id) REFERENCES resumes(id) ON DELETE CASCADE
+);
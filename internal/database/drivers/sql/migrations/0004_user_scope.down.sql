@@ -0,0 +1,29 @@
+-- only the first user's prompts fit the old one-prompt-per-title schema
+DROP INDEX idx_suggestions_user_id;
+ALTER TABLE suggestions DROP COLUMN user_id;
+
+DROP INDEX idx_embeddings_user_id;
+ALTER TABLE embeddings DROP COLUMN user_id;
+
+DROP INDEX idx_prompt_evals_user_id;
+ALTER TABLE prompt_evals DROP COLUMN user_id;
+
+CREATE TABLE prompt_versions_shared (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    temperature REAL DEFAULT 0.7,
+    max_tokens INTEGER DEFAULT 1024,
+    content TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (title, version)
+);
+INSERT INTO prompt_versions_shared (id, title, version, temperature, max_tokens, content, created_at)
+SELECT id, title, version, temperature, max_tokens, content, created_at FROM prompt_versions
+WHERE user_id IS (SELECT MIN(user_id) FROM prompt_versions);
+DROP TABLE prompt_versions;
+ALTER TABLE prompt_versions_shared RENAME TO prompt_versions;
+
+DELETE FROM prompts WHERE user_id IS NOT (SELECT MIN(user_id) FROM prompts);
+DROP INDEX idx_prompts_user_title;
+ALTER TABLE prompts DROP COLUMN user_id;
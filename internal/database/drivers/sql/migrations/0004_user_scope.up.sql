@@ -0,0 +1,34 @@
+-- prompts, evaluations, embeddings and suggestions belong to a user, like
+-- projects and resumes; what exists already goes to the first user. The
+-- columns have no foreign key so that the down migration can drop them.
+ALTER TABLE prompts ADD COLUMN user_id INTEGER;
+UPDATE prompts SET user_id = (SELECT MIN(id) FROM users);
+CREATE UNIQUE INDEX idx_prompts_user_title ON prompts(user_id, title);
+
+CREATE TABLE prompt_versions_scoped (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    title TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    temperature REAL DEFAULT 0.7,
+    max_tokens INTEGER DEFAULT 1024,
+    content TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, title, version)
+);
+INSERT INTO prompt_versions_scoped (id, user_id, title, version, temperature, max_tokens, content, created_at)
+SELECT id, (SELECT MIN(id) FROM users), title, version, temperature, max_tokens, content, created_at FROM prompt_versions;
+DROP TABLE prompt_versions;
+ALTER TABLE prompt_versions_scoped RENAME TO prompt_versions;
+
+ALTER TABLE prompt_evals ADD COLUMN user_id INTEGER;
+UPDATE prompt_evals SET user_id = (SELECT MIN(id) FROM users);
+CREATE INDEX idx_prompt_evals_user_id ON prompt_evals(user_id);
+
+ALTER TABLE embeddings ADD COLUMN user_id INTEGER;
+UPDATE embeddings SET user_id = (SELECT MIN(id) FROM users);
+CREATE INDEX idx_embeddings_user_id ON embeddings(user_id);
+
+ALTER TABLE suggestions ADD COLUMN user_id INTEGER;
+UPDATE suggestions SET user_id = (SELECT MIN(id) FROM users);
+CREATE INDEX idx_suggestions_user_id ON suggestions(user_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_commits_project_hash;
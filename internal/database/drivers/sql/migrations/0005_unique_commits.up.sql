@@ -0,0 +1,4 @@
+-- A re-seed after a failed run could store a commit twice. Make
+-- (project_id, hash) unique. Migrate refuses to run this while duplicates
+-- exist: `gitresume db check --repair` merges them and their summaries.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commits_project_hash ON commits(project_id, hash);
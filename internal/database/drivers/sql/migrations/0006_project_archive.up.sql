@@ -0,0 +1,2 @@
+-- archived projects keep their commits but are left out of the dashboard
+ALTER TABLE projects ADD COLUMN archived_at DATETIME;
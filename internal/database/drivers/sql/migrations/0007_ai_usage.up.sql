@@ -0,0 +1,20 @@
+-- one row per call to an AI provider, with its token counts and cost.
+-- Databases from before versioned migrations may already have it: the old
+-- schema script created it but could stop before reaching it.
+CREATE TABLE IF NOT EXISTS ai_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    model TEXT,
+    prompt_type TEXT,
+    project TEXT,
+    origin TEXT,
+    input_tokens INTEGER DEFAULT 0,
+    output_tokens INTEGER DEFAULT 0,
+    estimated BOOLEAN DEFAULT 0,
+    latency_ms INTEGER DEFAULT 0,
+    success BOOLEAN DEFAULT 1,
+    error TEXT,
+    cost REAL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_ai_usage_created_at ON ai_usage(created_at);
@@ -0,0 +1,5 @@
+DROP INDEX idx_ai_audit_user_id;
+ALTER TABLE ai_audit DROP COLUMN user_id;
+
+DROP INDEX idx_ai_usage_user_id;
+ALTER TABLE ai_usage DROP COLUMN user_id;
@@ -0,0 +1,9 @@
+-- AI usage and the audit log belong to the user a call was made for, like
+-- prompts and suggestions; what exists already goes to the first user.
+ALTER TABLE ai_usage ADD COLUMN user_id INTEGER;
+UPDATE ai_usage SET user_id = (SELECT MIN(id) FROM users);
+CREATE INDEX idx_ai_usage_user_id ON ai_usage(user_id);
+
+ALTER TABLE ai_audit ADD COLUMN user_id INTEGER;
+UPDATE ai_audit SET user_id = (SELECT MIN(id) FROM users);
+CREATE INDEX idx_ai_audit_user_id ON ai_audit(user_id);
@@ -2,13 +2,16 @@ package drivers
 
 import (
 	"database/sql"
-	_ "embed"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 
 	"path/filepath"
@@ -16,21 +19,18 @@ import (
 	"github.com/iamhabbeboy/gitresume/config"
 	"github.com/iamhabbeboy/gitresume/internal/git"
 	"github.com/iamhabbeboy/gitresume/util"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed sql/schema.sql
-var schema string
-
 const DEV_COMMIT_SQLITE_DB_FILE = "gitresume_sqlite.db"
 
 type sqliteDB struct {
 	conn *sql.DB
+	path string
+	// uID is the user projects, resumes, jobs and prompts are read and
+	// written for; 0 until ForUser picks one.
+	uID int64
 }
 
-var uID int64 = 1
-
 func NewSqlite() (*sqliteDB, error) {
 	home, _ := os.UserHomeDir()
 	dbPath := filepath.Join(home, "."+util.APP_NAME, DEV_COMMIT_SQLITE_DB_FILE)
@@ -38,17 +38,15 @@ func NewSqlite() (*sqliteDB, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(sqliteDriver, sqliteDSN(dbPath))
 	if err != nil {
 		return nil, err
 	}
-
-	_, err = db.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
+	if err := db.Ping(); err != nil {
 		return nil, err
 	}
 
-	return &sqliteDB{conn: db}, nil
+	return &sqliteDB{conn: db, path: dbPath}, nil
 }
 
 func (s *sqliteDB) Close() error {
@@ -78,7 +76,10 @@ func (s *sqliteDB) GetUser(email string) (git.Profile, error) {
 }
 
 func (s *sqliteDB) CreateUser(data git.Profile) (int64, error) {
-	hash, _ := util.GenerateHash(data.PasswordHash)
+	hash, err := hashPassword(data.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
 
 	row, err := s.conn.Exec("INSERT INTO users(name, email, password_hash) VALUES(?, ?, ?)", data.Name, data.Email, hash)
 	if err != nil {
@@ -90,96 +91,113 @@ func (s *sqliteDB) CreateUser(data git.Profile) (int64, error) {
 }
 
 func (s *sqliteDB) CreateProject(data git.Project) error {
+	uID, err := s.owner()
+	if err != nil {
+		return err
+	}
 	tx, err := s.conn.Begin()
 	if err != nil {
 		return err
 	}
 
+	defer tx.Rollback()
+
 	p, err := s.GetProjectByName(data.Name)
+	if err != nil {
+		return err
+	}
 	var prjID int64 = int64(p.ID)
 
 	if p.Name == "" {
 		// store projects
 		query := `INSERT INTO projects (user_id, name, path, technologies) VALUES (?, ?, ?, ?)`
-		row, err := tx.Exec(query, 1, data.Name, data.Path, data.Technologies)
+		row, err := tx.Exec(query, uID, data.Name, data.Path, data.Technologies)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
-		lID, _ := row.LastInsertId()
-		prjID = lID
-	}
-	// store commits
-	placeholders := make([]string, 0, len(data.Commits))
-	value := make([]any, 0, len(data.Commits)*2)
-
-	for i := len(data.Commits) - 1; i >= 0; i-- {
-		v := data.Commits[i]
-		placeholders = append(placeholders, "(?, ?, ?)")
-		value = append(value, prjID, v.Msg, v.Hash)
+		if prjID, err = row.LastInsertId(); err != nil {
+			return err
+		}
 	}
-	q := fmt.Sprintf(
-		"INSERT INTO commits (project_id, message, hash) VALUES %s",
-		strings.Join(placeholders, ","),
-	)
-	_, err = tx.Exec(q, value...)
 
-	if err := tx.Commit(); err != nil {
+	// git log lists the newest commit first; store the oldest first
+	commits := slices.Clone(data.Commits)
+	slices.Reverse(commits)
+	if _, err := insertCommits(tx, prjID, commits); err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
-func (s *sqliteDB) GetProjectByName(n string) (git.Project, error) {
-	var (
-		id      int
-		name    string
-		path    string
-		commits sql.NullString
-	)
-	query := `
-		SELECT 
-			p.id, p.name, p.path, COALESCE((
-        SELECT json_group_array(
-            json_object(
-                'id', c.id,
-                'hash', c.hash,
-                'message', c.message
-            )
-        )
-        FROM commits c
-	WHERE c.project_id = p.id
-        ), '[]') AS commits
-		FROM projects p WHERE p.name = ?`
-	err := s.conn.QueryRow(query, n).
-		Scan(&id, &name, &path, &commits)
+// AddCommits stores commits, oldest first, in one transaction and returns
+// how many were new.
+func (s *sqliteDB) AddCommits(projectID int, commits []git.GitCommit) (int, error) {
+	if err := s.ownProject(projectID); err != nil {
+		return 0, err
+	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-	if err == sql.ErrNoRows {
-		return git.Project{}, nil
+	n, err := insertCommits(tx, int64(projectID), commits)
+	if err != nil {
+		return 0, err
 	}
+	return n, tx.Commit()
+}
 
+// insertCommits reuses one prepared statement, so any number of commits
+// stays within SQLite's limit on bound variables.
+func insertCommits(tx *sql.Tx, prjID int64, commits []git.GitCommit) (int, error) {
+	if len(commits) == 0 {
+		return 0, nil
+	}
+	// commits already stored, say by a seed that failed halfway, are skipped
+	stmt, err := tx.Prepare(`INSERT INTO commits (project_id, message, hash, committed_at)
+		VALUES (?, ?, ?, NULLIF(?, '')) ON CONFLICT (project_id, hash) DO NOTHING`)
 	if err != nil {
-		return git.Project{}, err
+		return 0, err
 	}
+	defer stmt.Close()
 
-	var coms []git.GitCommit
-	if commits.Valid {
-		err = json.Unmarshal([]byte(commits.String), &coms)
+	added := 0
+	for _, v := range commits {
+		res, err := stmt.Exec(prjID, v.Msg, v.Hash, v.Date)
+		if err != nil {
+			return added, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return added, err
+		}
+		added += int(n)
 	}
+	return added, nil
+}
 
-	return git.Project{
-		ID:      id,
-		Name:    name,
-		Path:    path,
-		Commits: coms,
-	}, nil
+func (s *sqliteDB) GetProjectByName(n string) (git.Project, error) {
+	return s.getProject("p.name = ?", n)
 }
 
 func (s *sqliteDB) UpsertCommit(commits []git.CustomUpdateCommit) error {
+	checked := map[int]bool{}
+	for _, c := range commits {
+		if checked[c.ProjectID] {
+			continue
+		}
+		if err := s.ownProject(c.ProjectID); err != nil {
+			return err
+		}
+		checked[c.ProjectID] = true
+	}
 	tx, err := s.conn.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
 	query := `
 	   INSERT INTO commit_summary (project_id, commit_id, summary, created_at, updated_at)
 	    VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
@@ -193,7 +211,6 @@ func (s *sqliteDB) UpsertCommit(commits []git.CustomUpdateCommit) error {
 		return err
 	}
 	defer stmt.Close()
-	var rows sql.Result
 
 	if len(commits) > 0 {
 		f := commits[0]
@@ -201,7 +218,8 @@ func (s *sqliteDB) UpsertCommit(commits []git.CustomUpdateCommit) error {
 		cID := f.GitCommit.ID
 
 		if cID == 0 {
-			_, err = tx.Exec(`DELETE FROM commit_summary WHERE project_id = ? AND commit_id IS NULL`, prjID)
+			// summaries accepted from suggestions are managed by their review
+			_, err = tx.Exec(`DELETE FROM commit_summary WHERE project_id = ? AND commit_id IS NULL AND suggestion_id IS NULL`, prjID)
 			if err != nil {
 				return err
 			}
@@ -209,28 +227,22 @@ func (s *sqliteDB) UpsertCommit(commits []git.CustomUpdateCommit) error {
 	}
 
 	for _, v := range commits {
-		var commitID sql.NullInt64
+		var rows sql.Result
 		if v.GitCommit.ID == 0 {
-			// commitID = sql.NullInt64{Valid: false}
 			rows, err = tx.Exec(`
 				INSERT INTO commit_summary (project_id, commit_id, summary, created_at, updated_at)
 				VALUES (?, NULL, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 			`, v.ProjectID, v.GitCommit.Msg)
-			if err != nil {
-				log.Printf("Failed with: %v", err)
-			}
 		} else {
-			commitID = sql.NullInt64{Int64: int64(v.ID), Valid: true}
-			rows, err = stmt.Exec(v.ProjectID, commitID, v.Msg)
-			if err != nil {
-				log.Printf("Failed with: %v", err)
-			}
+			rows, err = stmt.Exec(v.ProjectID, sql.NullInt64{Int64: int64(v.ID), Valid: true}, v.Msg)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to save summary of commit id=%d: %w", v.ID, err)
 		}
 
 		rowsAffected, err := rows.RowsAffected()
 		if err != nil {
-			_ = tx.Rollback()
-			log.Printf("Failed to execute queries : %v", err)
+			return err
 		}
 		if rowsAffected == 0 {
 			log.Printf("⚠️ Record with id=%d does not exist, skipping update\n", v.ID)
@@ -239,17 +251,17 @@ func (s *sqliteDB) UpsertCommit(commits []git.CustomUpdateCommit) error {
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	return nil
+	return tx.Commit()
 }
 func (s *sqliteDB) GetCommitById(id int) (git.GitCommit, error) {
 	var (
-		msg string
+		hash sql.NullString
+		msg  string
 	)
-	err := s.conn.QueryRow("SELECT message FROM projects WHERE id = ?", id).
-		Scan(&msg)
+	err := s.conn.QueryRow(`
+	SELECT c.hash, c.message FROM commits c JOIN projects p ON p.id = c.project_id
+	WHERE c.id = ? AND p.user_id = ?`, id, s.uID).
+		Scan(&hash, &msg)
 
 	if err == sql.ErrNoRows {
 		return git.GitCommit{}, nil
@@ -260,15 +272,22 @@ func (s *sqliteDB) GetCommitById(id int) (git.GitCommit, error) {
 	}
 
 	return git.GitCommit{
-		Msg: msg,
+		ID:   id,
+		Hash: hash.String,
+		Msg:  msg,
 	}, nil
 }
 
 func (s *sqliteDB) GetAllCommitSummary(prjID int) ([]git.CustomUpdateCommit, error) {
 	query := `
-		SELECT id, summary, created_at FROM commit_summary WHERE project_id = ?
+		SELECT cs.id, cs.summary, cs.created_at, COALESCE(sg.source_commits, '')
+		FROM commit_summary cs
+		JOIN projects p ON p.id = cs.project_id
+		LEFT JOIN suggestions sg ON sg.id = cs.suggestion_id
+		WHERE cs.project_id = ? AND p.user_id = ?
+		ORDER BY cs.id
 	`
-	rows, err := s.conn.Query(query, prjID)
+	rows, err := s.conn.Query(query, prjID, s.uID)
 	if err != nil {
 		return nil, err
 	}
@@ -280,16 +299,20 @@ func (s *sqliteDB) GetAllCommitSummary(prjID int) ([]git.CustomUpdateCommit, err
 			summary   string
 			id        int
 			createdAt string
+			sources   string
+			commits   []string
 		)
-		if err := rows.Scan(&id, &summary, &createdAt); err != nil {
+		if err := rows.Scan(&id, &summary, &createdAt, &sources); err != nil {
 			return nil, err
 		}
+		_ = util.ConvertNullToSlice([]byte(sources), &commits)
 		summaries = append(summaries, git.CustomUpdateCommit{
 			ProjectID: id,
 			GitCommit: git.GitCommit{
 				Msg:       summary,
 				CreatedAt: createdAt,
 			},
+			SourceCommits: commits,
 		})
 	}
 
@@ -301,34 +324,41 @@ func (s *sqliteDB) GetAllProject(limit, offset int) ([]git.Project, error) {
 	SELECT 
 		p.id AS project_id,
 		p.name AS project_name,
+		p.path AS path,
 		p.technologies AS technologies,
 		c.id AS commit_id,
+		c.hash,
 		c.message,
 		c.created_at,
 		c.updated_at
 	FROM projects p
 	LEFT JOIN commits c
-    ON p.id = c.project_id;
+    ON p.id = c.project_id
+	WHERE p.user_id = ? AND p.archived_at IS NULL
+	ORDER BY p.id, c.id;
 	`
-	rows, err := s.conn.Query(query)
+	rows, err := s.conn.Query(query, s.uID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	projectsMap := make(map[int]*git.Project)
+	var order []int
 
 	for rows.Next() {
 		var (
 			projectID        int
 			projectName      string
+			path             sql.NullString
 			technologies     sql.NullString
 			commitID         sql.NullInt64
+			commitHash       sql.NullString
 			commitMsg        sql.NullString
 			commitDate       sql.NullString
 			commitUpdateDate sql.NullString
 		)
-		err := rows.Scan(&projectID, &projectName, &technologies, &commitID, &commitMsg, &commitDate, &commitUpdateDate)
+		err := rows.Scan(&projectID, &projectName, &path, &technologies, &commitID, &commitHash, &commitMsg, &commitDate, &commitUpdateDate)
 		if err != nil {
 			return nil, err
 		}
@@ -337,15 +367,18 @@ func (s *sqliteDB) GetAllProject(limit, offset int) ([]git.Project, error) {
 			projectsMap[projectID] = &git.Project{
 				ID:           projectID,
 				Name:         projectName,
+				Path:         path.String,
 				Technologies: technologies.String,
 				Commits:      []git.GitCommit{},
 			}
+			order = append(order, projectID)
 		}
 		if commitID.Valid {
 			projectsMap[projectID].Commits = append(
 				projectsMap[projectID].Commits,
 				git.GitCommit{
 					ID:        int(commitID.Int64),
+					Hash:      commitHash.String,
 					Msg:       commitMsg.String,
 					CreatedAt: commitDate.String,
 					UpdatedAt: commitUpdateDate.String,
@@ -355,13 +388,17 @@ func (s *sqliteDB) GetAllProject(limit, offset int) ([]git.Project, error) {
 	}
 
 	var projects []git.Project
-	for _, p := range projectsMap {
-		projects = append(projects, *p)
+	for _, id := range order {
+		projects = append(projects, *projectsMap[id])
 	}
 	return projects, nil
 }
 
 func (s *sqliteDB) CreateResume(r git.Resume) (git.Resume, error) {
+	uID, err := s.owner()
+	if err != nil {
+		return git.Resume{}, err
+	}
 	skillJSON := "[]"
 	if len(r.Skills) > 0 {
 		j, _ := json.Marshal(r.Skills)
@@ -369,9 +406,15 @@ func (s *sqliteDB) CreateResume(r git.Resume) (git.Resume, error) {
 	}
 
 	query := `
-	   INSERT INTO resumes (user_id, version, title, skills, created_at, updated_at)
-	    VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
-	row, err := s.conn.Exec(query, uID, r.Version, r.Title, skillJSON)
+	   INSERT INTO resumes (user_id, version, title, skills, parent_id, job_id, summary, language, created_at, updated_at)
+	    VALUES (?, ?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return git.Resume{}, err
+	}
+	defer tx.Rollback()
+
+	row, err := tx.Exec(query, uID, r.Version, r.Title, skillJSON, r.ParentID, r.JobID, r.Summary, r.Language)
 	if err != nil {
 		return git.Resume{}, err
 	}
@@ -380,6 +423,12 @@ func (s *sqliteDB) CreateResume(r git.Resume) (git.Resume, error) {
 	if err != nil {
 		return git.Resume{}, err
 	}
+	if err := s.saveRevision(tx, id, noteCreated); err != nil {
+		return git.Resume{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return git.Resume{}, err
+	}
 
 	res := git.Resume{
 		ID: id,
@@ -388,6 +437,10 @@ func (s *sqliteDB) CreateResume(r git.Resume) (git.Resume, error) {
 }
 
 func (s *sqliteDB) GetResume(ID int64) (git.Resume, error) {
+	return s.getResume(s.conn, ID)
+}
+
+func (s *sqliteDB) getResume(q querier, ID int64) (git.Resume, error) {
 	var (
 		version              sql.NullInt32
 		title                string
@@ -399,6 +452,10 @@ func (s *sqliteDB) GetResume(ID int64) (git.Resume, error) {
 		location             sql.NullString
 		links                sql.NullString
 		professional_summary sql.NullString
+		summary              sql.NullString
+		parentID             sql.NullInt64
+		jobID                sql.NullInt64
+		language             sql.NullString
 
 		education       sql.NullString
 		workExperience  sql.NullString
@@ -418,6 +475,10 @@ SELECT
     users.location,
     users.professional_summary,
     users.links,
+    resumes.summary,
+    resumes.parent_id,
+    resumes.job_id,
+    resumes.language,
 
  	-- VOLUNTEERING
     COALESCE((
@@ -484,12 +545,12 @@ SELECT
     ), '[]') AS work_experiences
 FROM resumes
 LEFT JOIN users ON resumes.user_id = users.id
-WHERE resumes.id = ?
+WHERE resumes.id = ? AND resumes.user_id = ?
 GROUP BY resumes.id;
   `
 
-	err := s.conn.QueryRow(query, ID).
-		Scan(&version, &title, &skills, &is_published, &name, &email, &phone, &location, &professional_summary, &links, &volunteering, &projectWorkedOn, &education, &workExperience)
+	err := q.QueryRow(query, ID, s.uID).
+		Scan(&version, &title, &skills, &is_published, &name, &email, &phone, &location, &professional_summary, &links, &summary, &parentID, &jobID, &language, &volunteering, &projectWorkedOn, &education, &workExperience)
 
 	if err == sql.ErrNoRows {
 		return git.Resume{}, errors.New("record with ID not found")
@@ -528,6 +589,10 @@ GROUP BY resumes.id;
 		_ = json.Unmarshal([]byte(volunteering.String), &vol)
 	}
 
+	if summary.String != "" {
+		professional_summary.String = summary.String
+	}
+
 	return git.Resume{
 		ID:              ID,
 		Version:         int(version.Int32),
@@ -538,6 +603,10 @@ GROUP BY resumes.id;
 		WorkExperiences: wk,
 		Volunteers:      vol,
 		ProjectWorkedOn: prj,
+		Summary:         summary.String,
+		ParentID:        parentID.Int64,
+		JobID:           jobID.Int64,
+		Language:        language.String,
 		Profile: git.Profile{
 			Name:                name.String,
 			Email:               strings.TrimSpace(email.String),
@@ -550,7 +619,7 @@ GROUP BY resumes.id;
 }
 
 func (c *sqliteDB) DeleteResume(rID int64) error {
-	_, err := c.conn.Exec("DELETE FROM resumes WHERE id=?", rID)
+	_, err := c.conn.Exec("DELETE FROM resumes WHERE id=? AND user_id=?", rID, c.uID)
 	if err != nil {
 		return err
 	}
@@ -558,100 +627,71 @@ func (c *sqliteDB) DeleteResume(rID int64) error {
 }
 
 func (c *sqliteDB) DeleteWorkExperience(wID int64) error {
-	_, err := c.conn.Exec("DELETE FROM work_experiences WHERE id=?", wID)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.deleteSectionItem("work_experiences", wID)
 }
 
 func (c *sqliteDB) DeleteEducation(eID int64) error {
-	_, err := c.conn.Exec("DELETE FROM educations WHERE id=?", eID)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.deleteSectionItem("educations", eID)
 }
 
 func (c *sqliteDB) DeleteVolunteer(vID int64) error {
-	_, err := c.conn.Exec("DELETE FROM volunteering WHERE id=?", vID)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.deleteSectionItem("volunteering", vID)
 }
 
 func (c *sqliteDB) CreateOrUpdateWorkExperiences(rID int64, w []git.WorkExperience) ([]int64, error) {
-	tx, err := c.conn.Begin()
 	if len(w) == 0 {
 		return nil, errors.New("no work experience found")
 	}
-
+	if err := c.ownResume(rID); err != nil {
+		return nil, err
+	}
+	tx, err := c.conn.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	var lastID int64
 	ids := make([]int64, 0, len(w))
-
 	for _, wk := range w {
 		var exists bool
 		q := `SELECT EXISTS(SELECT 1 FROM work_experiences WHERE id=? AND resume_id=?)`
-		err = tx.QueryRow(q, wk.ID, rID).Scan(&exists)
-
-		if err != nil {
-			log.Printf("check exists id=%d: %v", wk.ID, err)
-			continue
+		if err := tx.QueryRow(q, wk.ID, rID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check exists id=%d: %w", wk.ID, err)
 		}
 
 		if exists {
 			updateQuery := "UPDATE work_experiences SET company=?, role=?, location=?, start_date=?, end_date=?, responsibilities=?, projects=?, is_translated =? WHERE id=? AND resume_id=?"
-			wrw, err := tx.Exec(updateQuery, wk.Company, wk.Role, wk.Location, wk.StartDate, wk.EndDate, wk.Responsibilities, wk.Projects, wk.IsTranslated, wk.ID, rID)
-
-			if err != nil {
-				tx.Rollback()
-				log.Printf("error saving id=%d: %v", wk.ID, err)
-				continue
-			}
-
-			lID, err := wrw.LastInsertId()
-			lastID = lID
-
-			if err != nil {
-				tx.Rollback()
-				log.Printf("error getting the lastID id=%d: %v", wk.ID, err)
-				continue
-			}
-
-		} else {
-			query :=
-				"INSERT INTO work_experiences (resume_id, company, role, location, start_date, end_date, responsibilities, is_translated, projects) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
-			rw, err := tx.Exec(query, rID, wk.Company, wk.Role, wk.Location, wk.StartDate, wk.EndDate, wk.Responsibilities, wk.IsTranslated, wk.Projects)
-
-			if err != nil {
-				tx.Rollback()
-				log.Printf("error inserting id=%d: %v", wk.ID, err)
-				continue
+			if _, err := tx.Exec(updateQuery, wk.Company, wk.Role, wk.Location, wk.StartDate, wk.EndDate, wk.Responsibilities, wk.Projects, wk.IsTranslated, wk.ID, rID); err != nil {
+				return nil, fmt.Errorf("error saving id=%d: %w", wk.ID, err)
 			}
+			ids = append(ids, wk.ID)
+			continue
+		}
 
-			lstID, _ := rw.LastInsertId()
-			lastID = lstID
+		query :=
+			"INSERT INTO work_experiences (resume_id, company, role, location, start_date, end_date, responsibilities, is_translated, projects) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		rw, err := tx.Exec(query, rID, wk.Company, wk.Role, wk.Location, wk.StartDate, wk.EndDate, wk.Responsibilities, wk.IsTranslated, wk.Projects)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting work experience for resume id=%d: %w", rID, err)
 		}
-		ids = append(ids, lastID)
+		lstID, _ := rw.LastInsertId()
+		ids = append(ids, lstID)
 	}
 
+	if err := c.saveRevision(tx, rID, sectionNotes["work_experiences"][0]); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
-
 	return ids, nil
 }
 
 func (s *sqliteDB) GetResumes() ([]git.Resume, error) {
 	query := `
-	SELECT id, title, version, skills, published_at, created_at FROM resumes WHERE user_id = ?
+	SELECT id, title, version, skills, published_at, parent_id, job_id, language, created_at FROM resumes WHERE user_id = ? ORDER BY id
 	`
-	rows, err := s.conn.Query(query, uID)
+	rows, err := s.conn.Query(query, s.uID)
 	if err != nil {
 		return nil, err
 	}
@@ -665,9 +705,12 @@ func (s *sqliteDB) GetResumes() ([]git.Resume, error) {
 			version     int
 			skills      sql.NullString
 			publishedAt sql.NullString
+			parentID    sql.NullInt64
+			jobID       sql.NullInt64
+			language    sql.NullString
 			createdAt   string
 		)
-		if err := rows.Scan(&id, &title, &version, &skills, &publishedAt, &createdAt); err != nil {
+		if err := rows.Scan(&id, &title, &version, &skills, &publishedAt, &parentID, &jobID, &language, &createdAt); err != nil {
 			return nil, err
 		}
 		var skillUn []string
@@ -678,6 +721,9 @@ func (s *sqliteDB) GetResumes() ([]git.Resume, error) {
 			Version:         version,
 			Skills:          skillUn,
 			PublishedAt:     publishedAt.String,
+			ParentID:        parentID.Int64,
+			JobID:           jobID.Int64,
+			Language:        language.String,
 			CreatedAt:       createdAt,
 			WorkExperiences: []git.WorkExperience{},
 			Education:       []git.Education{},
@@ -724,19 +770,48 @@ func (s *sqliteDB) GetUserByID(id int32) (git.Profile, error) {
 }
 
 func (s *sqliteDB) UpdateResume(rID int64, req git.Resume) (int64, error) {
+	// A resume with its own summary keeps edits to it instead of changing
+	// the profile every other resume shares.
+	var own sql.NullString
+	err := s.conn.QueryRow("SELECT summary FROM resumes WHERE id = ? AND user_id = ?", rID, s.uID).Scan(&own)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	exists := err == nil
+	if own.String != "" && req.Summary == "" {
+		req.Summary = req.Profile.ProfessionalSummary
+	}
+	if req.Summary != "" {
+		req.Profile.ProfessionalSummary = ""
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	if !reflect.DeepEqual(req.Profile, git.Profile{}) {
-		if err := s.UpdateUser(uID, req.Profile); err != nil {
+		if err := updateUser(tx, s.uID, req.Profile); err != nil {
+			return 0, err
+		}
+	}
+	if err := updateResumeObj(tx, s.uID, rID, req); err != nil {
+		return 0, err
+	}
+	if exists {
+		if err := s.saveRevision(tx, rID, noteUpdated); err != nil {
 			return 0, err
 		}
 	}
-	if err := updateResumeObj(s, rID, req); err != nil {
+	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
 
 	return 0, nil
 }
 
-func updateResumeObj(s *sqliteDB, rID int64, req git.Resume) error {
+func updateResumeObj(q querier, uID, rID int64, req git.Resume) error {
 	keys := []string{}
 	values := []any{}
 
@@ -751,10 +826,19 @@ func updateResumeObj(s *sqliteDB, rID int64, req git.Resume) error {
 		values = append(values, string(j))
 	}
 
-	query := fmt.Sprintf("UPDATE resumes SET %v WHERE id = ?", strings.Join(keys, ", "))
-	values = append(values, rID)
+	if req.Summary != "" {
+		keys = append(keys, "summary = ?")
+		values = append(values, req.Summary)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
 
-	stmt, err := s.conn.Prepare(query)
+	query := fmt.Sprintf("UPDATE resumes SET %v WHERE id = ? AND user_id = ?", strings.Join(keys, ", "))
+	values = append(values, rID, uID)
+
+	stmt, err := q.Prepare(query)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -768,6 +852,10 @@ func updateResumeObj(s *sqliteDB, rID int64, req git.Resume) error {
 }
 
 func (s *sqliteDB) UpdateUser(uID int64, req git.Profile) error {
+	return updateUser(s.conn, uID, req)
+}
+
+func updateUser(q querier, uID int64, req git.Profile) error {
 	key := []string{}
 	val := []any{}
 
@@ -797,11 +885,14 @@ func (s *sqliteDB) UpdateUser(uID int64, req git.Profile) error {
 		val = append(val, string(j))
 	}
 
+	if len(key) == 0 {
+		return nil
+	}
 	query := fmt.Sprintf("UPDATE users SET %v WHERE id = ?", strings.Join(key, ", "))
 
 	val = append(val, uID)
 
-	stmt, err := s.conn.Prepare(query)
+	stmt, err := q.Prepare(query)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -816,54 +907,47 @@ func (s *sqliteDB) UpdateUser(uID int64, req git.Profile) error {
 }
 
 func (s *sqliteDB) CreateOrUpdateEducation(rID int64, edus []git.Education) ([]int64, error) {
-	tx, err := s.conn.Begin()
 	if len(edus) == 0 {
 		return nil, errors.New("invalid education")
 	}
+	if err := s.ownResume(rID); err != nil {
+		return nil, err
+	}
+	tx, err := s.conn.Begin()
 	if err != nil {
 		return nil, err
 	}
-	var errs []error
-	ids := make([]int64, 0, len(edus))
+	defer tx.Rollback()
 
+	ids := make([]int64, 0, len(edus))
 	for _, edu := range edus {
-		var (
-			exists bool
-			lastID int64
-		)
-		q := `SELECT EXISTS(SELECT 1 FROM educations WHERE id=?)`
-		err = tx.QueryRow(q, edu.ID).Scan(&exists)
-
-		if err != nil {
-			errs = append(errs, fmt.Errorf("check exists id=%d: %w", edu.ID, err))
-			continue
+		var exists bool
+		q := `SELECT EXISTS(SELECT 1 FROM educations WHERE id=? AND resume_id=?)`
+		if err := tx.QueryRow(q, edu.ID, rID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check exists id=%d: %w", edu.ID, err)
 		}
 
 		if exists {
 			query := `UPDATE educations SET school=?, degree=?, field_of_study=?, end_date=? WHERE id=? AND resume_id=?`
-			row, err := tx.Exec(query, edu.School, edu.Degree, edu.FieldOfStudy, edu.EndDate, edu.ID, rID)
-			if err != nil {
-				tx.Rollback()
-				errs = append(errs, fmt.Errorf("unable to create education id=%d: %w", edu.ID, err))
-				continue
+			if _, err := tx.Exec(query, edu.School, edu.Degree, edu.FieldOfStudy, edu.EndDate, edu.ID, rID); err != nil {
+				return nil, fmt.Errorf("unable to update education id=%d: %w", edu.ID, err)
 			}
-			id, _ := row.LastInsertId()
-			lastID = id
-		} else {
-			query := "INSERT INTO educations (resume_id, school, degree, field_of_study, end_date) VALUES (?, ?, ?, ?, ?)"
+			ids = append(ids, edu.ID)
+			continue
+		}
 
-			row, err := tx.Exec(query, rID, edu.School, edu.Degree, edu.FieldOfStudy, edu.EndDate)
-			if err != nil {
-				tx.Rollback()
-				errs = append(errs, fmt.Errorf("unable to create education resume id=%d: %w", rID, err))
-				continue
-			}
-			id, _ := row.LastInsertId()
-			lastID = id
+		query := "INSERT INTO educations (resume_id, school, degree, field_of_study, end_date) VALUES (?, ?, ?, ?, ?)"
+		row, err := tx.Exec(query, rID, edu.School, edu.Degree, edu.FieldOfStudy, edu.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create education resume id=%d: %w", rID, err)
 		}
-		ids = append(ids, lastID)
+		id, _ := row.LastInsertId()
+		ids = append(ids, id)
 	}
 
+	if err := s.saveRevision(tx, rID, sectionNotes["educations"][0]); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -871,14 +955,18 @@ func (s *sqliteDB) CreateOrUpdateEducation(rID int64, edus []git.Education) ([]i
 }
 
 func (s *sqliteDB) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
-	if len(cfg.Prompts) == 0 {
-		return fmt.Errorf("prompt cannot be empty")
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	uID, err := s.owner()
+	if err != nil {
+		return err
 	}
 
 	var exists bool
 
-	q := `SELECT EXISTS(SELECT 1 FROM prompts WHERE title=?)`
-	err := s.conn.QueryRow(q, cfg.Title).Scan(&exists)
+	q := `SELECT EXISTS(SELECT 1 FROM prompts WHERE title=? AND user_id=?)`
+	err = s.conn.QueryRow(q, cfg.Title, uID).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -886,35 +974,193 @@ func (s *sqliteDB) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
 	prmpts, _ := json.Marshal(cfg.Prompts)
 
 	if exists {
-		q := `UPDATE prompts SET temperature=?, max_tokens=?, content=? WHERE title=?`
+		q := `UPDATE prompts SET temperature=?, max_tokens=?, content=? WHERE title=? AND user_id=?`
 		stmt, err := s.conn.Prepare(q)
 		if err != nil {
 			return err
 		}
-		_, err = stmt.Exec(cfg.Temperature, cfg.MaxTokens, string(prmpts), cfg.Title)
+		_, err = stmt.Exec(cfg.Temperature, cfg.MaxTokens, string(prmpts), cfg.Title, uID)
 		if err != nil {
 			return err
 		}
 	} else {
-		q := `INSERT INTO prompts (title, temperature, max_tokens, content) VALUES(?, ?, ?, ?)`
+		q := `INSERT INTO prompts (user_id, title, temperature, max_tokens, content) VALUES(?, ?, ?, ?, ?)`
 		smt, err := s.conn.Prepare(q)
 		if err != nil {
 			return err
 		}
-		_, err = smt.Exec(cfg.Title, cfg.Temperature, cfg.MaxTokens, string(prmpts))
+		_, err = smt.Exec(uID, cfg.Title, cfg.Temperature, cfg.MaxTokens, string(prmpts))
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return s.createPromptVersion(cfg, string(prmpts))
 }
 
-func (s *sqliteDB) GetLLmPromptConfig() ([]config.CustomPrompt, error) {
-	rows, err := s.conn.Query("SELECT title, temperature, max_tokens, content FROM prompts")
+// createPromptVersion records a save as the next version of the prompt,
+// unless it is identical to the latest version.
+func (s *sqliteDB) createPromptVersion(cfg config.CustomPrompt, content string) error {
+	var (
+		latest      sql.NullInt64
+		lastTemp    sql.NullFloat64
+		lastMax     sql.NullInt64
+		lastContent sql.NullString
+	)
+	q := `SELECT version, temperature, max_tokens, content FROM prompt_versions
+	WHERE title=? AND user_id=? ORDER BY version DESC LIMIT 1`
+	err := s.conn.QueryRow(q, cfg.Title, s.uID).Scan(&latest, &lastTemp, &lastMax, &lastContent)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil && lastContent.String == content &&
+		float32(lastTemp.Float64) == cfg.Temperature && int(lastMax.Int64) == cfg.MaxTokens {
+		return nil
+	}
+
+	q = `INSERT INTO prompt_versions (user_id, title, version, temperature, max_tokens, content) VALUES(?, ?, ?, ?, ?, ?)`
+	_, err = s.conn.Exec(q, s.uID, cfg.Title, latest.Int64+1, cfg.Temperature, cfg.MaxTokens, content)
+	return err
+}
+
+func (s *sqliteDB) GetPromptVersions(title config.PromptType) ([]config.PromptVersion, error) {
+	var (
+		activeTemp    float32
+		activeMax     int
+		activeContent string
+	)
+	err := s.conn.QueryRow(`SELECT temperature, max_tokens, content FROM prompts WHERE title=? AND user_id=?`, title, s.uID).
+		Scan(&activeTemp, &activeMax, &activeContent)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	q := `SELECT id, title, version, temperature, max_tokens, content, created_at
+	FROM prompt_versions WHERE title=? AND user_id=? ORDER BY version`
+	rows, err := s.conn.Query(q, title, s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		versions []config.PromptVersion
+		active   = -1
+	)
+	for rows.Next() {
+		var (
+			v       config.PromptVersion
+			t       string
+			content string
+		)
+		if err := rows.Scan(&v.ID, &t, &v.Version, &v.Temperature, &v.MaxTokens, &content, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		v.Title = config.PromptType(t)
+		_ = json.Unmarshal([]byte(content), &v.Prompts)
+		if content == activeContent && v.Temperature == activeTemp && v.MaxTokens == activeMax {
+			active = len(versions)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if active >= 0 {
+		versions[active].Active = true
+	}
+	return versions, nil
+}
 
+// PromotePromptVersion makes a stored version the active prompt without
+// creating a new version.
+func (s *sqliteDB) PromotePromptVersion(title config.PromptType, version int) error {
+	uID, err := s.owner()
+	if err != nil {
+		return err
+	}
+	var (
+		temp    float32
+		maxTok  int
+		content string
+	)
+	q := `SELECT temperature, max_tokens, content FROM prompt_versions WHERE title=? AND version=? AND user_id=?`
+	err = s.conn.QueryRow(q, title, version, uID).Scan(&temp, &maxTok, &content)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no record found")
+		return fmt.Errorf("%s prompt has no version %d", title, version)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := s.conn.Exec(`UPDATE prompts SET temperature=?, max_tokens=?, content=? WHERE title=? AND user_id=?`, temp, maxTok, content, title, uID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_, err = s.conn.Exec(`INSERT INTO prompts (user_id, title, temperature, max_tokens, content) VALUES(?, ?, ?, ?, ?)`, uID, title, temp, maxTok, content)
+	}
+	return err
+}
+
+func (s *sqliteDB) CreatePromptEval(e config.PromptEval) error {
+	output, _ := json.Marshal(e.Output)
+	query := `
+	INSERT INTO prompt_evals (user_id, run_id, title, version, provider, model, output, bullets, avg_length, action_verbs, quantified, latency_ms, cost, error)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.conn.Exec(query, s.uID, e.RunID, string(e.Title), e.Version, e.Provider, e.Model, string(output),
+		e.Bullets, e.AvgLength, e.ActionVerbs, e.Quantified, e.LatencyMs, e.Cost, e.Error)
+	return err
+}
+
+// GetPromptEvals returns the results of an evaluation run, or of the most
+// recent run when runID is empty.
+func (s *sqliteDB) GetPromptEvals(runID string) ([]config.PromptEval, error) {
+	if runID == "" {
+		err := s.conn.QueryRow(`SELECT run_id FROM prompt_evals WHERE user_id = ? ORDER BY id DESC LIMIT 1`, s.uID).Scan(&runID)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+	SELECT id, run_id, title, version, provider, model, output, bullets, avg_length,
+		action_verbs, quantified, latency_ms, cost, error, created_at
+	FROM prompt_evals WHERE run_id = ? AND user_id = ? ORDER BY id`
+	rows, err := s.conn.Query(query, runID, s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evals []config.PromptEval
+	for rows.Next() {
+		var (
+			e                     config.PromptEval
+			title                 string
+			model, output, errMsg sql.NullString
+		)
+		err := rows.Scan(&e.ID, &e.RunID, &title, &e.Version, &e.Provider, &model, &output, &e.Bullets, &e.AvgLength,
+			&e.ActionVerbs, &e.Quantified, &e.LatencyMs, &e.Cost, &errMsg, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Title = config.PromptType(title)
+		e.Model = model.String
+		e.Error = errMsg.String
+		_ = json.Unmarshal([]byte(output.String), &e.Output)
+		evals = append(evals, e)
+	}
+	return evals, rows.Err()
+}
+
+func (s *sqliteDB) GetLLmPromptConfig() ([]config.CustomPrompt, error) {
+	rows, err := s.conn.Query("SELECT title, temperature, max_tokens, content FROM prompts WHERE user_id = ?", s.uID)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -943,54 +1189,47 @@ func (s *sqliteDB) GetLLmPromptConfig() ([]config.CustomPrompt, error) {
 }
 
 func (s *sqliteDB) CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]int64, error) {
-	tx, err := s.conn.Begin()
 	if len(v) == 0 {
 		return nil, errors.New("invalid data")
 	}
+	if err := s.ownResume(rID); err != nil {
+		return nil, err
+	}
+	tx, err := s.conn.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
 	ids := make([]int64, 0, len(v))
-
 	for _, vol := range v {
-		var (
-			exists bool
-			lastID int64
-		)
-		q := `SELECT EXISTS(SELECT 1 FROM volunteering WHERE id=?)`
-		err = tx.QueryRow(q, vol.ID).Scan(&exists)
-
-		if err != nil {
-			log.Printf("check exists id=%d: %v", vol.ID, err)
-			continue
+		var exists bool
+		q := `SELECT EXISTS(SELECT 1 FROM volunteering WHERE id=? AND resume_id=?)`
+		if err := tx.QueryRow(q, vol.ID, rID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check exists id=%d: %w", vol.ID, err)
 		}
 
 		if exists {
-			query := `UPDATE volunteering SET title=?, description=?, link=?WHERE resume_id=?`
-			row, err := tx.Exec(query, vol.Title, vol.Description, vol.Link, rID)
-			if err != nil {
-				tx.Rollback()
-				log.Printf("unable to update id=%d: %v", vol.ID, err)
-				continue
+			query := `UPDATE volunteering SET title=?, description=?, link=? WHERE id=? AND resume_id=?`
+			if _, err := tx.Exec(query, vol.Title, vol.Description, vol.Link, vol.ID, rID); err != nil {
+				return nil, fmt.Errorf("unable to update id=%d: %w", vol.ID, err)
 			}
-			id, _ := row.LastInsertId()
-			lastID = id
-		} else {
-			query := "INSERT INTO volunteering (resume_id, title, description, link) VALUES (?, ?, ?, ?)"
+			ids = append(ids, vol.ID)
+			continue
+		}
 
-			row, err := tx.Exec(query, rID, vol.Title, vol.Description, vol.Link, rID)
-			if err != nil {
-				tx.Rollback()
-				log.Printf("unable to create id=%d: %v", rID, err)
-				continue
-			}
-			id, _ := row.LastInsertId()
-			lastID = id
+		query := "INSERT INTO volunteering (resume_id, title, description, link) VALUES (?, ?, ?, ?)"
+		row, err := tx.Exec(query, rID, vol.Title, vol.Description, vol.Link)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create volunteering for resume id=%d: %w", rID, err)
 		}
-		ids = append(ids, lastID)
+		id, _ := row.LastInsertId()
+		ids = append(ids, id)
 	}
 
+	if err := s.saveRevision(tx, rID, sectionNotes["volunteering"][0]); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -998,77 +1237,463 @@ func (s *sqliteDB) CreateOrUpdateVolunteering(rID int64, v []git.Volunteer) ([]i
 }
 
 func (s *sqliteDB) CreateOrUpdateProjectOn(rID int64, p []git.ProjectWorkedOn) ([]int64, error) {
-	tx, err := s.conn.Begin()
 	if len(p) == 0 {
 		return nil, errors.New("invalid data")
 	}
+	if err := s.ownResume(rID); err != nil {
+		return nil, err
+	}
+	tx, err := s.conn.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
 	ids := make([]int64, 0, len(p))
+	for _, prj := range p {
+		var exists bool
+		q := `SELECT EXISTS(SELECT 1 FROM project_worked_on WHERE id=? AND resume_id=?)`
+		if err := tx.QueryRow(q, prj.ID, rID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check exists id=%d: %w", prj.ID, err)
+		}
+
+		if exists {
+			query := `UPDATE project_worked_on SET title=?, description=?, technologies=?, link=? WHERE id=? AND resume_id=?`
+			if _, err := tx.Exec(query, prj.Title, prj.Description, prj.Technologies, prj.Link, prj.ID, rID); err != nil {
+				return nil, fmt.Errorf("unable to update id=%d: %w", prj.ID, err)
+			}
+			ids = append(ids, prj.ID)
+			continue
+		}
+
+		query := "INSERT INTO project_worked_on (resume_id, title, description, technologies, link) VALUES (?, ?, ?, ?, ?)"
+		row, err := tx.Exec(query, rID, prj.Title, prj.Description, prj.Technologies, prj.Link)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create project for resume id=%d: %w", rID, err)
+		}
+		id, _ := row.LastInsertId()
+		ids = append(ids, id)
+	}
+
+	if err := s.saveRevision(tx, rID, sectionNotes["project_worked_on"][0]); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *sqliteDB) DeleteProjectWorkedOn(pID int64) error {
+	return s.deleteSectionItem("project_worked_on", pID)
+}
+
+func (s *sqliteDB) CreateAIUsage(u config.AiUsage) error {
+	query := `
+	INSERT INTO ai_usage (user_id, provider, model, prompt_type, project, origin, input_tokens, output_tokens, estimated, latency_ms, success, error, cost, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))`
+	_, err := s.conn.Exec(query, s.uID, u.Provider, u.Model, string(u.PromptType), u.Project, u.Origin,
+		u.InputTokens, u.OutputTokens, u.Estimated, u.LatencyMs, u.Success, u.Error, u.Cost, u.CreatedAt)
+	return err
+}
+
+func (s *sqliteDB) GetAIUsage(since string) ([]config.AiUsage, error) {
+	query := `
+	SELECT id, user_id, provider, model, prompt_type, project, origin, input_tokens, output_tokens,
+		estimated, latency_ms, success, error, cost, created_at
+	FROM ai_usage WHERE created_at >= ? AND user_id = ? ORDER BY created_at`
+	rows, err := s.conn.Query(query, since, s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	for _, vol := range p {
+	var usage []config.AiUsage
+	for rows.Next() {
 		var (
-			exists bool
-			lastID int64
+			u                                  config.AiUsage
+			model, promptType, project, origin sql.NullString
+			errMsg                             sql.NullString
 		)
-		q := `SELECT EXISTS(SELECT 1 FROM project_worked_on WHERE id=?)`
-		err = tx.QueryRow(q, vol.ID).Scan(&exists)
+		err := rows.Scan(&u.ID, &u.UserID, &u.Provider, &model, &promptType, &project, &origin, &u.InputTokens, &u.OutputTokens,
+			&u.Estimated, &u.LatencyMs, &u.Success, &errMsg, &u.Cost, &u.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		u.Model = model.String
+		u.PromptType = config.PromptType(promptType.String)
+		u.Project = project.String
+		u.Origin = origin.String
+		u.Error = errMsg.String
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (s *sqliteDB) GetAIUsageCost(since string) (float64, error) {
+	var cost float64
+	err := s.conn.QueryRow("SELECT COALESCE(SUM(cost), 0) FROM ai_usage WHERE created_at >= ?", since).Scan(&cost)
+	return cost, err
+}
+
+func (s *sqliteDB) CreateAIAudit(a config.AiAudit) error {
+	query := `
+	INSERT INTO ai_audit (user_id, provider, model, endpoint, prompt_type, project, origin, prompt, redactions, response_hash, success, error, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))`
+	_, err := s.conn.Exec(query, s.uID, a.Provider, a.Model, a.Endpoint, string(a.PromptType), a.Project, a.Origin,
+		a.Prompt, a.Redactions, a.ResponseHash, a.Success, a.Error, a.CreatedAt)
+	return err
+}
+
+const auditColumns = `id, user_id, provider, model, endpoint, prompt_type, project, origin, prompt, redactions,
+	response_hash, success, error, created_at`
 
+func scanAudit(row interface{ Scan(...any) error }) (config.AiAudit, error) {
+	var (
+		a                                            config.AiAudit
+		model, endpoint, promptType, project, origin sql.NullString
+		hash, errMsg                                 sql.NullString
+	)
+	err := row.Scan(&a.ID, &a.UserID, &a.Provider, &model, &endpoint, &promptType, &project, &origin, &a.Prompt, &a.Redactions,
+		&hash, &a.Success, &errMsg, &a.CreatedAt)
+	a.Model = model.String
+	a.Endpoint = endpoint.String
+	a.PromptType = config.PromptType(promptType.String)
+	a.Project = project.String
+	a.Origin = origin.String
+	a.ResponseHash = hash.String
+	a.Error = errMsg.String
+	return a, err
+}
+
+func (s *sqliteDB) GetAIAudit(since string, limit int) ([]config.AiAudit, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.conn.Query("SELECT "+auditColumns+" FROM ai_audit WHERE created_at >= ? AND user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?", since, s.uID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []config.AiAudit
+	for rows.Next() {
+		a, err := scanAudit(rows)
 		if err != nil {
-			log.Printf("check exists id=%d: %v", vol.ID, err)
+			return nil, err
+		}
+		entries = append(entries, a)
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqliteDB) GetAIAuditEntry(id int64) (config.AiAudit, error) {
+	a, err := scanAudit(s.conn.QueryRow("SELECT "+auditColumns+" FROM ai_audit WHERE id = ? AND user_id = ?", id, s.uID))
+	if err == sql.ErrNoRows {
+		return a, errors.New("record with ID not found")
+	}
+	return a, err
+}
+
+func (s *sqliteDB) PruneAIAudit(before string) (int64, error) {
+	res, err := s.conn.Exec("DELETE FROM ai_audit WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *sqliteDB) CreateJob(j git.Job) (int64, error) {
+	uID, err := s.owner()
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.conn.Exec("INSERT INTO jobs (user_id, title, company, description) VALUES (?, ?, ?, ?)",
+		uID, j.Title, j.Company, j.Description)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *sqliteDB) GetJob(id int64) (git.Job, error) {
+	var (
+		j              git.Job
+		title, company sql.NullString
+	)
+	err := s.conn.QueryRow("SELECT id, title, company, description, created_at FROM jobs WHERE id = ? AND user_id = ?", id, s.uID).
+		Scan(&j.ID, &title, &company, &j.Description, &j.CreatedAt)
+	if err == sql.ErrNoRows {
+		return git.Job{}, errors.New("record with ID not found")
+	}
+	if err != nil {
+		return git.Job{}, err
+	}
+	j.Title = title.String
+	j.Company = company.String
+	return j, nil
+}
+
+func (s *sqliteDB) GetJobs() ([]git.Job, error) {
+	rows, err := s.conn.Query("SELECT id, title, company, description, created_at FROM jobs WHERE user_id = ? ORDER BY id DESC", s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []git.Job{}
+	for rows.Next() {
+		var (
+			j              git.Job
+			title, company sql.NullString
+		)
+		if err := rows.Scan(&j.ID, &title, &company, &j.Description, &j.CreatedAt); err != nil {
+			return nil, err
+		}
+		j.Title = title.String
+		j.Company = company.String
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *sqliteDB) CreateSuggestions(sg []config.Suggestion) ([]int64, error) {
+	uID, err := s.owner()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range sg {
+		if v.ProjectID == 0 {
 			continue
 		}
+		if err := s.ownProject(v.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-		if exists {
-			query := `UPDATE project_worked_on SET title=?, description=?, technologies=?, link=? WHERE resume_id=?`
-			row, err := tx.Exec(query, vol.Title, vol.Description, vol.Technologies, vol.Link, rID)
-			if err != nil {
-				tx.Rollback()
-				log.Printf("unable to update id=%d: %v", vol.ID, err)
-				continue
-			}
-			id, _ := row.LastInsertId()
-			lastID = id
-		} else {
-			query := "INSERT INTO project_worked_on (resume_id, title, description, technologies, link) VALUES (?, ?, ?, ?, ?)"
+	stmt, err := tx.Prepare(`
+	INSERT INTO suggestions (user_id, project_id, task, provider, model, prompt_version, source_commits, content, confidence, state)
+	VALUES (?, NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
 
-			row, err := tx.Exec(query, rID, vol.Title, vol.Description, vol.Technologies, vol.Link, rID)
-			if err != nil {
-				tx.Rollback()
-				log.Printf("unable to create id=%d: %v", rID, err)
-				continue
-			}
-			id, _ := row.LastInsertId()
-			lastID = id
+	ids := make([]int64, 0, len(sg))
+	for _, v := range sg {
+		state := v.State
+		if state == "" {
+			state = config.SuggestionPending
+		}
+		sources, _ := json.Marshal(v.SourceCommits)
+		res, err := stmt.Exec(uID, v.ProjectID, v.Task, v.Provider, v.Model, v.PromptVersion, string(sources), v.Text, v.Confidence, state)
+		if err != nil {
+			return nil, err
 		}
-		ids = append(ids, lastID)
+		id, _ := res.LastInsertId()
+		ids = append(ids, id)
 	}
+	return ids, tx.Commit()
+}
 
-	if err := tx.Commit(); err != nil {
+const suggestionColumns = `id, COALESCE(project_id, 0), task, COALESCE(provider, ''), COALESCE(model, ''), prompt_version,
+	COALESCE(source_commits, '[]'), content, COALESCE(edited_content, ''), confidence, state, created_at, COALESCE(reviewed_at, '')`
+
+func scanSuggestion(row interface{ Scan(...any) error }) (config.Suggestion, error) {
+	var (
+		sg      config.Suggestion
+		sources string
+	)
+	err := row.Scan(&sg.ID, &sg.ProjectID, &sg.Task, &sg.Provider, &sg.Model, &sg.PromptVersion,
+		&sources, &sg.Text, &sg.EditedText, &sg.Confidence, &sg.State, &sg.CreatedAt, &sg.ReviewedAt)
+	if err != nil {
+		return sg, err
+	}
+	_ = util.ConvertNullToSlice([]byte(sources), &sg.SourceCommits)
+	return sg, nil
+}
+
+// GetSuggestions lists suggestions, newest first. A zero projectID or empty
+// state matches any.
+func (s *sqliteDB) GetSuggestions(projectID int, state config.SuggestionState) ([]config.Suggestion, error) {
+	rows, err := s.conn.Query(`SELECT `+suggestionColumns+` FROM suggestions
+	WHERE user_id = ? AND (? = 0 OR project_id = ?) AND (? = '' OR state = ?)
+	ORDER BY id DESC`, s.uID, projectID, projectID, state, state)
+	if err != nil {
 		return nil, err
 	}
-	return ids, nil
+	defer rows.Close()
+
+	out := []config.Suggestion{}
+	for rows.Next() {
+		sg, err := scanSuggestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sg)
+	}
+	return out, rows.Err()
 }
 
-func (s *sqliteDB) DeleteProjectWorkedOn(pID int64) error {
-	_, err := s.conn.Exec("DELETE FROM project_worked_on WHERE id=?", pID)
+func (s *sqliteDB) GetSuggestion(id int64) (config.Suggestion, error) {
+	sg, err := scanSuggestion(s.conn.QueryRow(`SELECT `+suggestionColumns+` FROM suggestions WHERE id = ? AND user_id = ?`, id, s.uID))
+	if err == sql.ErrNoRows {
+		return sg, errors.New("record with ID not found")
+	}
+	return sg, err
+}
+
+// ReviewSuggestion moves a suggestion to state and keeps its commit summary
+// in step: accepted and edited text is written to commit_summary, anything
+// else removes it again. text is only used by the edited state.
+func (s *sqliteDB) ReviewSuggestion(id int64, state config.SuggestionState, text string) error {
+	if !state.Valid() {
+		return config.ErrInvalidSuggestionState
+	}
+	if state == config.SuggestionEdited && strings.TrimSpace(text) == "" {
+		return errors.New("edited suggestions need the edited text")
+	}
+	if state != config.SuggestionEdited {
+		text = ""
+	}
+
+	tx, err := s.conn.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE suggestions SET state = ?, edited_content = NULLIF(?, ''),
+	reviewed_at = CASE WHEN ? = 'pending' THEN NULL ELSE CURRENT_TIMESTAMP END WHERE id = ? AND user_id = ?`, state, text, state, id, s.uID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("record with ID not found")
+	}
+	if _, err := tx.Exec(`DELETE FROM commit_summary WHERE suggestion_id = ?`, id); err != nil {
+		return err
+	}
+	if state == config.SuggestionAccepted || state == config.SuggestionEdited {
+		_, err = tx.Exec(`
+		INSERT INTO commit_summary (project_id, commit_id, summary, suggestion_id)
+		SELECT project_id, NULL, COALESCE(edited_content, content), id FROM suggestions
+		WHERE id = ? AND project_id IS NOT NULL`, id)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (s *sqliteDB) Delete(key string) error {
-	return nil
+func (s *sqliteDB) GetEmbeddings(model string) ([]config.Embedding, error) {
+	rows, err := s.conn.Query(`
+	SELECT id, ref, kind, source, content, model, hash, vector
+	FROM embeddings WHERE model = ? AND user_id = ? ORDER BY id`, model, s.uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var embeddings []config.Embedding
+	for rows.Next() {
+		var (
+			e      config.Embedding
+			source sql.NullString
+			blob   []byte
+		)
+		if err := rows.Scan(&e.ID, &e.Ref, &e.Kind, &source, &e.Text, &e.Model, &e.Hash, &blob); err != nil {
+			return nil, err
+		}
+		e.Source = source.String
+		e.Vector = decodeVector(blob)
+		embeddings = append(embeddings, e)
+	}
+	return embeddings, rows.Err()
 }
 
-func (s *sqliteDB) Migrate() error {
-	_, err := s.conn.Exec(schema)
-	if err != nil && !strings.Contains(err.Error(), "already exists") {
+func (s *sqliteDB) SaveEmbeddings(embeddings []config.Embedding) error {
+	uID, err := s.owner()
+	if err != nil {
 		return err
 	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	return nil
+	query := `
+	INSERT INTO embeddings (user_id, ref, kind, source, content, model, hash, vector)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(ref, model) DO UPDATE SET
+		user_id = excluded.user_id, kind = excluded.kind, source = excluded.source, content = excluded.content,
+		hash = excluded.hash, vector = excluded.vector, created_at = CURRENT_TIMESTAMP`
+	for _, e := range embeddings {
+		if _, err := tx.Exec(query, uID, e.Ref, e.Kind, e.Source, e.Text, e.Model, e.Hash, encodeVector(e.Vector)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteDB) DeleteEmbeddings(model string, refs []string) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ref := range refs {
+		if _, err := tx.Exec("DELETE FROM embeddings WHERE model = ? AND ref = ? AND user_id = ?", model, ref, s.uID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchEmbeddings ranks the stored vectors of a model by cosine similarity
+// to vector. SQLite has no vector functions, so scoring happens here; a
+// resume history is small enough for a full scan.
+func (s *sqliteDB) SearchEmbeddings(model string, vector []float32, limit int) ([]config.EmbeddingMatch, error) {
+	embeddings, err := s.GetEmbeddings(model)
+	if err != nil {
+		return nil, err
+	}
+	return rankEmbeddings(embeddings, vector, limit), nil
+}
+
+// rankEmbeddings scores embeddings against vector, best first, keeping at
+// most limit of them when limit is positive.
+func rankEmbeddings(embeddings []config.Embedding, vector []float32, limit int) []config.EmbeddingMatch {
+	matches := make([]config.EmbeddingMatch, 0, len(embeddings))
+	for _, e := range embeddings {
+		matches = append(matches, config.EmbeddingMatch{Evidence: e.Evidence, Score: util.Cosine(vector, e.Vector)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(x))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
 }
@@ -0,0 +1,16 @@
+//go:build cgo && !purego
+
+package drivers
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver is mattn/go-sqlite3, which links the SQLite C library. Build
+// with -tags purego, or with CGO_ENABLED=0, to use the pure-Go driver.
+const sqliteDriver = "sqlite3"
+
+// sqliteDSN turns on foreign keys for every connection in the pool.
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_foreign_keys=on"
+}
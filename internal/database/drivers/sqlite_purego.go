@@ -0,0 +1,17 @@
+//go:build !cgo || purego
+
+package drivers
+
+import (
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver is modernc.org/sqlite, SQLite translated to Go. It needs no C
+// compiler and has the same SQL dialect and JSON1 functions as the cgo
+// driver, so the migrations and queries are shared.
+const sqliteDriver = "sqlite"
+
+// sqliteDSN turns on foreign keys for every connection in the pool.
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)"
+}
@@ -2,11 +2,19 @@ package drivers
 
 import (
 	// "os"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
-	// "github.com/iamhabbeboy/gitresume/internal/git"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
 )
 
 func TestNewSqliteAndClose(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	db, err := NewSqlite()
 	if err != nil {
 		t.Fatalf("failed to create sqlite db: %v", err)
@@ -20,12 +28,26 @@ func TestNewSqliteAndClose(t *testing.T) {
 	}
 }
 
+// withUser creates a user and returns db scoped to them.
+func withUser(t *testing.T, db *sqliteDB) *sqliteDB {
+	t.Helper()
+	id, err := db.CreateUser(git.Profile{Name: "Ada", Email: "ada@example.com", PasswordHash: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.ForUser(id).(*sqliteDB)
+}
+
 func TestGetUser_NotFound(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	db, err := NewSqlite()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
 	user, err := db.GetUser("nonexistent@example.com")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -35,4 +57,770 @@ func TestGetUser_NotFound(t *testing.T) {
 	}
 }
 
+func TestPromptVersions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// running twice must not stop before the later tables are created
+	for i := 0; i < 2; i++ {
+		if err := db.Migrate(); err != nil {
+			t.Fatalf("migrate %d failed: %v", i, err)
+		}
+	}
+	db = withUser(t, db)
+
+	save := func(content string) {
+		t.Helper()
+		err := db.CreateOrUpdateLLmPrompt(config.CustomPrompt{
+			Title:       config.ProjectPrompt,
+			Temperature: 0.5,
+			MaxTokens:   400,
+			Prompts:     []config.Prompt{{Role: "user", Content: content}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("v1 {{.Content}}")
+	save("v1 {{.Content}}") // unchanged saves do not create a version
+	save("v2 {{.Content}}")
+
+	versions, err := db.GetPromptVersions(config.ProjectPrompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if versions[0].Active || !versions[1].Active {
+		t.Errorf("expected version 2 to be active, got %+v", versions)
+	}
+
+	if err := db.PromotePromptVersion(config.ProjectPrompt, 1); err != nil {
+		t.Fatal(err)
+	}
+	prompts, _ := db.GetLLmPromptConfig()
+	if len(prompts) != 1 || prompts[0].Prompts[0].Content != "v1 {{.Content}}" {
+		t.Errorf("expected version 1 to be restored, got %+v", prompts)
+	}
+	versions, _ = db.GetPromptVersions(config.ProjectPrompt)
+	if len(versions) != 2 || !versions[0].Active {
+		t.Errorf("promotion should not add a version and should mark v1 active, got %+v", versions)
+	}
+
+	if err := db.PromotePromptVersion(config.ProjectPrompt, 9); err == nil {
+		t.Error("expected an error for a missing version")
+	}
+
+	if err := db.CreatePromptEval(config.PromptEval{RunID: "r1", Title: config.ProjectPrompt, Version: 1, Provider: "fake", Output: []string{"Built it"}}); err != nil {
+		t.Fatal(err)
+	}
+	evals, err := db.GetPromptEvals("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evals) != 1 || evals[0].RunID != "r1" || evals[0].Output[0] != "Built it" {
+		t.Errorf("unexpected evals: %+v", evals)
+	}
+}
+
+func TestAuthenticateUpgradesPlainPassword(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	// init used to store this for every user
+	if _, err := db.conn.Exec("INSERT INTO users(name, email, password_hash) VALUES('Ada', 'ada@example.com', 'admin')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Authenticate("ada@example.com", "wrong"); err == nil {
+		t.Fatal("expected a wrong password to fail")
+	}
+	if _, err := db.Authenticate("ada@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	var hash string
+	if err := db.conn.QueryRow("SELECT password_hash FROM users WHERE email = 'ada@example.com'").Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	if hash == "admin" {
+		t.Error("expected the password to be hashed after signing in")
+	}
+	if _, err := db.Authenticate("ada@example.com", "admin"); err != nil {
+		t.Errorf("the hashed password no longer works: %v", err)
+	}
+}
+
 // TODO: Add tests for CreateUser, Store, GetProjectByName, UpsertCommit, etc. with setup/teardown using a temp database file.
+
+func TestEmbeddings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+
+	ev := func(ref, text string, v ...float32) config.Embedding {
+		return config.Embedding{
+			Evidence: config.Evidence{Ref: ref, Kind: config.EvidenceCommit, Source: "gitresume", Text: text},
+			Model:    "m", Hash: text, Vector: v,
+		}
+	}
+	err = db.SaveEmbeddings([]config.Embedding{ev("a", "x-axis", 1, 0), ev("b", "y-axis", 0, 1), ev("c", "diagonal", 1, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// upsert replaces the vector of an existing ref
+	if err := db.SaveEmbeddings([]config.Embedding{ev("b", "y-axis again", 0, 2)}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := db.GetEmbeddings("m")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("expected 3 embeddings, got %d, %v", len(all), err)
+	}
+	if all[1].Text != "y-axis again" || all[1].Vector[1] != 2 {
+		t.Errorf("upsert did not update the row: %+v", all[1])
+	}
+
+	matches, err := db.SearchEmbeddings("m", []float32{1, 0.1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Ref != "a" || matches[1].Ref != "c" {
+		t.Errorf("unexpected ranking %+v", matches)
+	}
+
+	if err := db.DeleteEmbeddings("m", []string{"a", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := db.GetEmbeddings("other"); len(other) != 0 {
+		t.Errorf("embeddings of another model should be separate")
+	}
+	if all, _ = db.GetEmbeddings("m"); len(all) != 1 || all[0].Ref != "b" {
+		t.Errorf("expected only b to remain, got %+v", all)
+	}
+}
+
+func TestTailoredResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the added resume columns must survive a second migration
+	for i := 0; i < 2; i++ {
+		if err := db.Migrate(); err != nil {
+			t.Fatalf("migrate %d failed: %v", i, err)
+		}
+	}
+
+	db = withUser(t, db)
+	if err := db.UpdateUser(1, git.Profile{ProfessionalSummary: "Generalist."}); err != nil {
+		t.Fatal(err)
+	}
+	parent, err := db.CreateResume(git.Resume{Title: "Backend", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobID, err := db.CreateJob(git.Job{Title: "DBA", Company: "Globex", Description: "Own PostgreSQL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := db.CreateResume(git.Resume{Title: "Backend (DBA)", Version: 2, ParentID: parent.ID, JobID: jobID, Summary: "Database engineer."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetResume(child.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ParentID != parent.ID || got.JobID != jobID || got.Profile.ProfessionalSummary != "Database engineer." {
+		t.Errorf("unexpected tailored resume %+v", got)
+	}
+
+	// editing the tailored summary leaves the shared profile alone
+	if _, err := db.UpdateResume(child.ID, git.Resume{Profile: git.Profile{ProfessionalSummary: "Postgres engineer."}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = db.GetResume(child.ID); got.Summary != "Postgres engineer." {
+		t.Errorf("summary override was not updated: %q", got.Summary)
+	}
+	if got, _ = db.GetResume(parent.ID); got.Profile.ProfessionalSummary != "Generalist." || got.ParentID != 0 {
+		t.Errorf("the parent resume changed: %+v", got)
+	}
+
+	translated, err := db.CreateResume(git.Resume{Title: "Backend (German)", Version: 1, ParentID: parent.ID, Language: "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = db.GetResume(translated.ID); got.Language != "de" || got.ParentID != parent.ID {
+		t.Errorf("the language version was not linked: %+v", got)
+	}
+
+	jobs, err := db.GetJobs()
+	if err != nil || len(jobs) != 1 || jobs[0].Company != "Globex" {
+		t.Errorf("unexpected jobs %+v, %v", jobs, err)
+	}
+	if _, err := db.GetJob(99); err == nil {
+		t.Error("expected an error for a missing job")
+	}
+}
+
+func TestSuggestionReview(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+	if err := db.CreateProject(git.Project{Name: "shop", Path: "/src/shop", Commits: []git.GitCommit{{Msg: "Add checkout", Hash: "a1b2c3d"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := db.CreateSuggestions([]config.Suggestion{
+		{ProjectID: 1, Task: config.ProjectPrompt, Provider: "fake", Model: "fake", PromptVersion: 2, SourceCommits: []string{"a1b2c3d"}, Text: "Built the checkout", Confidence: 0.9},
+		{ProjectID: 1, Task: config.ProjectPrompt, Text: "Did things"},
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("CreateSuggestions = %v, %v", ids, err)
+	}
+	pending, _ := db.GetSuggestions(1, config.SuggestionPending)
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending suggestions, got %d", len(pending))
+	}
+	summaries := func() []git.CustomUpdateCommit {
+		t.Helper()
+		s, err := db.GetAllCommitSummary(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	if len(summaries()) != 0 {
+		t.Fatal("pending suggestions must not reach the commit summaries")
+	}
+
+	if err := db.ReviewSuggestion(ids[0], config.SuggestionAccepted, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ReviewSuggestion(ids[1], config.SuggestionRejected, ""); err != nil {
+		t.Fatal(err)
+	}
+	got := summaries()
+	if len(got) != 1 || got[0].Msg != "Built the checkout" || len(got[0].SourceCommits) != 1 || got[0].SourceCommits[0] != "a1b2c3d" {
+		t.Fatalf("unexpected summaries after review %+v", got)
+	}
+
+	// replacing hand-written summaries keeps the reviewed ones
+	if err := db.UpsertCommit([]git.CustomUpdateCommit{{ProjectID: 1, GitCommit: git.GitCommit{Msg: "By hand"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got = summaries(); len(got) != 2 {
+		t.Errorf("expected the accepted and hand-written summaries, got %+v", got)
+	}
+
+	if err := db.ReviewSuggestion(ids[0], config.SuggestionEdited, "Built the Stripe checkout"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := db.GetSuggestion(ids[0])
+	if err != nil || s.State != config.SuggestionEdited || s.FinalText() != "Built the Stripe checkout" || s.ReviewedAt == "" || s.PromptVersion != 2 {
+		t.Errorf("unexpected edited suggestion %+v, %v", s, err)
+	}
+	if got = summaries(); len(got) != 2 || got[0].Msg != "Built the Stripe checkout" && got[1].Msg != "Built the Stripe checkout" {
+		t.Errorf("the edit did not reach the summary: %+v", got)
+	}
+
+	if err := db.ReviewSuggestion(ids[0], config.SuggestionPending, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got = summaries(); len(got) != 1 || got[0].Msg != "By hand" {
+		t.Errorf("reopening should withdraw the summary, got %+v", got)
+	}
+
+	if err := db.ReviewSuggestion(ids[0], config.SuggestionEdited, " "); err == nil {
+		t.Error("expected an error for an edit without text")
+	}
+	if err := db.ReviewSuggestion(ids[0], "maybe", ""); err != config.ErrInvalidSuggestionState {
+		t.Errorf("expected ErrInvalidSuggestionState, got %v", err)
+	}
+	if err := db.ReviewSuggestion(99, config.SuggestionAccepted, ""); err == nil {
+		t.Error("expected an error for a missing suggestion")
+	}
+}
+
+func TestAIAudit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []config.AiAudit{
+		{CreatedAt: "2026-01-05 10:00:00", Provider: "openai", Model: "gpt-5", PromptType: config.ProjectPrompt, Prompt: "old", Success: true, ResponseHash: "aa"},
+		{CreatedAt: "2026-03-01 10:00:00", Provider: "llama", Model: "llama3.2", PromptType: config.SummaryPrompt, Prompt: "middle", Success: true, ResponseHash: "bb"},
+		{CreatedAt: "2026-03-02 10:00:00", Provider: "gemini", Model: "gemini-2.5", Prompt: "new", Error: "quota exceeded", Redactions: 2},
+	}
+	for _, e := range entries {
+		if err := db.CreateAIAudit(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := db.GetAIAudit("2026-02-01", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Prompt != "new" || got[1].Prompt != "middle" {
+		t.Fatalf("expected the two recent entries newest first, got %+v", got)
+	}
+	if got[0].Success || got[0].Error != "quota exceeded" || got[0].Redactions != 2 {
+		t.Errorf("failure was not stored: %+v", got[0])
+	}
+	if limited, _ := db.GetAIAudit("", 1); len(limited) != 1 || limited[0].Prompt != "new" {
+		t.Errorf("limit was not applied: %+v", limited)
+	}
+
+	e, err := db.GetAIAuditEntry(got[1].ID)
+	if err != nil || e.Provider != "llama" || e.PromptType != config.SummaryPrompt || e.ResponseHash != "bb" {
+		t.Errorf("unexpected entry %+v, %v", e, err)
+	}
+	if _, err := db.GetAIAuditEntry(999); err == nil {
+		t.Error("expected an error for a missing entry")
+	}
+
+	n, err := db.PruneAIAudit("2026-02-01 00:00:00")
+	if err != nil || n != 1 {
+		t.Fatalf("expected one pruned entry, got %d, %v", n, err)
+	}
+	if all, _ := db.GetAIAudit("", 0); len(all) != 2 {
+		t.Errorf("expected two entries after pruning, got %d", len(all))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	// running it again must be a no-op
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != len(migrations) {
+		t.Fatalf("expected %d migrations, got %+v", len(migrations), status)
+	}
+	for _, m := range status {
+		if m.AppliedAt == "" {
+			t.Errorf("migration %d was not applied", m.Version)
+		}
+	}
+	if backups, _ := filepath.Glob(filepath.Join(home, ".gitresume", "backups", "*.db")); len(backups) != 0 {
+		t.Errorf("a new database should not be backed up, got %v", backups)
+	}
+	if _, err := db.Rollback(len(migrations)); err == nil {
+		t.Error("expected the initial migration to be irreversible")
+	}
+	// nothing is reverted when one of the steps cannot be
+	if status, _ = db.MigrationStatus(); status[len(status)-1].AppliedAt == "" {
+		t.Error("a failed rollback reverted a migration")
+	}
+}
+
+func TestMigrate_AdoptsUnversionedDatabase(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// a database from before migrations were versioned, with one of the
+	// columns that used to be added on the fly and an index the old schema
+	// script created
+	_, err = db.conn.Exec(`
+	CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, email TEXT NOT NULL UNIQUE,
+		phone TEXT, location TEXT, professional_summary TEXT, links TEXT, password_hash TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+	CREATE TABLE resumes (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, version INTEGER NOT NULL,
+		title TEXT DEFAULT 'Untitled Resume', skills TEXT, is_published BOOLEAN DEFAULT 0, published_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+	ALTER TABLE resumes ADD COLUMN summary TEXT;
+	CREATE TABLE work_experiences (id INTEGER PRIMARY KEY AUTOINCREMENT, resume_id INTEGER NOT NULL, company TEXT,
+		role VARCHAR(200), location VARCHAR(200), start_date DATETIME, end_date DATETIME, projects TEXT,
+		responsibilities TEXT, is_translated BOOLEAN DEFAULT 0);
+	CREATE INDEX idx_work_experiences_resume_id ON work_experiences(resume_id);
+	INSERT INTO users (name, email, password_hash) VALUES ('Ada', 'ada@example.com', '');
+	INSERT INTO resumes (user_id, version, title, summary) VALUES (1, 1, 'Backend', 'Builds APIs');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	var title, summary string
+	var language sql.NullString
+	if err := db.conn.QueryRow("SELECT title, summary, language FROM resumes WHERE id = 1").Scan(&title, &summary, &language); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Backend" || summary != "Builds APIs" || language.Valid {
+		t.Errorf("existing data was not kept: %q %q %v", title, summary, language)
+	}
+	for _, table := range []string{"suggestions", "ai_usage"} {
+		if ok, _ := db.hasTable(table); !ok {
+			t.Errorf("missing table %s was not created", table)
+		}
+	}
+	if backups, _ := filepath.Glob(filepath.Join(home, ".gitresume", "backups", "*.db")); len(backups) != 1 {
+		t.Errorf("expected one backup, got %v", backups)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(ms []migration) { migrations = ms }(migrations)
+	next := migrations[len(migrations)-1].version + 1
+	migrations = append(migrations[:len(migrations):len(migrations)], migration{
+		version: next,
+		name:    "add_notes",
+		up:      "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);",
+		down:    "DROP TABLE notes;",
+	})
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := db.hasTable("notes"); !ok {
+		t.Fatal("the new migration was not applied")
+	}
+	if backups, _ := filepath.Glob(filepath.Join(home, ".gitresume", "backups", "*.db")); len(backups) != 1 {
+		t.Errorf("expected a backup before migrating, got %v", backups)
+	}
+
+	reverted, err := db.Rollback(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != next {
+		t.Errorf("unexpected rollback %+v", reverted)
+	}
+	if ok, _ := db.hasTable("notes"); ok {
+		t.Error("the table was not dropped")
+	}
+	status, _ := db.MigrationStatus()
+	if last := status[len(status)-1]; last.Version != next || last.AppliedAt != "" {
+		t.Errorf("expected the migration to be pending again, got %+v", last)
+	}
+}
+
+func TestSearchIndexIsRebuilt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if !db.hasFTS5() {
+		t.Skip("this SQLite build has no FTS5")
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+
+	// a build without FTS5 drops the triggers, so its writes are not indexed
+	if err := db.dropSearchTriggers(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "a1", Msg: "Add checkout"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	results, err := db.Search(config.SearchQuery{Text: "checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "a1" || results[0].Snippet != "Add <mark>checkout</mark>" {
+		t.Errorf("expected the index to be rebuilt, got %+v", results)
+	}
+}
+
+// corrupt runs stmts with foreign keys off, as databases written before they
+// were enforced were.
+func corrupt(t *testing.T, db *sqliteDB, stmts ...string) {
+	t.Helper()
+	c, err := db.conn.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.ExecContext(context.Background(), "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatal(err)
+	}
+	defer c.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+	for _, stmt := range stmts {
+		if _, err := c.ExecContext(context.Background(), stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+// beforeUniqueCommits creates a project and rolls the schema back to before
+// commits were unique.
+func beforeUniqueCommits(t *testing.T) *sqliteDB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+	if err := db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "b2", Msg: "Add checkout"}, {Hash: "a1", Msg: "Init"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// roll back to just before 0005_unique_commits
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	for _, m := range status {
+		if m.Version >= 5 && m.AppliedAt != "" {
+			steps++
+		}
+	}
+	if _, err := db.Rollback(steps); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateRefusesDuplicateCommits(t *testing.T) {
+	db := beforeUniqueCommits(t)
+	corrupt(t, db,
+		"INSERT INTO commits (id, project_id, message, hash) VALUES (10, 1, 'Init', 'a1')",
+		"INSERT INTO commit_summary (project_id, commit_id, summary) VALUES (1, 10, 'Set up the repo')",
+	)
+	err := db.Migrate()
+	if err == nil || !strings.Contains(err.Error(), "gitresume db check --repair") {
+		t.Fatalf("expected migrating to point to the repair, got %v", err)
+	}
+	var n, commitID int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM commits WHERE hash = 'a1'").Scan(&n); err != nil || n != 2 {
+		t.Errorf("expected both a1 commits to be kept, got %d (%v)", n, err)
+	}
+
+	// the check still runs on the old schema and unblocks the migration
+	if _, err := db.CheckIntegrity(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.conn.QueryRow("SELECT commit_id FROM commit_summary").Scan(&commitID); err != nil || commitID != 1 {
+		t.Errorf("expected the summary to move to commit 1, got %d (%v)", commitID, err)
+	}
+	if _, err := db.conn.Exec("INSERT INTO commits (project_id, message, hash) VALUES (1, 'Init', 'a1')"); err == nil {
+		t.Error("expected a duplicate commit to be rejected")
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	db := beforeUniqueCommits(t)
+	corrupt(t, db,
+		"INSERT INTO commits (id, project_id, message, hash) VALUES (10, 1, 'Init', 'a1')",
+		"INSERT INTO commit_summary (project_id, commit_id, summary) VALUES (1, 10, 'Set up the repo')",
+		"INSERT INTO commit_summary (project_id, summary) VALUES (99, 'Gone')",
+		"INSERT INTO work_experiences (resume_id, company) VALUES (42, 'Ghost')",
+		"INSERT INTO resumes (id, user_id, version, job_id) VALUES (5, 1, 1, 77)",
+	)
+
+	counts := func(issues []config.IntegrityIssue, repaired bool) map[string]int64 {
+		t.Helper()
+		out := map[string]int64{}
+		for _, i := range issues {
+			if i.Repaired != repaired {
+				t.Errorf("expected repaired to be %v, got %+v", repaired, i)
+			}
+			out[string(i.Check)+" "+i.Table+" "+i.Reference] = i.Count
+		}
+		return out
+	}
+	want := map[string]int64{
+		"duplicate_commits commits ":                                1,
+		"orphaned_summaries commit_summary ":                        1,
+		"dangling_references work_experiences resume_id -> resumes": 1,
+		"dangling_references resumes job_id -> jobs":                1,
+	}
+	issues, err := db.CheckIntegrity(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(issues, false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CheckIntegrity =\n%v\nwant\n%v", got, want)
+	}
+
+	if issues, err = db.CheckIntegrity(true); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(issues, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("repair fixed\n%v\nwant\n%v", got, want)
+	}
+	if issues, err = db.CheckIntegrity(false); err != nil || len(issues) != 0 {
+		t.Errorf("expected a clean database after repairing, got %+v (%v)", issues, err)
+	}
+
+	var commitID int
+	if err := db.conn.QueryRow("SELECT commit_id FROM commit_summary").Scan(&commitID); err != nil || commitID != 1 {
+		t.Errorf("expected the duplicate's summary to move to commit 1, got %d (%v)", commitID, err)
+	}
+	var jobID sql.NullInt64
+	if err := db.conn.QueryRow("SELECT job_id FROM resumes WHERE id = 5").Scan(&jobID); err != nil || jobID.Valid {
+		t.Errorf("expected the resume to be kept with its job cleared, got %v (%v)", jobID, err)
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("the unique index could not be added after repairing: %v", err)
+	}
+}
+
+func TestRevisionFailureRollsBackTheChange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+	r, err := db.CreateResume(git.Resume{Title: "Backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := db.CreateOrUpdateEducation(r.ID, []git.Education{{School: "MIT"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.conn.Exec(`CREATE TRIGGER fail_revisions BEFORE INSERT ON resume_revisions
+	BEGIN SELECT RAISE(FAIL, 'no more revisions'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateOrUpdateEducation(r.ID, []git.Education{{ID: ids[0], School: "Stanford"}}); err == nil {
+		t.Fatal("expected the failed revision to fail the save")
+	}
+	if _, err := db.UpdateResume(r.ID, git.Resume{Title: "Frontend"}); err == nil {
+		t.Fatal("expected the failed revision to fail the update")
+	}
+	if err := db.DeleteEducation(ids[0]); err == nil {
+		t.Fatal("expected the failed revision to fail the delete")
+	}
+	if _, err := db.CreateResume(git.Resume{Title: "Data"}); err == nil {
+		t.Fatal("expected the failed revision to fail the create")
+	}
+
+	got, err := db.GetResume(r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Backend" || len(got.Education) != 1 || got.Education[0].School != "MIT" {
+		t.Errorf("the changes were kept without their revisions: %+v", got)
+	}
+	if resumes, _ := db.GetResumes(); len(resumes) != 1 {
+		t.Errorf("expected the new resume to be rolled back, got %d resumes", len(resumes))
+	}
+	revisions, err := db.GetResumeRevisions(r.ID)
+	if err != nil || len(revisions) != 2 {
+		t.Errorf("expected the 2 revisions saved before the failure, got %d, %v", len(revisions), err)
+	}
+}
+
+func TestUpsertCommitFailureRollsBack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := NewSqlite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	db = withUser(t, db)
+	if err := db.CreateProject(git.Project{Name: "shop", Commits: []git.GitCommit{{Hash: "a1", Msg: "Init"}}}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := db.GetProjectByName("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second summary is of a commit that does not exist
+	err = db.UpsertCommit([]git.CustomUpdateCommit{
+		{ProjectID: p.ID, GitCommit: git.GitCommit{ID: p.Commits[0].ID, Msg: "Set up the repo"}},
+		{ProjectID: p.ID, GitCommit: git.GitCommit{ID: 999, Msg: "Never written"}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a summary of a missing commit")
+	}
+	summaries, err := db.GetAllCommitSummary(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 0 {
+		t.Errorf("expected the summaries to be rolled back, got %+v", summaries)
+	}
+}
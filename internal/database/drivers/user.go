@@ -0,0 +1,137 @@
+package drivers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+var errNoUser = errors.New("no user selected, run 'gitresume init' or 'gitresume user switch'")
+
+// checkPassword compares password with a stored bcrypt hash. Users created
+// before passwords were hashed have the plain text stored instead; rehash
+// reports that it matched one of those, so the caller can hash it now.
+func checkPassword(hash, password string) (rehash bool, err error) {
+	if hash == "" {
+		return false, config.ErrInvalidCredentials
+	}
+	if !strings.HasPrefix(hash, "$2") {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(password)) != 1 {
+			return false, config.ErrInvalidCredentials
+		}
+		return true, nil
+	}
+	if util.VeryHash([]byte(hash), password) != nil {
+		return false, config.ErrInvalidCredentials
+	}
+	return false, nil
+}
+
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+	return util.GenerateHash(password)
+}
+
+// ForUser returns a handle on the same connection that reads and writes as
+// the user uID.
+func (s *sqliteDB) ForUser(uID int64) IDatabase {
+	c := *s
+	c.uID = uID
+	return &c
+}
+
+func (s *sqliteDB) UserID() int64 {
+	return s.uID
+}
+
+// owner is the user new records belong to.
+func (s *sqliteDB) owner() (int64, error) {
+	if s.uID == 0 {
+		return 0, errNoUser
+	}
+	return s.uID, nil
+}
+
+// ownResume fails unless the resume belongs to the user.
+func (s *sqliteDB) ownResume(rID int64) error {
+	var ok bool
+	err := s.conn.QueryRow("SELECT EXISTS(SELECT 1 FROM resumes WHERE id = ? AND user_id = ?)", rID, s.uID).Scan(&ok)
+	if err == nil && !ok {
+		err = errors.New("record with ID not found")
+	}
+	return err
+}
+
+// ownProject fails unless the project belongs to the user.
+func (s *sqliteDB) ownProject(pID int) error {
+	var ok bool
+	err := s.conn.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = ? AND user_id = ?)", pID, s.uID).Scan(&ok)
+	if err == nil && !ok {
+		err = fmt.Errorf("project %d not found", pID)
+	}
+	return err
+}
+
+func (s *sqliteDB) GetUsers() ([]git.Profile, error) {
+	rows, err := s.conn.Query("SELECT id, name, email FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []git.Profile{}
+	for rows.Next() {
+		var u git.Profile
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *sqliteDB) Authenticate(email, password string) (git.Profile, error) {
+	var (
+		u    git.Profile
+		hash string
+	)
+	err := s.conn.QueryRow("SELECT id, name, email, password_hash FROM users WHERE email = ?", email).
+		Scan(&u.ID, &u.Name, &u.Email, &hash)
+	if err == sql.ErrNoRows {
+		return git.Profile{}, config.ErrInvalidCredentials
+	}
+	if err != nil {
+		return git.Profile{}, err
+	}
+	rehash, err := checkPassword(hash, password)
+	if err != nil {
+		return git.Profile{}, err
+	}
+	if rehash {
+		err = s.SetPassword(int64(u.ID), password)
+	}
+	return u, err
+}
+
+func (s *sqliteDB) SetPassword(uID int64, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	res, err := s.conn.Exec("UPDATE users SET password_hash = ? WHERE id = ?", hash, uID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("record with ID not found")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+// CopyResume saves r and all of its sections as a new resume and returns its
+// id. The ids inside r are ignored, so r may be a resume read from the
+// database with its title, version or content changed.
+func CopyResume(db IDatabase, r git.Resume) (int64, error) {
+	created, err := db.CreateResume(r)
+	if err != nil {
+		return 0, err
+	}
+	id := created.ID
+
+	if len(r.WorkExperiences) > 0 {
+		exps := make([]git.WorkExperience, 0, len(r.WorkExperiences))
+		for _, v := range r.WorkExperiences {
+			v.ID = 0
+			exps = append(exps, v)
+		}
+		if _, err := db.CreateOrUpdateWorkExperiences(id, exps); err != nil {
+			return id, err
+		}
+	}
+	if len(r.Education) > 0 {
+		edus := make([]git.Education, 0, len(r.Education))
+		for _, v := range r.Education {
+			v.ID = 0
+			v.ResumeID = id
+			edus = append(edus, v)
+		}
+		if _, err := db.CreateOrUpdateEducation(id, edus); err != nil {
+			return id, err
+		}
+	}
+	if len(r.Volunteers) > 0 {
+		vols := make([]git.Volunteer, 0, len(r.Volunteers))
+		for _, v := range r.Volunteers {
+			v.ID = 0
+			vols = append(vols, v)
+		}
+		if _, err := db.CreateOrUpdateVolunteering(id, vols); err != nil {
+			return id, err
+		}
+	}
+	if len(r.ProjectWorkedOn) > 0 {
+		prjs := make([]git.ProjectWorkedOn, 0, len(r.ProjectWorkedOn))
+		for _, v := range r.ProjectWorkedOn {
+			v.ID = 0
+			prjs = append(prjs, v)
+		}
+		if _, err := db.CreateOrUpdateProjectOn(id, prjs); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+// BulletSource links a work experience bullet to the accepted suggestion it
+// came from and the commits behind it.
+type BulletSource struct {
+	ExperienceID int64    `json:"experience_id"`
+	Bullet       string   `json:"bullet"`
+	SuggestionID int64    `json:"suggestion_id"`
+	Commits      []string `json:"commits"`
+}
+
+// ResumeSources finds the source commits of the work experience bullets of
+// r. Bullets are matched to accepted suggestions by text (see util.TextKey),
+// so a bullet reworded after it was accepted loses its link.
+func ResumeSources(db IDatabase, r git.Resume) ([]BulletSource, error) {
+	all, err := db.GetSuggestions(0, "")
+	if err != nil {
+		return nil, err
+	}
+	// all is newest first, and the newest suggestion for a text wins
+	accepted := map[string]config.Suggestion{}
+	for _, s := range all {
+		key := util.TextKey(s.FinalText())
+		if _, seen := accepted[key]; seen {
+			continue
+		}
+		if s.State == config.SuggestionAccepted || s.State == config.SuggestionEdited {
+			accepted[key] = s
+		}
+	}
+
+	sources := []BulletSource{}
+	for _, w := range r.WorkExperiences {
+		for _, b := range util.HTMLListItems(w.Responsibilities) {
+			if s, ok := accepted[util.TextKey(b)]; ok {
+				sources = append(sources, BulletSource{ExperienceID: w.ID, Bullet: b, SuggestionID: s.ID, Commits: s.SourceCommits})
+			}
+		}
+	}
+	return sources, nil
+}
+
+// DiffResumeRevisions lists the fields of a resume that changed from
+// revision from to revision to. A to of 0 means the resume as it is now.
+func DiffResumeRevisions(db IDatabase, rID int64, from, to int) ([]git.Change, error) {
+	before, err := db.GetResumeRevision(rID, from)
+	if err != nil {
+		return nil, err
+	}
+	var after git.Resume
+	if to == 0 {
+		after, err = db.GetResume(rID)
+	} else {
+		var rev git.ResumeRevision
+		rev, err = db.GetResumeRevision(rID, to)
+		if rev.Resume != nil {
+			after = *rev.Resume
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return git.DiffRevisions(*before.Resume, after), nil
+}
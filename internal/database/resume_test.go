@@ -0,0 +1,42 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+type suggestionsDB struct {
+	IDatabase
+	suggestions []config.Suggestion
+}
+
+func (d suggestionsDB) GetSuggestions(int, config.SuggestionState) ([]config.Suggestion, error) {
+	return d.suggestions, nil
+}
+
+func TestResumeSources(t *testing.T) {
+	db := suggestionsDB{suggestions: []config.Suggestion{
+		{ID: 3, State: config.SuggestionEdited, Text: "Wrote tests", EditedText: "Raised coverage to 80%", SourceCommits: []string{"c3"}},
+		{ID: 2, State: config.SuggestionRejected, Text: "Fixed bugs", SourceCommits: []string{"b2"}},
+		{ID: 1, State: config.SuggestionAccepted, Text: "Built the Stripe checkout", SourceCommits: []string{"a1", "a2"}},
+	}}
+	r := git.Resume{WorkExperiences: []git.WorkExperience{{
+		ID:               7,
+		Responsibilities: "<ul><li>built the stripe   checkout.</li><li>Fixed bugs</li><li>Raised coverage to 80%</li><li>Wrote tests</li></ul>",
+	}}}
+
+	got, err := ResumeSources(db, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BulletSource{
+		{ExperienceID: 7, Bullet: "built the stripe   checkout.", SuggestionID: 1, Commits: []string{"a1", "a2"}},
+		{ExperienceID: 7, Bullet: "Raised coverage to 80%", SuggestionID: 3, Commits: []string{"c3"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResumeSources =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headings translates the section headings of the dashboard's resume
+// template. Regional codes such as "pt-BR" fall back to the language.
+var headings = map[string]map[string]string{
+	"de": {
+		"Professional Summary": "Berufsprofil",
+		"Experience":           "Berufserfahrung",
+		"Education":            "Ausbildung",
+		"Skills":               "Kenntnisse",
+		"Projects":             "Projekte",
+		"Volunteering":         "Ehrenamtliches Engagement",
+	},
+	"fr": {
+		"Professional Summary": "Profil professionnel",
+		"Experience":           "Expérience professionnelle",
+		"Education":            "Formation",
+		"Skills":               "Compétences",
+		"Projects":             "Projets",
+		"Volunteering":         "Bénévolat",
+	},
+	"es": {
+		"Professional Summary": "Perfil profesional",
+		"Experience":           "Experiencia profesional",
+		"Education":            "Formación",
+		"Skills":               "Habilidades",
+		"Projects":             "Proyectos",
+		"Volunteering":         "Voluntariado",
+	},
+	"it": {
+		"Professional Summary": "Profilo professionale",
+		"Experience":           "Esperienza professionale",
+		"Education":            "Istruzione",
+		"Skills":               "Competenze",
+		"Projects":             "Progetti",
+		"Volunteering":         "Volontariato",
+	},
+	"nl": {
+		"Professional Summary": "Profiel",
+		"Experience":           "Werkervaring",
+		"Education":            "Opleiding",
+		"Skills":               "Vaardigheden",
+		"Projects":             "Projecten",
+		"Volunteering":         "Vrijwilligerswerk",
+	},
+	"pt": {
+		"Professional Summary": "Resumo profissional",
+		"Experience":           "Experiência profissional",
+		"Education":            "Formação académica",
+		"Skills":               "Competências",
+		"Projects":             "Projetos",
+		"Volunteering":         "Voluntariado",
+	},
+	"pt-BR": {
+		"Professional Summary": "Resumo profissional",
+		"Experience":           "Experiência profissional",
+		"Education":            "Formação acadêmica",
+		"Skills":               "Habilidades",
+		"Projects":             "Projetos",
+		"Volunteering":         "Trabalho voluntário",
+	},
+}
+
+var heading = regexp.MustCompile(`(?s)(<h[1-6][^>]*>)(\s*)([^<]+?)(\s*)(</h[1-6]>)`)
+
+// LocalizeHeadings replaces the English section headings in content with
+// those of language. Unknown languages and headings are left alone.
+func LocalizeHeadings(content []byte, language string) []byte {
+	names := headings[language]
+	if names == nil {
+		base, _, _ := strings.Cut(language, "-")
+		names = headings[base]
+	}
+	if names == nil {
+		return content
+	}
+	return heading.ReplaceAllFunc(content, func(m []byte) []byte {
+		parts := heading.FindSubmatch(m)
+		name, ok := names[string(parts[3])]
+		if !ok {
+			return m
+		}
+		return []byte(string(parts[1]) + string(parts[2]) + name + string(parts[4]) + string(parts[5]))
+	})
+}
@@ -0,0 +1,27 @@
+package export
+
+import "testing"
+
+func TestLocalizeHeadings(t *testing.T) {
+	in := `<h2 class="text-lg">Experience</h2><h3>Acme</h3><h2>
+  Professional Summary
+</h2>`
+	tests := []struct{ lang, want string }{
+		{"de", `<h2 class="text-lg">Berufserfahrung</h2><h3>Acme</h3><h2>
+  Berufsprofil
+</h2>`},
+		{"pt-BR", `<h2 class="text-lg">Experiência profissional</h2><h3>Acme</h3><h2>
+  Resumo profissional
+</h2>`},
+		{"fr-CA", `<h2 class="text-lg">Expérience professionnelle</h2><h3>Acme</h3><h2>
+  Profil professionnel
+</h2>`},
+		{"", in},
+		{"en", in},
+	}
+	for _, tt := range tests {
+		if got := string(LocalizeHeadings([]byte(in), tt.lang)); got != tt.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package export
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+var listItem = regexp.MustCompile(`(?s)(<li[^>]*>)(.*?)(</li>)`)
+
+// AnnotateSources appends the source commits to every list item of a
+// rendered resume that has them. sources is keyed by util.TextKey of the
+// bullet text.
+func AnnotateSources(content []byte, sources map[string][]string) []byte {
+	if len(sources) == 0 {
+		return content
+	}
+	return listItem.ReplaceAllFunc(content, func(item []byte) []byte {
+		m := listItem.FindSubmatch(item)
+		commits := sources[util.TextKey(string(m[2]))]
+		if len(commits) == 0 {
+			return item
+		}
+		note := ` <small class="sources">(commits: ` + html.EscapeString(strings.Join(commits, ", ")) + `)</small>`
+		return []byte(string(m[1]) + string(m[2]) + note + string(m[3]))
+	})
+}
@@ -0,0 +1,15 @@
+package export
+
+import "testing"
+
+func TestAnnotateSources(t *testing.T) {
+	in := `<ul><li>Built the <b>API</b>.</li><li class="x">Wrote docs</li></ul>`
+	got := string(AnnotateSources([]byte(in), map[string][]string{"built the api": {"a1b2c3d", "e4f5a6b"}}))
+	want := `<ul><li>Built the <b>API</b>. <small class="sources">(commits: a1b2c3d, e4f5a6b)</small></li><li class="x">Wrote docs</li></ul>`
+	if got != want {
+		t.Errorf("AnnotateSources =\n%s\nwant\n%s", got, want)
+	}
+	if got := AnnotateSources([]byte(in), nil); string(got) != in {
+		t.Errorf("no sources should leave the content alone, got %s", got)
+	}
+}
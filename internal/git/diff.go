@@ -0,0 +1,254 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+// Line diff operations.
+const (
+	LineSame    = " "
+	LineRemoved = "-"
+	LineAdded   = "+"
+)
+
+type LineDiff struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Change is one field that differs between two versions of a resume.
+type Change struct {
+	// Field is a stable path such as "skills" or "work_experiences.3".
+	Field string     `json:"field"`
+	Label string     `json:"label"`
+	Lines []LineDiff `json:"lines"`
+}
+
+// DiffResumes lists what changed from a to b in the fields tailoring
+// touches: the summary, skills, work experience bullets and projects.
+// Work experiences are paired by position, since a copy has new ids.
+func DiffResumes(a, b Resume) []Change {
+	var changes changeList
+	add := changes.add
+
+	add("summary", "Professional summary", nonEmpty(a.Profile.ProfessionalSummary), nonEmpty(b.Profile.ProfessionalSummary))
+	add("skills", "Skills", a.Skills, b.Skills)
+	for i := range max(len(a.WorkExperiences), len(b.WorkExperiences)) {
+		var before, after []string
+		label := ""
+		if i < len(a.WorkExperiences) {
+			before = util.HTMLListItems(a.WorkExperiences[i].Responsibilities)
+			label = experienceLabel(a.WorkExperiences[i])
+		}
+		if i < len(b.WorkExperiences) {
+			after = util.HTMLListItems(b.WorkExperiences[i].Responsibilities)
+			label = experienceLabel(b.WorkExperiences[i])
+		}
+		add(fmt.Sprintf("work_experiences.%d", i), label, before, after)
+	}
+	add("projects", "Projects", projectTitles(a.ProjectWorkedOn), projectTitles(b.ProjectWorkedOn))
+	return changes
+}
+
+type changeList []Change
+
+// add records the field if before and after differ.
+func (c *changeList) add(field, label string, before, after []string) {
+	lines := DiffLines(before, after)
+	for _, l := range lines {
+		if l.Op != LineSame {
+			*c = append(*c, Change{Field: field, Label: label, Lines: lines})
+			return
+		}
+	}
+}
+
+// DiffRevisions lists every field that differs from revision a to revision
+// b of a resume, including the profile it was saved with. Section items
+// are paired by ID: an item only in a has all of its fields removed, one
+// only in b has them all added.
+func DiffRevisions(a, b Resume) []Change {
+	var changes changeList
+	diffFields(&changes, "", "", resumeFields(a), resumeFields(b))
+	diffSection(&changes, "work_experiences", a.WorkExperiences, b.WorkExperiences,
+		func(w WorkExperience) int64 { return w.ID }, experienceLabel, experienceFields)
+	diffSection(&changes, "education", a.Education, b.Education,
+		func(e Education) int64 { return e.ID }, func(e Education) string { return e.School }, educationFields)
+	diffSection(&changes, "volunteers", a.Volunteers, b.Volunteers,
+		func(v Volunteer) int64 { return v.ID }, func(v Volunteer) string { return v.Title }, volunteerFields)
+	diffSection(&changes, "project_worked_on", a.ProjectWorkedOn, b.ProjectWorkedOn,
+		func(p ProjectWorkedOn) int64 { return p.ID }, func(p ProjectWorkedOn) string { return p.Title }, projectFields)
+	return changes
+}
+
+// field is one diffable value, split into lines.
+type field struct {
+	key   string
+	label string
+	lines []string
+}
+
+// diffFields compares fields with the same key. Keys are prefixed with
+// prefix, and labels with label when it is set.
+func diffFields(changes *changeList, prefix, label string, a, b []field) {
+	for i := range max(len(a), len(b)) {
+		var before, after []string
+		f := field{}
+		if i < len(a) {
+			f, before = a[i], a[i].lines
+		}
+		if i < len(b) {
+			f, after = b[i], b[i].lines
+		}
+		l := f.label
+		if label != "" {
+			l = label + ": " + l
+		}
+		changes.add(prefix+f.key, l, before, after)
+	}
+}
+
+func diffSection[T any](changes *changeList, name string, a, b []T, id func(T) int64, label func(T) string, fields func(T) []field) {
+	before := make(map[int64]T, len(a))
+	for _, v := range a {
+		before[id(v)] = v
+	}
+	seen := make(map[int64]bool, len(b))
+	diff := func(v T, old []field, cur []field) {
+		diffFields(changes, fmt.Sprintf("%s.%d.", name, id(v)), label(v), old, cur)
+	}
+	for _, v := range b {
+		seen[id(v)] = true
+		if old, ok := before[id(v)]; ok {
+			diff(v, fields(old), fields(v))
+		} else {
+			diff(v, nil, fields(v))
+		}
+	}
+	for _, v := range a {
+		if !seen[id(v)] {
+			diff(v, fields(v), nil)
+		}
+	}
+}
+
+func resumeFields(r Resume) []field {
+	return []field{
+		{"title", "Title", nonEmpty(r.Title)},
+		{"language", "Language", nonEmpty(r.Language)},
+		{"summary", "Professional summary", nonEmpty(r.Profile.ProfessionalSummary)},
+		{"skills", "Skills", r.Skills},
+		{"profile.name", "Name", nonEmpty(r.Profile.Name)},
+		{"profile.email", "Email", nonEmpty(r.Profile.Email)},
+		{"profile.phone", "Phone", nonEmpty(r.Profile.Phone)},
+		{"profile.location", "Location", nonEmpty(r.Profile.Location)},
+		{"profile.links", "Links", r.Profile.Links},
+	}
+}
+
+func experienceFields(w WorkExperience) []field {
+	return []field{
+		{"role", "Role", nonEmpty(w.Role)},
+		{"company", "Company", nonEmpty(w.Company)},
+		{"location", "Location", nonEmpty(w.Location)},
+		{"start_date", "Start date", nonEmpty(w.StartDate)},
+		{"end_date", "End date", nonEmpty(w.EndDate)},
+		{"responsibilities", "Responsibilities", util.HTMLListItems(w.Responsibilities)},
+		{"projects", "Projects", util.HTMLListItems(w.Projects)},
+	}
+}
+
+func educationFields(e Education) []field {
+	return []field{
+		{"school", "School", nonEmpty(e.School)},
+		{"degree", "Degree", nonEmpty(e.Degree)},
+		{"field_of_study", "Field of study", nonEmpty(e.FieldOfStudy)},
+		{"start_date", "Start date", nonEmpty(e.StartDate)},
+		{"end_date", "End date", nonEmpty(e.EndDate)},
+	}
+}
+
+func volunteerFields(v Volunteer) []field {
+	return []field{
+		{"title", "Title", nonEmpty(v.Title)},
+		{"description", "Description", util.HTMLListItems(v.Description)},
+		{"link", "Link", nonEmpty(v.Link)},
+	}
+}
+
+func projectFields(p ProjectWorkedOn) []field {
+	return []field{
+		{"title", "Title", nonEmpty(p.Title)},
+		{"description", "Description", util.HTMLListItems(p.Description)},
+		{"technologies", "Technologies", nonEmpty(p.Technologies)},
+		{"link", "Link", nonEmpty(p.Link)},
+	}
+}
+
+// DiffLines is a line diff of a and b based on their longest common
+// subsequence.
+func DiffLines(a, b []string) []LineDiff {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []LineDiff
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, LineDiff{LineSame, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, LineDiff{LineRemoved, a[i]})
+			i++
+		default:
+			out = append(out, LineDiff{LineAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, LineDiff{LineRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, LineDiff{LineAdded, b[j]})
+	}
+	return out
+}
+
+func nonEmpty(s string) []string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func experienceLabel(w WorkExperience) string {
+	if w.Role == "" {
+		return w.Company
+	}
+	return w.Role + " at " + w.Company
+}
+
+func projectTitles(p []ProjectWorkedOn) []string {
+	titles := make([]string, 0, len(p))
+	for _, v := range p {
+		titles = append(titles, v.Title)
+	}
+	return titles
+}
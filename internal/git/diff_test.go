@@ -0,0 +1,78 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	got := DiffLines([]string{"a", "b", "c"}, []string{"c", "a", "d"})
+	want := []LineDiff{{LineRemoved, "a"}, {LineRemoved, "b"}, {LineSame, "c"}, {LineAdded, "a"}, {LineAdded, "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines = %v, want %v", got, want)
+	}
+}
+
+func TestDiffResumes(t *testing.T) {
+	a := Resume{
+		Skills:          []string{"Go", "SQL"},
+		WorkExperiences: []WorkExperience{{ID: 1, Company: "Acme", Role: "Engineer", Responsibilities: "<ul><li>One</li><li>Two</li></ul>"}},
+		ProjectWorkedOn: []ProjectWorkedOn{{Title: "Blog"}},
+	}
+	b := a
+	b.Skills = []string{"SQL", "Go"}
+	b.WorkExperiences = []WorkExperience{{ID: 9, Company: "Acme", Role: "Engineer", Responsibilities: "<ul><li>One</li><li>Two</li></ul>"}}
+
+	changes := DiffResumes(a, b)
+	if len(changes) != 1 || changes[0].Field != "skills" {
+		t.Fatalf("only the skills should differ, got %+v", changes)
+	}
+
+	b.Profile.ProfessionalSummary = "New summary"
+	b.WorkExperiences[0].Responsibilities = "<ul><li>Two</li></ul>"
+	b.ProjectWorkedOn = nil
+	var fields []string
+	for _, c := range DiffResumes(a, b) {
+		fields = append(fields, c.Field)
+	}
+	if want := []string{"summary", "skills", "work_experiences.0", "projects"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	a := Resume{
+		Title:           "Backend",
+		Skills:          []string{"Go"},
+		Profile:         Profile{Name: "Ada", Location: "Berlin"},
+		WorkExperiences: []WorkExperience{{ID: 1, Company: "Acme", Role: "Engineer", Responsibilities: "<ul><li>One</li></ul>"}},
+		Education:       []Education{{ID: 4, School: "MIT"}},
+	}
+	if changes := DiffRevisions(a, a); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+
+	b := a
+	b.Title = "Platform"
+	b.Profile.Location = "Lisbon"
+	b.WorkExperiences = []WorkExperience{{ID: 1, Company: "Acme", Role: "Senior Engineer", Responsibilities: "<ul><li>One</li><li>Two</li></ul>"}}
+	b.Education = nil
+	b.ProjectWorkedOn = []ProjectWorkedOn{{ID: 7, Title: "Tracker"}}
+
+	var fields []string
+	for _, c := range DiffRevisions(a, b) {
+		fields = append(fields, c.Field)
+	}
+	want := []string{
+		"title", "profile.location",
+		"work_experiences.1.role", "work_experiences.1.responsibilities",
+		"education.4.school",
+		"project_worked_on.7.title",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	if c := DiffRevisions(a, b)[2]; c.Label != "Senior Engineer at Acme: Role" {
+		t.Errorf("unexpected label %q", c.Label)
+	}
+}
@@ -13,8 +13,38 @@ type Resume struct {
 	WorkExperiences []WorkExperience  `json:"work_experiences"`
 	ProjectWorkedOn []ProjectWorkedOn `json:"project_worked_on"`
 	Volunteers      []Volunteer       `json:"volunteers"`
-	CreatedAt       string            `json:"created_at"`
-	UpdatedAt       string            `json:"updated_at"`
+	// Summary overrides Profile.ProfessionalSummary for this resume only,
+	// e.g. on a resume tailored to a job.
+	Summary  string `json:"summary,omitempty"`
+	ParentID int64  `json:"parent_id,omitempty"`
+	JobID    int64  `json:"job_id,omitempty"`
+	// Language is the code of the language the resume is written in, e.g.
+	// "de" for a translated copy; empty means English.
+	Language  string `json:"language,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ResumeRevision is a snapshot of a resume, with its profile and every
+// section, taken when it was saved. Revisions count up from 1 per resume.
+type ResumeRevision struct {
+	ID       int64 `json:"id"`
+	ResumeID int64 `json:"resume_id"`
+	Revision int   `json:"revision"`
+	// Note says what the save changed, e.g. "Saved work experience".
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
+	// Resume is the snapshot; lists of revisions leave it out.
+	Resume *Resume `json:"resume,omitempty"`
+}
+
+// Job is a job description a resume can be tailored to.
+type Job struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Company     string `json:"company"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
 }
 
 type Profile struct {
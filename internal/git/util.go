@@ -1,12 +1,17 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 
 	// "strconv"
 	"strings"
@@ -22,9 +27,12 @@ type GitUser struct {
 }
 
 type GitCommit struct {
-	ID        int    `json:"commit_id"`
-	Hash      string `json:"hash"`
-	Msg       string `json:"message"`
+	ID   int    `json:"commit_id"`
+	Hash string `json:"hash"`
+	Msg  string `json:"message"`
+	// Date is the author date as YYYY-MM-DD; commits seeded before it was
+	// recorded have none.
+	Date      string `json:"date,omitempty"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
@@ -32,6 +40,9 @@ type GitCommit struct {
 type CustomUpdateCommit struct {
 	ProjectID int `json:"project_id"`
 	GitCommit
+	// SourceCommits are the commits behind a summary accepted from an AI
+	// suggestion.
+	SourceCommits []string `json:"source_commits,omitempty"`
 }
 
 type Project struct {
@@ -40,6 +51,11 @@ type Project struct {
 	Path         string      `json:"path"`
 	Technologies string      `json:"technologies"`
 	Commits      []GitCommit `json:"commits"`
+	// Archived projects are kept but left out of the dashboard, matching
+	// and resume generation.
+	Archived bool `json:"archived"`
+	// CommitCount is set when a project is listed without its commits.
+	CommitCount int `json:"commit_count,omitempty"`
 }
 
 type TechStack struct {
@@ -47,6 +63,38 @@ type TechStack struct {
 	Framework map[string]bool `json:"framework"`
 }
 
+// ParseTechStack decodes the technologies column of a project. Invalid or
+// empty input yields an empty stack.
+func ParseTechStack(raw string) TechStack {
+	var t TechStack
+	_ = json.Unmarshal([]byte(raw), &t)
+	return t
+}
+
+// Names lists languages by number of touched files, followed by frameworks
+// in alphabetical order.
+func (t TechStack) Names() []string {
+	langs := make([]string, 0, len(t.Stack))
+	for l := range t.Stack {
+		langs = append(langs, l)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if t.Stack[langs[i]] != t.Stack[langs[j]] {
+			return t.Stack[langs[i]] > t.Stack[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+
+	frameworks := make([]string, 0, len(t.Framework))
+	for f, ok := range t.Framework {
+		if ok && !slices.Contains(langs, f) {
+			frameworks = append(frameworks, f)
+		}
+	}
+	sort.Strings(frameworks)
+	return append(langs, frameworks...)
+}
+
 func detectLang(ext string) string {
 	techMap := map[string]string{
 		".go":   "Go",
@@ -106,46 +154,16 @@ func (g *GitUtil) GetUserInfo() (*GitUser, error) {
 }
 
 func (g *GitUtil) GetStacks(email string) (TechStack, error) {
-	cmd, err := RunGitCommand(g.Path, "log", "--name-only", "--pretty=format:", "--author", email)
-	if err != nil {
-		return TechStack{}, err
-	}
-
-	files := strings.Split(strings.TrimSpace(string(cmd)), "\n")
-
 	techCount := map[string]int{}
 	techFramework := map[string]bool{}
-	for _, file := range files {
-		ext := strings.ToLower(filepath.Ext(file))
-		if ext == "" {
-			continue
-		}
-
-		if lang := detectLang(ext); lang != "" {
-			techCount[lang]++
-		}
-		switch {
-		case strings.HasSuffix(file, "package.json"):
-			techFramework["Node.js"] = true
-		case strings.HasSuffix(file, "next.config.js"):
-			techFramework["Next.js"] = true
-		case strings.HasSuffix(file, "tailwind.config.js"):
-			techFramework["TailwindCSS"] = true
-		case strings.HasSuffix(file, "vite.config.js"):
-			techFramework["Vite"] = true
-		case strings.HasSuffix(file, "angular.json"):
-			techFramework["Angular"] = true
-		case strings.HasSuffix(file, "requirements.txt"):
-			techFramework["Python"] = true
-		case strings.HasSuffix(file, "bun.lock"):
-			techFramework["Bun"] = true
-		case strings.HasSuffix(file, "go.mod"):
-			techFramework["Go"] = true
-		case strings.HasSuffix(file, "pom.xml"):
-			techFramework["Java"] = true
-		case strings.HasSuffix(file, "Gemfile"):
-			techFramework["Ruby on Rails"] = true
-		}
+	err := StreamGitCommand(g.Path, func(r io.Reader) error {
+		return eachLine(r, func(file string) error {
+			countFile(file, techCount, techFramework)
+			return nil
+		})
+	}, "log", "--name-only", "--pretty=format:", "--author", email)
+	if err != nil {
+		return TechStack{}, err
 	}
 
 	return TechStack{
@@ -154,40 +172,124 @@ func (g *GitUtil) GetStacks(email string) (TechStack, error) {
 	}, nil
 }
 
-func (g *GitUtil) GetCommits(email, lastHash string) ([]GitCommit, error) {
-	var logs string
-	cmdArgs := []string{"log", "--pretty=format:%h=%s", "--author", email}
+// countFile adds the language and framework a changed file points to.
+func countFile(file string, techCount map[string]int, techFramework map[string]bool) {
+	ext := strings.ToLower(filepath.Ext(file))
+	if ext == "" {
+		return
+	}
 
-	if lastHash != "" {
-		cmdArgs = append(cmdArgs, fmt.Sprintf("%s..HEAD", lastHash))
+	if lang := detectLang(ext); lang != "" {
+		techCount[lang]++
 	}
-	logs, err := RunGitCommand(g.Path, cmdArgs...)
+	switch {
+	case strings.HasSuffix(file, "package.json"):
+		techFramework["Node.js"] = true
+	case strings.HasSuffix(file, "next.config.js"):
+		techFramework["Next.js"] = true
+	case strings.HasSuffix(file, "tailwind.config.js"):
+		techFramework["TailwindCSS"] = true
+	case strings.HasSuffix(file, "vite.config.js"):
+		techFramework["Vite"] = true
+	case strings.HasSuffix(file, "angular.json"):
+		techFramework["Angular"] = true
+	case strings.HasSuffix(file, "requirements.txt"):
+		techFramework["Python"] = true
+	case strings.HasSuffix(file, "bun.lock"):
+		techFramework["Bun"] = true
+	case strings.HasSuffix(file, "go.mod"):
+		techFramework["Go"] = true
+	case strings.HasSuffix(file, "pom.xml"):
+		techFramework["Java"] = true
+	case strings.HasSuffix(file, "Gemfile"):
+		techFramework["Ruby on Rails"] = true
+	}
+}
+
+// GetCommits lists the user's commits after lastHash, newest first.
+func (g *GitUtil) GetCommits(email, lastHash string) ([]GitCommit, error) {
+	var commits []GitCommit
+	err := StreamGitCommand(g.Path, func(r io.Reader) error {
+		return ParseCommits(r, func(c GitCommit) error {
+			commits = append(commits, c)
+			return nil
+		})
+	}, commitLogArgs(email, lastHash)...)
 	if err != nil {
 		return nil, err
 	}
+	return commits, nil
+}
 
-	var commits []GitCommit
-	splt := strings.SplitSeq(logs, "\n")
-	for value := range splt {
-		log := strings.Split(value, "=")
-		if len(log) < 2 {
-			continue
+// EachCommit calls fn with each of the user's commits after lastHash,
+// oldest first, as git log prints them. Only one commit is held in memory
+// at a time, so it suits repositories of any size.
+func (g *GitUtil) EachCommit(email, lastHash string, fn func(GitCommit) error) error {
+	args := append(commitLogArgs(email, lastHash), "--reverse")
+	return StreamGitCommand(g.Path, func(r io.Reader) error {
+		return ParseCommits(r, fn)
+	}, args...)
+}
+
+func commitLogArgs(email, lastHash string) []string {
+	args := []string{"log", "--pretty=format:" + CommitLogFormat, "--author", email}
+	if lastHash != "" {
+		args = append(args, fmt.Sprintf("%s..HEAD", lastHash))
+	}
+	return args
+}
+
+// CommitLogFormat is the git log --pretty format ParseCommits reads.
+// Commits are stored by their full hash: an abbreviated one grows as the
+// repository does, so the same commit would be stored again.
+const CommitLogFormat = "%H=%as=%s"
+
+// ShortHash abbreviates a commit hash for display.
+func ShortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// ParseCommits reads git log output in CommitLogFormat and calls fn with
+// each commit, skipping merges. It stops at the first error fn returns.
+func ParseCommits(r io.Reader, fn func(GitCommit) error) error {
+	return eachLine(r, func(line string) error {
+		// the subject may itself contain "="
+		log := strings.SplitN(line, "=", 3)
+		if len(log) < 3 {
+			return nil
 		}
-		hash := log[0]
-		msg := log[1]
+		hash, date, msg := log[0], log[1], log[2]
 
 		if strings.Contains(msg, "Merge") {
-			continue
+			return nil
 		}
 
 		stripMsg := strings.Replace(msg, "--author", "", 1)
 		stripMsg = strings.TrimSpace(stripMsg)
-		commits = append(commits, GitCommit{
+		return fn(GitCommit{
 			Msg:  stripMsg,
 			Hash: hash,
+			Date: date,
 		})
+	})
+}
+
+// maxLine bounds a line of git output; a longer commit subject fails the
+// read instead of growing the buffer without limit.
+const maxLine = 1 << 20
+
+func eachLine(r io.Reader, fn func(string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for sc.Scan() {
+		if err := fn(sc.Text()); err != nil {
+			return err
+		}
 	}
-	return commits, nil
+	return sc.Err()
 }
 
 func RunGitCommand(dir string, args ...string) (string, error) {
@@ -204,3 +306,37 @@ func RunGitCommand(dir string, args ...string) (string, error) {
 	}
 	return out.String(), nil
 }
+
+// StreamGitCommand runs git and hands its standard output to read while
+// git is still writing it, so large output is never held in memory. When
+// read fails, git is stopped and read's error returned.
+func StreamGitCommand(dir string, read func(io.Reader) error, args ...string) error {
+	cmd := exec.Command("git", args...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := read(out); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	// git blocks on a full pipe until what read left is drained
+	_, _ = io.Copy(io.Discard, out)
+	if err := cmd.Wait(); err != nil {
+		if stderr.Len() > 0 {
+			return errors.New(stderr.String())
+		}
+		return err
+	}
+	return nil
+}
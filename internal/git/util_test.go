@@ -1,6 +1,11 @@
 package git
 
-import "testing"
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestDetectLang(t *testing.T) {
 	if lang := detectLang(".go"); lang != "Go" {
@@ -12,3 +17,53 @@ func TestDetectLang(t *testing.T) {
 }
 
 // More tests would be done for methods like GetStacks/GetCommits using mocks or fixtures
+
+func TestTechStackNames(t *testing.T) {
+	stack := ParseTechStack(`{"stack":{"Go":10,"TypeScript":3},"framework":{"Vite":true,"Go":true}}`)
+	got := stack.Names()
+	want := []string{"Go", "TypeScript", "Vite"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	if names := ParseTechStack("").Names(); len(names) != 0 {
+		t.Errorf("expected no names for empty input, got %v", names)
+	}
+}
+
+func TestParseCommits(t *testing.T) {
+	log := "a1b2c3=2024-03-01=Add checkout\n" +
+		"d4e5f6=2024-03-02=Merge branch 'main'\n" +
+		"\n" +
+		"0a9b8c=2024-03-03=Set x=1 --author\n"
+	var got []GitCommit
+	err := ParseCommits(strings.NewReader(log), func(c GitCommit) error {
+		got = append(got, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GitCommit{
+		{Hash: "a1b2c3", Date: "2024-03-01", Msg: "Add checkout"},
+		{Hash: "0a9b8c", Date: "2024-03-03", Msg: "Set x=1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCommits = %+v, want %+v", got, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ParseCommits(strings.NewReader(log), func(GitCommit) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected ParseCommits to stop at the first error, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,317 @@
+// Package lint checks resume text for common writing problems without a
+// model: weak openings, missing numbers, passive voice, tense changes, long
+// bullets, repeated verbs, buzzwords, first-person pronouns and acronyms
+// that are never spelled out.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
+)
+
+type Rule string
+
+const (
+	WeakOpening   Rule = "weak-opening"
+	NoMetric      Rule = "missing-quantification"
+	PassiveVoice  Rule = "passive-voice"
+	MixedTense    Rule = "tense"
+	TooLong       Rule = "too-long"
+	DuplicateVerb Rule = "duplicate-verb"
+	Buzzword      Rule = "buzzword"
+	FirstPerson   Rule = "first-person"
+	Jargon        Rule = "jargon"
+)
+
+type Severity string
+
+const (
+	Warning Severity = "warning"
+	Info    Severity = "info"
+)
+
+// Kind picks the rules that apply to a text. Bullets get every rule; prose
+// such as a summary or project description skips the ones that only make
+// sense for a list of achievements.
+type Kind int
+
+const (
+	Bullet Kind = iota
+	Prose
+)
+
+const (
+	maxBulletWords = 35
+	maxProseWords  = 100
+)
+
+// Span is a range of characters (runes, not bytes) in Diagnostic.Text.
+// An empty span means the whole text.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type Diagnostic struct {
+	// Field is the path of the text in the resume, such as "summary" or
+	// "work_experiences.0.responsibilities.2".
+	Field    string   `json:"field"`
+	Rule     Rule     `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Text     string   `json:"text"`
+	Span     Span     `json:"span"`
+}
+
+// Warnings counts the diagnostics that are not merely informational.
+func Warnings(ds []Diagnostic) int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// Text lints a single text. Acronyms only count as expanded when they are
+// spelled out in the text itself.
+func Text(field string, kind Kind, text string) []Diagnostic {
+	l := newLinter(text)
+	return l.text(field, kind, text)
+}
+
+// List lints a list of bullets, including the rules that compare bullets:
+// a tense change and the same opening verb used twice.
+func List(field string, bullets []string) []Diagnostic {
+	l := newLinter(strings.Join(bullets, "\n"))
+	return l.list(field, bullets)
+}
+
+// Resume lints the summary, every work experience bullet and every project
+// description of r. A resume's own summary is checked instead of the
+// profile's when it has one.
+func Resume(r git.Resume) []Diagnostic {
+	var all []string
+	summary := r.Summary
+	if summary == "" {
+		summary = r.Profile.ProfessionalSummary
+	}
+	summary = strings.Join(util.HTMLListItems(summary), " ")
+	all = append(all, summary)
+	for _, w := range r.WorkExperiences {
+		all = append(all, util.HTMLListItems(w.Responsibilities)...)
+	}
+	for _, p := range r.ProjectWorkedOn {
+		all = append(all, p.Description)
+	}
+	l := newLinter(strings.Join(all, "\n"))
+
+	var ds []Diagnostic
+	if summary != "" {
+		ds = append(ds, l.text("summary", Prose, summary)...)
+	}
+	for i, w := range r.WorkExperiences {
+		ds = append(ds, l.list(fmt.Sprintf("work_experiences.%d.responsibilities", i), util.HTMLListItems(w.Responsibilities))...)
+	}
+	for i, p := range r.ProjectWorkedOn {
+		if d := strings.TrimSpace(p.Description); d != "" {
+			ds = append(ds, l.text(fmt.Sprintf("project_worked_on.%d.description", i), Prose, d)...)
+		}
+	}
+	return ds
+}
+
+type linter struct {
+	// expanded holds acronyms spelled out somewhere in the document, and
+	// reported those already flagged once.
+	expanded map[string]bool
+	reported map[string]bool
+}
+
+func newLinter(document string) *linter {
+	l := &linter{expanded: map[string]bool{}, reported: map[string]bool{}}
+	for _, re := range []*regexp.Regexp{expandedAfter, expandedBefore} {
+		for _, m := range re.FindAllStringSubmatch(document, -1) {
+			l.expanded[strings.TrimSuffix(m[1], "s")] = true
+		}
+	}
+	return l
+}
+
+func (l *linter) list(field string, bullets []string) []Diagnostic {
+	var ds []Diagnostic
+	for i, b := range bullets {
+		ds = append(ds, l.text(fmt.Sprintf("%s.%d", field, i), Bullet, b)...)
+	}
+	ds = append(ds, tenses(field, bullets)...)
+	ds = append(ds, duplicateVerbs(field, bullets)...)
+	sort.SliceStable(ds, func(i, j int) bool { return ds[i].Field < ds[j].Field })
+	return ds
+}
+
+func (l *linter) text(field string, kind Kind, text string) []Diagnostic {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	d := func(rule Rule, sev Severity, start, end int, format string, args ...any) Diagnostic {
+		return Diagnostic{
+			Field: field, Rule: rule, Severity: sev, Text: text,
+			Message: fmt.Sprintf(format, args...),
+			Span:    Span{runeOffset(text, start), runeOffset(text, end)},
+		}
+	}
+
+	var ds []Diagnostic
+	if kind == Bullet {
+		if m := weakOpening.FindStringIndex(text); m != nil {
+			ds = append(ds, d(WeakOpening, Warning, m[0], m[1],
+				"%q says what you were around, not what you did; open with an action verb such as Built or Reduced", text[m[0]:m[1]]))
+		}
+		if !metric.MatchString(text) {
+			ds = append(ds, d(NoMetric, Info, 0, 0, "no number shows the size or result of the work"))
+		}
+	}
+	for _, m := range passive.FindAllStringIndex(text, -1) {
+		ds = append(ds, d(PassiveVoice, Warning, m[0], m[1], "%q is passive; say who did it", text[m[0]:m[1]]))
+	}
+	max := maxBulletWords
+	if kind == Prose {
+		max = maxProseWords
+	}
+	if n := len(strings.Fields(text)); n > max {
+		ds = append(ds, d(TooLong, Warning, 0, 0, "%d words; keep it under %d", n, max))
+	}
+	for _, m := range buzzword.FindAllStringIndex(text, -1) {
+		ds = append(ds, d(Buzzword, Warning, m[0], m[1], "%q is a buzzword; show it with a result instead", text[m[0]:m[1]]))
+	}
+	for _, m := range firstPerson.FindAllStringIndex(text, -1) {
+		ds = append(ds, d(FirstPerson, Warning, m[0], m[1], "drop the pronoun %q; resumes are written without them", text[m[0]:m[1]]))
+	}
+	for _, m := range acronym.FindAllStringIndex(text, -1) {
+		word := strings.TrimSuffix(text[m[0]:m[1]], "s")
+		if knownAcronyms[word] || l.expanded[word] || l.reported[word] {
+			continue
+		}
+		l.reported[word] = true
+		ds = append(ds, d(Jargon, Info, m[0], m[1], "spell out %s the first time, e.g. \"Service Level Objective (SLO)\"", word))
+	}
+	return ds
+}
+
+// tenses flags bullets whose opening verb is in the other tense from most
+// of the list; on a tie the first bullet sets the tense.
+func tenses(field string, bullets []string) []Diagnostic {
+	var past, present int
+	first := ""
+	for _, b := range bullets {
+		switch t := tense(openingWord(b)); t {
+		case "past":
+			past++
+		case "present":
+			present++
+		}
+		if first == "" {
+			first = tense(openingWord(b))
+		}
+	}
+	if past == 0 || present == 0 {
+		return nil
+	}
+	want := first
+	if past > present {
+		want = "past"
+	} else if present > past {
+		want = "present"
+	}
+
+	var ds []Diagnostic
+	for i, b := range bullets {
+		b = strings.TrimSpace(b)
+		w := openingWord(b)
+		if t := tense(w); t != "" && t != want {
+			ds = append(ds, Diagnostic{
+				Field: fmt.Sprintf("%s.%d", field, i), Rule: MixedTense, Severity: Warning, Text: b,
+				Message: fmt.Sprintf("%q is in the %s tense but the other bullets use the %s tense", w, t, want),
+				Span:    Span{0, utf8.RuneCountInString(w)},
+			})
+		}
+	}
+	return ds
+}
+
+func duplicateVerbs(field string, bullets []string) []Diagnostic {
+	seen := map[string]int{}
+	var ds []Diagnostic
+	for i, b := range bullets {
+		b = strings.TrimSpace(b)
+		w := openingWord(b)
+		if tense(w) == "" {
+			continue
+		}
+		key := strings.ToLower(w)
+		if j, ok := seen[key]; ok {
+			ds = append(ds, Diagnostic{
+				Field: fmt.Sprintf("%s.%d", field, i), Rule: DuplicateVerb, Severity: Warning, Text: b,
+				Message: fmt.Sprintf("%q already opens bullet %d; vary the verb", w, j+1),
+				Span:    Span{0, utf8.RuneCountInString(w)},
+			})
+			continue
+		}
+		seen[key] = i
+	}
+	return ds
+}
+
+func openingWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], ",.:;-•*")
+}
+
+// tense tells whether an opening verb is in the past or present tense, or
+// returns "" when it is not a verb this package knows.
+func tense(word string) string {
+	w := strings.ToLower(word)
+	switch {
+	case irregularPast[w]:
+		return "past"
+	case presentVerbs[w], presentVerbs[strings.TrimSuffix(w, "s")], presentVerbs[strings.TrimSuffix(w, "es")]:
+		return "present"
+	case len(w) > 4 && strings.HasSuffix(w, "ed") && presentVerbs[pastStem(w)]:
+		return "past"
+	}
+	return ""
+}
+
+// pastStem undoes the regular past tense: "shipped" → "ship",
+// "automated" → "automate", "optimized" → "optimize".
+func pastStem(w string) string {
+	stem := strings.TrimSuffix(w, "ed")
+	if presentVerbs[stem] {
+		return stem
+	}
+	if presentVerbs[stem+"e"] {
+		return stem + "e"
+	}
+	if n := len(stem); n > 2 && stem[n-1] == stem[n-2] {
+		return stem[:n-1]
+	}
+	if strings.HasSuffix(stem, "i") {
+		return strings.TrimSuffix(stem, "i") + "y"
+	}
+	return stem
+}
+
+func runeOffset(s string, byteOffset int) int {
+	return utf8.RuneCountInString(s[:byteOffset])
+}
@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+func rules(ds []Diagnostic) map[Rule]Diagnostic {
+	m := map[Rule]Diagnostic{}
+	for _, d := range ds {
+		if _, ok := m[d.Rule]; !ok {
+			m[d.Rule] = d
+		}
+	}
+	return m
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		text  string
+		rule  Rule
+		match string
+	}{
+		{"Worked on the billing service", WeakOpening, "Worked on"},
+		{"Helped migrate 12 services to Kubernetes", WeakOpening, "Helped"},
+		{"Reduced checkout latency by 40% after the cache was redesigned", PassiveVoice, "was redesigned"},
+		{"Built a synergy between 3 teams", Buzzword, "synergy"},
+		{"Leveraging Kafka, cut ingest time by 2x", Buzzword, "Leveraging"},
+		{"I rewrote the importer, cutting runtime by 30%", FirstPerson, "I"},
+		{"Defined SLOs for 4 services", Jargon, "SLOs"},
+		{"Shipped the new onboarding flow", NoMetric, ""},
+	}
+	for _, tt := range tests {
+		d, ok := rules(Text("bullet", Bullet, tt.text))[tt.rule]
+		if !ok {
+			t.Errorf("%q: expected %s", tt.text, tt.rule)
+			continue
+		}
+		if got := string([]rune(tt.text)[d.Span.Start:d.Span.End]); got != tt.match {
+			t.Errorf("%q: %s span covers %q, want %q", tt.text, tt.rule, got, tt.match)
+		}
+	}
+}
+
+func TestTextClean(t *testing.T) {
+	for _, text := range []string{
+		"Cut API latency by 40% by moving sessions to Redis",
+		"Defined Service Level Objectives (SLOs) for 4 services",
+		"Migrated 30 US customers to the new billing system",
+	} {
+		if ds := Text("bullet", Bullet, text); len(ds) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %+v", text, ds)
+		}
+	}
+}
+
+func TestTooLong(t *testing.T) {
+	long := "Built"
+	for i := 0; i < maxBulletWords; i++ {
+		long += " word"
+	}
+	if _, ok := rules(Text("bullet", Bullet, long))[TooLong]; !ok {
+		t.Error("expected a long bullet to be flagged")
+	}
+	if _, ok := rules(Text("summary", Prose, long))[TooLong]; ok {
+		t.Error("prose allows more words than a bullet")
+	}
+}
+
+func TestSpansCountRunes(t *testing.T) {
+	text := "Café rewrite — I shipped it in 2 weeks"
+	d := rules(Text("bullet", Bullet, text))[FirstPerson]
+	if got := string([]rune(text)[d.Span.Start:d.Span.End]); got != "I" {
+		t.Errorf("span covers %q", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	ds := List("bullets", []string{
+		"Built the payments API handling 2M requests a day",
+		"Built a fraud model that cut chargebacks by 18%",
+		"Leads a team of 4 engineers",
+		"Reduced deploy time from 40 to 6 minutes",
+	})
+	got := map[string]Rule{}
+	for _, d := range ds {
+		if d.Rule == MixedTense || d.Rule == DuplicateVerb {
+			got[d.Field] = d.Rule
+		}
+	}
+	want := map[string]Rule{"bullets.1": DuplicateVerb, "bullets.2": MixedTense}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for f, r := range want {
+		if got[f] != r {
+			t.Errorf("%s: expected %s, got %s", f, r, got[f])
+		}
+	}
+}
+
+func TestTense(t *testing.T) {
+	for word, want := range map[string]string{
+		"Built": "past", "Shipped": "past", "Optimized": "past", "Applied": "past",
+		"Builds": "present", "Fixes": "present", "Manage": "present", "Kubernetes": "",
+	} {
+		if got := tense(word); got != want {
+			t.Errorf("tense(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	r := git.Resume{
+		Profile: git.Profile{ProfessionalSummary: "My passion is building SRE tooling."},
+		WorkExperiences: []git.WorkExperience{{
+			Responsibilities: "<ul><li>Responsible for the Site Reliability Engineering (SRE) rotation</li><li>Cut paging volume by 60%</li></ul>",
+		}},
+		ProjectWorkedOn: []git.ProjectWorkedOn{{Description: "A CLI we built to scale deploys"}},
+	}
+	fields := map[string][]Rule{}
+	for _, d := range Resume(r) {
+		fields[d.Field] = append(fields[d.Field], d.Rule)
+	}
+	if !contains(fields["summary"], FirstPerson) {
+		t.Errorf("expected the summary pronoun to be flagged, got %v", fields)
+	}
+	if contains(fields["summary"], Jargon) {
+		t.Error("SRE is spelled out elsewhere in the resume")
+	}
+	if !contains(fields["work_experiences.0.responsibilities.0"], WeakOpening) {
+		t.Errorf("expected a weak opening, got %v", fields)
+	}
+	if _, ok := fields["work_experiences.0.responsibilities.1"]; ok {
+		t.Errorf("expected the second bullet to pass, got %v", fields["work_experiences.0.responsibilities.1"])
+	}
+	if !contains(fields["project_worked_on.0.description"], FirstPerson) {
+		t.Errorf("expected the project pronoun to be flagged, got %v", fields)
+	}
+}
+
+func contains(rs []Rule, r Rule) bool {
+	for _, x := range rs {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
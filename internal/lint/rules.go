@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	weakOpening = regexp.MustCompile(`(?i)^(worked (on|with|in)|helped( to)?|assisted( with| in)?|participated in|involved in|was involved in|responsible for|was responsible for|tasked with|duties included|contributed to|handled|did)\b`)
+
+	// metric accepts digits, percentages and the number words people use
+	// instead of digits.
+	metric = regexp.MustCompile(`(?i)\d|\b(one|two|three|four|five|six|seven|eight|nine|ten|twelve|dozens?|hundreds?|thousands?|millions?|billions?|half|double[ds]?|triple[ds]?|tenfold)\b`)
+
+	passive = regexp.MustCompile(`(?i)\b(am|is|are|was|were|be|been|being)\s+(\w+ly\s+)?(\w+ed|built|written|led|made|done|given|taken|run|shown|driven|grown|chosen|held|kept|sold|taught|brought|found|begun|set|rewritten|rebuilt)\b`)
+
+	// Only "I" is case sensitive: lowercase "us" is a pronoun, "US" is not.
+	firstPerson = regexp.MustCompile(`\b(I|[Mm]e|[Mm]y|[Mm]ine|[Mm]yself|[Ww]e|[Oo]ur|[Oo]urs|us)\b`)
+
+	buzzword = regexp.MustCompile(`(?i)\b(` + strings.Join([]string{
+		"synerg(y|ies|ize|ized)", "go-getter", "team player", "hard[- ]working", "detail[- ]oriented",
+		"results[- ]driven", "self[- ]starter", "think outside the box", "best[- ]of[- ]breed",
+		"rockstar", "ninja", "guru", "thought leader(ship)?", "passionate", "dynamic",
+		"leverag(e|ed|ing)", "cutting[- ]edge", "world[- ]class", "value[- ]add(ed)?",
+		"proactive(ly)?", "seamless(ly)?", "robust", "motivated", "out of the box",
+		"paradigm shift", "move the needle", "low[- ]hanging fruit", "game[- ]chang(er|ing)",
+	}, "|") + `)\b`)
+
+	acronym = regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,5}s?\b`)
+
+	// "Service Level Objective (SLO)" and "SLO (Service Level Objective)"
+	// both count as spelling an acronym out.
+	expandedAfter  = regexp.MustCompile(`[A-Za-z][\w-]*(?:\s+[\w-]+)+\s*\(([A-Z][A-Z0-9]{1,5}s?)\)`)
+	expandedBefore = regexp.MustCompile(`\b([A-Z][A-Z0-9]{1,5}s?)\s*\([A-Za-z][\w-]*(?:\s+[\w-]+)+\)`)
+)
+
+// knownAcronyms are common enough that spelling them out reads worse.
+var knownAcronyms = set(
+	"AI", "ML", "LLM", "API", "SDK", "CLI", "UI", "UX", "CSS", "HTML", "JSON", "XML", "YAML",
+	"SQL", "HTTP", "HTTPS", "REST", "URL", "DNS", "TCP", "UDP", "IP", "SSH", "TLS", "SSL", "VPN",
+	"JWT", "CPU", "GPU", "RAM", "SSD", "OS", "PDF", "CSV", "AWS", "GCP", "IBM", "CI", "CD", "QA",
+	"IT", "HR", "CEO", "CTO", "CFO", "VP", "MVP", "B2B", "B2C", "USA", "US", "UK", "EU", "PHP",
+	"JS", "TS", "NPM", "ORM", "MVC", "SPA", "CDN", "CMS", "CRM", "ETL", "KPI", "ROI", "GDPR",
+	"BS", "BSC", "MS", "MSC", "PHD", "MBA", "II", "III", "IV",
+)
+
+// irregularPast holds the past tense verbs that do not end in "ed".
+var irregularPast = set(
+	"built", "led", "wrote", "drove", "ran", "made", "grew", "won", "taught", "brought", "found",
+	"began", "chose", "gave", "held", "kept", "met", "oversaw", "rebuilt", "rewrote", "sold",
+	"spoke", "spent", "took", "became", "cut", "set", "shed", "split", "saw", "sped", "drew",
+)
+
+// presentVerbs are base forms of verbs that commonly open a bullet.
+var presentVerbs = set(
+	"build", "lead", "write", "drive", "run", "make", "grow", "win", "teach", "bring", "find",
+	"begin", "choose", "give", "hold", "keep", "meet", "oversee", "rebuild", "rewrite", "sell",
+	"speak", "spend", "take", "become", "cut", "split", "speed", "draw",
+	"design", "develop", "implement", "manage", "create", "improve", "reduce", "increase",
+	"migrate", "automate", "optimize", "deliver", "maintain", "own", "mentor", "ship", "launch",
+	"integrate", "refactor", "architect", "support", "test", "deploy", "monitor", "analyze",
+	"coordinate", "collaborate", "review", "document", "establish", "introduce", "streamline",
+	"resolve", "fix", "add", "enable", "scale", "secure", "replace", "redesign", "define",
+	"prototype", "model", "train", "plan", "direct", "organize", "negotiate", "hire", "onboard",
+	"standardize", "consolidate", "simplify", "accelerate", "modernize", "upgrade", "port",
+	"extend", "expand", "instrument", "profile", "debug", "diagnose", "investigate", "research",
+	"engineer", "program", "configure", "containerize", "orchestrate", "publish", "present",
+	"cover", "raise", "lower", "save", "eliminate", "remove", "cache", "index", "shard",
+	"partner", "champion", "spearhead", "pioneer", "initiate", "implement", "conduct", "perform",
+	"handle", "assist", "help", "work", "participate", "contribute", "use", "apply", "adopt",
+)
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
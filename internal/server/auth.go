@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/database"
+)
+
+// credentialTTL is how long a checked email and password are trusted before
+// they are compared with the stored hash again. bcrypt is slow on purpose and
+// the browser sends the credentials with every request.
+const credentialTTL = 5 * time.Minute
+
+type session struct {
+	uID     int64
+	expires time.Time
+}
+
+// userHandlers serves every signed-in user from their own routes, built on a
+// database handle scoped to them.
+type userHandlers struct {
+	db     database.IDatabase
+	routes func(database.IDatabase) http.Handler
+
+	mu       sync.Mutex
+	sessions map[[sha256.Size]byte]session
+	handlers map[int64]http.Handler
+}
+
+// LoginHandler asks for an email and password with HTTP Basic auth and
+// serves the request as that user.
+func LoginHandler(db database.IDatabase, routes func(database.IDatabase) http.Handler) http.Handler {
+	return &userHandlers{
+		db:       db,
+		routes:   routes,
+		sessions: map[[sha256.Size]byte]session{},
+		handlers: map[int64]http.Handler{},
+	}
+}
+
+func (u *userHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		challenge(w)
+		return
+	}
+	uID, err := u.authenticate(email, password)
+	if errors.Is(err, config.ErrInvalidCredentials) {
+		challenge(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to sign in: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	u.handler(uID).ServeHTTP(w, r)
+}
+
+func challenge(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="gitresume", charset="UTF-8"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+func (u *userHandlers) authenticate(email, password string) (int64, error) {
+	key := sha256.Sum256([]byte(email + "\x00" + password))
+	now := time.Now()
+
+	u.mu.Lock()
+	s, ok := u.sessions[key]
+	u.mu.Unlock()
+	if ok && now.Before(s.expires) {
+		return s.uID, nil
+	}
+
+	prf, err := u.db.Authenticate(email, password)
+	if err != nil {
+		return 0, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for k, s := range u.sessions {
+		if now.After(s.expires) {
+			delete(u.sessions, k)
+		}
+	}
+	u.sessions[key] = session{uID: int64(prf.ID), expires: now.Add(credentialTTL)}
+	return int64(prf.ID), nil
+}
+
+func (u *userHandlers) handler(uID int64) http.Handler {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	h, ok := u.handlers[uID]
+	if !ok {
+		h = u.routes(u.db.ForUser(uID))
+		u.handlers[uID] = h
+	}
+	return h
+}
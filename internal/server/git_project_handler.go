@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/database"
+)
+
+// UpdateGitProjectRequest changes the fields that are set.
+type UpdateGitProjectRequest struct {
+	Name     *string `json:"name"`
+	Archived *bool   `json:"archived"`
+}
+
+// UpdateGitProjectHandler renames, archives or unarchives a seeded project.
+func UpdateGitProjectHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, err := strconv.Atoi(GetID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid project ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req UpdateGitProjectRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p, err := db.GetProject(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		if req.Name != nil {
+			p.Name = *req.Name
+			err := db.UpdateProject(p)
+			if errors.Is(err, config.ErrProjectExists) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Archived != nil {
+			if err := db.ArchiveProject(id, *req.Archived); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if p, err = db.GetProject(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "project updated", Status: http.StatusOK, Data: p})
+	}
+}
+
+// DeleteGitProjectHandler deletes a seeded project with its commits,
+// summaries and suggestions.
+func DeleteGitProjectHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, err := strconv.Atoi(GetID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid project ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := db.DeleteProject(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "project deleted", Status: http.StatusOK})
+	}
+}
+
+// RescanProjectHandler reads a project's whole history again as the
+// signed-in user, from where the project was seeded. ?prune=true also
+// deletes the commits git no longer lists. Pointing a project at a
+// repository that moved is left to the CLI, so the dashboard cannot read
+// any repository on the machine.
+func RescanProjectHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid project ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := db.GetUserByID(int32(db.UserID()))
+		if err != nil {
+			http.Error(w, "failed to retrieve user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		prune := r.URL.Query().Get("prune") == "true"
+		res, err := database.RescanProject(db, id, "", u.Email, prune, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "project rescanned", Status: http.StatusOK, Data: res})
+	}
+}
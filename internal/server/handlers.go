@@ -11,6 +11,7 @@ import (
 	"io/fs"
 	"log"
 	"strconv"
+	"strings"
 
 	"net/http"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/iamhabbeboy/gitresume/internal/database"
 	"github.com/iamhabbeboy/gitresume/internal/export"
 	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/util"
 )
 
 //go:embed web/dist/*
@@ -42,12 +44,88 @@ type Response struct {
 
 type AiRequest struct {
 	// Commits     []string `json:"commits"`
-	Model       string          `json:"model"`
-	Version     string          `json:"version"`
-	Temperature float32         `json:"temperature"`
-	MaxTokens   int             `json:"max_tokens"`
-	ApiKey      string          `json:"api_key"`
-	Prompts     []config.Prompt `json:"prompts"`
+	Title       config.PromptType `json:"title"`
+	Model       string            `json:"model"`
+	Version     string            `json:"version"`
+	Temperature float32           `json:"temperature"`
+	MaxTokens   int               `json:"max_tokens"`
+	ApiKey      string            `json:"api_key"`
+	Prompts     []config.Prompt   `json:"prompts"`
+	// Variables fill in the prompt templates, which are rendered on the
+	// server. Suggestions default them from the project.
+	Variables *config.PromptData `json:"variables"`
+}
+
+// promptType is the task the request is for. Requests without a title are
+// treated as commit bullets, the dashboard's original use of /api/ai.
+func (r AiRequest) promptType() config.PromptType {
+	if r.Title == "" {
+		return config.ProjectPrompt
+	}
+	return r.Title
+}
+
+type RewriteRequest struct {
+	// Field names the resume field being rewritten, e.g. summary.
+	Field       string  `json:"field"`
+	Text        string  `json:"text"`
+	Instruction string  `json:"instruction"`
+	Model       string  `json:"model"`
+	Version     string  `json:"version"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	ApiKey      string  `json:"api_key"`
+	Seed        *int    `json:"seed"`
+}
+
+type MatchRequest struct {
+	JobDescription string `json:"job_description"`
+	// ResumeID limits the resume evidence to one resume; 0 uses all.
+	ResumeID int64 `json:"resume_id"`
+	Top      int   `json:"top"`
+}
+
+type TailorRequest struct {
+	// JobID reuses a saved job; otherwise Job is used.
+	JobID       int64   `json:"job_id"`
+	Job         git.Job `json:"job"`
+	Model       string  `json:"model"`
+	Version     string  `json:"version"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	ApiKey      string  `json:"api_key"`
+}
+
+type TranslateRequest struct {
+	// Language is a code from config.Languages, e.g. "de" or "pt-BR".
+	Language    string  `json:"language"`
+	Model       string  `json:"model"`
+	Version     string  `json:"version"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	ApiKey      string  `json:"api_key"`
+}
+
+type TailorResponse struct {
+	Resume  git.Resume   `json:"resume"`
+	Job     git.Job      `json:"job"`
+	Changes []git.Change `json:"changes"`
+}
+
+// TailorApplyRequest saves a reviewed proposal, possibly edited.
+type TailorApplyRequest struct {
+	Job    git.Job    `json:"job"`
+	Resume git.Resume `json:"resume"`
+}
+
+type PromptPreviewRequest struct {
+	Title config.PromptType `json:"title"`
+	// Prompts previews unsaved edits; the stored prompt for Title is used
+	// when empty.
+	Prompts   []config.Prompt `json:"prompts"`
+	ProjectID int             `json:"project_id"`
+	ResumeID  int64           `json:"resume_id"`
+	config.PromptData
 }
 
 type AiConfigRequest struct {
@@ -93,8 +171,21 @@ func ProjectHandler(db database.IDatabase) http.HandlerFunc {
 	}
 }
 
+// ProjectsHandler lists the projects that are not archived with their
+// commits. With ?archived=true it lists every project without commits.
 func ProjectsHandler(db database.IDatabase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("archived") == "true" {
+			projects, err := db.ListProjects(true)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: projects})
+			return
+		}
 		resp, err := getAllCommits(db)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -141,6 +232,18 @@ func getAllCommits(db database.IDatabase) (Response, error) {
 	return resp, nil
 }
 
+// canConfigure reports whether db's user may change the settings every user
+// shares. Without a signed-in user the dashboard is the owner's.
+func canConfigure(db database.IDatabase) (bool, error) {
+	if db.UserID() == 0 {
+		return true, nil
+	}
+	owner, err := database.Owner(db)
+	return owner == 0 || owner == db.UserID(), err
+}
+
+// AIConfigHandler saves the user's prompts and, for the owner only, the AI
+// providers and how tasks are routed to them.
 func AIConfigHandler(db database.IDatabase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req config.AiConfigResponse
@@ -150,6 +253,18 @@ func AIConfigHandler(db database.IDatabase) http.HandlerFunc {
 			return
 		}
 
+		if len(req.Models) > 0 || req.Fallback != nil || req.Routes != nil {
+			ok, err := canConfigure(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "only the owner can change the AI providers", http.StatusForbidden)
+				return
+			}
+		}
+
 		if len(req.Models) > 0 {
 			if err := config.UpdateAIConfig(req.Models[0]); err != nil {
 				log.Println(err.Error())
@@ -160,7 +275,18 @@ func AIConfigHandler(db database.IDatabase) http.HandlerFunc {
 
 		if len(req.CustomPrompt) > 0 {
 			if err = db.CreateOrUpdateLLmPrompt(req.CustomPrompt[0]); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				status := http.StatusInternalServerError
+				if errors.Is(err, config.ErrInvalidPrompt) {
+					status = http.StatusBadRequest
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+		}
+
+		if req.Fallback != nil || req.Routes != nil {
+			if err := config.UpdateAIRouting(req.Fallback, req.Routes); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 		}
@@ -183,15 +309,27 @@ func GetAIConfigHandler(db database.IDatabase) http.HandlerFunc {
 			return
 		}
 
+		// keys never leave the server; a masked key sent back keeps the
+		// stored one
 		models := []config.AiOptions{}
-		if len(cfg.AiOptions) > 0 {
-			models = cfg.AiOptions
+		for _, o := range cfg.AiOptions {
+			o.ApiKey = config.MaskAPIKey(o.ApiKey)
+			models = append(models, o)
 		}
 
 		prmps, _ := db.GetLLmPromptConfig()
 
 		resp := config.AiConfigResponse{
-			Models: models,
+			Models:    models,
+			Fallback:  cfg.AiFallback,
+			Routes:    cfg.AiRoutes,
+			Variables: config.PromptVariables,
+		}
+		if resp.Fallback == nil {
+			resp.Fallback = []string{}
+		}
+		if resp.Routes == nil {
+			resp.Routes = []config.AiRoute{}
 		}
 		prm := []config.CustomPrompt{}
 		if prmps != nil {
@@ -205,38 +343,832 @@ func GetAIConfigHandler(db database.IDatabase) http.HandlerFunc {
 	}
 }
 
-func AiHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+func AiHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req AiRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if len(req.Prompts) == 0 {
+			http.Error(w, errors.New("prompt is missing").Error(), http.StatusInternalServerError)
+			return
+		}
+		req.Title = req.promptType()
+		// prompts are always templates, so they are rendered even when the
+		// client sends no variables
+		var data config.PromptData
+		if req.Variables != nil {
+			data = *req.Variables
+		}
+		req.Prompts, err = config.CustomPrompt{Title: req.Title, Prompts: req.Prompts}.Render(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		model, err := aiRequestModel(db, req, ai.GenOptions{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := ai.WithCallInfo(r.Context(), ai.CallInfo{Task: req.Title, Origin: "api:" + r.URL.Path})
+		var resp []string
+		if req.Title == config.ProjectPrompt {
+			bullets, err := generateBullets(ctx, model, req.Prompts)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusMethodNotAllowed)
+				return
+			}
+			resp = ai.BulletTexts(bullets)
+		} else {
+			resp, err = model.Chat(ctx, req.Prompts)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusMethodNotAllowed)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// generateBullets lets the linter revise the bullets once when ai_lint is
+// enabled.
+func generateBullets(ctx context.Context, model ai.AiModel, prompts []config.Prompt) ([]ai.Bullet, error) {
+	if cfg, err := config.LoadConfig(); err == nil && cfg.AiLint {
+		return ai.GenerateLintedBullets(ctx, model, prompts)
+	}
+	return ai.GenerateBullets(ctx, model, prompts)
+}
+
+// aiRequestModel uses the provider named in the request, or falls back to
+// the routing configured for the prompt type.
+func aiRequestModel(db database.IDatabase, req AiRequest, opts ai.GenOptions) (ai.AiModel, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	base := ai.ModelConfig{Meter: ai.NewMeter(cfg, db), Audit: ai.NewAuditor(cfg, db), GenOptions: opts}
+	switch req.promptType() {
+	case config.ProjectPrompt:
+		base.ResponseSchema = ai.BulletSchema
+	case config.TailorPrompt:
+		base.ResponseSchema = ai.TailorSchema
+	case config.TranslatePrompt:
+		base.ResponseSchema = ai.TranslationSchema
+	}
+
+	if req.Model != "" {
+		base.Type = ai.ModelType(req.Model)
+		base.Model = req.Version
+		base.Temperature = req.Temperature
+		base.MaxToken = req.MaxTokens
+		if o, err := cfg.Providers([]string{req.Model}); err == nil {
+			base.APIKey = config.UnmaskAPIKey(req.ApiKey, o[0].ApiKey)
+			base.BaseURL = o[0].BaseURL
+		} else {
+			base.APIKey = req.ApiKey
+		}
+		return ai.NewChatModel(base)
+	}
+
+	return ai.NewTaskModel(cfg, req.promptType(), config.CustomPrompt{
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}, base)
+}
+
+// RewriteHandler rewrites any resume field with a single-shot completion.
+func RewriteHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req RewriteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Temperature == 0 {
+			req.Temperature = 0.5
+		}
+		if req.MaxTokens == 0 {
+			req.MaxTokens = 400
+		}
+
+		model, err := aiRequestModel(db, AiRequest{
+			Title:       config.RewritePrompt,
+			Model:       req.Model,
+			Version:     req.Version,
+			Temperature: req.Temperature,
+			MaxTokens:   req.MaxTokens,
+			ApiKey:      req.ApiKey,
+		}, ai.GenOptions{System: ai.RewriteSystem, Seed: req.Seed})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := ai.WithCallInfo(r.Context(), ai.CallInfo{Task: config.RewritePrompt, Origin: "api:" + r.URL.Path})
+		text, err := ai.Rewrite(ctx, model, req.Field, req.Text, req.Instruction)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		res := Response{
+			Message: "success",
+			Status:  http.StatusOK,
+			Data: map[string]string{
+				"field": req.Field,
+				"text":  text,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// MatchHandler ranks the user's evidence against a pasted job description
+// and lists the requirements nothing covers.
+func MatchHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req MatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Top == 0 {
+			req.Top = 10
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		m, err := ai.NewMatcher(cfg, db, ai.NewMeter(cfg, db), ai.NewAuditor(cfg, db))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		report, err := m.MatchJob(r.Context(), db, req.JobDescription, req.ResumeID, req.Top)
+		if errors.Is(err, ai.ErrEmptyJobDescription) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: report})
+	}
+}
+
+// TailorHandler proposes a version of a resume tailored to a job, with the
+// changes to review. Nothing is saved until TailorApplyHandler.
+func TailorHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req TailorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.JobID > 0 {
+			if req.Job, err = db.GetJob(req.JobID); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+		}
+		if req.Temperature == 0 {
+			req.Temperature = 0.4
+		}
+		if req.MaxTokens == 0 {
+			req.MaxTokens = 2000
+		}
+
+		resume, err := db.GetResume(int64(rID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		model, err := aiRequestModel(db, AiRequest{
+			Title:       config.TailorPrompt,
+			Model:       req.Model,
+			Version:     req.Version,
+			Temperature: req.Temperature,
+			MaxTokens:   req.MaxTokens,
+			ApiKey:      req.ApiKey,
+		}, ai.GenOptions{System: ai.TailorSystem})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := ai.WithCallInfo(r.Context(), ai.CallInfo{Task: config.TailorPrompt, Origin: "api:" + r.URL.Path})
+		tailored, err := ai.Tailor(ctx, model, resume, req.Job)
+		if errors.Is(err, ai.ErrEmptyJobDescription) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		res := Response{
+			Message: "success",
+			Status:  http.StatusOK,
+			Data: TailorResponse{
+				Resume:  tailored,
+				Job:     req.Job,
+				Changes: git.DiffResumes(resume, tailored),
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// TailorApplyHandler saves a reviewed tailoring as a new resume linked to
+// the resume it came from and to the job, which is saved first if new.
+func TailorApplyHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req TailorApplyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		parent, err := db.GetResume(int64(rID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		jobID := req.Job.ID
+		if jobID == 0 {
+			if strings.TrimSpace(req.Job.Description) == "" {
+				http.Error(w, ai.ErrEmptyJobDescription.Error(), http.StatusBadRequest)
+				return
+			}
+			if jobID, err = db.CreateJob(req.Job); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		tailored := req.Resume
+		tailored.ParentID = parent.ID
+		tailored.JobID = jobID
+		tailored.Version = parent.Version + 1
+		if tailored.Title == "" {
+			tailored.Title = parent.Title + " (tailored)"
+		}
+		newID, err := database.CopyResume(db, tailored)
+		if err != nil {
+			http.Error(w, "error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := Response{
+			Message: "tailored resume saved successfully",
+			Status:  http.StatusCreated,
+			Data: struct {
+				ID    int64 `json:"id"`
+				JobID int64 `json:"job_id"`
+			}{newID, jobID},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// TranslateHandler translates a resume and saves it as a new resume linked
+// to the original, returning both the new id and its content.
+func TranslateHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req TranslateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := config.LanguageCode(req.Language); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Temperature == 0 {
+			req.Temperature = 0.2
+		}
+		if req.MaxTokens == 0 {
+			req.MaxTokens = 3000
+		}
+
+		resume, err := db.GetResume(int64(rID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		model, err := aiRequestModel(db, AiRequest{
+			Title:       config.TranslatePrompt,
+			Model:       req.Model,
+			Version:     req.Version,
+			Temperature: req.Temperature,
+			MaxTokens:   req.MaxTokens,
+			ApiKey:      req.ApiKey,
+		}, ai.GenOptions{System: ai.TranslateSystem})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := ai.WithCallInfo(r.Context(), ai.CallInfo{Task: config.TranslatePrompt, Origin: "api:" + r.URL.Path})
+		translated, err := ai.Translate(ctx, model, resume, req.Language)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		newID, err := database.CopyResume(db, translated)
+		if err != nil {
+			http.Error(w, "error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		translated.ID = newID
+
+		res := Response{
+			Message: "translated resume saved successfully",
+			Status:  http.StatusCreated,
+			Data:    translated,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+func JobsHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		jobs, err := db.GetJobs()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: jobs})
+	}
+}
+
+// PromptPreviewHandler renders a prompt against a real project and resume
+// without calling the model, so template mistakes show up before spending
+// tokens.
+func PromptPreviewHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req PromptPreviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		prompt := config.CustomPrompt{Title: req.Title, Prompts: req.Prompts}
+		if len(prompt.Prompts) == 0 {
+			prmps, err := db.GetLLmPromptConfig()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, p := range prmps {
+				if p.Title == req.Title {
+					prompt = p
+				}
+			}
+			if len(prompt.Prompts) == 0 {
+				http.Error(w, fmt.Sprintf("no prompt saved for %q", req.Title), http.StatusNotFound)
+				return
+			}
+		}
+
+		var project git.Project
+		if req.ProjectID > 0 {
+			projects, err := db.GetAllProject(0, 0)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, p := range projects {
+				if p.ID == req.ProjectID {
+					project = p
+				}
+			}
+			if project.Name == "" {
+				http.Error(w, "project not found", http.StatusNotFound)
+				return
+			}
+			// GetAllProject omits hashes, which prompts cite as [hash]
+			if withHashes, err := db.GetProjectByName(project.Name); err == nil && len(withHashes.Commits) > 0 {
+				project.Commits = withHashes.Commits
+			}
+		}
+
+		var resume git.Resume
+		if req.ResumeID > 0 {
+			var err error
+			resume, err = db.GetResume(req.ResumeID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		data := ai.PromptDataFor(project, resume)
+		data.Content = req.Content
+		data.TargetRole = req.TargetRole
+		data.Company = req.Company
+		data.Seniority = req.Seniority
+		data.JobDescription = req.JobDescription
+		data.Language = req.Language
+		if len(req.Commits) > 0 {
+			data.Commits = req.Commits
+		}
+		if len(req.TechStack) > 0 {
+			data.TechStack = req.TechStack
+		}
+		if req.ProjectName != "" {
+			data.ProjectName = req.ProjectName
+		}
+		if req.ResumeTitle != "" {
+			data.ResumeTitle = req.ResumeTitle
+		}
+
+		rendered, err := prompt.Render(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := Response{
+			Message: "success",
+			Status:  http.StatusOK,
+			Data: struct {
+				Prompts   []config.Prompt   `json:"prompts"`
+				Variables config.PromptData `json:"variables"`
+			}{rendered, data},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+type EvalRequest struct {
+	Task     config.PromptType `json:"task"`
+	Versions []int             `json:"versions"`
+	Models   []string          `json:"models"`
+}
+
+// AiEvalHandler returns the results of an evaluation run (GET, ?run=, the
+// latest by default) or starts a new run (POST).
+func AiEvalHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			evals []config.PromptEval
+			err   error
+		)
+		switch r.Method {
+		case http.MethodGet:
+			evals, err = db.GetPromptEvals(r.URL.Query().Get("run"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case http.MethodPost:
+			var req EvalRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.Task == "" {
+				req.Task = config.ProjectPrompt
+			}
+			evals, err = runEval(r, db, req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		type result struct {
+			config.PromptEval
+			Score float64 `json:"score"`
+		}
+		results := make([]result, 0, len(evals))
+		for _, e := range evals {
+			results = append(results, result{e, ai.EvalScore(e)})
+		}
+		data := map[string]any{"results": results, "winner": nil}
+		if len(evals) > 0 {
+			data["run_id"] = evals[0].RunID
+		}
+		if winner, ok := ai.Winner(evals); ok {
+			data["winner"] = winner
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: data})
+	}
+}
+
+func runEval(r *http.Request, db database.IDatabase, req EvalRequest) ([]config.PromptEval, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	all, err := db.GetPromptVersions(req.Task)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := ai.SelectVersions(all, req.Versions)
+	if err != nil {
+		return nil, err
+	}
+	providers := cfg.ProvidersFor(req.Task)
+	if len(req.Models) > 0 {
+		if providers, err = cfg.Providers(req.Models); err != nil {
+			return nil, err
+		}
+	}
+
+	ev := &ai.Evaluator{Store: db, Meter: ai.NewMeter(cfg, db), Audit: ai.NewAuditor(cfg, db)}
+	_, evals, err := ev.Run(r.Context(), versions, providers)
+	return evals, err
+}
+
+// PromptVersionsHandler lists the versions of a prompt (GET) or promotes
+// one to active (POST {"version": n}).
+func PromptVersionsHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		title := config.PromptType(GetID(w, r.URL.Path))
+		if title == "" {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var req struct {
+				Version int `json:"version"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := db.PromotePromptVersion(title, req.Version); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		versions, err := db.GetPromptVersions(title)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if versions == nil {
+			versions = []config.PromptVersion{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: versions})
+	}
+}
+
+func AiUsageHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		by := query.Get("by")
+		if by == "" {
+			by = "provider"
+		}
+		since := query.Get("since")
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		meter := ai.NewMeter(cfg, db)
+		spent, err := meter.MonthToDate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		records, err := db.GetAIUsage(since)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		summary, err := ai.SummarizeUsage(records, by)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := Response{
+			Message: "success",
+			Status:  http.StatusOK,
+			Data: struct {
+				By           string            `json:"by"`
+				Summary      []ai.UsageSummary `json:"summary"`
+				MonthToDate  float64           `json:"month_to_date"`
+				MonthlyLimit float64           `json:"monthly_limit"`
+			}{
+				By:           by,
+				Summary:      summary,
+				MonthToDate:  spent,
+				MonthlyLimit: cfg.AiBudget.MonthlyLimit,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// AiAuditHandler lists the requests sent to AI providers, newest first.
+func AiAuditHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		limit := 50
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		entries, err := db.GetAIAudit(query.Get("since"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res := Response{
+			Message: "success",
+			Status:  http.StatusOK,
+			Data:    entries,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+type OllamaModelsResponse struct {
+	Available  bool             `json:"available"`
+	Models     []ai.OllamaModel `json:"models"`
+	Configured string           `json:"configured"`
+	Pulled     bool             `json:"pulled"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// OllamaModelsHandler reports the local Ollama models and whether the
+// configured one is pulled. An unreachable Ollama is not an error here, the
+// dashboard shows it as unavailable.
+func OllamaModelsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	var req AiRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	cfg, _ := config.LoadConfig()
+	data := OllamaModelsResponse{
+		Models:     []ai.OllamaModel{},
+		Configured: ai.ConfiguredOllamaModel(cfg),
+	}
+	models, err := ai.NewOllamaClient().ListModels(r.Context())
+	switch {
+	case errors.Is(err, ai.ErrOllamaUnavailable):
+		data.Error = err.Error()
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
+	default:
+		data.Available = true
+		data.Models = models
+		data.Pulled = ai.ModelPulled(models, data.Configured)
 	}
 
-	if len(req.Prompts) == 0 {
-		http.Error(w, errors.New("prompt is missing").Error(), http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: data})
+}
+
+// OllamaPullHandler pulls a model and streams the progress as NDJSON, one
+// PullProgress per line. The body may name the model; it defaults to the
+// configured one.
+func OllamaPullHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Model == "" {
+		cfg, _ := config.LoadConfig()
+		req.Model = ai.ConfiguredOllamaModel(cfg)
+	}
 
-	provider := ai.NewChatModel(ai.ModelConfig{
-		Type:        ai.ModelType(req.Model),
-		Model:       req.Version,
-		Temperature: req.Temperature,
-		MaxToken:    req.MaxTokens,
-		APIKey:      req.ApiKey,
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	err := ai.NewOllamaClient().PullModel(r.Context(), req.Model, func(p ai.PullProgress) {
+		enc.Encode(p)
+		if flusher != nil {
+			flusher.Flush()
+		}
 	})
-	resp, err := provider.Chat(context.Background(), req.Prompts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusMethodNotAllowed)
-		return
+		enc.Encode(ai.PullProgress{Status: "error", Error: err.Error()})
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(resp)
 }
 
 func BulkUpdateCommitMessageHandler(db database.IDatabase) http.HandlerFunc {
@@ -367,12 +1299,25 @@ func UserHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+// GetUserHandler returns a user's profile. "me" is the user the dashboard
+// is serving; nobody else's profile can be read.
 func GetUserHandler(db database.IDatabase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := GetID(w, r.URL.Path)
-		userID, err := strconv.Atoi(idStr)
-		if err != nil {
-			http.Error(w, "invalid project ID: "+err.Error(), http.StatusBadRequest)
+		userID := db.UserID()
+		if idStr != "me" {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid user ID: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if id != userID {
+				http.Error(w, "user not found", http.StatusNotFound)
+				return
+			}
+		}
+		if userID == 0 {
+			http.Error(w, "no user selected", http.StatusNotFound)
 			return
 		}
 
@@ -383,7 +1328,7 @@ func GetUserHandler(db database.IDatabase) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(res)
 	}
 }
@@ -523,12 +1468,19 @@ func CreateOrUpdateWorkExperiencesHandler(db database.IDatabase) http.HandlerFun
 	}
 }
 
-func ExportResumeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// ExportResumeHandler converts the rendered resume in the body. With
+// sources=1 and resume=<id>, bullets are followed by their source commits.
+func ExportResumeHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		exportResume(db, w, r)
 	}
+}
 
+func exportResume(db database.IDatabase, w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	etype := query.Get("format")
 	var (
@@ -555,6 +1507,33 @@ func ExportResumeHandler(w http.ResponseWriter, r *http.Request) {
 	defer exp.Close()
 
 	htmlBytes, _ := io.ReadAll(r.Body)
+	var resume git.Resume
+	if query.Get("sources") == "1" || query.Get("resume") != "" {
+		rID, _ := strconv.Atoi(query.Get("resume"))
+		var err error
+		if resume, err = db.GetResume(int64(rID)); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+	if query.Get("sources") == "1" {
+		sources, err := database.ResumeSources(db, resume)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		commits := map[string][]string{}
+		for _, s := range sources {
+			commits[util.TextKey(s.Bullet)] = s.Commits
+		}
+		htmlBytes = export.AnnotateSources(htmlBytes, commits)
+	}
+	// headings follow the resume's language unless ?lang= asks otherwise
+	lang := query.Get("lang")
+	if lang == "" {
+		lang = resume.Language
+	}
+	htmlBytes = export.LocalizeHeadings(htmlBytes, lang)
 	buf, err := exp.Export(htmlBytes)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -588,42 +1567,16 @@ func ResumeCopyHandler(db database.IDatabase) http.HandlerFunc {
 		newRs := res
 		newRs.Title = res.Title + " copy"
 		newRs.Version = res.Version + 1
-		newRes, err := db.CreateResume(newRs)
+		newRs.ParentID = res.ID
+		newID, err := database.CopyResume(db, newRs)
 		if err != nil {
 			http.Error(w, "error: "+err.Error(), http.StatusBadRequest)
 			return
 		}
-		if len(newRs.WorkExperiences) > 0 {
-			newExp := []git.WorkExperience{}
-			for _, v := range newRs.WorkExperiences {
-				exp := v
-				exp.ID = 0
-				newExp = append(newExp, exp)
-			}
-			_, err := db.CreateOrUpdateWorkExperiences(newRes.ID, newExp)
-			if err != nil {
-				http.Error(w, "error: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-		}
-		if len(newRs.Education) > 0 {
-			newEdu := []git.Education{}
-			for _, v := range newRs.Education {
-				edu := v
-				edu.ID = 0
-				newEdu = append(newEdu, edu)
-			}
-			_, err = db.CreateOrUpdateEducation(newRes.ID, newEdu)
-			if err != nil {
-				http.Error(w, "error: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-		}
-		fmt.Println("resume ID: ", newRes.ID)
 		response := Response{
 			Message: "resume duplicated successfully",
 			Status:  http.StatusCreated,
-			Data:    newRes.ID,
+			Data:    newID,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -1,16 +1,206 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
 
-	// "strings"
 	"testing"
 
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/ai"
 	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+	"github.com/iamhabbeboy/gitresume/internal/lint"
+	"github.com/iamhabbeboy/gitresume/util"
 )
 
-type mockDB struct{ database.IDatabase }
+type mockDB struct {
+	database.IDatabase
+	usage    []config.AiUsage
+	projects []git.Project
+	prompts  []config.CustomPrompt
+	evals    []config.PromptEval
+	vectors  []config.Embedding
+	resumes  map[int64]git.Resume
+	jobs     []git.Job
+	queued   []config.Suggestion
+	audit    []config.AiAudit
+	searched []config.SearchQuery
+	revs     []git.ResumeRevision
+	uID      int64
+	logins   int
+}
+
+func (m *mockDB) ForUser(uID int64) database.IDatabase { return &mockDB{uID: uID} }
+func (m *mockDB) UserID() int64                        { return m.uID }
+func (m *mockDB) Authenticate(email, password string) (git.Profile, error) {
+	m.logins++
+	if email != "ada@example.com" || password != "secret" {
+		return git.Profile{}, config.ErrInvalidCredentials
+	}
+	return git.Profile{ID: 2, Name: "Ada", Email: email}, nil
+}
+func (m *mockDB) GetUsers() ([]git.Profile, error) {
+	return []git.Profile{{ID: 1, Name: "Grace"}, {ID: 2, Name: "Ada"}}, nil
+}
+func (m *mockDB) GetUser(email string) (git.Profile, error) {
+	if email == "ada@example.com" {
+		return git.Profile{ID: 2, Name: "Ada", Email: email}, nil
+	}
+	return git.Profile{}, nil
+}
+func (m *mockDB) CreateOrUpdateLLmPrompt(cfg config.CustomPrompt) error {
+	m.prompts = append(m.prompts, cfg)
+	return nil
+}
+func (m *mockDB) GetUserByID(id int32) (git.Profile, error) {
+	return git.Profile{ID: id, Name: "Ada"}, nil
+}
+
+func (m *mockDB) GetAllProject(limit, offset int) ([]git.Project, error) { return m.projects, nil }
+func (m *mockDB) GetProjectByName(name string) (git.Project, error) {
+	for _, p := range m.projects {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return git.Project{}, nil
+}
+func (m *mockDB) GetProject(id int) (git.Project, error) {
+	for _, p := range m.projects {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return git.Project{}, fmt.Errorf("project %d not found", id)
+}
+func (m *mockDB) UpdateProject(p git.Project) error {
+	for i, o := range m.projects {
+		if o.Name == p.Name && o.ID != p.ID {
+			return config.ErrProjectExists
+		}
+		if o.ID == p.ID {
+			m.projects[i].Name = p.Name
+		}
+	}
+	return nil
+}
+func (m *mockDB) ArchiveProject(id int, archived bool) error {
+	for i, p := range m.projects {
+		if p.ID == id {
+			m.projects[i].Archived = archived
+		}
+	}
+	return nil
+}
+func (m *mockDB) DeleteProject(id int) error {
+	for i, p := range m.projects {
+		if p.ID == id {
+			m.projects = append(m.projects[:i], m.projects[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("project %d not found", id)
+}
+func (m *mockDB) GetLLmPromptConfig() ([]config.CustomPrompt, error)       { return m.prompts, nil }
+func (m *mockDB) GetPromptEvals(runID string) ([]config.PromptEval, error) { return m.evals, nil }
+func (m *mockDB) CreateAIUsage(u config.AiUsage) error                     { m.usage = append(m.usage, u); return nil }
+func (m *mockDB) GetAIUsage(since string) ([]config.AiUsage, error)        { return m.usage, nil }
+func (m *mockDB) GetAIUsageCost(since string) (float64, error) {
+	var cost float64
+	for _, u := range m.usage {
+		cost += u.Cost
+	}
+	return cost, nil
+}
+func (m *mockDB) CreateAIAudit(a config.AiAudit) error { m.audit = append(m.audit, a); return nil }
+func (m *mockDB) PruneAIAudit(string) (int64, error)   { return 0, nil }
+func (m *mockDB) GetAIAudit(since string, limit int) ([]config.AiAudit, error) {
+	if limit > 0 && limit < len(m.audit) {
+		return m.audit[:limit], nil
+	}
+	return m.audit, nil
+}
+func (m *mockDB) GetAllCommitSummary(int) ([]git.CustomUpdateCommit, error) { return nil, nil }
+func (m *mockDB) GetResumes() ([]git.Resume, error)                         { return nil, nil }
+func (m *mockDB) GetEmbeddings(string) ([]config.Embedding, error)          { return m.vectors, nil }
+func (m *mockDB) SaveEmbeddings(e []config.Embedding) error {
+	m.vectors = append(m.vectors, e...)
+	return nil
+}
+func (m *mockDB) DeleteEmbeddings(string, []string) error { return nil }
+func (m *mockDB) SearchEmbeddings(model string, v []float32, limit int) ([]config.EmbeddingMatch, error) {
+	var out []config.EmbeddingMatch
+	for _, e := range m.vectors {
+		out = append(out, config.EmbeddingMatch{Evidence: e.Evidence, Score: util.Cosine(v, e.Vector)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out, nil
+}
+
+func (m *mockDB) GetResume(id int64) (git.Resume, error) {
+	r, ok := m.resumes[id]
+	if !ok {
+		return r, errors.New("record with ID not found")
+	}
+	return r, nil
+}
+func (m *mockDB) CreateResume(r git.Resume) (git.Resume, error) {
+	// like the drivers, only the resume row is created
+	r.ID = int64(len(m.resumes) + 1)
+	r.WorkExperiences, r.Education, r.Volunteers, r.ProjectWorkedOn = nil, nil, nil, nil
+	m.resumes[r.ID] = r
+	return r, nil
+}
+func (m *mockDB) CreateOrUpdateWorkExperiences(id int64, w []git.WorkExperience) ([]int64, error) {
+	r := m.resumes[id]
+	r.WorkExperiences = append(r.WorkExperiences, w...)
+	m.resumes[id] = r
+	return nil, nil
+}
+func (m *mockDB) CreateJob(j git.Job) (int64, error) {
+	j.ID = int64(len(m.jobs) + 1)
+	m.jobs = append(m.jobs, j)
+	return j.ID, nil
+}
+
+func (m *mockDB) GetPromptVersions(config.PromptType) ([]config.PromptVersion, error) {
+	return []config.PromptVersion{{Version: 1}, {Version: 2, Active: true}}, nil
+}
+func (m *mockDB) CreateSuggestions(s []config.Suggestion) ([]int64, error) {
+	var ids []int64
+	for _, v := range s {
+		v.ID = int64(len(m.queued) + 1)
+		m.queued = append(m.queued, v)
+		ids = append(ids, v.ID)
+	}
+	return ids, nil
+}
+func (m *mockDB) GetResumeRevision(rID int64, revision int) (git.ResumeRevision, error) {
+	for _, r := range m.revs {
+		if r.ResumeID == rID && r.Revision == revision {
+			return r, nil
+		}
+	}
+	return git.ResumeRevision{}, errors.New("no such revision")
+}
+func (m *mockDB) RestoreResumeRevision(rID int64, revision int) error {
+	r, err := m.GetResumeRevision(rID, revision)
+	if err == nil {
+		m.resumes[rID] = *r.Resume
+	}
+	return err
+}
+func (m *mockDB) Search(q config.SearchQuery) ([]config.SearchResult, error) {
+	m.searched = append(m.searched, q)
+	return []config.SearchResult{{Kind: config.SearchCommit, ID: 1, Snippet: "Add <mark>checkout</mark>"}}, nil
+}
 
 func TestIndexHandler(t *testing.T) {
 	r := httptest.NewRequest("GET", "/", nil)
@@ -33,4 +223,585 @@ func TestProjectsHandler_BasicResponse(t *testing.T) {
 	}
 }
 
+func TestAiHandler_FakeProvider(t *testing.T) {
+	body := `{
+		"title": "project",
+		"model": "fake",
+		"prompts": [
+			{"role": "system", "content": "You are a resume writer"},
+			{"role": "user", "content": "[a1] add login page\n[b2] fix CSRF check"}
+		]
+	}`
+	t.Setenv("HOME", t.TempDir())
+	db := &mockDB{}
+	r := httptest.NewRequest("POST", "/api/ai", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	AiHandler(db)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp []string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"add login page", "fix CSRF check"}; !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %q, got %q", want, resp)
+	}
+	if len(db.usage) != 1 || db.usage[0].Provider != "fake" || !db.usage[0].Estimated {
+		t.Errorf("expected one estimated usage record, got %+v", db.usage)
+	}
+	if len(db.audit) != 1 || !strings.Contains(db.audit[0].Prompt, "fix CSRF check") {
+		t.Errorf("expected the request to be logged, got %+v", db.audit)
+	}
+
+	r = httptest.NewRequest("GET", "/api/ai/audit?limit=5", nil)
+	w = httptest.NewRecorder()
+	AiAuditHandler(db)(w, r)
+	var audit struct {
+		Data []config.AiAudit `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&audit); err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Data) != 1 || audit.Data[0].Provider != "fake" {
+		t.Errorf("unexpected audit log %+v", audit.Data)
+	}
+}
+
+func TestAiHandler_RendersPromptTemplates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	prompts := `[
+		{"role": "system", "content": "You are an expert technical writer."},
+		{"role": "user", "content": "Write a summary for a {{with .TargetRole}}{{.}}{{else}}Software Engineer{{end}}: {{.Content}}"}
+	]`
+	tests := []struct {
+		name, variables, want string
+	}{
+		{"dashboard", `, "variables": {"commits": ["Built the checkout", "Cut latency"]}`, "Software Engineer: Built the checkout\nCut latency"},
+		{"no variables", "", "Software Engineer: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mockDB{}
+			body := `{"title": "summary", "model": "fake", "prompts": ` + prompts + tt.variables + `}`
+			r := httptest.NewRequest("POST", "/api/ai", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			AiHandler(db)(w, r)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+			if len(db.audit) != 1 || strings.Contains(db.audit[0].Prompt, "{{") || !strings.Contains(db.audit[0].Prompt, tt.want) {
+				t.Errorf("expected the rendered prompt to contain %q, got %+v", tt.want, db.audit)
+			}
+		})
+	}
+}
+
+func TestAiHandler_UntitledRequestIsProjectPrompt(t *testing.T) {
+	// older dashboards send an empty title with the commit prompt
+	body := `{"title": "", "model": "fake", "prompts": [{"role": "user", "content": "[a1] add login page"}]}`
+	t.Setenv("HOME", t.TempDir())
+	db := &mockDB{}
+	r := httptest.NewRequest("POST", "/api/ai", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	AiHandler(db)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(db.audit) != 1 || db.audit[0].PromptType != config.ProjectPrompt {
+		t.Errorf("expected a project prompt request, got %+v", db.audit)
+	}
+}
+
+func TestPromptPreviewHandler(t *testing.T) {
+	db := &mockDB{
+		projects: []git.Project{{
+			ID:           3,
+			Name:         "gitresume",
+			Technologies: `{"stack":{"Go":4},"framework":{}}`,
+			Commits:      []git.GitCommit{{Hash: "a1", Msg: "add api"}},
+		}},
+		prompts: []config.CustomPrompt{{
+			Title:   config.ProjectPrompt,
+			Prompts: []config.Prompt{{Role: "user", Content: "{{.ProjectName}} in {{join .TechStack \",\"}} for {{.Company}}: %content%"}},
+		}},
+	}
+	body := `{"title": "project", "project_id": 3, "company": "Acme"}`
+	r := httptest.NewRequest("POST", "/api/prompts/preview", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	PromptPreviewHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Prompts []config.Prompt `json:"prompts"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Prompts) != 1 || resp.Data.Prompts[0].Content != "gitresume in Go for Acme: [a1] add api" {
+		t.Errorf("unexpected preview: %+v", resp.Data.Prompts)
+	}
+
+	r = httptest.NewRequest("POST", "/api/prompts/preview", strings.NewReader(`{"prompts": [{"role": "user", "content": "{{.Nope}}"}]}`))
+	w = httptest.NewRecorder()
+	PromptPreviewHandler(db)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid template, got %d", w.Code)
+	}
+}
+
+func TestAiEvalHandler_Latest(t *testing.T) {
+	db := &mockDB{evals: []config.PromptEval{
+		{RunID: "r1", Version: 1, Provider: "llama", Bullets: 2, AvgLength: 60, ActionVerbs: 0.5},
+		{RunID: "r1", Version: 2, Provider: "llama", Bullets: 2, AvgLength: 60, ActionVerbs: 1, Quantified: 0.5},
+	}}
+	r := httptest.NewRequest("GET", "/api/ai/evals", nil)
+	w := httptest.NewRecorder()
+	AiEvalHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			RunID   string            `json:"run_id"`
+			Winner  config.PromptEval `json:"winner"`
+			Results []struct {
+				Score float64 `json:"score"`
+			} `json:"results"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.RunID != "r1" || resp.Data.Winner.Version != 2 || len(resp.Data.Results) != 2 {
+		t.Errorf("unexpected eval report: %+v", resp.Data)
+	}
+}
+
+func TestRewriteHandler_FakeProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := &mockDB{}
+	body := `{"field": "summary", "text": "backend engineer who builds apis", "model": "fake"}`
+	r := httptest.NewRequest("POST", "/api/ai/rewrite", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	RewriteHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Data["text"], "Backend engineer who builds apis") {
+		t.Errorf("expected the fake rewrite to echo the text, got %q", resp.Data["text"])
+	}
+	if len(db.usage) != 1 || db.usage[0].PromptType != config.RewritePrompt {
+		t.Errorf("expected the rewrite to be metered, got %+v", db.usage)
+	}
+
+	r = httptest.NewRequest("POST", "/api/ai/rewrite", strings.NewReader(`{"text": "x", "model": "nope"}`))
+	w = httptest.NewRecorder()
+	RewriteHandler(db)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown provider, got %d", w.Code)
+	}
+}
+
+func TestMatchHandler_FakeProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveConfig(&config.AppConfig{AiOptions: []config.AiOptions{{Name: "fake", Model: "fake", IsDefault: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	db := &mockDB{projects: []git.Project{{ID: 1, Name: "shop", Commits: []git.GitCommit{
+		{ID: 1, Msg: "Add Stripe checkout with webhooks"},
+		{ID: 2, Msg: "Fix typo in footer"},
+	}}}}
+
+	body := `{"job_description": "Built payment flows with Stripe webhooks\nWrote Rust firmware for drones", "top": 1}`
+	r := httptest.NewRequest("POST", "/api/match", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	MatchHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data ai.MatchReport `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Matches) != 1 || resp.Data.Matches[0].Ref != "commit:1" {
+		t.Errorf("expected the Stripe commit on top, got %+v", resp.Data.Matches)
+	}
+	if len(resp.Data.Missing) != 1 || resp.Data.Missing[0] != "Wrote Rust firmware for drones" {
+		t.Errorf("unexpected missing requirements %q", resp.Data.Missing)
+	}
+
+	r = httptest.NewRequest("POST", "/api/match", strings.NewReader(`{"job_description": " "}`))
+	w = httptest.NewRecorder()
+	MatchHandler(db)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an empty job description, got %d", w.Code)
+	}
+}
+
+func TestTailorHandlers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveConfig(&config.AppConfig{AiOptions: []config.AiOptions{{Name: "fake", Model: "fake", IsDefault: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	db := &mockDB{resumes: map[int64]git.Resume{1: {
+		ID: 1, Title: "Backend", Version: 1, Skills: []string{"Go", "Kafka"},
+		WorkExperiences: []git.WorkExperience{{ID: 2, Company: "Acme", Responsibilities: "<ul><li>Ran Kafka</li></ul>"}},
+	}}}
+
+	body := `{"job": {"title": "SRE", "company": "Globex", "description": "Operate Kafka clusters"}}`
+	r := httptest.NewRequest("POST", "/api/resumes/1/tailor", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	TailorHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data TailorResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Changes) != 1 || resp.Data.Changes[0].Field != "skills" {
+		t.Errorf("expected the skills to be reordered, got %+v", resp.Data.Changes)
+	}
+	if len(db.resumes) != 1 || len(db.jobs) != 0 {
+		t.Error("a proposal must not be saved")
+	}
+
+	apply, _ := json.Marshal(TailorApplyRequest{Job: resp.Data.Job, Resume: resp.Data.Resume})
+	r = httptest.NewRequest("POST", "/api/resumes/1/tailor/apply", strings.NewReader(string(apply)))
+	w = httptest.NewRecorder()
+	TailorApplyHandler(db)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	saved := db.resumes[2]
+	if saved.ParentID != 1 || saved.JobID != 1 || saved.Version != 2 || saved.Title != "Backend (SRE at Globex)" {
+		t.Errorf("unexpected saved resume %+v", saved)
+	}
+	if len(saved.WorkExperiences) != 1 || saved.WorkExperiences[0].ID != 0 {
+		t.Errorf("work experiences should be copied as new rows, got %+v", saved.WorkExperiences)
+	}
+
+	r = httptest.NewRequest("POST", "/api/resumes/9/tailor", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	TailorHandler(db)(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing resume, got %d", w.Code)
+	}
+}
+
+func TestSuggestionsHandler_Create(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveConfig(&config.AppConfig{AiOptions: []config.AiOptions{{Name: "fake", Model: "fake", IsDefault: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	db := &mockDB{projects: []git.Project{{ID: 4, Name: "shop", Commits: []git.GitCommit{{Hash: "a1b2c3d", Msg: "Add checkout"}}}}}
+
+	// the default prompt, sent without variables by older dashboards
+	body := `{"project_id": 4, "prompts": [{"role": "system", "content": "Resume bullets for {{.ProjectName}}"}, {"role": "user", "content": "{{lines .Commits}}"}]}`
+	r := httptest.NewRequest("POST", "/api/suggestions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	SuggestionsHandler(db)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(db.queued) != 1 {
+		t.Fatalf("expected one queued suggestion, got %+v", db.queued)
+	}
+	s := db.queued[0]
+	if s.ProjectID != 4 || s.State != config.SuggestionPending || s.PromptVersion != 2 || s.Provider != "fake" || s.SourceCommits[0] != "a1b2c3d" {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+	if len(db.audit) != 1 || !strings.Contains(db.audit[0].Prompt, "for shop\n\n[user]\n[a1b2c3d] Add checkout") {
+		t.Errorf("expected the prompt to be rendered from the project, got %+v", db.audit)
+	}
+
+	r = httptest.NewRequest("POST", "/api/suggestions", strings.NewReader(`{"prompts": [{"role": "user", "content": "x"}]}`))
+	w = httptest.NewRecorder()
+	SuggestionsHandler(db)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a project, got %d", w.Code)
+	}
+}
+
 // TODO: Write similar test stubs for ProjectHandler, AIConfigHandler, CreateResumeHandler, etc. (mock DB as needed)
+
+func TestResumeLintHandler(t *testing.T) {
+	db := &mockDB{resumes: map[int64]git.Resume{1: {
+		ID:              1,
+		WorkExperiences: []git.WorkExperience{{ID: 2, Responsibilities: "<ul><li>Helped with the billing migration</li></ul>"}},
+	}}}
+
+	r := httptest.NewRequest("GET", "/api/resumes/1/lint", nil)
+	w := httptest.NewRecorder()
+	ResumeLintHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data LintResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Warnings != 1 || len(resp.Data.Diagnostics) != 2 {
+		t.Fatalf("expected a weak opening and a missing number, got %+v", resp.Data)
+	}
+	d := resp.Data.Diagnostics[0]
+	if d.Field != "work_experiences.0.responsibilities.0" || d.Rule != lint.WeakOpening || d.Span != (lint.Span{Start: 0, End: 6}) {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+}
+
+func TestTranslateHandler(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveConfig(&config.AppConfig{AiOptions: []config.AiOptions{{Name: "fake", Model: "fake", IsDefault: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	db := &mockDB{resumes: map[int64]git.Resume{1: {
+		ID: 1, Title: "Backend", Version: 1,
+		WorkExperiences: []git.WorkExperience{{ID: 2, Company: "Acme", Responsibilities: "<ul><li>Ran Kafka</li></ul>"}},
+	}}}
+
+	r := httptest.NewRequest("POST", "/api/resumes/1/translate", strings.NewReader(`{"language": "fr"}`))
+	w := httptest.NewRecorder()
+	TranslateHandler(db)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	saved := db.resumes[2]
+	if saved.ParentID != 1 || saved.Language != "fr" || saved.Title != "Backend (French)" || len(saved.WorkExperiences) != 1 {
+		t.Errorf("unexpected language version %+v", saved)
+	}
+
+	r = httptest.NewRequest("POST", "/api/resumes/1/translate", strings.NewReader(`{"language": "tlh"}`))
+	w = httptest.NewRecorder()
+	TranslateHandler(db)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown language, got %d", w.Code)
+	}
+}
+
+func TestExportLocalizesHeadings(t *testing.T) {
+	db := &mockDB{resumes: map[int64]git.Resume{1: {ID: 1, Language: "de"}}}
+	r := httptest.NewRequest("POST", "/api/export?format=md&resume=1", strings.NewReader("<h2>Education</h2>"))
+	w := httptest.NewRecorder()
+	ExportResumeHandler(db)(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Ausbildung") {
+		t.Errorf("expected a German heading, got %d: %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	db := &mockDB{}
+	r := httptest.NewRequest("GET", "/api/search?q=checkout&project_id=2&type=commit,summary&since=2025-01-01", nil)
+	w := httptest.NewRecorder()
+	SearchHandler(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := config.SearchQuery{Text: "checkout", ProjectID: 2, Kinds: []string{"commit", "summary"}, Since: "2025-01-01", Limit: 20}
+	if len(db.searched) != 1 || !reflect.DeepEqual(db.searched[0], want) {
+		t.Errorf("expected %+v, got %+v", want, db.searched)
+	}
+	var resp struct {
+		Data []config.SearchResult `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Snippet != "Add <mark>checkout</mark>" {
+		t.Errorf("unexpected results %+v", resp.Data)
+	}
+
+	for _, bad := range []string{"", "?q=%20", "?q=x&type=issue", "?q=x&since=yesterday", "?q=x&limit=-1"} {
+		w = httptest.NewRecorder()
+		SearchHandler(db)(w, httptest.NewRequest("GET", "/api/search"+bad, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %q, got %d", bad, w.Code)
+		}
+	}
+}
+
+func TestRevisionHandlers(t *testing.T) {
+	db := &mockDB{
+		resumes: map[int64]git.Resume{1: {ID: 1, Title: "Platform"}},
+		revs:    []git.ResumeRevision{{ResumeID: 1, Revision: 1, Resume: &git.Resume{ID: 1, Title: "Backend"}}},
+	}
+
+	w := httptest.NewRecorder()
+	RevisionDiffHandler(db)(w, httptest.NewRequest("GET", "/api/resumes/1/revisions/diff?from=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var diff struct {
+		Data []git.Change `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Data) != 1 || diff.Data[0].Field != "title" {
+		t.Errorf("expected the title to differ from the current resume, got %+v", diff.Data)
+	}
+
+	w = httptest.NewRecorder()
+	RevisionDiffHandler(db)(w, httptest.NewRequest("GET", "/api/resumes/1/revisions/diff?from=1&to=7", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing revision, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	RevisionHandler(db)(w, httptest.NewRequest("GET", "/api/resumes/1/revisions/1", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Backend"`) {
+		t.Errorf("expected revision 1, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	RevisionsHandler(db)(w, httptest.NewRequest("POST", "/api/resumes/1/revisions", strings.NewReader(`{"revision": 1}`)))
+	if w.Code != http.StatusOK || db.resumes[1].Title != "Backend" {
+		t.Errorf("expected revision 1 to be restored, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGitProjectHandlers(t *testing.T) {
+	db := &mockDB{projects: []git.Project{{ID: 1, Name: "shop"}, {ID: 2, Name: "blog"}}}
+	h := routes(db)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := send("PATCH", "/api/projects/1", `{"name": "store", "archived": true}`)
+	if w.Code != http.StatusOK || db.projects[0].Name != "store" || !db.projects[0].Archived {
+		t.Errorf("expected the project renamed and archived, got %d: %s %+v", w.Code, w.Body.String(), db.projects[0])
+	}
+	if w := send("PATCH", "/api/projects/1", `{"name": "blog"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a taken name, got %d", w.Code)
+	}
+	if w := send("PATCH", "/api/projects/9", `{"archived": false}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing project, got %d", w.Code)
+	}
+	if w := send("DELETE", "/api/projects/2", ""); w.Code != http.StatusOK || len(db.projects) != 1 {
+		t.Errorf("expected the blog deleted, got %d: %+v", w.Code, db.projects)
+	}
+	if w := send("DELETE", "/api/projects/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 deleting twice, got %d", w.Code)
+	}
+	if w := send("PUT", "/api/projects/1", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+
+	// a rescan reads the stored path, whatever the request asks for
+	db.projects[0].Path = "/nowhere/shop"
+	elsewhere := t.TempDir()
+	w = send("POST", "/api/projects/1/rescan?path="+elsewhere, "")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "/nowhere/shop") || strings.Contains(w.Body.String(), elsewhere) {
+		t.Errorf("expected the rescan to ignore ?path=, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	db := &mockDB{}
+	h := LoginHandler(db, func(db database.IDatabase) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, db.UserID())
+		})
+	})
+	get := func(email, password string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/api/resumes", nil)
+		if email != "" {
+			r.SetBasicAuth(email, password)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get("", ""); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected a login challenge, got %d %v", w.Code, w.Header())
+	}
+	if w := get("ada@example.com", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for a wrong password, got %d", w.Code)
+	}
+	for i := 0; i < 2; i++ {
+		if w := get("ada@example.com", "secret"); w.Code != http.StatusOK || w.Body.String() != "2" {
+			t.Errorf("expected to be served as user 2, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if db.logins != 2 {
+		t.Errorf("expected the password to be checked once and then cached, got %d checks", db.logins)
+	}
+}
+
+func TestGetUserHandler(t *testing.T) {
+	db := &mockDB{uID: 2}
+	for path, want := range map[string]int{"/api/users/me": http.StatusOK, "/api/users/2": http.StatusOK, "/api/users/1": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		GetUserHandler(db)(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+func TestAIConfigHandlers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveConfig(&config.AppConfig{AiOptions: []config.AiOptions{{Name: "openai", Model: "gpt-5-mini", ApiKey: "sk-abcdefghijklmnop", IsDefault: true}}}); err != nil {
+		t.Fatal(err)
+	}
+	put := func(db database.IDatabase, body string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		AIConfigHandler(db)(w, httptest.NewRequest("PUT", "/api/config", strings.NewReader(body)))
+		return w.Code
+	}
+
+	w := httptest.NewRecorder()
+	GetAIConfigHandler(&mockDB{uID: 1})(w, httptest.NewRequest("GET", "/api/config", nil))
+	var got config.AiConfigResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Models) != 1 || got.Models[0].ApiKey != "****mnop" {
+		t.Fatalf("expected the API key to be masked, got %+v", got.Models)
+	}
+
+	// Ada is not the owner: her prompts are hers, the providers are not
+	ada := &mockDB{uID: 2}
+	if code := put(ada, `{"models":[{"name":"openai","model":"gpt-5","api_key":"sk-stolen"}]}`); code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a provider change by another user, got %d", code)
+	}
+	if code := put(ada, `{"fallback":[]}`); code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a routing change by another user, got %d", code)
+	}
+	if code := put(ada, `{"custom_prompt":[{"title":"project","prompts":[{"role":"user","content":"Summarise"}]}]}`); code != http.StatusCreated || len(ada.prompts) != 1 {
+		t.Errorf("expected another user to save their own prompt, got %d", code)
+	}
+
+	// the owner sending the masked key back keeps the stored one
+	if code := put(&mockDB{uID: 1}, `{"models":[{"name":"openai","model":"gpt-5","api_key":"****mnop","is_default":true}]}`); code != http.StatusCreated {
+		t.Fatalf("expected the owner to change the providers, got %d", code)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := cfg.AiOptions[0]; o.Model != "gpt-5" || o.ApiKey != "sk-abcdefghijklmnop" {
+		t.Errorf("expected the model to change and the key to be kept, got %+v", o)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/lint"
+)
+
+type LintResponse struct {
+	Warnings    int               `json:"warnings"`
+	Diagnostics []lint.Diagnostic `json:"diagnostics"`
+}
+
+// ResumeLintHandler runs the offline linter over a resume. Spans are
+// character offsets into each diagnostic's text.
+func ResumeLintHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resume, err := db.GetResume(int64(rID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		diags := lint.Resume(resume)
+		if diags == nil {
+			diags = []lint.Diagnostic{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: LintResponse{
+			Warnings:    lint.Warnings(diags),
+			Diagnostics: diags,
+		}})
+	}
+}
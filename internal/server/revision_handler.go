@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// RevisionsHandler lists the revisions of a resume. A POST with
+// {"revision": n} restores one and returns the restored resume.
+func RevisionsHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rID, err := strconv.ParseInt(GetCenterID(w, r.URL.Path), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			revisions, err := db.GetResumeRevisions(rID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: revisions})
+		case http.MethodPost:
+			var req struct {
+				Revision int `json:"revision"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := db.RestoreResumeRevision(rID, req.Revision); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resume, err := db.GetResume(rID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response{Message: "revision restored", Status: http.StatusOK, Data: resume})
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// RevisionHandler returns one revision of a resume with its snapshot.
+func RevisionHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.ParseInt(GetCenterID(w, r.URL.Path), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		revision, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			http.Error(w, "invalid revision: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rev, err := db.GetResumeRevision(rID, revision)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: rev})
+	}
+}
+
+// RevisionDiffHandler lists the fields that changed from revision from to
+// revision to. Without to, it compares with the resume as it is now.
+func RevisionDiffHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.ParseInt(GetCenterID(w, r.URL.Path), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := r.URL.Query()
+		from, err := strconv.Atoi(query.Get("from"))
+		if err != nil {
+			http.Error(w, "invalid from revision", http.StatusBadRequest)
+			return
+		}
+		to := 0
+		if t := query.Get("to"); t != "" {
+			if to, err = strconv.Atoi(t); err != nil {
+				http.Error(w, "invalid to revision", http.StatusBadRequest)
+				return
+			}
+		}
+
+		changes, err := database.DiffResumeRevisions(db, rID, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if changes == nil {
+			changes = []git.Change{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: changes})
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/database"
+)
+
+// SearchHandler runs a full-text search. It takes q, and optionally
+// project_id, type (comma separated), since, until and limit.
+func SearchHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		q := config.SearchQuery{
+			Text:  query.Get("q"),
+			Since: query.Get("since"),
+			Until: query.Get("until"),
+			Limit: 20,
+		}
+		if strings.TrimSpace(q.Text) == "" {
+			http.Error(w, "q is required", http.StatusBadRequest)
+			return
+		}
+		if p := query.Get("project_id"); p != "" {
+			id, err := strconv.Atoi(p)
+			if err != nil {
+				http.Error(w, "invalid project_id", http.StatusBadRequest)
+				return
+			}
+			q.ProjectID = id
+		}
+		for _, t := range query["type"] {
+			for _, k := range strings.Split(t, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					q.Kinds = append(q.Kinds, k)
+				}
+			}
+		}
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			q.Limit = n
+		}
+		if err := q.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		results, err := db.Search(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: results})
+	}
+}
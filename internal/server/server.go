@@ -22,10 +22,53 @@ var err error
 
 type Middleware func(http.Handler) http.Handler
 
-func Serve(db database.IDatabase) {
-	mux := http.NewServeMux()
-
+// Serve starts the dashboard. With login set, every request has to sign in
+// as one of the users and only sees that user's data; otherwise it is served
+// as the user db is scoped to.
+func Serve(db database.IDatabase, login bool) {
 	InitReactHandler()
+
+	var h http.Handler = routes(db)
+	if login {
+		h = LoginHandler(db, routes)
+	}
+
+	middlewares := []Middleware{
+		CORSSecurityMiddleware,
+		LoggingMiddleware,
+	}
+	handler := ApplyMiddleware(h, middlewares...)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", startPort),
+		Handler: handler,
+	}
+
+	fmt.Printf("🚀 Starting dashboard on http://localhost:%d\n", startPort)
+	fmt.Println("✨ Build your resume visually...")
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Server error: %v \n", err)
+	}
+	// Keep command running until interrupted
+	// quit := make(chan os.Signal, 1)
+	// signal.Notify(quit, os.Interrupt)
+	// <-quit
+	//
+	// fmt.Println("Shutting down server...")
+	// ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// defer cancel()
+	//
+	// if err := srv.Shutdown(ctx); err != nil {
+	// 	fmt.Printf("Shutdown error: %v\n", err)
+	// } else {
+	// 	fmt.Println("Server stopped gracefully")
+	// }
+}
+
+// routes builds the dashboard and its API on db.
+func routes(db database.IDatabase) http.Handler {
+	mux := http.NewServeMux()
 	mux.HandleFunc("/", IndexHandler)
 
 	mux.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(assetsFS))))
@@ -36,7 +79,17 @@ func Serve(db database.IDatabase) {
 
 	// ---- API endpoints ----
 	//Config
-	mux.HandleFunc("/api/ai", AiHandler)
+	mux.HandleFunc("/api/ai", AiHandler(db))
+	mux.HandleFunc("/api/ai/usage", AiUsageHandler(db))
+	mux.HandleFunc("/api/ai/audit", AiAuditHandler(db))
+	mux.HandleFunc("/api/ai/evals", AiEvalHandler(db))
+	mux.HandleFunc("/api/ai/rewrite", RewriteHandler(db))
+	mux.HandleFunc("/api/match", MatchHandler(db))
+	mux.HandleFunc("/api/search", SearchHandler(db))
+	mux.HandleFunc("/api/ai/models", OllamaModelsHandler)
+	mux.HandleFunc("/api/ai/models/pull", OllamaPullHandler)
+	mux.HandleFunc("/api/prompts/preview", PromptPreviewHandler(db))
+	mux.HandleFunc("/api/prompts/{title}/versions", PromptVersionsHandler(db))
 	mux.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPut:
@@ -49,7 +102,7 @@ func Serve(db database.IDatabase) {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
-	mux.HandleFunc("/api/export", ExportResumeHandler)
+	mux.HandleFunc("/api/export", ExportResumeHandler(db))
 
 	// User
 	mux.HandleFunc("/api/users", UserHandler)
@@ -57,7 +110,19 @@ func Serve(db database.IDatabase) {
 
 	// Project
 	mux.HandleFunc("/api/projects", ProjectsHandler(db))
-	mux.HandleFunc("/api/projects/{id}", ProjectHandler(db))
+	mux.HandleFunc("/api/projects/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ProjectHandler(db)(w, r)
+		case http.MethodPatch:
+			UpdateGitProjectHandler(db)(w, r)
+		case http.MethodDelete:
+			DeleteGitProjectHandler(db)(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/projects/{id}/rescan", RescanProjectHandler(db))
 
 	// Resumes
 	mux.HandleFunc("/api/resumes", func(w http.ResponseWriter, r *http.Request) {
@@ -72,6 +137,15 @@ func Serve(db database.IDatabase) {
 	})
 
 	mux.HandleFunc("/api/resumes/{id}/duplicate", ResumeCopyHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/tailor", TailorHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/tailor/apply", TailorApplyHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/translate", TranslateHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/sources", ResumeSourcesHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/lint", ResumeLintHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/revisions", RevisionsHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/revisions/diff", RevisionDiffHandler(db))
+	mux.HandleFunc("/api/resumes/{id}/revisions/{revision}", RevisionHandler(db))
+	mux.HandleFunc("/api/jobs", JobsHandler(db))
 
 	mux.HandleFunc("/api/resumes/{id}", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -86,6 +160,8 @@ func Serve(db database.IDatabase) {
 		}
 	})
 	mux.HandleFunc("/api/commits/bulk-update", BulkUpdateCommitMessageHandler(db))
+	mux.HandleFunc("/api/suggestions", SuggestionsHandler(db))
+	mux.HandleFunc("/api/suggestions/{id}", ReviewSuggestionHandler(db))
 
 	// Work Experience
 	mux.HandleFunc("/api/work-experiences/{id}", func(w http.ResponseWriter, r *http.Request) {
@@ -134,38 +210,7 @@ func Serve(db database.IDatabase) {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
-
-	middlewares := []Middleware{
-		CORSSecurityMiddleware,
-		LoggingMiddleware,
-	}
-	handler := ApplyMiddleware(mux, middlewares...)
-
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", startPort),
-		Handler: handler,
-	}
-
-	fmt.Printf("🚀 Starting dashboard on http://localhost:%d\n", startPort)
-	fmt.Println("✨ Build your resume visually...")
-
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Server error: %v \n", err)
-	}
-	// Keep command running until interrupted
-	// quit := make(chan os.Signal, 1)
-	// signal.Notify(quit, os.Interrupt)
-	// <-quit
-	//
-	// fmt.Println("Shutting down server...")
-	// ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	// defer cancel()
-	//
-	// if err := srv.Shutdown(ctx); err != nil {
-	// 	fmt.Printf("Shutdown error: %v\n", err)
-	// } else {
-	// 	fmt.Println("Server stopped gracefully")
-	// }
+	return mux
 }
 
 // func handlePort() {
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/iamhabbeboy/gitresume/config"
+	"github.com/iamhabbeboy/gitresume/internal/ai"
+	"github.com/iamhabbeboy/gitresume/internal/database"
+	"github.com/iamhabbeboy/gitresume/internal/git"
+)
+
+// SuggestRequest generates bullets for a project and queues them for review
+// instead of saving them as commit summaries.
+type SuggestRequest struct {
+	ProjectID int `json:"project_id"`
+	AiRequest
+}
+
+type ReviewRequest struct {
+	State config.SuggestionState `json:"state"`
+	// Text is the reviewed wording, required when State is edited.
+	Text string `json:"text"`
+}
+
+func SuggestionsHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			listSuggestions(db, w, r)
+		case http.MethodPost:
+			createSuggestions(db, w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func listSuggestions(db database.IDatabase, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	projectID, _ := strconv.Atoi(query.Get("project_id"))
+	state := config.SuggestionState(query.Get("state"))
+	if state != "" && !state.Valid() {
+		http.Error(w, config.ErrInvalidSuggestionState.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := db.GetSuggestions(projectID, state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: suggestions})
+}
+
+func createSuggestions(db database.IDatabase, w http.ResponseWriter, r *http.Request) {
+	var req SuggestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ProjectID == 0 {
+		http.Error(w, "project_id is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Prompts) == 0 {
+		http.Error(w, errors.New("prompt is missing").Error(), http.StatusBadRequest)
+		return
+	}
+	req.Title = config.ProjectPrompt
+	var data config.PromptData
+	if req.Variables != nil {
+		data = *req.Variables
+	} else {
+		p, err := db.GetProject(req.ProjectID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data = ai.PromptDataFor(p, git.Resume{})
+	}
+	prompts, err := config.CustomPrompt{Title: req.Title, Prompts: req.Prompts}.Render(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Prompts = prompts
+
+	model, err := aiRequestModel(db, req.AiRequest, ai.GenOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := ai.WithCallInfo(r.Context(), ai.CallInfo{Task: req.Title, Origin: "api:" + r.URL.Path})
+	bullets, err := generateBullets(ctx, model, req.Prompts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	suggestions := ai.Suggest(model, bullets, config.Suggestion{
+		ProjectID:     req.ProjectID,
+		Task:          req.Title,
+		PromptVersion: ai.ActivePromptVersion(db, req.Title),
+	})
+
+	ids, err := db.CreateSuggestions(suggestions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i, id := range ids {
+		suggestions[i].ID = id
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Response{Message: "suggestions are waiting for review", Status: http.StatusCreated, Data: suggestions})
+}
+
+// ReviewSuggestionHandler accepts, edits, rejects or reopens a suggestion.
+func ReviewSuggestionHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, err := strconv.Atoi(GetID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid suggestion ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req ReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := db.ReviewSuggestion(int64(id), req.State, req.Text); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s, err := db.GetSuggestion(int64(id))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "suggestion " + string(s.State), Status: http.StatusOK, Data: s})
+	}
+}
+
+// ResumeSourcesHandler lists the commits behind each bullet of a resume.
+func ResumeSourcesHandler(db database.IDatabase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rID, err := strconv.Atoi(GetCenterID(w, r.URL.Path))
+		if err != nil {
+			http.Error(w, "invalid resume ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resume, err := db.GetResume(int64(rID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		sources, err := database.ResumeSources(db, resume)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{Message: "success", Status: http.StatusOK, Data: sources})
+	}
+}
@@ -7,8 +7,9 @@ import { Bot, BriefcaseBusiness, ImportIcon, Info } from "lucide-react";
 import { t } from "../../util/config";
 import { Button } from "../ui/Button";
 import Spinner from "../Spinner";
-import { buildAIBody, transformTech } from "../../../lib/utils";
+import { transformTech } from "../../../lib/utils";
 import type { CustomPrompt } from "../../types/ai-config";
+import SuggestionReview from "./SuggestionReview";
 
 const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
   selectedProject,
@@ -60,7 +61,10 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
       });
     }
 
-    const messages = commits.map((c) => c.message);
+    // the [hash] prefix lets the model cite the commits behind each bullet
+    const messages = commits.map((c) =>
+      c.hash ? `[${c.hash.slice(0, 7)}] ${c.message}` : c.message
+    );
     if (messages.length === 0) {
       return t({
         message: "An error occurred, you have an empty commit messages",
@@ -78,17 +82,19 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
         icon: <Info />,
       });
     }
-    const prompt = promptAvailable?.prompts;
-    const transformer = buildAIBody(prompt, messages);
-
     const body: CustomPrompt = {
       temperature: promptAvailable.temperature,
       max_tokens: promptAvailable.max_tokens,
       model: defaultModel?.name,
       version: defaultModel?.model,
-      prompts: transformer,
-      title: "",
+      prompts: promptAvailable.prompts,
+      title: promptAvailable.title,
       api_key: defaultModel?.api_key,
+      variables: {
+        commits: messages,
+        project_name: selectedProject?.name,
+        tech_stack: transformTech(selectedProject?.technologies as string),
+      },
     };
     console.log(body);
 
@@ -105,10 +111,8 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
         icon: <Info />,
       });
     }
-    setTab(1);
-    setCommits([]);
     setIsAIAction(true);
-    setCommits(response.data);
+    setTab(2);
   };
 
   const toggle = (commitIndex: number) => {
@@ -171,7 +175,9 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
               <h4 className="text-md font-bold text-cyan-800 mt-2">
                 {tab === 0
                   ? "Original Commit Logs"
-                  : "Translated/Updated Logs "}
+                  : tab === 1
+                    ? "Translated/Updated Logs "
+                    : "AI Suggestions to Review"}
               </h4>
             </div>
             <div className="ml-auto ">
@@ -195,6 +201,16 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
               >
                 Translated
               </button>
+              <button
+                className={` ${
+                  tab === 2
+                    ? "bg-blue-400 hover:bg-blue-800 text-white"
+                    : "text-blue-400"
+                } border px-5 py-2 text-sm text-blue-400 cursor-pointer`}
+                onClick={() => setTab(2)}
+              >
+                Review
+              </button>
             </div>
           </div>
           {tab == 1 && !commits?.length && (
@@ -204,7 +220,10 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
               </h1>
             </div>
           )}
-          <ul className="h-[600px] overflow-y-scroll">
+          {tab === 2 && <SuggestionReview projectId={selectedProject.id} />}
+          <ul
+            className={`h-[600px] overflow-y-scroll ${tab === 2 ? "hidden" : ""}`}
+          >
             {commits &&
               commits.map((commit, key) => (
                 <li
@@ -215,6 +234,11 @@ const Contribution: React.FC<{ selectedProject: Prop | null }> = ({
                   <div className="flex justify-between">
                     <div className="w-10/12">
                       <ReactMarkdown>{commit.message}</ReactMarkdown>
+                      {commit.source_commits?.length ? (
+                        <p className="text-xs text-gray-500">
+                          Commits: {commit.source_commits.join(", ")}
+                        </p>
+                      ) : null}
                     </div>
                     {/* {index === commit.commit_id && (
                       <div className="w-2/12 flex items-center justify-end">
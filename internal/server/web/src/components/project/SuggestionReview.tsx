@@ -0,0 +1,118 @@
+import { useEffect, useState } from "react";
+import { Check, Info, Pencil, X } from "lucide-react";
+import { useStore } from "../../store";
+import type { Suggestion } from "../../types/project";
+import { t } from "../../util/config";
+import { Button } from "../ui/Button";
+
+const SuggestionReview: React.FC<{ projectId: number }> = ({ projectId }) => {
+  const store = useStore();
+  const { suggestions, fetchSuggestions, reviewSuggestion, fetchCommitSummary } =
+    store;
+  const [editing, setEditing] = useState<number | null>(null);
+  const [text, setText] = useState("");
+
+  useEffect(() => {
+    fetchSuggestions(projectId);
+  }, [projectId, fetchSuggestions]);
+
+  const review = async (
+    s: Suggestion,
+    state: "accepted" | "edited" | "rejected"
+  ) => {
+    const ok = await reviewSuggestion(
+      s.id,
+      state,
+      state === "edited" ? text : undefined
+    );
+    if (!ok) {
+      return t({ message: "Could not save the review", icon: <Info /> });
+    }
+    setEditing(null);
+    if (state !== "rejected") {
+      fetchCommitSummary(projectId);
+    }
+  };
+
+  const pending = suggestions.filter((s) => s.project_id === projectId);
+  if (pending.length === 0) {
+    return (
+      <div className="my-5">
+        <h1 className="text-lg text-gray-400">
+          Nothing to review. Summarize with AI to get suggestions.
+        </h1>
+      </div>
+    );
+  }
+
+  return (
+    <ul className="h-[600px] overflow-y-scroll">
+      {pending.map((s) => (
+        <li key={s.id} className="py-3 text-gray-700 border-b border-gray-300">
+          {editing === s.id ? (
+            <textarea
+              className="w-full border border-gray-300 rounded-md p-2 text-sm"
+              value={text}
+              onChange={(e) => setText(e.target.value)}
+            />
+          ) : (
+            <p>{s.text}</p>
+          )}
+          <div className="flex justify-between items-center mt-2">
+            <p className="text-xs text-gray-500">
+              {s.source_commits?.length
+                ? `Commits: ${s.source_commits.join(", ")}`
+                : "No source commits"}{" "}
+              · {s.provider}/{s.model} · prompt v{s.prompt_version} ·
+              confidence {s.confidence.toFixed(2)}
+            </p>
+            <div className="flex gap-2">
+              {editing === s.id ? (
+                <Button
+                  size="sm"
+                  className="bg-green-600 text-white hover:bg-green-500"
+                  disabled={!text.trim()}
+                  onClick={() => review(s, "edited")}
+                >
+                  <Check /> Save
+                </Button>
+              ) : (
+                <>
+                  <Button
+                    size="sm"
+                    className="bg-green-600 text-white hover:bg-green-500"
+                    onClick={() => review(s, "accepted")}
+                  >
+                    <Check /> Accept
+                  </Button>
+                  <Button
+                    size="sm"
+                    variant="outline"
+                    onClick={() => {
+                      setEditing(s.id);
+                      setText(s.text);
+                    }}
+                  >
+                    <Pencil /> Edit
+                  </Button>
+                </>
+              )}
+              <Button
+                size="sm"
+                variant="outline"
+                className="text-red-600"
+                onClick={() =>
+                  editing === s.id ? setEditing(null) : review(s, "rejected")
+                }
+              >
+                <X /> {editing === s.id ? "Cancel" : "Reject"}
+              </Button>
+            </div>
+          </div>
+        </li>
+      ))}
+    </ul>
+  );
+};
+
+export default SuggestionReview;
@@ -18,7 +18,7 @@ import { Input } from "../../ui/Input";
 import { Label } from "../../ui/Label";
 import { useStore } from "../../../store";
 import { AIAvailableOptions, type CommitMessage } from "../../../types/project";
-import { buildAIBody, transformTech } from "../../../../lib/utils";
+import { transformTech } from "../../../../lib/utils";
 import Spinner from "../../Spinner";
 import _ from "lodash";
 import type { CustomPrompt } from "../../../types/ai-config";
@@ -177,17 +177,15 @@ const JobWorkExperience = () => {
         icon: <Info />,
       });
     }
-    const prompt = promptAvailable?.prompts;
-    const transformer = buildAIBody(prompt, transform);
-
     const body: CustomPrompt = {
       temperature: promptAvailable.temperature,
       max_tokens: promptAvailable.max_tokens,
       model: defaultModel?.name,
       version: defaultModel?.model,
-      prompts: transformer,
-      title: "",
+      prompts: promptAvailable.prompts,
+      title: promptAvailable.title,
       api_key: defaultModel?.api_key,
+      variables: { commits: transform },
     };
 
     const resp = await summarizeResponsibility(body);
@@ -2,7 +2,7 @@ import { useEffect, useRef, useState } from "react";
 import { useResumeStore } from "../../../store/resumeStore";
 import { prependTailwindHTMLForExport } from "../../../../lib/utils";
 import axios from "axios";
-import { CopyCheck, Download, Info } from "lucide-react";
+import { CopyCheck, Download, Info, Languages } from "lucide-react";
 import { baseUri, defaultTitle, t } from "../../../util/config";
 import Spinner from "../../Spinner";
 import { Button } from "../../ui/Button";
@@ -26,6 +26,7 @@ const ResumeHeader: React.FC<Props> = ({ id, resumeHTML }) => {
   };
 
   const [format, setFormat] = useState("");
+  const [withSources, setWithSources] = useState(false);
   const [isDownloading, setIsDownloading] = useState(false);
 
   const handleDownload = async () => {
@@ -36,8 +37,9 @@ const ResumeHeader: React.FC<Props> = ({ id, resumeHTML }) => {
       setIsDownloading(true);
       const resumeTitle = resume.title || defaultTitle;
       const formatTitle = resumeTitle.trim().replace(/[^a-zA-Z0-9]/g, "-");
+      const sources = withSources ? "&sources=1" : "";
       const res = await axios.post(
-        `${baseUri}/api/export?format=${format}`,
+        `${baseUri}/api/export?format=${format}&resume=${id}${sources}`,
         prependTailwindHTMLForExport(resumeHTML?.innerHTML as string),
         {
           headers: { "Content-Type": "text/html" },
@@ -87,6 +89,27 @@ const ResumeHeader: React.FC<Props> = ({ id, resumeHTML }) => {
       setIsLoadingDuplicate(false);
     }
   };
+  const [isTranslating, setIsTranslating] = useState(false);
+
+  const handleTranslate = async (language: string) => {
+    if (language === "") {
+      return;
+    }
+    try {
+      setIsTranslating(true);
+      const res = await axios.post(`${baseUri}/api/resumes/${id}/translate`, {
+        language,
+      });
+      if (res.data.data?.id) {
+        return router(`/resumes/${res.data.data.id}`);
+      }
+    } catch (e) {
+      const message = e instanceof Error ? e.message : "Unknown error";
+      t({ message, icon: <Info /> });
+    } finally {
+      setIsTranslating(false);
+    }
+  };
   const inputRef = useRef<HTMLDivElement | null>(null);
   const [value, setValue] = useState("");
   useEffect(() => {
@@ -146,6 +169,17 @@ const ResumeHeader: React.FC<Props> = ({ id, resumeHTML }) => {
           <option value="docx">DOCX</option>
           <option value="md">Markdown</option>
         </select>
+        <label
+          className="flex items-center gap-1 text-sm text-gray-600 whitespace-nowrap"
+          title="Add the commits behind each bullet"
+        >
+          <input
+            type="checkbox"
+            checked={withSources}
+            onChange={(e) => setWithSources(e.target.checked)}
+          />
+          Commit sources
+        </label>
         <Button
           onClick={handleDownload}
           className="gap-2 bg-blue-400 text-white hover:bg-blue-500 transition"
@@ -154,6 +188,25 @@ const ResumeHeader: React.FC<Props> = ({ id, resumeHTML }) => {
           {isDownloading ? <Spinner /> : <Download className="h-4 w-4" />}
           Download
         </Button>
+        <label className="flex items-center gap-1 text-sm text-gray-600">
+          {isTranslating ? <Spinner /> : <Languages className="h-4 w-4" />}
+          <select
+            className="bg-white p-1 border border-1 border-gray-300 rounded-md h-10 focus:outline-none text-sm"
+            disabled={isTranslating}
+            value=""
+            onChange={(e) => handleTranslate(e.target.value)}
+            title="Save a translated copy of this resume"
+          >
+            <option value="">Translate</option>
+            <option value="de">German</option>
+            <option value="fr">French</option>
+            <option value="es">Spanish</option>
+            <option value="it">Italian</option>
+            <option value="nl">Dutch</option>
+            <option value="pt">Portuguese</option>
+            <option value="pt-BR">Portuguese (Brazil)</option>
+          </select>
+        </label>
         <Button
           onClick={handleCopyResume}
           className="gap-2 bg-yellow-600 text-white hover:bg-yellow-700 transition"
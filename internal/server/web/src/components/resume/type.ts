@@ -26,6 +26,10 @@ export interface Resume {
   work_experiences: WorkExperience[];
   volunteers: Volunteer[];
   project_worked_on: Project[];
+  summary?: string;
+  parent_id?: number;
+  job_id?: number;
+  language?: string;
 }
 
 export interface Education {
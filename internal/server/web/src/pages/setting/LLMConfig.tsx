@@ -8,7 +8,13 @@ import { baseUri, t } from "../../util/config";
 import type { OptionType } from "../../components/resume/type";
 import { ChevronDown, CircleCheck, Info } from "lucide-react";
 import { Textarea } from "../../components/ui/Textarea";
-import type { CustomPrompt, LLmConfig, Prompt } from "../../types/ai-config";
+import type {
+  CustomPrompt,
+  LLmConfig,
+  OllamaStatus,
+  Prompt,
+  PullProgress,
+} from "../../types/ai-config";
 import { useStore } from "../../store";
 
 const LLMConfig = () => {
@@ -33,6 +39,7 @@ const LLMConfig = () => {
   const [llmValue, setLlmValue] = useState<LLmConfig>({
     name: "",
     api_key: "",
+    base_url: "",
     is_default: false,
     model: "",
   });
@@ -49,10 +56,65 @@ const LLMConfig = () => {
       name: defaultLLm?.name ?? "",
       model: defaultLLm?.model ?? "",
       api_key: defaultLLm?.api_key ?? "",
+      base_url: defaultLLm?.base_url ?? "",
       is_default: defaultLLm?.is_default ?? false,
     });
   }, [defaultLLm, llms, llms.length]);
 
+  const [ollama, setOllama] = useState<OllamaStatus | null>(null);
+  const [pullStatus, setPullStatus] = useState("");
+
+  const loadOllamaStatus = async () => {
+    try {
+      const res = await axios.get(`${baseUri}/api/ai/models`);
+      setOllama(res.data.data);
+    } catch {
+      setOllama(null);
+    }
+  };
+
+  useEffect(() => {
+    if (llmValue.name === "llama") loadOllamaStatus();
+  }, [llmValue.name]);
+
+  const handlePullModel = async () => {
+    setPullStatus("starting");
+    try {
+      const res = await fetch(`${baseUri}/api/ai/models/pull`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ model: llmValue.model || ollama?.configured }),
+      });
+      const reader = res.body?.getReader();
+      const decoder = new TextDecoder();
+      let buffer = "";
+      while (reader) {
+        const { done, value } = await reader.read();
+        if (done) break;
+        buffer += decoder.decode(value, { stream: true });
+        const lines = buffer.split("\n");
+        buffer = lines.pop() ?? "";
+        for (const line of lines) {
+          if (!line.trim()) continue;
+          const p: PullProgress = JSON.parse(line);
+          if (p.error) throw new Error(p.error);
+          setPullStatus(
+            p.total && p.completed
+              ? `${p.status} ${Math.floor((p.completed / p.total) * 100)}%`
+              : p.status
+          );
+        }
+      }
+      t({ message: "Model pulled successfully!", icon: <CircleCheck /> });
+    } catch (e) {
+      const message = e instanceof Error ? e.message : "Unknown error";
+      t({ message, icon: <Info /> });
+    } finally {
+      setPullStatus("");
+      loadOllamaStatus();
+    }
+  };
+
   const handleConfig = (values: OptionType[]) => {
     setConfig(values);
     const llm = llms.find((ll) => ll.name === values?.[0]?.label);
@@ -224,6 +286,41 @@ const LLMConfig = () => {
               // disabled={config.length > 0 && config[0].label === "llama"}
             />
           </div>
+          {llmValue.name === "huggingface" && (
+            <div className="my-3">
+              <Label htmlFor="base_url">Base URL</Label>
+              <Input
+                id="base_url"
+                placeholder="https://router.huggingface.co/v1 or http://localhost:8080/v1"
+                className="my-1 border-gray-300"
+                value={llmValue?.base_url}
+                onChange={(e) =>
+                  setLlmValue({ ...llmValue, base_url: e.target.value })
+                }
+              />
+            </div>
+          )}
+
+          {llmValue.name === "llama" && ollama && !ollama.available && (
+            <div className="rounded-md bg-amber-400 p-2 text-xs text-white my-1 flex gap-2">
+              <Info /> Ollama is not running. Start it with `ollama serve` to
+              use local models.
+            </div>
+          )}
+          {llmValue.name === "llama" && ollama?.available && !ollama.pulled && (
+            <div className="rounded-md bg-amber-400 p-2 text-xs text-white my-1 flex gap-2 items-center justify-between">
+              <span className="flex gap-2 items-center">
+                <Info /> {ollama.configured} is not pulled yet.
+              </span>
+              <Button
+                className="bg-white text-gray-800 h-7 text-xs"
+                onClick={handlePullModel}
+                disabled={pullStatus !== ""}
+              >
+                {pullStatus || "Pull model"}
+              </Button>
+            </div>
+          )}
 
           <hr className="text-gray-300 my-5" />
           <div>
@@ -282,9 +379,9 @@ const LLMConfig = () => {
             Add New Role <Plus />
           </Button> */}
           <div className="rounded-md bg-indigo-400 p-2 text-xs text-white my-1 flex gap-2">
-            <Info /> Include %content% in one of the custom prompt role to mark
-            where the platform should inject your dynamic input during LLM
-            processing.
+            <Info /> Prompts are templates: use {"{{lines .Commits}}"},{" "}
+            {"{{.ProjectName}}"} or %content% to mark where the platform should
+            inject your dynamic input during LLM processing.
           </div>
           {prompts.map((prmp, indx) => (
             <div key={indx}>
@@ -4,6 +4,8 @@ import type {
   CommitMessage,
   CustomCommitMessage,
   Project,
+  Suggestion,
+  SuggestionState,
 } from "../types/project";
 import { baseUri } from "../util/config";
 import type { AIConfig, CustomPrompt } from "../types/ai-config";
@@ -11,6 +13,7 @@ import type { AIConfig, CustomPrompt } from "../types/ai-config";
 type ProjectStore = {
   projects: Project[];
   commits: CommitMessage[];
+  suggestions: Suggestion[];
   ai_config: AIConfig;
   loading: boolean;
   error: string | null;
@@ -24,7 +27,13 @@ type Action = {
   updateAllCommitsWithAI: (
     projtID: number,
     prompts: CustomPrompt
-  ) => Promise<{ success: boolean; data: CommitMessage[]; error?: string }>;
+  ) => Promise<{ success: boolean; data: Suggestion[]; error?: string }>;
+  fetchSuggestions: (projectID: number) => Promise<Suggestion[]>;
+  reviewSuggestion: (
+    id: number,
+    state: SuggestionState,
+    text?: string
+  ) => Promise<boolean>;
   fetchCommitSummary: (projectId: number) => Promise<CommitMessage[]>;
   fetchAIConfig: () => void;
 };
@@ -32,6 +41,7 @@ type Action = {
 export const useStore = create<ProjectStore & Action>()((set, get) => ({
   projects: [],
   commits: [],
+  suggestions: [],
   ai_config: {
     custom_prompt: [],
     models: [],
@@ -67,21 +77,20 @@ export const useStore = create<ProjectStore & Action>()((set, get) => ({
       return [];
     }
   },
+  // AI output is queued for review; only accepted suggestions become
+  // commit summaries.
   updateAllCommitsWithAI: async (projectID: number, prompt: CustomPrompt) => {
     try {
       set((state) => ({ ...state, loading: true }));
-      const { data } = await axios.post(`${baseUri}/api/ai`, {
+      const { data } = await axios.post(`${baseUri}/api/suggestions`, {
         ...prompt,
-      });
-
-      const payload = data.map((c: string) => ({
         project_id: projectID,
-        commit_id: 0,
-        message: c,
+      });
+      const created: Suggestion[] = data.data ?? [];
+      set((state) => ({
+        suggestions: [...created, ...state.suggestions],
       }));
-
-      get().bulkUpdateCommit(projectID, payload);
-      return { success: true, data: payload };
+      return { success: true, data: created };
     } catch (e) {
       const message = e instanceof Error ? e.message : "Unknown error occurred";
       set((state) => ({ ...state, error: message as string }));
@@ -90,6 +99,34 @@ export const useStore = create<ProjectStore & Action>()((set, get) => ({
       set((state) => ({ ...state, loading: false, error: "" }));
     }
   },
+  fetchSuggestions: async (projectID: number) => {
+    try {
+      const { data } = await axios.get(
+        `${baseUri}/api/suggestions?project_id=${projectID}&state=pending`
+      );
+      set({ suggestions: data.data ?? [] });
+      return data.data ?? [];
+    } catch (e) {
+      console.error(e);
+      return [];
+    }
+  },
+  reviewSuggestion: async (
+    id: number,
+    state: SuggestionState,
+    text?: string
+  ) => {
+    try {
+      await axios.put(`${baseUri}/api/suggestions/${id}`, { state, text });
+      set((s) => ({
+        suggestions: s.suggestions.filter((sg) => sg.id !== id),
+      }));
+      return true;
+    } catch (e) {
+      console.error(e);
+      return false;
+    }
+  },
   updateCommitsWithAI: async (projectID: number, commits: CommitMessage[]) => {
     try {
       const { data } = await axios.post(`${baseUri}/api/ai`, {
@@ -2,6 +2,7 @@ export interface LLmConfig {
   name: string;
   model?: string;
   api_key?: string;
+  base_url?: string;
   is_default: boolean;
 }
 
@@ -19,10 +20,21 @@ export interface Model {
   name: string;
   api_key: string;
   model: string;
+  base_url?: string;
   is_default: boolean;
   custom_prompt: Record<string, unknown>;
 }
 
+// PromptData fills in the prompt templates, which the server renders.
+export interface PromptData {
+  content?: string;
+  commits?: string[];
+  project_name?: string;
+  tech_stack?: string[];
+  target_role?: string;
+  language?: string;
+}
+
 export interface CustomPrompt {
   title: string;
   model?: string;
@@ -31,4 +43,28 @@ export interface CustomPrompt {
   max_tokens?: number;
   prompts: Prompt[];
   api_key?: string;
+  variables?: PromptData;
+}
+
+export interface OllamaModel {
+  name: string;
+  size: number;
+  digest: string;
+  modified_at: string;
+}
+
+export interface OllamaStatus {
+  available: boolean;
+  models: OllamaModel[];
+  configured: string;
+  pulled: boolean;
+  error?: string;
+}
+
+export interface PullProgress {
+  status: string;
+  digest?: string;
+  total?: number;
+  completed?: number;
+  error?: string;
 }
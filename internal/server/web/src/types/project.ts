@@ -1,8 +1,28 @@
 export type CommitMessage = {
   commit_id: number;
+  hash?: string;
   message: string;
   ai_generated_msg?: string;
   project_id?: number;
+  source_commits?: string[];
+};
+
+export type SuggestionState = "pending" | "accepted" | "edited" | "rejected";
+
+export type Suggestion = {
+  id: number;
+  project_id: number;
+  task: string;
+  provider: string;
+  model: string;
+  prompt_version: number;
+  source_commits: string[];
+  text: string;
+  edited_text?: string;
+  confidence: number;
+  state: SuggestionState;
+  created_at: string;
+  reviewed_at?: string;
 };
 
 export type Project = {
@@ -3,7 +3,10 @@ package util
 import (
 	"encoding/json"
 	// "fmt"
+	"html"
+	"math"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
@@ -30,12 +33,10 @@ func GenerateHash(pwdStr string) (string, error) {
 	return string(hash), nil
 }
 
+// VeryHash returns an error unless pwdStr is the password hash was
+// generated from.
 func VeryHash(hash []byte, pwdStr string) error {
-	err := bcrypt.CompareHashAndPassword(hash, []byte(pwdStr))
-	if err != nil {
-		return nil
-	}
-	return nil
+	return bcrypt.CompareHashAndPassword(hash, []byte(pwdStr))
 }
 
 func ConvertNullToSlice[T any](b []byte, out *[]T) error {
@@ -62,3 +63,58 @@ func ConvertNullToSlice[T any](b []byte, out *[]T) error {
 
 	return nil
 }
+
+var htmlTag = regexp.MustCompile(`<[^>]+>`)
+
+// HTMLListItems splits an editor list such as "<ul><li>a</li><li>b</li></ul>"
+// into its items. Plain text is split on new lines.
+func HTMLListItems(html string) []string {
+	text := strings.ReplaceAll(html, "</li>", "\n")
+	text = strings.ReplaceAll(text, "<br>", "\n")
+	text = htmlTag.ReplaceAllString(text, "")
+
+	var items []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// HTMLList renders items as the editor's "<ul><li>…</li></ul>" list.
+func HTMLList(items []string) string {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, it := range items {
+		b.WriteString("<li>" + it + "</li>")
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// TextKey reduces a bullet to what matters when comparing it with another:
+// no markup or entities, lower case, single spaces and no final full stop.
+func TextKey(s string) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 when their
+// lengths differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
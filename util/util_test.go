@@ -2,6 +2,8 @@ package util
 
 import (
 	"encoding/json"
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -27,6 +29,9 @@ func TestGenerateAndVeryHash(t *testing.T) {
 	if err := VeryHash([]byte(hash), "pw"); err != nil {
 		t.Errorf("VeryHash did not validate: %v", err)
 	}
+	if err := VeryHash([]byte(hash), "wrong"); err == nil {
+		t.Error("VeryHash accepted the wrong password")
+	}
 }
 
 func TestConvertNullToSlice(t *testing.T) {
@@ -36,3 +41,32 @@ func TestConvertNullToSlice(t *testing.T) {
 		t.Errorf("failed to convert: %v", err)
 	}
 }
+
+func TestHTMLListItems(t *testing.T) {
+	got := HTMLListItems("<ul><li>Built <b>OAuth</b> login</li><li> </li><li>Cut p95 by 40%</li></ul>")
+	want := []string{"Built OAuth login", "Cut p95 by 40%"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HTMLListItems = %q, want %q", got, want)
+	}
+	if got := HTMLListItems("one\ntwo"); len(got) != 2 {
+		t.Errorf("plain text should split on new lines, got %q", got)
+	}
+}
+
+func TestTextKey(t *testing.T) {
+	if a, b := TextKey("Built <b>R&amp;D</b>  tooling."), TextKey("built r&d tooling"); a != b {
+		t.Errorf("TextKey(%q) != TextKey(%q)", a, b)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := Cosine([]float32{1, 0}, []float32{2, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("parallel vectors = %v, want 1", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Errorf("orthogonal vectors = %v, want 0", got)
+	}
+	if got := Cosine([]float32{1}, []float32{1, 2}); got != 0 {
+		t.Errorf("mismatched lengths = %v, want 0", got)
+	}
+}